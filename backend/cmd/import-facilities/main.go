@@ -0,0 +1,154 @@
+// Command import-facilities loads a facility directory from a CSV or XLSX file, or
+// exports the current directory in the same format.
+//
+//	import-facilities -file facilities.xlsx -dry-run
+//	import-facilities -file facilities.csv
+//	import-facilities -export facilities.xlsx
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/social-worker-platform/backend/config"
+	"github.com/social-worker-platform/backend/models"
+	"github.com/social-worker-platform/backend/services"
+)
+
+func main() {
+	file := flag.String("file", "", "CSV or XLSX file to import")
+	dryRun := flag.Bool("dry-run", false, "validate and preview without saving")
+	export := flag.String("export", "", "write the current facilities to this .csv or .xlsx file instead of importing")
+	adminEmail := flag.String("admin", "admin@example.com", "admin account recorded as the author of facility changes")
+	noGeocode := flag.Bool("no-geocode", false, "do not look up coordinates for addresses")
+	flag.Parse()
+
+	if (*file == "") == (*export == "") {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// Load configuration
+	dbConfig := &config.DatabaseConfig{
+		Host:     getEnv("DB_HOST", "localhost"),
+		Port:     getEnv("DB_PORT", "5432"),
+		User:     getEnv("DB_USER", "postgres"),
+		Password: getEnv("DB_PASSWORD", "postgres"),
+		DBName:   getEnv("DB_NAME", "social_worker_platform"),
+		SSLMode:  getEnv("DB_SSLMODE", "disable"),
+	}
+
+	// Connect to database
+	db, err := config.ConnectDatabase(dbConfig)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	var geocoder services.AddressGeocoder
+	if !*noGeocode {
+		geocoder = services.NewGeocodingService()
+	}
+	importer := services.NewFacilityImporter(models.NewFacilityRepository(db), geocoder)
+
+	if *export != "" {
+		if err := exportFacilities(importer, *export); err != nil {
+			log.Fatalf("Failed to export facilities: %v", err)
+		}
+		return
+	}
+
+	admin, err := models.NewUserRepository(db).GetByEmail(*adminEmail)
+	if err != nil || admin.Role != "admin" {
+		log.Fatalf("Admin account %s not found", *adminEmail)
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		log.Fatalf("Failed to open %s: %v", *file, err)
+	}
+	defer f.Close()
+
+	rows, err := services.ReadSpreadsheet(*file, f)
+	if err != nil {
+		log.Fatalf("Failed to read %s: %v", *file, err)
+	}
+
+	result, err := importer.Import(rows, admin.ID, *dryRun)
+	if err != nil {
+		log.Fatalf("Failed to import facilities: %v", err)
+	}
+
+	for _, rec := range result.Rows {
+		fmt.Printf("line %d: %s %s (%s)\n", rec.Line, rec.Action, rec.Name, rec.Email)
+		for _, w := range rec.Warnings {
+			fmt.Printf("  warning: %s\n", w)
+		}
+		if rec.InitialPassword != "" {
+			fmt.Printf("  initial password: %s\n", rec.InitialPassword)
+		}
+	}
+	for _, e := range result.Errors {
+		fmt.Printf("line %d: error: %s %s\n", e.Line, e.Column, e.Message)
+	}
+	if len(result.IgnoredColumns) > 0 {
+		fmt.Printf("Ignored columns: %s\n", strings.Join(result.IgnoredColumns, ", "))
+	}
+
+	summary, _ := json.Marshal(map[string]int{
+		"total": result.Total, "created": result.Created, "updated": result.Updated,
+		"unchanged": result.Unchanged, "errors": len(result.Errors),
+	})
+	fmt.Printf("%s\n", summary)
+
+	switch {
+	case result.Committed:
+		fmt.Println("Import completed.")
+	case result.DryRun:
+		fmt.Println("Dry run: nothing was saved.")
+	default:
+		fmt.Println("Import aborted: fix the errors above and try again. Nothing was saved.")
+		os.Exit(1)
+	}
+}
+
+func exportFacilities(importer *services.FacilityImporter, path string) error {
+	rows, err := importer.ExportFacilities()
+	if err != nil {
+		return err
+	}
+
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".xlsx":
+		err = services.WriteXLSX(out, "施設一覧", rows)
+	case ".csv":
+		err = services.WriteCSV(out, rows)
+	default:
+		return services.ErrUnsupportedSpreadsheet
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Exported %d facilities to %s\n", len(rows)-1, path)
+	return nil
+}
+
+func getEnv(key, defaultValue string) string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
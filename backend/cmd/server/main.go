@@ -1,8 +1,11 @@
 package main
 
 import (
+	"database/sql"
 	"log"
 	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -10,6 +13,7 @@ import (
 	"github.com/social-worker-platform/backend/handlers"
 	"github.com/social-worker-platform/backend/middleware"
 	"github.com/social-worker-platform/backend/models"
+	"github.com/social-worker-platform/backend/services"
 )
 
 func main() {
@@ -40,12 +44,37 @@ func main() {
 	hospitalRepo := models.NewHospitalRepository(db)
 	facilityRepo := models.NewFacilityRepository(db)
 	documentRepo := models.NewDocumentRepository(db)
+	settingRepo := models.NewSettingRepository(db)
+	savedSearchRepo := models.NewSavedSearchRepository(db)
+	notificationRepo := models.NewNotificationRepository(db)
+	facilityTypeRepo := models.NewFacilityTypeRepository(db)
+	mergeRepo := models.NewMergeRepository(db)
+
+	// Email alerts are sent only when SMTP is configured
+	var mailer services.Mailer
+	if smtpMailer := services.NewSMTPMailerFromEnv(); smtpMailer != nil {
+		mailer = smtpMailer
+	}
+	savedSearchAlerter := services.NewSavedSearchAlerter(savedSearchRepo, facilityRepo, notificationRepo, mailer)
 
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(userRepo)
-	facilityHandler := handlers.NewFacilityHandler(facilityRepo, userRepo)
+	facilityHandler := handlers.NewFacilityHandler(facilityRepo, userRepo, settingRepo)
 	documentHandler := handlers.NewDocumentHandler(documentRepo)
-	adminHandler := handlers.NewAdminHandler(hospitalRepo, facilityRepo, userRepo)
+	adminHandler := handlers.NewAdminHandler(hospitalRepo, facilityRepo, userRepo, settingRepo)
+	savedSearchHandler := handlers.NewSavedSearchHandler(savedSearchRepo, facilityRepo, hospitalRepo, savedSearchAlerter)
+	notificationHandler := handlers.NewNotificationHandler(notificationRepo)
+	facilityTypeHandler := handlers.NewFacilityTypeHandler(facilityTypeRepo)
+	duplicateHandler := handlers.NewDuplicateHandler(mergeRepo)
+
+	// Release bed holds that passed their expiry
+	go expireBedHolds(db)
+
+	// Notify hospitals about facilities that newly match their saved searches
+	go refreshSavedSearches(savedSearchAlerter)
+
+	// Recompute facility responsiveness metrics shown in search results
+	go refreshFacilityResponsiveness(db)
 
 	// Setup Gin
 	ginMode := getEnv("GIN_MODE", "debug")
@@ -63,6 +92,9 @@ func main() {
 		c.JSON(200, gin.H{"status": "ok"})
 	})
 
+	// Uploaded facility photos are public like externally hosted image URLs
+	router.Static("/uploads/facilities", filepath.Join(getEnv("UPLOAD_DIR", "./uploads"), "facilities"))
+
 	// API Documentation routes
 	router.GET("/api/docs", handlers.ServeSwaggerUI)
 	router.GET("/api/docs/openapi.yaml", handlers.ServeOpenAPISpec)
@@ -81,14 +113,37 @@ func main() {
 		facilities.POST("", middleware.AuthMiddleware(), middleware.RequireRole("facility"), facilityHandler.Create)
 		facilities.GET("", middleware.AuthMiddleware(), middleware.RequireRole("hospital"), facilityHandler.List)
 		facilities.GET("/me", middleware.AuthMiddleware(), middleware.RequireRole("facility"), facilityHandler.GetMyFacility)
+		facilities.GET("/acceptance-conditions", middleware.AuthMiddleware(), middleware.RequireRole("hospital", "facility", "admin"), facilityHandler.GetAcceptanceConditionSchema)
+		facilities.GET("/fee-options", middleware.AuthMiddleware(), middleware.RequireRole("hospital", "facility", "admin"), facilityHandler.GetFeeOptions)
 		facilities.GET("/:id", middleware.AuthMiddleware(), middleware.RequireRole("hospital"), facilityHandler.GetByID)
+		facilities.GET("/:id/ratings", middleware.AuthMiddleware(), middleware.RequireRole("hospital", "admin"), handlers.GetFacilityRatings(db))
 		facilities.PUT("/:id", middleware.AuthMiddleware(), middleware.RequireRole("facility", "admin"), facilityHandler.Update)
 		facilities.PUT("/:id/images", middleware.AuthMiddleware(), middleware.RequireRole("facility", "admin"), facilityHandler.UpdateImages)
+		facilities.POST("/:id/images/upload", middleware.AuthMiddleware(), middleware.RequireRole("facility", "admin"), facilityHandler.UploadImage)
+		facilities.GET("/:id/history", middleware.AuthMiddleware(), middleware.RequireRole("facility", "admin"), facilityHandler.GetHistory)
+		facilities.GET("/:id/history/diff", middleware.AuthMiddleware(), middleware.RequireRole("facility", "admin"), facilityHandler.GetHistoryDiff)
 		// Room types routes
 		facilities.GET("/:id/room-types", middleware.AuthMiddleware(), middleware.RequireRole("hospital", "facility", "admin"), facilityHandler.GetRoomTypes)
 		facilities.PUT("/:id/room-types", middleware.AuthMiddleware(), middleware.RequireRole("facility", "admin"), facilityHandler.UpdateRoomTypes)
+		facilities.POST("/:id/room-types", middleware.AuthMiddleware(), middleware.RequireRole("facility", "admin"), facilityHandler.CreateRoomType)
+		facilities.GET("/:id/room-types/:roomTypeId", middleware.AuthMiddleware(), middleware.RequireRole("hospital", "facility", "admin"), facilityHandler.GetRoomType)
+		facilities.PUT("/:id/room-types/:roomTypeId", middleware.AuthMiddleware(), middleware.RequireRole("facility", "admin"), facilityHandler.UpdateRoomType)
+		facilities.DELETE("/:id/room-types/:roomTypeId", middleware.AuthMiddleware(), middleware.RequireRole("facility", "admin"), facilityHandler.DeleteRoomType)
+		facilities.PATCH("/:id/room-types/:roomTypeId/available", middleware.AuthMiddleware(), middleware.RequireRole("facility", "admin"), facilityHandler.AdjustRoomTypeAvailable)
+		// Fee schedule routes
+		facilities.GET("/:id/fees", middleware.AuthMiddleware(), middleware.RequireRole("hospital", "facility", "admin"), facilityHandler.GetFeeSchedules)
+		facilities.PUT("/:id/fees", middleware.AuthMiddleware(), middleware.RequireRole("facility", "admin"), facilityHandler.UpdateFeeSchedules)
+		facilities.GET("/:id/service-areas", middleware.AuthMiddleware(), middleware.RequireRole("hospital", "facility", "admin"), facilityHandler.GetServiceAreas)
+		facilities.PUT("/:id/service-areas", middleware.AuthMiddleware(), middleware.RequireRole("facility", "admin"), facilityHandler.UpdateServiceAreas)
+		facilities.GET("/:id/fees/estimate", middleware.AuthMiddleware(), middleware.RequireRole("hospital", "facility", "admin"), facilityHandler.EstimateFee)
 	}
 
+	// Facility type taxonomy
+	router.GET("/api/facility-types", middleware.AuthMiddleware(), facilityTypeHandler.List)
+
+	// Placement request and message room workflow, for rendering available actions
+	router.GET("/api/workflow", middleware.AuthMiddleware(), handlers.GetWorkflow)
+
 	// Document routes
 	documents := router.Group("/api/documents")
 	documents.Use(middleware.AuthMiddleware())
@@ -100,6 +155,60 @@ func main() {
 		documents.DELETE("/:id", documentHandler.Delete)
 	}
 
+	// Saved search routes
+	savedSearches := router.Group("/api/saved-searches")
+	savedSearches.Use(middleware.AuthMiddleware(), middleware.RequireRole("hospital"))
+	{
+		savedSearches.POST("", savedSearchHandler.Create)
+		savedSearches.GET("", savedSearchHandler.List)
+		savedSearches.GET("/:id", savedSearchHandler.GetByID)
+		savedSearches.PUT("/:id", savedSearchHandler.Update)
+		savedSearches.DELETE("/:id", savedSearchHandler.Delete)
+		savedSearches.GET("/:id/matches", savedSearchHandler.GetMatches)
+	}
+
+	// Shortlist and favorites routes
+	shortlists := router.Group("/api/shortlists")
+	shortlists.Use(middleware.AuthMiddleware(), middleware.RequireRole("hospital"))
+	{
+		shortlists.GET("", handlers.GetShortlists(db))
+		shortlists.POST("", handlers.CreateShortlist(db))
+		shortlists.GET("/:id", handlers.GetShortlist(db))
+		shortlists.PUT("/:id", handlers.UpdateShortlist(db))
+		shortlists.DELETE("/:id", handlers.DeleteShortlist(db))
+		shortlists.PUT("/:id/facilities/:facilityId", handlers.SaveShortlistFacility(db))
+		shortlists.DELETE("/:id/facilities/:facilityId", handlers.RemoveShortlistFacility(db))
+		shortlists.POST("/:id/requests", handlers.CreateShortlistRequests(db))
+	}
+
+	favorites := router.Group("/api/favorites")
+	favorites.Use(middleware.AuthMiddleware(), middleware.RequireRole("hospital"))
+	{
+		favorites.GET("", handlers.GetFavorites(db))
+		favorites.PUT("/:facilityId", handlers.AddFavorite(db))
+		favorites.DELETE("/:facilityId", handlers.RemoveFavorite(db))
+	}
+
+	// Placement request template routes
+	requestTemplates := router.Group("/api/request-templates")
+	requestTemplates.Use(middleware.AuthMiddleware(), middleware.RequireRole("hospital"))
+	{
+		requestTemplates.GET("", handlers.GetRequestTemplates(db))
+		requestTemplates.POST("", handlers.CreateRequestTemplate(db))
+		requestTemplates.GET("/:id", handlers.GetRequestTemplate(db))
+		requestTemplates.PUT("/:id", handlers.UpdateRequestTemplate(db))
+		requestTemplates.DELETE("/:id", handlers.DeleteRequestTemplate(db))
+	}
+
+	// Notification routes
+	notifications := router.Group("/api/notifications")
+	notifications.Use(middleware.AuthMiddleware())
+	{
+		notifications.GET("", notificationHandler.List)
+		notifications.POST("/:id/read", notificationHandler.MarkRead)
+		notifications.POST("/read-all", notificationHandler.MarkAllRead)
+	}
+
 	// Unread counts route
 	router.GET("/api/unread", middleware.AuthMiddleware(), handlers.GetUnreadCounts(db))
 
@@ -110,14 +219,33 @@ func main() {
 		requests.POST("", handlers.CreatePlacementRequest(db))
 		requests.GET("", handlers.GetPlacementRequests(db))
 		requests.GET("/:id", handlers.GetPlacementRequestByID(db))
+		requests.GET("/:id/history", handlers.GetPlacementRequestHistory(db))
 		requests.PUT("/:id", handlers.UpdatePlacementRequest(db))
-		requests.DELETE("/:id", handlers.CancelPlacementRequest(db))
+		requests.DELETE("/:id", handlers.WithdrawPlacementRequest(db))
+		requests.POST("/:id/submit", handlers.SubmitPlacementRequest(db))
 		requests.POST("/:id/accept", handlers.AcceptPlacementRequest(db))
 		requests.POST("/:id/reject", handlers.RejectPlacementRequest(db))
+		requests.POST("/:id/accept-with-conditions", handlers.AcceptPlacementRequestWithConditions(db))
+		requests.POST("/:id/conditions/accept", handlers.AcceptRequestConditions(db))
+		requests.POST("/:id/conditions/decline", handlers.DeclineRequestConditions(db))
+		requests.POST("/:id/withdraw", handlers.WithdrawPlacementRequest(db))
 		requests.POST("/:id/read", handlers.MarkRequestAsRead(db))
+		requests.POST("/:id/waitlist", handlers.AddToWaitlist(db))
+		requests.POST("/:id/forward", handlers.ForwardPlacementRequest(db))
 		requests.POST("/read-all", handlers.MarkAllRequestsAsRead(db))
 	}
 
+	// Waitlist routes
+	waitlist := router.Group("/api/waitlist")
+	waitlist.Use(middleware.AuthMiddleware())
+	{
+		waitlist.GET("", handlers.GetWaitlist(db))
+		waitlist.PUT("/order", handlers.ReorderWaitlist(db))
+		waitlist.PUT("/:id", handlers.UpdateWaitlistEntry(db))
+		waitlist.POST("/:id/convert", handlers.ConvertWaitlistEntry(db))
+		waitlist.DELETE("/:id", handlers.RemoveWaitlistEntry(db))
+	}
+
 	// Message room routes
 	rooms := router.Group("/api/rooms")
 	rooms.Use(middleware.AuthMiddleware())
@@ -131,11 +259,41 @@ func main() {
 		rooms.DELETE("/:id/files/:fileId", handlers.DeleteRoomFile(db))
 		rooms.POST("/:id/accept", handlers.AcceptRoom(db))
 		rooms.POST("/:id/reject", handlers.RejectRoom(db))
+		rooms.POST("/:id/withdraw", handlers.WithdrawRoom(db))
 		rooms.POST("/:id/complete", handlers.CompleteRoom(db))
 		rooms.POST("/:id/cancel-completion", handlers.CancelCompletion(db))
+		rooms.GET("/:id/checklist", handlers.GetRoomChecklist(db))
+		rooms.POST("/:id/checklist", handlers.AddRoomChecklistItem(db))
+		rooms.PUT("/:id/checklist/:itemId", handlers.UpdateRoomChecklistItem(db))
+		rooms.DELETE("/:id/checklist/:itemId", handlers.DeleteRoomChecklistItem(db))
+		rooms.GET("/:id/schedule", handlers.GetRoomSchedule(db))
+		rooms.POST("/:id/schedule/proposals", handlers.ProposeSchedule(db))
+		rooms.POST("/:id/schedule/proposals/:proposalId/accept", handlers.AcceptScheduleProposal(db))
+		rooms.POST("/:id/schedule/proposals/:proposalId/decline", handlers.DeclineScheduleProposal(db))
+		rooms.DELETE("/:id/schedule/proposals/:proposalId", handlers.CancelScheduleProposal(db))
+		rooms.POST("/:id/reopen", handlers.RequestRoomReopen(db))
+		rooms.DELETE("/:id/reopen", handlers.CancelRoomReopen(db))
+		rooms.POST("/:id/reopen/confirm", handlers.ConfirmRoomReopen(db))
+		rooms.POST("/:id/reopen/decline", handlers.DeclineRoomReopen(db))
+		rooms.POST("/:id/hold", handlers.HoldBed(db))
+		rooms.DELETE("/:id/hold", handlers.ReleaseBed(db))
+		rooms.GET("/:id/rating", handlers.GetRoomRating(db))
+		rooms.POST("/:id/rating", handlers.RateRoom(db))
 		rooms.POST("/:id/read", handlers.MarkRoomAsRead(db))
 	}
 
+	// Schedule routes
+	schedule := router.Group("/api/schedule")
+	schedule.Use(middleware.AuthMiddleware())
+	{
+		schedule.GET("", handlers.GetUpcomingSchedule(db))
+		schedule.GET("/feed", handlers.GetCalendarFeed(db))
+		schedule.POST("/feed/rotate", handlers.RotateCalendarFeed(db))
+	}
+
+	// iCalendar feeds are authenticated by the token in the URL
+	router.GET("/api/calendar/:token", handlers.ServeCalendarFeed(db))
+
 	// Admin routes
 	admin := router.Group("/api/admin")
 	admin.Use(middleware.AuthMiddleware(), middleware.RequireRole("admin"))
@@ -149,8 +307,43 @@ func main() {
 		// Facility management
 		admin.POST("/facilities", adminHandler.CreateFacility)
 		admin.GET("/facilities", adminHandler.ListFacilities)
+		admin.POST("/facilities/import", adminHandler.ImportFacilities)
+		admin.GET("/facilities/export", adminHandler.ExportFacilities)
 		admin.PUT("/facilities/:id", adminHandler.UpdateFacility)
 		admin.DELETE("/facilities/:id", adminHandler.DeleteFacility)
+
+		// Facility change moderation
+		admin.GET("/facility-changes", adminHandler.ListFacilityChanges)
+		admin.POST("/facility-changes/:id/approve", adminHandler.ApproveFacilityChange)
+		admin.POST("/facility-changes/:id/reject", adminHandler.RejectFacilityChange)
+		admin.GET("/settings/facility-moderation", adminHandler.GetFacilityModeration)
+		admin.PUT("/settings/facility-moderation", adminHandler.UpdateFacilityModeration)
+		admin.GET("/settings/handoff-checklist", adminHandler.GetHandoffChecklistSetting)
+		admin.PUT("/settings/handoff-checklist", adminHandler.UpdateHandoffChecklistSetting)
+
+		// Rating moderation
+		admin.GET("/ratings", handlers.AdminGetRatings(db))
+		admin.PUT("/ratings/:id", handlers.AdminModerateRating(db))
+
+		// Facility type taxonomy
+		admin.POST("/facility-types", facilityTypeHandler.Create)
+		admin.PUT("/facility-types/:id", facilityTypeHandler.Update)
+		admin.DELETE("/facility-types/:id", facilityTypeHandler.Delete)
+
+		// Duplicate facilities and hospitals
+		admin.GET("/duplicates", duplicateHandler.List)
+		admin.POST("/duplicates/dismiss", duplicateHandler.Dismiss)
+		admin.POST("/duplicates/merge", duplicateHandler.Merge)
+		admin.GET("/merges", duplicateHandler.ListMerges)
+
+		// Handoff checklist templates
+		admin.GET("/handoff-templates", handlers.AdminGetHandoffTemplate(db))
+		admin.POST("/handoff-templates", handlers.AdminCreateHandoffTemplateItem(db))
+		admin.PUT("/handoff-templates/:id", handlers.AdminUpdateHandoffTemplateItem(db))
+		admin.DELETE("/handoff-templates/:id", handlers.AdminDeleteHandoffTemplateItem(db))
+
+		// Reopening closed rooms without the parties' agreement
+		admin.POST("/rooms/:id/reopen", handlers.AdminReopenRoom(db))
 	}
 
 	// Start server
@@ -168,3 +361,46 @@ func getEnv(key, defaultValue string) string {
 	}
 	return value
 }
+
+// expireBedHolds periodically marks expired bed holds so held units return to availability
+func expireBedHolds(db *sql.DB) {
+	ticker := time.NewTicker(10 * time.Minute)
+	defer ticker.Stop()
+
+	for {
+		if n, err := models.ExpireBedHolds(db); err != nil {
+			log.Printf("Failed to expire bed holds: %v", err)
+		} else if n > 0 {
+			log.Printf("Expired %d bed holds", n)
+		}
+		<-ticker.C
+	}
+}
+
+// refreshSavedSearches periodically re-runs saved searches to detect newly matching facilities
+func refreshSavedSearches(alerter *services.SavedSearchAlerter) {
+	ticker := time.NewTicker(5 * time.Minute)
+	defer ticker.Stop()
+
+	for {
+		if n, err := alerter.RefreshAll(); err != nil {
+			log.Printf("Failed to refresh saved searches: %v", err)
+		} else if n > 0 {
+			log.Printf("Found %d new saved search matches", n)
+		}
+		<-ticker.C
+	}
+}
+
+// refreshFacilityResponsiveness periodically refreshes the facility responsiveness materialized view
+func refreshFacilityResponsiveness(db *sql.DB) {
+	ticker := time.NewTicker(30 * time.Minute)
+	defer ticker.Stop()
+
+	for {
+		if err := models.RefreshFacilityResponsiveness(db); err != nil {
+			log.Printf("Failed to refresh facility responsiveness: %v", err)
+		}
+		<-ticker.C
+	}
+}
@@ -1,24 +1,31 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"sort"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/social-worker-platform/backend/models"
+	"github.com/social-worker-platform/backend/services"
 )
 
 type AdminHandler struct {
 	hospitalRepo *models.HospitalRepository
 	facilityRepo *models.FacilityRepository
 	userRepo     *models.UserRepository
+	settingRepo  *models.SettingRepository
+	importer     *services.FacilityImporter
 }
 
-func NewAdminHandler(hospitalRepo *models.HospitalRepository, facilityRepo *models.FacilityRepository, userRepo *models.UserRepository) *AdminHandler {
+func NewAdminHandler(hospitalRepo *models.HospitalRepository, facilityRepo *models.FacilityRepository, userRepo *models.UserRepository, settingRepo *models.SettingRepository) *AdminHandler {
 	return &AdminHandler{
 		hospitalRepo: hospitalRepo,
 		facilityRepo: facilityRepo,
 		userRepo:     userRepo,
+		settingRepo:  settingRepo,
+		importer:     services.NewFacilityImporter(facilityRepo, services.NewGeocodingService()),
 	}
 }
 
@@ -52,6 +59,7 @@ type UpdateFacilityAdminRequest struct {
 	Phone                string `json:"phone"`
 	BedCapacity          int    `json:"bed_capacity" binding:"min=0"`
 	AcceptanceConditions string `json:"acceptance_conditions"`
+	FacilityMetadataRequest
 }
 
 // Hospital Management
@@ -229,6 +237,8 @@ func (h *AdminHandler) UpdateFacility(c *gin.Context) {
 		return
 	}
 
+	before := models.ProfileOf(facility)
+
 	if req.Name != "" {
 		facility.Name = req.Name
 	}
@@ -244,8 +254,23 @@ func (h *AdminHandler) UpdateFacility(c *gin.Context) {
 	if req.AcceptanceConditions != "" {
 		facility.AcceptanceConditions = req.AcceptanceConditions
 	}
+	if err := req.FacilityMetadataRequest.applyTo(facility); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid acceptance conditions", "details": err.Error()})
+		return
+	}
+	if err := h.facilityRepo.ValidateFacilityType(facility, before.FacilityType); err != nil {
+		writeFacilityTypeError(c, err)
+		return
+	}
 
-	if err := h.facilityRepo.Update(facility); err != nil {
+	changes, err := models.DiffProfiles(before, models.ProfileOf(facility))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update facility"})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	if _, _, err := h.facilityRepo.UpdateWithHistory(facility, userID.(int), changes, nil); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update facility"})
 		return
 	}
@@ -282,3 +307,161 @@ func (h *AdminHandler) DeleteFacility(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "Facility account deactivated successfully"})
 }
+
+// Facility change moderation
+
+type RejectFacilityChangeRequest struct {
+	Comment string `json:"comment"`
+}
+
+type FacilityModerationRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+func writeFacilityChangeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrFacilityChangeNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Facility change not found"})
+	case errors.Is(err, models.ErrFacilityChangeNotPending):
+		c.JSON(http.StatusConflict, gin.H{"error": "Facility change has already been reviewed"})
+	case errors.Is(err, models.ErrFacilityChangeMerged):
+		c.JSON(http.StatusConflict, gin.H{"error": "Facility has been merged into another facility"})
+	case errors.Is(err, models.ErrFacilityChangeStale):
+		c.JSON(http.StatusConflict, gin.H{"error": "Facility was edited after this change was made. Reject it and ask for a new one", "details": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to review facility change"})
+	}
+}
+
+// ListFacilityChanges returns facility changes by status (pending by default)
+func (h *AdminHandler) ListFacilityChanges(c *gin.Context) {
+	status := c.DefaultQuery("status", models.FacilityChangePending)
+	switch status {
+	case models.FacilityChangeApplied, models.FacilityChangePending, models.FacilityChangeApproved, models.FacilityChangeRejected:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+		return
+	}
+
+	changes, err := h.facilityRepo.GetChangesByStatus(status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve facility changes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, changes)
+}
+
+// ApproveFacilityChange applies a pending change to the facility profile
+func (h *AdminHandler) ApproveFacilityChange(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid change ID"})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	change, facility, err := h.facilityRepo.ApproveChange(id, userID.(int))
+	if err != nil {
+		writeFacilityChangeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"change": change, "facility": facility})
+}
+
+// RejectFacilityChange discards a pending change
+func (h *AdminHandler) RejectFacilityChange(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid change ID"})
+		return
+	}
+
+	var req RejectFacilityChangeRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format", "details": err.Error()})
+			return
+		}
+	}
+
+	userID, _ := c.Get("userID")
+	change, err := h.facilityRepo.RejectChange(id, userID.(int), req.Comment)
+	if err != nil {
+		writeFacilityChangeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, change)
+}
+
+// GetFacilityModeration reports whether sensitive facility changes require approval
+func (h *AdminHandler) GetFacilityModeration(c *gin.Context) {
+	enabled, err := h.settingRepo.GetBool(models.SettingFacilityModeration, false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve setting"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"enabled": enabled, "fields": sensitiveFacilityFieldNames()})
+}
+
+// UpdateFacilityModeration turns approval of sensitive facility changes on or off
+func (h *AdminHandler) UpdateFacilityModeration(c *gin.Context) {
+	var req FacilityModerationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format", "details": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	if err := h.settingRepo.SetBool(models.SettingFacilityModeration, *req.Enabled, userID.(int)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update setting"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"enabled": *req.Enabled, "fields": sensitiveFacilityFieldNames()})
+}
+
+// GetHandoffChecklistSetting reports whether unchecked required handoff checklist items
+// keep rooms from being completed
+func (h *AdminHandler) GetHandoffChecklistSetting(c *gin.Context) {
+	enabled, err := h.settingRepo.GetBool(models.SettingChecklistBlocksCompletion, false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve setting"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"enabled": enabled})
+}
+
+type HandoffChecklistSettingRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// UpdateHandoffChecklistSetting turns blocking completion on unchecked required items on or off
+func (h *AdminHandler) UpdateHandoffChecklistSetting(c *gin.Context) {
+	var req HandoffChecklistSettingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format", "details": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	if err := h.settingRepo.SetBool(models.SettingChecklistBlocksCompletion, *req.Enabled, userID.(int)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update setting"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"enabled": *req.Enabled})
+}
+
+func sensitiveFacilityFieldNames() []string {
+	fields := make([]string, 0, len(models.SensitiveFacilityFields))
+	for field := range models.SensitiveFacilityFields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/social-worker-platform/backend/models"
+	"github.com/social-worker-platform/backend/workflow"
+)
+
+// maxBedHoldHours caps how long a facility may keep a bed held for one negotiation
+const maxBedHoldHours = 14 * 24
+
+type bedHoldRequest struct {
+	RoomTypeID int `json:"room_type_id" binding:"required"`
+	HoldHours  int `json:"hold_hours" binding:"omitempty,min=1"`
+}
+
+// holdBed places a hold on one unit of the requested room type for the room.
+// It writes the error response itself and returns nil when the hold could not be placed.
+func holdBed(c *gin.Context, db *sql.DB, room *models.MessageRoom, req bedHoldRequest) *models.BedHold {
+	duration := models.DefaultBedHoldDuration
+	if req.HoldHours > 0 {
+		if req.HoldHours > maxBedHoldHours {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "hold_hours is too long"})
+			return nil
+		}
+		duration = time.Duration(req.HoldHours) * time.Hour
+	}
+
+	hold := &models.BedHold{
+		RoomID:     room.ID,
+		RoomTypeID: req.RoomTypeID,
+		FacilityID: room.FacilityID,
+		ExpiresAt:  time.Now().Add(duration),
+	}
+
+	if err := models.CreateBedHold(db, hold); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Room type not found"})
+		} else if errors.Is(err, models.ErrNoBedAvailable) {
+			c.JSON(http.StatusConflict, gin.H{"error": "No bed available for this room type"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hold bed"})
+		}
+		return nil
+	}
+
+	return hold
+}
+
+// HoldBed handles POST /api/rooms/:id/hold (facility holds a bed for an accepted placement)
+func HoldBed(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userID")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		if !canTrigger(c, workflow.MessageRooms, workflow.OpHoldBed) {
+			return
+		}
+
+		roomID := c.Param("id")
+
+		room, err := models.GetMessageRoomByID(db, roomID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve room"})
+			return
+		}
+
+		if room == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Room not found"})
+			return
+		}
+
+		// Check authorization
+		facility, err := models.GetFacilityByUserID(db, userID.(int))
+		if err != nil || facility == nil || facility.ID != room.FacilityID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+			return
+		}
+
+		if err := workflow.MessageRooms.Allow(room.Status, workflow.OpHoldBed, roleOf(c)); err != nil {
+			writeWorkflowError(c, err)
+			return
+		}
+
+		var req bedHoldRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		hold := holdBed(c, db, room, req)
+		if hold == nil {
+			return
+		}
+
+		c.JSON(http.StatusCreated, hold)
+	}
+}
+
+// ReleaseBed handles DELETE /api/rooms/:id/hold (facility releases the held bed)
+func ReleaseBed(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userID")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		if !canTrigger(c, workflow.MessageRooms, workflow.OpReleaseBed) {
+			return
+		}
+
+		roomID := c.Param("id")
+
+		room, err := models.GetMessageRoomByID(db, roomID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve room"})
+			return
+		}
+
+		if room == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Room not found"})
+			return
+		}
+
+		// Check authorization
+		facility, err := models.GetFacilityByUserID(db, userID.(int))
+		if err != nil || facility == nil || facility.ID != room.FacilityID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+			return
+		}
+
+		if err := workflow.MessageRooms.Allow(room.Status, workflow.OpReleaseBed, roleOf(c)); err != nil {
+			writeWorkflowError(c, err)
+			return
+		}
+
+		if err := models.ReleaseBedHold(db, roomID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to release bed"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Bed released"})
+	}
+}
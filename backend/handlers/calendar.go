@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/social-worker-platform/backend/models"
+	"github.com/social-worker-platform/backend/services"
+)
+
+// calendarFeedHistory is how far back a feed lists past events, so subscribed
+// calendars keep recent admissions
+const calendarFeedHistory = 90 * 24 * time.Hour
+
+// organizationOfUser returns the role of the calling hospital or facility user and the
+// ID of their organization. It writes the error response itself and returns ok=false
+// for other users.
+func organizationOfUser(c *gin.Context, db *sql.DB) (role string, orgID int, ok bool) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return "", 0, false
+	}
+
+	switch role = roleOf(c); role {
+	case "hospital":
+		hospital, err := models.GetHospitalByUserID(db, userID.(int))
+		if err != nil || hospital == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Hospital not found"})
+			return "", 0, false
+		}
+		return role, hospital.ID, true
+	case "facility":
+		facility, err := models.GetFacilityByUserID(db, userID.(int))
+		if err != nil || facility == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Facility not found"})
+			return "", 0, false
+		}
+		return role, facility.ID, true
+	default:
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only hospital and facility users have a schedule"})
+		return "", 0, false
+	}
+}
+
+func roomEventsOf(db *sql.DB, role string, orgID int, since time.Time) ([]*models.RoomEvent, error) {
+	if role == "hospital" {
+		return models.GetRoomEventsByHospitalID(db, orgID, since)
+	}
+	return models.GetRoomEventsByFacilityID(db, orgID, since)
+}
+
+// calendarFeedResponse describes a feed with the URL calendar apps subscribe to
+func calendarFeedResponse(c *gin.Context, feed *models.CalendarFeed) gin.H {
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return gin.H{
+		"url":        fmt.Sprintf("%s://%s/api/calendar/%s.ics", scheme, c.Request.Host, feed.Token),
+		"created_at": feed.CreatedAt,
+	}
+}
+
+// GetUpcomingSchedule handles GET /api/schedule. It lists the confirmed interviews,
+// admissions and transports of the caller's organization from now on.
+func GetUpcomingSchedule(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, orgID, ok := organizationOfUser(c, db)
+		if !ok {
+			return
+		}
+
+		events, err := roomEventsOf(db, role, orgID, time.Now())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve schedule"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"events": events})
+	}
+}
+
+// GetCalendarFeed handles GET /api/schedule/feed. The feed is created on first use.
+func GetCalendarFeed(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, orgID, ok := organizationOfUser(c, db)
+		if !ok {
+			return
+		}
+
+		feed, err := models.GetOrCreateCalendarFeed(db, role, orgID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve calendar feed"})
+			return
+		}
+
+		c.JSON(http.StatusOK, calendarFeedResponse(c, feed))
+	}
+}
+
+// RotateCalendarFeed handles POST /api/schedule/feed/rotate. The previous feed URL
+// stops working.
+func RotateCalendarFeed(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, orgID, ok := organizationOfUser(c, db)
+		if !ok {
+			return
+		}
+
+		feed, err := models.RotateCalendarFeed(db, role, orgID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rotate calendar feed"})
+			return
+		}
+
+		c.JSON(http.StatusOK, calendarFeedResponse(c, feed))
+	}
+}
+
+// ServeCalendarFeed handles GET /api/calendar/:token.ics. Calendar apps cannot send the
+// auth header, so the secret token in the URL identifies the organization.
+func ServeCalendarFeed(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := strings.TrimSuffix(c.Param("token"), ".ics")
+
+		feed, err := models.GetCalendarFeedByToken(db, token)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve calendar feed"})
+			return
+		}
+
+		if feed == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Calendar feed not found"})
+			return
+		}
+
+		role, orgID, orgName := "facility", 0, ""
+		if feed.HospitalID != nil {
+			role, orgID = "hospital", *feed.HospitalID
+			if hospital, err := models.NewHospitalRepository(db).GetByID(orgID); err == nil && hospital != nil {
+				orgName = hospital.Name
+			}
+		} else {
+			orgID = *feed.FacilityID
+			if facility, err := models.GetFacilityByID(db, orgID); err == nil && facility != nil {
+				orgName = facility.Name
+			}
+		}
+
+		events, err := roomEventsOf(db, role, orgID, time.Now().Add(-calendarFeedHistory))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve schedule"})
+			return
+		}
+
+		calendarEvents := make([]services.CalendarEvent, len(events))
+		for i, e := range events {
+			calendarEvents[i] = calendarEventOf(e, role, c.Request.Host)
+		}
+
+		c.Header("Content-Type", "text/calendar; charset=utf-8")
+		c.Header("Cache-Control", "no-cache")
+		c.Status(http.StatusOK)
+		if err := services.WriteICalendar(c.Writer, orgName+" 受け入れ予定", calendarEvents); err != nil {
+			log.Printf("Failed to write calendar feed %d: %v", feed.ID, err)
+		}
+	}
+}
+
+// calendarEventOf describes a room event for the feed of role, naming the other party.
+// Details are left to the room, which needs a login.
+func calendarEventOf(e *models.RoomEvent, role, host string) services.CalendarEvent {
+	counterpart := e.FacilityName
+	if role == "facility" {
+		counterpart = e.HospitalName
+	}
+
+	event := services.CalendarEvent{
+		// Stable across reschedules so calendar apps update the event in place
+		UID:     fmt.Sprintf("room-%s-%s@%s", e.RoomID, e.EventType, host),
+		Start:   e.StartsAt,
+		End:     e.EndsAt,
+		Summary: scheduleEventLabels[e.EventType] + "（" + counterpart + "）",
+		Updated: e.UpdatedAt,
+	}
+	if e.Location != nil {
+		event.Location = *e.Location
+	}
+
+	// Anyone holding the feed URL can read it, so nothing about the patient goes in,
+	// nor the free-text note, which may mention the patient
+	event.Description = fmt.Sprintf("依頼番号: %d\n相手先: %s", e.RequestID, counterpart)
+
+	return event
+}
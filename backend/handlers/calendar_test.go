@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/social-worker-platform/backend/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalendarEventOfLeavesOutPatientDetails(t *testing.T) {
+	note := "長男様が同席されます"
+	e := &models.RoomEvent{
+		RoomID:        "room-1",
+		EventType:     models.ScheduleInterview,
+		StartsAt:      time.Date(2026, 11, 15, 10, 0, 0, 0, time.UTC),
+		Note:          &note,
+		RequestID:     42,
+		HospitalName:  "さくら病院",
+		FacilityName:  "ひまわり苑",
+		PatientAge:    82,
+		PatientGender: "女性",
+	}
+
+	event := calendarEventOf(e, "facility", "example.com")
+
+	assert.Equal(t, "依頼番号: 42\n相手先: さくら病院", event.Description)
+	assert.NotContains(t, event.Description, "82")
+	assert.NotContains(t, event.Description, note)
+	assert.Contains(t, event.Summary, "さくら病院")
+}
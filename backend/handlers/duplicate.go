@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/social-worker-platform/backend/models"
+	"github.com/social-worker-platform/backend/services"
+)
+
+type DuplicateHandler struct {
+	mergeRepo *models.MergeRepository
+}
+
+func NewDuplicateHandler(mergeRepo *models.MergeRepository) *DuplicateHandler {
+	return &DuplicateHandler{mergeRepo: mergeRepo}
+}
+
+type DismissDuplicateRequest struct {
+	Kind     string `json:"kind" binding:"required"`
+	FirstID  int    `json:"first_id" binding:"required"`
+	SecondID int    `json:"second_id" binding:"required"`
+}
+
+type MergeOrganizationsRequest struct {
+	Kind        string `json:"kind" binding:"required"`
+	SurvivorID  int    `json:"survivor_id" binding:"required"`
+	DuplicateID int    `json:"duplicate_id" binding:"required"`
+}
+
+func writeMergeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrOrganizationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Organization not found"})
+	case errors.Is(err, models.ErrOrganizationAlreadyMerged):
+		c.JSON(http.StatusConflict, gin.H{"error": "Organization has already been merged"})
+	case errors.Is(err, models.ErrMergeSameOrganization):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot merge an organization into itself"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to merge organizations"})
+	}
+}
+
+// List handles GET /api/admin/duplicates?kind=facility|hospital, reporting probable
+// duplicates by name similarity, phone number, address and (for facilities) distance
+func (h *DuplicateHandler) List(c *gin.Context) {
+	kind := c.DefaultQuery("kind", models.OrganizationFacility)
+	if !models.IsOrganizationKind(kind) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "kind must be facility or hospital"})
+		return
+	}
+
+	records, err := h.mergeRepo.GetOrganizationRecords(kind)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get organizations"})
+		return
+	}
+	dismissed, err := h.mergeRepo.GetDismissedPairs(kind)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get dismissed duplicates"})
+		return
+	}
+
+	c.JSON(http.StatusOK, services.FindDuplicates(records, dismissed))
+}
+
+// Dismiss handles POST /api/admin/duplicates/dismiss for pairs that are not duplicates
+func (h *DuplicateHandler) Dismiss(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var req DismissDuplicateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format", "details": err.Error()})
+		return
+	}
+	if !models.IsOrganizationKind(req.Kind) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "kind must be facility or hospital"})
+		return
+	}
+
+	if err := h.mergeRepo.DismissDuplicate(req.Kind, req.FirstID, req.SecondID, userID.(int)); err != nil {
+		if errors.Is(err, models.ErrMergeSameOrganization) {
+			writeMergeError(c, err)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to dismiss duplicate"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Duplicate dismissed"})
+}
+
+// Merge handles POST /api/admin/duplicates/merge. Everything attached to the duplicate
+// moves to the survivor and the duplicate's account is deactivated.
+func (h *DuplicateHandler) Merge(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var req MergeOrganizationsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format", "details": err.Error()})
+		return
+	}
+
+	var merge *models.OrganizationMerge
+	var err error
+	switch req.Kind {
+	case models.OrganizationFacility:
+		merge, err = h.mergeRepo.MergeFacilities(req.SurvivorID, req.DuplicateID, userID.(int))
+	case models.OrganizationHospital:
+		merge, err = h.mergeRepo.MergeHospitals(req.SurvivorID, req.DuplicateID, userID.(int))
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "kind must be facility or hospital"})
+		return
+	}
+	if err != nil {
+		writeMergeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, merge)
+}
+
+// ListMerges handles GET /api/admin/merges, optionally filtered by kind
+func (h *DuplicateHandler) ListMerges(c *gin.Context) {
+	kind := c.Query("kind")
+	if kind != "" && !models.IsOrganizationKind(kind) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "kind must be facility or hospital"})
+		return
+	}
+
+	merges, err := h.mergeRepo.GetMerges(kind)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get merges"})
+		return
+	}
+
+	c.JSON(http.StatusOK, merges)
+}
@@ -1,9 +1,17 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/social-worker-platform/backend/models"
@@ -13,14 +21,29 @@ import (
 type FacilityHandler struct {
 	facilityRepo     *models.FacilityRepository
 	userRepo         *models.UserRepository
+	settingRepo      *models.SettingRepository
 	geocodingService *services.GeocodingService
+	travelTime       services.TravelTimeEstimator
+	imageProcessor   *services.ImageProcessor
+	uploadDir        string
+	maxUploadBytes   int64
 }
 
-func NewFacilityHandler(facilityRepo *models.FacilityRepository, userRepo *models.UserRepository) *FacilityHandler {
+func NewFacilityHandler(facilityRepo *models.FacilityRepository, userRepo *models.UserRepository, settingRepo *models.SettingRepository) *FacilityHandler {
+	maxUploadMB, err := strconv.Atoi(getEnv("MAX_UPLOAD_SIZE_MB", "10"))
+	if err != nil || maxUploadMB <= 0 {
+		maxUploadMB = 10
+	}
+
 	return &FacilityHandler{
 		facilityRepo:     facilityRepo,
 		userRepo:         userRepo,
+		settingRepo:      settingRepo,
 		geocodingService: services.NewGeocodingService(),
+		travelTime:       services.NewRoadSpeedEstimator(),
+		imageProcessor:   services.NewImageProcessor(services.FacilityImageVariants),
+		uploadDir:        getEnv("UPLOAD_DIR", "./uploads"),
+		maxUploadBytes:   int64(maxUploadMB) << 20,
 	}
 }
 
@@ -43,30 +66,173 @@ type UpdateFacilityRequest struct {
 	Longitude            *float64 `json:"longitude"`
 	MonthlyFee           *int     `json:"monthly_fee" binding:"omitempty,min=0"`
 	MedicineCost         *int     `json:"medicine_cost" binding:"omitempty,min=0"`
+	FacilityMetadataRequest
+}
+
+// FacilityMetadataRequest holds the descriptive fields editable by both facilities and admins
+type FacilityMetadataRequest struct {
+	FacilityType             *string         `json:"facility_type" binding:"omitempty,min=1,max=50"`
+	AcceptanceConditionsJSON json.RawMessage `json:"acceptance_conditions_json"`
+	TypeAttributes           json.RawMessage `json:"type_attributes"`
+	Description              *string         `json:"description"`
+	ContactName              *string         `json:"contact_name" binding:"omitempty,max=100"`
+	ContactHours             *string         `json:"contact_hours" binding:"omitempty,max=100"`
+}
+
+// applyTo copies the provided fields onto the facility. Empty strings clear the optional
+// text fields; acceptance conditions are validated against the schema.
+func (m FacilityMetadataRequest) applyTo(facility *models.Facility) error {
+	if m.FacilityType != nil {
+		// Attributes of the previous type don't carry over to a new one
+		if *m.FacilityType != facility.FacilityType && m.TypeAttributes == nil {
+			facility.TypeAttributes = json.RawMessage(`{}`)
+		}
+		facility.FacilityType = *m.FacilityType
+	}
+	if m.AcceptanceConditionsJSON != nil {
+		conditions, err := models.NormalizeAcceptanceConditions(m.AcceptanceConditionsJSON)
+		if err != nil {
+			return err
+		}
+		facility.AcceptanceConditionsJSON = conditions
+	}
+	if m.TypeAttributes != nil {
+		facility.TypeAttributes = m.TypeAttributes
+	}
+	if m.Description != nil {
+		facility.Description = optionalString(*m.Description)
+	}
+	if m.ContactName != nil {
+		facility.ContactName = optionalString(*m.ContactName)
+	}
+	if m.ContactHours != nil {
+		facility.ContactHours = optionalString(*m.ContactHours)
+	}
+	return nil
+}
+
+func optionalString(value string) *string {
+	if strings.TrimSpace(value) == "" {
+		return nil
+	}
+	return &value
 }
 
 type SearchFacilityRequest struct {
-	Name             string   `form:"name"`
-	Address          string   `form:"address"`
-	HasAvailableBeds bool     `form:"has_available_beds"`
-	Latitude         *float64 `form:"latitude"`
-	Longitude        *float64 `form:"longitude"`
-	MaxDistanceKm    *float64 `form:"max_distance_km"`
-	MinMonthlyFee    *int     `form:"min_monthly_fee"`
-	MaxMonthlyFee    *int     `form:"max_monthly_fee"`
-	MinMedicineCost  *int     `form:"min_medicine_cost"`
-	MaxMedicineCost  *int     `form:"max_medicine_cost"`
-	SortBy           string   `form:"sort_by"`    // distance, monthly_fee, medicine_cost, available_beds
-	SortOrder        string   `form:"sort_order"` // asc, desc
-	// Acceptance conditions filters
-	Ventilator    *bool `form:"ventilator"`
-	IvAntibiotics *bool `form:"iv_antibiotics"`
-	TubeFeeding   *bool `form:"tube_feeding"`
-	Tracheostomy  *bool `form:"tracheostomy"`
-	Dialysis      *bool `form:"dialysis"`
-	Oxygen        *bool `form:"oxygen"`
-	PressureUlcer *bool `form:"pressure_ulcer"`
-	Dementia      *bool `form:"dementia"`
+	Name              string   `form:"name" json:"name"`
+	Address           string   `form:"address" json:"address"`
+	HasAvailableBeds  bool     `form:"has_available_beds" json:"has_available_beds"`
+	Latitude          *float64 `form:"latitude" json:"latitude"`
+	Longitude         *float64 `form:"longitude" json:"longitude"`
+	MaxDistanceKm     *float64 `form:"max_distance_km" json:"max_distance_km"`
+	MinMonthlyFee     *int     `form:"min_monthly_fee" json:"min_monthly_fee"`
+	MaxMonthlyFee     *int     `form:"max_monthly_fee" json:"max_monthly_fee"`
+	MinMedicineCost   *int     `form:"min_medicine_cost" json:"min_medicine_cost"`
+	MaxMedicineCost   *int     `form:"max_medicine_cost" json:"max_medicine_cost"`
+	CareLevel         string   `form:"care_level" json:"care_level"`
+	IncomeTier        string   `form:"income_tier" json:"income_tier"`
+	MaxEstimatedTotal *int     `form:"max_estimated_total" json:"max_estimated_total"`
+	SortBy            string   `form:"sort_by" json:"sort_by"`       // distance, travel_time, monthly_fee, medicine_cost, available_beds, estimated_total, responsiveness
+	SortOrder         string   `form:"sort_order" json:"sort_order"` // asc, desc
+	// Municipality code (5 or 6 digits) of the patient's home
+	ResidenceMunicipalityCode string `form:"residence_municipality_code" json:"residence_municipality_code"`
+	// Repeat facility_type to match any of several types, e.g. ?facility_type=特別養護老人ホーム&facility_type=グループホーム
+	FacilityTypes []string `form:"facility_type" json:"facility_types"`
+	// In query strings acceptance condition filters are given by schema key, e.g. ?ventilator=true
+	AcceptanceConditions []string `form:"-" json:"acceptance_conditions"`
+}
+
+// toParams validates the request and converts it to repository search parameters
+func (req SearchFacilityRequest) toParams() (models.FacilitySearchParams, error) {
+	// Validate: both latitude and longitude must be provided together
+	if (req.Latitude != nil) != (req.Longitude != nil) {
+		return models.FacilitySearchParams{}, fmt.Errorf("Both latitude and longitude are required for distance search")
+	}
+
+	// Validate: sorting by travel time needs the starting point
+	if req.SortBy == "travel_time" && req.Latitude == nil {
+		return models.FacilitySearchParams{}, fmt.Errorf("latitude and longitude are required to sort by travel_time")
+	}
+
+	// Validate: max_distance_km must be positive
+	if req.MaxDistanceKm != nil && *req.MaxDistanceKm <= 0 {
+		return models.FacilitySearchParams{}, fmt.Errorf("max_distance_km must be positive")
+	}
+
+	for _, key := range req.AcceptanceConditions {
+		if !models.IsAcceptanceCondition(key) {
+			return models.FacilitySearchParams{}, fmt.Errorf("unknown acceptance condition: %s", key)
+		}
+	}
+
+	residence := req.ResidenceMunicipalityCode
+	if residence != "" {
+		code, err := models.NormalizeMunicipalityCode(residence)
+		if err != nil {
+			return models.FacilitySearchParams{}, err
+		}
+		residence = code
+	}
+
+	if req.CareLevel != "" && !models.IsCareLevel(req.CareLevel) {
+		return models.FacilitySearchParams{}, fmt.Errorf("unknown care level: %s", req.CareLevel)
+	}
+	if req.IncomeTier != "" && !models.IsIncomeTier(req.IncomeTier) {
+		return models.FacilitySearchParams{}, fmt.Errorf("unknown income tier: %s", req.IncomeTier)
+	}
+
+	return models.FacilitySearchParams{
+		Name:             req.Name,
+		Address:          req.Address,
+		HasAvailableBeds: req.HasAvailableBeds,
+		UserLatitude:     req.Latitude,
+		UserLongitude:    req.Longitude,
+		MaxDistanceKm:    req.MaxDistanceKm,
+		MinMonthlyFee:    req.MinMonthlyFee,
+		MaxMonthlyFee:    req.MaxMonthlyFee,
+		MinMedicineCost:  req.MinMedicineCost,
+		MaxMedicineCost:  req.MaxMedicineCost,
+		SortBy:           req.SortBy,
+		SortOrder:        req.SortOrder,
+		// Fee estimate
+		EstimateCareLevel:  req.CareLevel,
+		EstimateIncomeTier: req.IncomeTier,
+		MaxEstimatedTotal:  req.MaxEstimatedTotal,
+		FacilityTypes:      req.FacilityTypes,
+		// Acceptance conditions
+		AcceptanceConditions: req.AcceptanceConditions,
+	}, nil
+}
+
+// writeFacilityTypeError maps facility type validation errors to responses
+func writeFacilityTypeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrFacilityTypeNotFound):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown facility type"})
+	case errors.Is(err, models.ErrFacilityTypeInactive):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Facility type is no longer available"})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid type attributes", "details": err.Error()})
+	}
+}
+
+// acceptanceConditionFilters collects the schema conditions requested as ?<key>=true
+func acceptanceConditionFilters(c *gin.Context) ([]string, error) {
+	var keys []string
+	for _, cond := range models.AcceptanceConditionSchema {
+		value := c.Query(cond.Key)
+		if value == "" {
+			continue
+		}
+		accepted, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be true or false", cond.Key)
+		}
+		if accepted {
+			keys = append(keys, cond.Key)
+		}
+	}
+	return keys, nil
 }
 
 func (h *FacilityHandler) Create(c *gin.Context) {
@@ -119,40 +285,20 @@ func (h *FacilityHandler) List(c *gin.Context) {
 		return
 	}
 
-	// Validate: both latitude and longitude must be provided together
-	if (req.Latitude != nil) != (req.Longitude != nil) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Both latitude and longitude are required for distance search"})
+	conditions, err := acceptanceConditionFilters(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters", "details": err.Error()})
 		return
 	}
+	req.AcceptanceConditions = conditions
 
-	// Validate: max_distance_km must be positive
-	if req.MaxDistanceKm != nil && *req.MaxDistanceKm <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "max_distance_km must be positive"})
+	params, err := req.toParams()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-
-	params := models.FacilitySearchParams{
-		Name:             req.Name,
-		Address:          req.Address,
-		HasAvailableBeds: req.HasAvailableBeds,
-		UserLatitude:     req.Latitude,
-		UserLongitude:    req.Longitude,
-		MaxDistanceKm:    req.MaxDistanceKm,
-		MinMonthlyFee:    req.MinMonthlyFee,
-		MaxMonthlyFee:    req.MaxMonthlyFee,
-		MinMedicineCost:  req.MinMedicineCost,
-		MaxMedicineCost:  req.MaxMedicineCost,
-		SortBy:           req.SortBy,
-		SortOrder:        req.SortOrder,
-		// Acceptance conditions
-		Ventilator:    req.Ventilator,
-		IvAntibiotics: req.IvAntibiotics,
-		TubeFeeding:   req.TubeFeeding,
-		Tracheostomy:  req.Tracheostomy,
-		Dialysis:      req.Dialysis,
-		Oxygen:        req.Oxygen,
-		PressureUlcer: req.PressureUlcer,
-		Dementia:      req.Dementia,
+	if userID, exists := c.Get("userID"); exists {
+		params.FavoritesForUserID = userID.(int)
 	}
 
 	facilities, err := h.facilityRepo.SearchAdvanced(params)
@@ -161,9 +307,40 @@ func (h *FacilityHandler) List(c *gin.Context) {
 		return
 	}
 
+	if params.SortBy == "travel_time" {
+		h.sortByTravelTime(facilities, *params.UserLatitude, *params.UserLongitude, params.SortOrder == "desc")
+	}
+
 	c.JSON(http.StatusOK, facilities)
 }
 
+// sortByTravelTime sets the estimated travel minutes from the given point and sorts
+// by them. Facilities without coordinates go last.
+func (h *FacilityHandler) sortByTravelTime(facilities []*models.Facility, lat, lng float64, desc bool) {
+	for _, f := range facilities {
+		if f.Latitude == nil || f.Longitude == nil {
+			continue
+		}
+		minutes, err := h.travelTime.EstimateMinutes(lat, lng, *f.Latitude, *f.Longitude)
+		if err != nil {
+			log.Printf("Failed to estimate travel time to facility %d: %v", f.ID, err)
+			continue
+		}
+		f.TravelMinutes = &minutes
+	}
+
+	sort.SliceStable(facilities, func(i, j int) bool {
+		a, b := facilities[i].TravelMinutes, facilities[j].TravelMinutes
+		if a == nil || b == nil {
+			return a != nil
+		}
+		if desc {
+			return *a > *b
+		}
+		return *a < *b
+	})
+}
+
 func (h *FacilityHandler) GetByID(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
@@ -214,6 +391,8 @@ func (h *FacilityHandler) Update(c *gin.Context) {
 		return
 	}
 
+	before := models.ProfileOf(facility)
+
 	addressChanged := false
 	if req.Name != "" {
 		facility.Name = req.Name
@@ -246,6 +425,14 @@ func (h *FacilityHandler) Update(c *gin.Context) {
 	if req.MedicineCost != nil {
 		facility.MedicineCost = req.MedicineCost
 	}
+	if err := req.FacilityMetadataRequest.applyTo(facility); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid acceptance conditions", "details": err.Error()})
+		return
+	}
+	if err := h.facilityRepo.ValidateFacilityType(facility, before.FacilityType); err != nil {
+		writeFacilityTypeError(c, err)
+		return
+	}
 
 	// 住所が変更された場合は自動で緯度経度を更新（手動で緯度経度が指定されていない場合のみ）
 	if addressChanged && req.Latitude == nil && req.Longitude == nil {
@@ -258,14 +445,155 @@ func (h *FacilityHandler) Update(c *gin.Context) {
 		}
 	}
 
-	if err := h.facilityRepo.Update(facility); err != nil {
+	changes, err := models.DiffProfiles(before, models.ProfileOf(facility))
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update facility"})
 		return
 	}
 
+	// モデレーションが有効な場合、重要項目の変更は管理者の承認まで保留する
+	var pending []models.FieldChange
+	if userRole != "admin" {
+		moderated, err := h.settingRepo.GetBool(models.SettingFacilityModeration, false)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update facility"})
+			return
+		}
+		if moderated {
+			pending, changes = models.SplitSensitiveChanges(changes)
+			profile, err := models.ApplyFieldChanges(models.ProfileOf(facility), pending, true)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update facility"})
+				return
+			}
+			profile.ApplyTo(facility)
+		}
+	}
+
+	_, pendingChange, err := h.facilityRepo.UpdateWithHistory(facility, userID.(int), changes, pending)
+	if errors.Is(err, models.ErrFacilityChangeStale) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Facility was edited by someone else, reload and try again", "details": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update facility"})
+		return
+	}
+
+	if pendingChange != nil {
+		c.JSON(http.StatusAccepted, gin.H{"facility": facility, "pending_change": pendingChange})
+		return
+	}
+
 	c.JSON(http.StatusOK, facility)
 }
 
+// GetHistory returns the versioned change history of a facility, newest first
+func (h *FacilityHandler) GetHistory(c *gin.Context) {
+	id, ok := h.authorizeFacilityEdit(c)
+	if !ok {
+		return
+	}
+
+	changes, err := h.facilityRepo.GetChangesByFacilityID(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve facility history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, changes)
+}
+
+// GetHistoryDiff returns the field-level diff between two versions of a facility.
+// `to` defaults to the latest version; without `from` the changes recorded by `to` are returned.
+func (h *FacilityHandler) GetHistoryDiff(c *gin.Context) {
+	id, ok := h.authorizeFacilityEdit(c)
+	if !ok {
+		return
+	}
+
+	var to *models.FacilityChange
+	if toStr := c.Query("to"); toStr != "" {
+		if to = h.facilityChangeParam(c, id, toStr); to == nil {
+			return
+		}
+	} else {
+		var err error
+		to, err = h.facilityRepo.GetLatestSnapshotChange(id)
+		if errors.Is(err, models.ErrFacilityChangeNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Facility has no recorded changes"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve facility history"})
+			return
+		}
+	}
+
+	fromStr := c.Query("from")
+	if fromStr == "" {
+		c.JSON(http.StatusOK, gin.H{"from": nil, "to": to.ID, "changes": to.Changes})
+		return
+	}
+
+	from := h.facilityChangeParam(c, id, fromStr)
+	if from == nil {
+		return
+	}
+
+	if from.Snapshot == nil || to.Snapshot == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only applied or approved changes can be compared"})
+		return
+	}
+
+	var fromProfile, toProfile models.FacilityProfile
+	if json.Unmarshal(from.Snapshot, &fromProfile) != nil || json.Unmarshal(to.Snapshot, &toProfile) != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read facility history"})
+		return
+	}
+
+	changes, err := models.DiffProfiles(fromProfile, toProfile)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compare facility versions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"from": from.ID, "to": to.ID, "changes": changes})
+}
+
+// facilityChangeParam loads a change of the facility from a query value.
+// It writes the error response itself and returns nil when the change can't be used.
+func (h *FacilityHandler) facilityChangeParam(c *gin.Context, facilityID int, value string) *models.FacilityChange {
+	changeID, err := strconv.Atoi(value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid change ID"})
+		return nil
+	}
+
+	change, err := h.facilityRepo.GetChangeByID(changeID)
+	if err == nil && change.FacilityID != facilityID {
+		err = models.ErrFacilityChangeNotFound
+	}
+	if errors.Is(err, models.ErrFacilityChangeNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Facility change not found"})
+		return nil
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve facility history"})
+		return nil
+	}
+
+	return change
+}
+
+// GetAcceptanceConditionSchema returns the acceptance conditions that can be stored and searched
+func (h *FacilityHandler) GetAcceptanceConditionSchema(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"version":    models.AcceptanceConditionsVersion,
+		"conditions": models.AcceptanceConditionSchema,
+	})
+}
+
 func (h *FacilityHandler) GetMyFacility(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
@@ -319,11 +647,19 @@ func (h *FacilityHandler) UpdateImages(c *gin.Context) {
 		return
 	}
 
-	if err := h.facilityRepo.UpdateFacilityImages(id, req.Images); err != nil {
+	removedDirs, err := h.facilityRepo.UpdateFacilityImages(id, req.Images)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update images"})
 		return
 	}
 
+	// Delete files of uploaded images that were dropped from the list
+	for _, dir := range removedDirs {
+		if err := os.RemoveAll(dir); err != nil {
+			log.Printf("Failed to remove image directory '%s': %v", dir, err)
+		}
+	}
+
 	// Return updated facility with images
 	updatedFacility, err := h.facilityRepo.GetByID(id)
 	if err != nil {
@@ -334,6 +670,86 @@ func (h *FacilityHandler) UpdateImages(c *gin.Context) {
 	c.JSON(http.StatusOK, updatedFacility)
 }
 
+// UploadImage accepts a multipart image, strips its metadata and stores
+// thumbnail, card and full-size variants under the upload directory
+func (h *FacilityHandler) UploadImage(c *gin.Context) {
+	id, ok := h.authorizeFacilityEdit(c)
+	if !ok {
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File is required"})
+		return
+	}
+
+	if file.Size > h.maxUploadBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File is too large"})
+		return
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file"})
+		return
+	}
+	defer src.Close()
+
+	processed, err := h.imageProcessor.Process(src)
+	if err != nil {
+		if errors.Is(err, services.ErrUnsupportedImage) || errors.Is(err, services.ErrImageTooLarge) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process image"})
+		return
+	}
+
+	// Each upload gets its own directory holding one file per variant
+	name := strconv.FormatInt(time.Now().UnixNano(), 10)
+	dir := filepath.Join(h.uploadDir, "facilities", strconv.Itoa(id), name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create upload directory"})
+		return
+	}
+
+	urls := map[string]string{}
+	for variant, data := range processed.Variants {
+		if err := os.WriteFile(filepath.Join(dir, variant+".jpg"), data, 0644); err != nil {
+			os.RemoveAll(dir)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file"})
+			return
+		}
+		urls[variant] = fmt.Sprintf("/uploads/facilities/%d/%s/%s.jpg", id, name, variant)
+	}
+
+	imageType := c.PostForm("image_type")
+	if imageType == "" {
+		imageType = "exterior"
+	}
+	thumbnailURL, cardURL := urls["thumbnail"], urls["card"]
+	image := &models.FacilityImage{
+		FacilityID:   id,
+		ImageURL:     urls["full"],
+		ThumbnailURL: &thumbnailURL,
+		CardURL:      &cardURL,
+		ImageType:    imageType,
+		StorageDir:   &dir,
+	}
+	if caption := c.PostForm("caption"); caption != "" {
+		image.Caption = &caption
+	}
+
+	if err := h.facilityRepo.AddFacilityImage(image); err != nil {
+		os.RemoveAll(dir) // Clean up files if database insert fails
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create image record"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, image)
+}
+
 // GetRoomTypes returns all room types for a facility
 func (h *FacilityHandler) GetRoomTypes(c *gin.Context) {
 	idStr := c.Param("id")
@@ -349,14 +765,17 @@ func (h *FacilityHandler) GetRoomTypes(c *gin.Context) {
 		return
 	}
 
+	c.Header("ETag", roomTypesETag(roomTypes))
 	c.JSON(http.StatusOK, roomTypes)
 }
 
 type UpdateRoomTypesRequest struct {
 	RoomTypes []models.FacilityRoomTypeInput `json:"room_types" binding:"required"`
+	Version   string                         `json:"version"`
 }
 
-// UpdateRoomTypes updates all room types for a facility
+// UpdateRoomTypes replaces all room types for a facility. Requires the ETag of the list the
+// caller last read, either as If-Match or as "version" in the body.
 func (h *FacilityHandler) UpdateRoomTypes(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
@@ -390,8 +809,23 @@ func (h *FacilityHandler) UpdateRoomTypes(c *gin.Context) {
 		return
 	}
 
-	if err := h.facilityRepo.UpdateRoomTypes(id, req.RoomTypes); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	version := ifMatchValue(c.GetHeader("If-Match"))
+	if version == "" {
+		version = ifMatchValue(req.Version)
+	}
+	if version == "" {
+		c.JSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match header or version is required"})
+		return
+	}
+
+	before, err := h.facilityRepo.GetRoomTypesByFacilityID(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get room types"})
+		return
+	}
+
+	if err := h.facilityRepo.UpdateRoomTypes(id, version, req.RoomTypes); err != nil {
+		writeRoomTypeError(c, err)
 		return
 	}
 
@@ -402,5 +836,290 @@ func (h *FacilityHandler) UpdateRoomTypes(c *gin.Context) {
 		return
 	}
 
+	h.offerWaitlistOpenings(id, roomTypeOpenings(before, roomTypes))
+
+	c.Header("ETag", roomTypesETag(roomTypes))
 	c.JSON(http.StatusOK, roomTypes)
 }
+
+// roomTypeOpenings returns how many beds opened up per room type between two reads of
+// a facility's room types. Room types that are new in after count all their available beds.
+func roomTypeOpenings(before, after []*models.FacilityRoomType) map[int]int {
+	previous := make(map[int]int, len(before))
+	for _, rt := range before {
+		previous[rt.ID] = rt.Available
+	}
+
+	openings := map[int]int{}
+	for _, rt := range after {
+		if delta := rt.Available - previous[rt.ID]; delta > 0 {
+			openings[rt.ID] = delta
+		}
+	}
+	return openings
+}
+
+// offerWaitlistOpenings notifies the top of the facility's waitlist about beds that opened up.
+// Failures are logged: the room type change itself has already been saved.
+func (h *FacilityHandler) offerWaitlistOpenings(facilityID int, openings map[int]int) {
+	for roomTypeID, count := range openings {
+		if count <= 0 {
+			continue
+		}
+		if _, err := h.facilityRepo.OfferWaitlistOpenings(facilityID, roomTypeID, count); err != nil {
+			log.Printf("Failed to notify waitlist of facility %d: %v", facilityID, err)
+		}
+	}
+}
+
+// authorizeFacilityEdit parses the facility ID and checks that the caller may edit it.
+// It writes the error response itself and returns false when the caller may not proceed.
+func (h *FacilityHandler) authorizeFacilityEdit(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid facility ID"})
+		return 0, false
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return 0, false
+	}
+
+	userRole, _ := c.Get("userRole")
+
+	facility, err := h.facilityRepo.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Facility not found"})
+		return 0, false
+	}
+
+	if userRole != "admin" && facility.UserID != userID.(int) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to update this facility"})
+		return 0, false
+	}
+
+	return id, true
+}
+
+// roomTypeETag builds the entity tag of a room type from its version
+func roomTypeETag(rt *models.FacilityRoomType) string {
+	return fmt.Sprintf(`"%d"`, rt.Version)
+}
+
+// roomTypesETag builds the entity tag of a facility's whole room type list
+func roomTypesETag(roomTypes []*models.FacilityRoomType) string {
+	return fmt.Sprintf(`"%s"`, models.RoomTypesVersion(roomTypes))
+}
+
+// ifMatchValue strips the quotes and weak prefix from an If-Match header such as `"3"` or `W/"3"`
+func ifMatchValue(header string) string {
+	value := strings.TrimPrefix(strings.TrimSpace(header), "W/")
+	return strings.Trim(value, `"`)
+}
+
+// parseIfMatch reads the version from an If-Match header such as `"3"` or `W/"3"`
+func parseIfMatch(header string) (int, bool) {
+	version, err := strconv.Atoi(ifMatchValue(header))
+	if err != nil {
+		return 0, false
+	}
+	return version, true
+}
+
+// writeRoomTypeError maps the errors of the room type repository methods to a response.
+// Only the validation and conflict errors are shown to the caller.
+func writeRoomTypeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrRoomTypeNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Room type not found"})
+	case errors.Is(err, models.ErrRoomTypeVersionConflict):
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "Room type was modified by another user. Reload and try again"})
+	case errors.Is(err, models.ErrInvalidRoomType):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrRoomTypeExists), errors.Is(err, models.ErrRoomTypeHasHolds):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		log.Printf("Failed to save room type: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save room type"})
+	}
+}
+
+// GetRoomType returns a single room type with its ETag
+func (h *FacilityHandler) GetRoomType(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid facility ID"})
+		return
+	}
+
+	roomTypeID, err := strconv.Atoi(c.Param("roomTypeId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid room type ID"})
+		return
+	}
+
+	roomType, err := h.facilityRepo.GetRoomTypeByID(id, roomTypeID)
+	if err != nil {
+		if errors.Is(err, models.ErrRoomTypeNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Room type not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get room type"})
+		return
+	}
+
+	c.Header("ETag", roomTypeETag(roomType))
+	c.JSON(http.StatusOK, roomType)
+}
+
+// CreateRoomType adds a single room type to a facility
+func (h *FacilityHandler) CreateRoomType(c *gin.Context) {
+	id, ok := h.authorizeFacilityEdit(c)
+	if !ok {
+		return
+	}
+
+	var req models.FacilityRoomTypeInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format", "details": err.Error()})
+		return
+	}
+
+	roomType, err := h.facilityRepo.CreateRoomType(id, req)
+	if err != nil {
+		writeRoomTypeError(c, err)
+		return
+	}
+
+	h.offerWaitlistOpenings(id, map[int]int{roomType.ID: roomType.Available})
+
+	c.Header("ETag", roomTypeETag(roomType))
+	c.JSON(http.StatusCreated, roomType)
+}
+
+type UpdateRoomTypeRequest struct {
+	models.FacilityRoomTypeInput
+	Version *int `json:"version"`
+}
+
+// UpdateRoomType updates a single room type. The caller must send the version it
+// last read, either as If-Match or as "version" in the body.
+func (h *FacilityHandler) UpdateRoomType(c *gin.Context) {
+	id, ok := h.authorizeFacilityEdit(c)
+	if !ok {
+		return
+	}
+
+	roomTypeID, err := strconv.Atoi(c.Param("roomTypeId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid room type ID"})
+		return
+	}
+
+	var req UpdateRoomTypeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format", "details": err.Error()})
+		return
+	}
+
+	version, ok := parseIfMatch(c.GetHeader("If-Match"))
+	if !ok {
+		if req.Version == nil {
+			c.JSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match header or version is required"})
+			return
+		}
+		version = *req.Version
+	}
+
+	before, err := h.facilityRepo.GetRoomTypeByID(id, roomTypeID)
+	if err != nil {
+		writeRoomTypeError(c, err)
+		return
+	}
+
+	roomType, err := h.facilityRepo.UpdateRoomType(id, roomTypeID, version, req.FacilityRoomTypeInput)
+	if err != nil {
+		writeRoomTypeError(c, err)
+		return
+	}
+
+	h.offerWaitlistOpenings(id, map[int]int{roomTypeID: roomType.Available - before.Available})
+
+	c.Header("ETag", roomTypeETag(roomType))
+	c.JSON(http.StatusOK, roomType)
+}
+
+// DeleteRoomType removes a single room type. Requires If-Match.
+func (h *FacilityHandler) DeleteRoomType(c *gin.Context) {
+	id, ok := h.authorizeFacilityEdit(c)
+	if !ok {
+		return
+	}
+
+	roomTypeID, err := strconv.Atoi(c.Param("roomTypeId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid room type ID"})
+		return
+	}
+
+	version, ok := parseIfMatch(c.GetHeader("If-Match"))
+	if !ok {
+		c.JSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match header is required"})
+		return
+	}
+
+	if err := h.facilityRepo.DeleteRoomType(id, roomTypeID, version); err != nil {
+		writeRoomTypeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Room type deleted"})
+}
+
+type AdjustAvailableRequest struct {
+	Delta int `json:"delta" binding:"required"`
+}
+
+// AdjustRoomTypeAvailable changes the available count of a room type by a delta.
+// If-Match is optional here: a bare delta is safe to apply on top of concurrent changes.
+func (h *FacilityHandler) AdjustRoomTypeAvailable(c *gin.Context) {
+	id, ok := h.authorizeFacilityEdit(c)
+	if !ok {
+		return
+	}
+
+	roomTypeID, err := strconv.Atoi(c.Param("roomTypeId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid room type ID"})
+		return
+	}
+
+	var req AdjustAvailableRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format", "details": err.Error()})
+		return
+	}
+
+	var expectedVersion *int
+	if header := c.GetHeader("If-Match"); header != "" {
+		version, ok := parseIfMatch(header)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid If-Match header"})
+			return
+		}
+		expectedVersion = &version
+	}
+
+	roomType, err := h.facilityRepo.AdjustRoomTypeAvailable(id, roomTypeID, req.Delta, expectedVersion)
+	if err != nil {
+		writeRoomTypeError(c, err)
+		return
+	}
+
+	h.offerWaitlistOpenings(id, map[int]int{roomTypeID: req.Delta})
+
+	c.Header("ETag", roomTypeETag(roomType))
+	c.JSON(http.StatusOK, roomType)
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/social-worker-platform/backend/models"
+	"github.com/social-worker-platform/backend/services"
+)
+
+// maxImportFileBytes caps the size of an uploaded facility directory
+const maxImportFileBytes = 20 << 20
+
+const xlsxContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// ImportFacilities handles POST /api/admin/facilities/import with a CSV or XLSX file.
+// With dry_run=true nothing is saved and the response previews every row. Without it
+// the import is all or nothing: any row error rejects the whole file.
+func (h *AdminHandler) ImportFacilities(c *gin.Context) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+		return
+	}
+	if file.Size > maxImportFileBytes {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File is too large"})
+		return
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read uploaded file"})
+		return
+	}
+	defer src.Close()
+
+	rows, err := services.ReadSpreadsheet(file.Filename, src)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	result, err := h.importer.Import(rows, userID.(int), c.Query("dry_run") == "true")
+	if err != nil {
+		if errors.Is(err, models.ErrImportMissingColumns) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import facilities", "details": err.Error()})
+		return
+	}
+
+	if !result.DryRun && !result.Committed {
+		c.JSON(http.StatusUnprocessableEntity, result)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// ExportFacilities handles GET /api/admin/facilities/export?format=csv|xlsx.
+// The file can be edited and imported again.
+func (h *AdminHandler) ExportFacilities(c *gin.Context) {
+	format := c.DefaultQuery("format", "csv")
+	if format != "csv" && format != "xlsx" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv or xlsx"})
+		return
+	}
+
+	rows, err := h.importer.ExportFacilities()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export facilities"})
+		return
+	}
+
+	var buf bytes.Buffer
+	contentType := "text/csv; charset=utf-8"
+	if format == "xlsx" {
+		err = services.WriteXLSX(&buf, "施設一覧", rows)
+		contentType = xlsxContentType
+	} else {
+		err = services.WriteCSV(&buf, rows)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export facilities"})
+		return
+	}
+
+	filename := fmt.Sprintf("facilities-%s.%s", time.Now().Format("20060102"), format)
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
@@ -33,7 +33,7 @@ func TestProperty_FacilityCreationStoresAllFields(t *testing.T) {
 
 	facilityRepo := models.NewFacilityRepository(db)
 	userRepo := models.NewUserRepository(db)
-	facilityHandler := NewFacilityHandler(facilityRepo, userRepo)
+	facilityHandler := NewFacilityHandler(facilityRepo, userRepo, models.NewSettingRepository(db))
 
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
@@ -118,7 +118,7 @@ func TestProperty_FacilityUpdatesArePersisted(t *testing.T) {
 
 	facilityRepo := models.NewFacilityRepository(db)
 	userRepo := models.NewUserRepository(db)
-	facilityHandler := NewFacilityHandler(facilityRepo, userRepo)
+	facilityHandler := NewFacilityHandler(facilityRepo, userRepo, models.NewSettingRepository(db))
 
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
@@ -155,7 +155,7 @@ func TestProperty_FacilityUpdatesArePersisted(t *testing.T) {
 			updatedName := fmt.Sprintf("Updated %d", updatedSeed)
 			reqBody := UpdateFacilityRequest{
 				Name:        updatedName,
-				BedCapacity: newBedCapacity,
+				BedCapacity: &newBedCapacity,
 			}
 			body, _ := json.Marshal(reqBody)
 
@@ -201,7 +201,7 @@ func TestProperty_FacilityOwnersCanViewTheirData(t *testing.T) {
 
 	facilityRepo := models.NewFacilityRepository(db)
 	userRepo := models.NewUserRepository(db)
-	facilityHandler := NewFacilityHandler(facilityRepo, userRepo)
+	facilityHandler := NewFacilityHandler(facilityRepo, userRepo, models.NewSettingRepository(db))
 
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
@@ -0,0 +1,286 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/social-worker-platform/backend/models"
+	"github.com/social-worker-platform/backend/workflow"
+)
+
+type facilityRatingRequest struct {
+	Responsiveness int     `json:"responsiveness" binding:"required,min=1,max=5"`
+	Accuracy       int     `json:"accuracy" binding:"required,min=1,max=5"`
+	Outcome        int     `json:"outcome" binding:"required,min=1,max=5"`
+	Comment        *string `json:"comment"`
+}
+
+type moderateRatingRequest struct {
+	Hidden *bool   `json:"hidden" binding:"required"`
+	Reason *string `json:"reason"`
+}
+
+// ratingForViewer strips what another organization must not see: the comment and
+// which hospital or room the rating came from. Admins and the rating hospital see everything.
+func ratingForViewer(r *models.FacilityRating, role string, hospitalID int) *models.FacilityRating {
+	if role == "admin" || (role == "hospital" && r.HospitalID == hospitalID) {
+		return r
+	}
+
+	return &models.FacilityRating{
+		ID:             r.ID,
+		FacilityID:     r.FacilityID,
+		FacilityName:   r.FacilityName,
+		Responsiveness: r.Responsiveness,
+		Accuracy:       r.Accuracy,
+		Outcome:        r.Outcome,
+		CreatedAt:      r.CreatedAt,
+		UpdatedAt:      r.UpdatedAt,
+	}
+}
+
+// RateRoom handles POST /api/rooms/:id/rating (hospital rates a completed placement)
+func RateRoom(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userID")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		if !canTrigger(c, workflow.MessageRooms, workflow.OpRate) {
+			return
+		}
+
+		roomID := c.Param("id")
+
+		room, err := models.GetMessageRoomByID(db, roomID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve room"})
+			return
+		}
+
+		if room == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Room not found"})
+			return
+		}
+
+		// Check authorization
+		hospital, err := models.GetHospitalByUserID(db, userID.(int))
+		if err != nil || hospital == nil || hospital.ID != room.HospitalID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+			return
+		}
+
+		if err := workflow.MessageRooms.Allow(room.Status, workflow.OpRate, roleOf(c)); err != nil {
+			writeWorkflowError(c, err)
+			return
+		}
+
+		var req facilityRatingRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		ratedBy := userID.(int)
+		rating := &models.FacilityRating{
+			RoomID:         room.ID,
+			FacilityID:     room.FacilityID,
+			FacilityName:   room.FacilityName,
+			HospitalID:     hospital.ID,
+			HospitalName:   hospital.Name,
+			RatedBy:        &ratedBy,
+			Responsiveness: req.Responsiveness,
+			Accuracy:       req.Accuracy,
+			Outcome:        req.Outcome,
+		}
+		if req.Comment != nil {
+			rating.Comment = optionalString(*req.Comment)
+		}
+
+		if err := models.SaveFacilityRating(db, rating); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save rating"})
+			return
+		}
+
+		c.JSON(http.StatusOK, rating)
+	}
+}
+
+// GetRoomRating handles GET /api/rooms/:id/rating
+func GetRoomRating(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userID")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		role, exists := c.Get("userRole")
+		if !exists {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Role not found"})
+			return
+		}
+
+		roomID := c.Param("id")
+
+		room, err := models.GetMessageRoomByID(db, roomID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve room"})
+			return
+		}
+
+		if room == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Room not found"})
+			return
+		}
+
+		// Check authorization
+		hospitalID := 0
+		if role == "hospital" {
+			hospital, err := models.GetHospitalByUserID(db, userID.(int))
+			if err != nil || hospital == nil || hospital.ID != room.HospitalID {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+				return
+			}
+			hospitalID = hospital.ID
+		} else if role == "facility" {
+			facility, err := models.GetFacilityByUserID(db, userID.(int))
+			if err != nil || facility == nil || facility.ID != room.FacilityID {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+				return
+			}
+		} else if role != "admin" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Invalid role"})
+			return
+		}
+
+		rating, err := models.GetFacilityRatingByRoomID(db, roomID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve rating"})
+			return
+		}
+
+		if rating == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Room has not been rated"})
+			return
+		}
+
+		c.JSON(http.StatusOK, ratingForViewer(rating, role.(string), hospitalID))
+	}
+}
+
+// GetFacilityRatings handles GET /api/facilities/:id/ratings
+// Hospitals see the aggregate and every visible rating, with comments only for their own.
+func GetFacilityRatings(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userID")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		role, _ := c.Get("userRole")
+
+		facilityID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid facility ID"})
+			return
+		}
+
+		hospitalID := 0
+		if role == "hospital" {
+			hospital, err := models.GetHospitalByUserID(db, userID.(int))
+			if err != nil || hospital == nil {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Hospital not found for this user"})
+				return
+			}
+			hospitalID = hospital.ID
+		}
+
+		summary, err := models.GetFacilityRatingSummary(db, facilityID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve ratings"})
+			return
+		}
+
+		ratings, err := models.GetVisibleFacilityRatings(db, facilityID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve ratings"})
+			return
+		}
+
+		visible := make([]*models.FacilityRating, len(ratings))
+		for i, r := range ratings {
+			visible[i] = ratingForViewer(r, role.(string), hospitalID)
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"summary": summary,
+			"ratings": visible,
+		})
+	}
+}
+
+// AdminGetRatings handles GET /api/admin/ratings (?hidden=true|false)
+func AdminGetRatings(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var hidden *bool
+		if hiddenStr := c.Query("hidden"); hiddenStr != "" {
+			h, err := strconv.ParseBool(hiddenStr)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "hidden must be true or false"})
+				return
+			}
+			hidden = &h
+		}
+
+		ratings, err := models.GetAllFacilityRatings(db, hidden)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve ratings"})
+			return
+		}
+
+		c.JSON(http.StatusOK, ratings)
+	}
+}
+
+// AdminModerateRating handles PUT /api/admin/ratings/:id (hide or restore a rating)
+func AdminModerateRating(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := c.Get("userID")
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rating ID"})
+			return
+		}
+
+		var req moderateRatingRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := models.ModerateFacilityRating(db, id, *req.Hidden, req.Reason, userID.(int)); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Rating not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to moderate rating"})
+			return
+		}
+
+		rating, err := models.GetFacilityRatingByID(db, id)
+		if err != nil || rating == nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve rating"})
+			return
+		}
+
+		c.JSON(http.StatusOK, rating)
+	}
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/social-worker-platform/backend/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRatingForViewer(t *testing.T) {
+	comment := "Quick to respond, conditions were accurate"
+	ratedBy := 7
+	rating := &models.FacilityRating{
+		ID:             1,
+		RoomID:         "room-1",
+		FacilityID:     3,
+		HospitalID:     10,
+		HospitalName:   "Central Hospital",
+		RatedBy:        &ratedBy,
+		Responsiveness: 5,
+		Accuracy:       4,
+		Outcome:        5,
+		Comment:        &comment,
+	}
+
+	t.Run("rating hospital sees its comment", func(t *testing.T) {
+		assert.Same(t, rating, ratingForViewer(rating, "hospital", 10))
+	})
+
+	t.Run("admin sees everything", func(t *testing.T) {
+		assert.Same(t, rating, ratingForViewer(rating, "admin", 0))
+	})
+
+	t.Run("other hospitals see anonymous scores", func(t *testing.T) {
+		for _, viewer := range []struct {
+			role       string
+			hospitalID int
+		}{{"hospital", 11}, {"facility", 0}} {
+			r := ratingForViewer(rating, viewer.role, viewer.hospitalID)
+			assert.Nil(t, r.Comment)
+			assert.Nil(t, r.RatedBy)
+			assert.Empty(t, r.RoomID)
+			assert.Zero(t, r.HospitalID)
+			assert.Empty(t, r.HospitalName)
+			assert.Equal(t, 5, r.Responsiveness)
+			assert.Equal(t, 4, r.Accuracy)
+			assert.Equal(t, 5, r.Outcome)
+		}
+	})
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/social-worker-platform/backend/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchFacilityRequest_ToParams(t *testing.T) {
+	lat, lng, dist := 35.68, 139.76, 10.0
+	maxFee := 200000
+
+	t.Run("saved criteria round trip", func(t *testing.T) {
+		var req SearchFacilityRequest
+		body := `{"name": "さくら", "has_available_beds": true, "latitude": 35.68, "longitude": 139.76,
+			"max_distance_km": 10, "max_monthly_fee": 200000, "acceptance_conditions": ["oxygen", "insulin"],
+			"care_level": "care_3", "income_tier": "tier_2", "max_estimated_total": 200000,
+			"facility_types": ["特別養護老人ホーム", "グループホーム"]}`
+		require.NoError(t, json.Unmarshal([]byte(body), &req))
+
+		params, err := req.toParams()
+		require.NoError(t, err)
+		assert.Equal(t, models.FacilitySearchParams{
+			Name:                 "さくら",
+			HasAvailableBeds:     true,
+			UserLatitude:         &lat,
+			UserLongitude:        &lng,
+			MaxDistanceKm:        &dist,
+			MaxMonthlyFee:        &maxFee,
+			AcceptanceConditions: []string{"oxygen", "insulin"},
+			EstimateCareLevel:    "care_3",
+			EstimateIncomeTier:   "tier_2",
+			MaxEstimatedTotal:    &maxFee,
+			FacilityTypes:        []string{"特別養護老人ホーム", "グループホーム"},
+		}, params)
+
+		// Stored criteria use the same field names as the request
+		stored, err := json.Marshal(params)
+		require.NoError(t, err)
+		var again SearchFacilityRequest
+		require.NoError(t, json.Unmarshal(stored, &again))
+		assert.Equal(t, req, again)
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		zero := 0.0
+		invalid := []SearchFacilityRequest{
+			{Latitude: &lat},
+			{Latitude: &lat, Longitude: &lng, MaxDistanceKm: &zero},
+			{AcceptanceConditions: []string{"teleportation"}},
+			{CareLevel: "care_9"},
+			{IncomeTier: "tier_0"},
+			{ResidenceMunicipalityCode: "999"},
+			{SortBy: "travel_time"},
+		}
+		for _, req := range invalid {
+			_, err := req.toParams()
+			assert.Error(t, err)
+		}
+	})
+}
+
+type fixedEstimator map[float64]float64
+
+func (e fixedEstimator) EstimateMinutes(fromLat, fromLng, toLat, toLng float64) (float64, error) {
+	return e[toLat], nil
+}
+
+func TestSortByTravelTime(t *testing.T) {
+	lat := func(v float64) *float64 { return &v }
+	facilities := []*models.Facility{
+		{ID: 1, Latitude: lat(1), Longitude: lat(0)},
+		{ID: 2},
+		{ID: 3, Latitude: lat(3), Longitude: lat(0)},
+		{ID: 4, Latitude: lat(4), Longitude: lat(0)},
+	}
+	h := &FacilityHandler{travelTime: fixedEstimator{1: 40, 3: 15, 4: 25}}
+
+	h.sortByTravelTime(facilities, 0, 0, false)
+
+	ids := []int{}
+	for _, f := range facilities {
+		ids = append(ids, f.ID)
+	}
+	assert.Equal(t, []int{3, 4, 1, 2}, ids)
+	assert.Equal(t, 15.0, *facilities[0].TravelMinutes)
+	assert.Nil(t, facilities[3].TravelMinutes)
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -22,7 +23,7 @@ func setupFacilityTest(t *testing.T) (*gin.Engine, *models.FacilityRepository, *
 	db := config.SetupTestDatabase(t)
 	facilityRepo := models.NewFacilityRepository(db)
 	userRepo := models.NewUserRepository(db)
-	facilityHandler := NewFacilityHandler(facilityRepo, userRepo)
+	facilityHandler := NewFacilityHandler(facilityRepo, userRepo, models.NewSettingRepository(db))
 
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
@@ -216,9 +217,10 @@ func TestFacilityUpdate(t *testing.T) {
 
 		token, _ := middleware.GenerateToken(facilityUser.ID, facilityUser.Email, facilityUser.Role)
 
+		bedCapacity := 50
 		reqBody := UpdateFacilityRequest{
 			Name:        "Updated Name",
-			BedCapacity: 50,
+			BedCapacity: &bedCapacity,
 		}
 		body, _ := json.Marshal(reqBody)
 
@@ -290,3 +292,60 @@ func TestGetMyFacility(t *testing.T) {
 		facilityRepo.Delete(facility.ID)
 	})
 }
+
+func TestRoomTypeOpenings(t *testing.T) {
+	before := []*models.FacilityRoomType{
+		{ID: 1, Available: 0},
+		{ID: 2, Available: 3},
+		{ID: 3, Available: 1},
+	}
+	after := []*models.FacilityRoomType{
+		{ID: 1, Available: 2}, // opened up
+		{ID: 2, Available: 1}, // filled
+		{ID: 4, Available: 1}, // new room type
+	}
+
+	assert.Equal(t, map[int]int{1: 2, 4: 1}, roomTypeOpenings(before, after))
+	assert.Empty(t, roomTypeOpenings(after, after))
+}
+
+func TestWriteRoomTypeError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		err    error
+		status int
+	}{
+		{models.ErrRoomTypeNotFound, http.StatusNotFound},
+		{models.ErrRoomTypeVersionConflict, http.StatusPreconditionFailed},
+		{fmt.Errorf("%w: available (2) cannot exceed capacity (1) for room type '個室'", models.ErrInvalidRoomType), http.StatusBadRequest},
+		{fmt.Errorf("%w: '個室'", models.ErrRoomTypeExists), http.StatusConflict},
+		{fmt.Errorf("%w: 1 bed(s)", models.ErrRoomTypeHasHolds), http.StatusConflict},
+		{errors.New("pq: connection refused"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		writeRoomTypeError(c, tt.err)
+
+		assert.Equal(t, tt.status, w.Code, tt.err.Error())
+	}
+
+	t.Run("does not leak internal errors", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		writeRoomTypeError(c, errors.New("pq: relation \"facility_room_types\" does not exist"))
+
+		assert.NotContains(t, w.Body.String(), "pq:")
+	})
+}
+
+func TestIfMatchValue(t *testing.T) {
+	assert.Equal(t, "3", ifMatchValue(`"3"`))
+	assert.Equal(t, "3", ifMatchValue(`W/"3"`))
+	assert.Equal(t, "ab12", ifMatchValue(` "ab12" `))
+	assert.Equal(t, "", ifMatchValue(""))
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/social-worker-platform/backend/models"
+)
+
+type FacilityTypeHandler struct {
+	typeRepo *models.FacilityTypeRepository
+}
+
+func NewFacilityTypeHandler(typeRepo *models.FacilityTypeRepository) *FacilityTypeHandler {
+	return &FacilityTypeHandler{typeRepo: typeRepo}
+}
+
+type FacilityTypeRequest struct {
+	Name        string                         `json:"name" binding:"required,max=50"`
+	ShortName   *string                        `json:"short_name" binding:"omitempty,max=20"`
+	Description *string                        `json:"description"`
+	Attributes  []models.FacilityTypeAttribute `json:"attributes" binding:"dive"`
+	Eligibility models.EligibilityRules        `json:"eligibility"`
+	SortOrder   int                            `json:"sort_order"`
+	IsActive    *bool                          `json:"is_active"`
+}
+
+func (req FacilityTypeRequest) applyTo(t *models.FacilityType) {
+	t.Name = req.Name
+	t.ShortName = req.ShortName
+	t.Description = req.Description
+	t.Attributes = req.Attributes
+	t.Eligibility = req.Eligibility
+	t.SortOrder = req.SortOrder
+	if req.IsActive != nil {
+		t.IsActive = *req.IsActive
+	}
+}
+
+func writeFacilityTypeAdminError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrFacilityTypeNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Facility type not found"})
+	case errors.Is(err, models.ErrFacilityTypeExists):
+		c.JSON(http.StatusConflict, gin.H{"error": "A facility type with this name already exists"})
+	case errors.Is(err, models.ErrFacilityTypeInUse):
+		c.JSON(http.StatusConflict, gin.H{"error": "Facility type is used by facilities. Deactivate it instead"})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
+
+// List handles GET /api/facility-types. Admins may pass include_inactive=true.
+func (h *FacilityTypeHandler) List(c *gin.Context) {
+	role, _ := c.Get("userRole")
+	includeInactive := role == "admin" && c.Query("include_inactive") == "true"
+
+	types, err := h.typeRepo.GetAll(includeInactive)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get facility types"})
+		return
+	}
+
+	c.JSON(http.StatusOK, types)
+}
+
+// Create handles POST /api/admin/facility-types
+func (h *FacilityTypeHandler) Create(c *gin.Context) {
+	var req FacilityTypeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format", "details": err.Error()})
+		return
+	}
+
+	facilityType := &models.FacilityType{IsActive: true}
+	req.applyTo(facilityType)
+
+	if err := h.typeRepo.Create(facilityType); err != nil {
+		writeFacilityTypeAdminError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, facilityType)
+}
+
+// Update handles PUT /api/admin/facility-types/:id. Renaming a type renames it on
+// every facility of that type.
+func (h *FacilityTypeHandler) Update(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid facility type ID"})
+		return
+	}
+
+	var req FacilityTypeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format", "details": err.Error()})
+		return
+	}
+
+	facilityType, err := h.typeRepo.GetByID(id)
+	if err != nil {
+		writeFacilityTypeAdminError(c, err)
+		return
+	}
+
+	req.applyTo(facilityType)
+	if err := h.typeRepo.Update(facilityType); err != nil {
+		writeFacilityTypeAdminError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, facilityType)
+}
+
+// Delete handles DELETE /api/admin/facility-types/:id for types no facility uses
+func (h *FacilityTypeHandler) Delete(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid facility type ID"})
+		return
+	}
+
+	if err := h.typeRepo.Delete(id); err != nil {
+		writeFacilityTypeAdminError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Facility type deleted successfully"})
+}
@@ -30,7 +30,7 @@ func TestFacilityValidation_EmptyRequiredFields(t *testing.T) {
 
 	userRepo := models.NewUserRepository(db)
 	facilityRepo := models.NewFacilityRepository(db)
-	handler := NewFacilityHandler(facilityRepo, userRepo)
+	handler := NewFacilityHandler(facilityRepo, userRepo, models.NewSettingRepository(db))
 
 	// Create facility user
 	user, _ := createTestUser(t, userRepo, "facility@test.com", "facility")
@@ -48,9 +48,9 @@ func TestFacilityValidation_EmptyRequiredFields(t *testing.T) {
 		{
 			name: "empty name",
 			requestBody: map[string]interface{}{
-				"name":                   "",
-				"bed_capacity":           10,
-				"acceptance_conditions":  "Test conditions",
+				"name":                  "",
+				"bed_capacity":          10,
+				"acceptance_conditions": "Test conditions",
 			},
 			description: "Name is required",
 		},
@@ -110,7 +110,7 @@ func TestFacilityValidation_InvalidDataFormats(t *testing.T) {
 
 	userRepo := models.NewUserRepository(db)
 	facilityRepo := models.NewFacilityRepository(db)
-	handler := NewFacilityHandler(facilityRepo, userRepo)
+	handler := NewFacilityHandler(facilityRepo, userRepo, models.NewSettingRepository(db))
 
 	user, _ := createTestUser(t, userRepo, "facility2@test.com", "facility")
 	token, _ := middleware.GenerateToken(user.ID, user.Email, user.Role)
@@ -164,7 +164,7 @@ func TestFacilityValidation_SQLInjectionAttempts(t *testing.T) {
 
 	userRepo := models.NewUserRepository(db)
 	facilityRepo := models.NewFacilityRepository(db)
-	handler := NewFacilityHandler(facilityRepo, userRepo)
+	handler := NewFacilityHandler(facilityRepo, userRepo, models.NewSettingRepository(db))
 
 	user, _ := createTestUser(t, userRepo, "facility3@test.com", "facility")
 	token, _ := middleware.GenerateToken(user.ID, user.Email, user.Role)
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/social-worker-platform/backend/models"
+)
+
+// GetFeeOptions returns the care levels and income tiers accepted by fee schedules and estimates
+func (h *FacilityHandler) GetFeeOptions(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"care_levels":  models.CareLevels,
+		"income_tiers": models.IncomeTiers,
+	})
+}
+
+// GetFeeSchedules returns the facility-wide and per room type fee schedules of a facility
+func (h *FacilityHandler) GetFeeSchedules(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid facility ID"})
+		return
+	}
+
+	schedules, err := h.facilityRepo.GetFeeSchedules(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get fee schedules"})
+		return
+	}
+
+	c.JSON(http.StatusOK, schedules)
+}
+
+type UpdateFeeSchedulesRequest struct {
+	FeeSchedules []models.FeeScheduleInput `json:"fee_schedules" binding:"required,dive"`
+}
+
+// UpdateFeeSchedules replaces all fee schedules of a facility
+func (h *FacilityHandler) UpdateFeeSchedules(c *gin.Context) {
+	id, ok := h.authorizeFacilityEdit(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateFeeSchedulesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format", "details": err.Error()})
+		return
+	}
+
+	if err := h.facilityRepo.ReplaceFeeSchedules(id, req.FeeSchedules); err != nil {
+		if errors.Is(err, models.ErrRoomTypeNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Room type does not belong to this facility"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	schedules, err := h.facilityRepo.GetFeeSchedules(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get updated fee schedules"})
+		return
+	}
+
+	c.JSON(http.StatusOK, schedules)
+}
+
+// EstimateFee handles GET /api/facilities/:id/fees/estimate?care_level=&room_type_id=&income_tier=
+// and returns the projected monthly total. Room types without their own schedule use
+// the facility-wide one.
+func (h *FacilityHandler) EstimateFee(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid facility ID"})
+		return
+	}
+
+	var roomTypeID *int
+	if value := c.Query("room_type_id"); value != "" {
+		rtID, err := strconv.Atoi(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid room type ID"})
+			return
+		}
+		roomTypeID = &rtID
+	}
+
+	schedule, err := h.facilityRepo.GetFeeScheduleForRoomType(id, roomTypeID)
+	if err != nil {
+		if errors.Is(err, models.ErrFeeScheduleNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "This facility has no fee schedule"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get fee schedule"})
+		return
+	}
+
+	estimate, err := schedule.Estimate(c.Query("care_level"), c.Query("income_tier"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, estimate)
+}
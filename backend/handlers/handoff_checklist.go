@@ -0,0 +1,353 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/social-worker-platform/backend/models"
+	"github.com/social-worker-platform/backend/workflow"
+)
+
+// GetRoomChecklist handles GET /api/rooms/:id/checklist
+func GetRoomChecklist(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		room := roomForParty(c, db)
+		if room == nil {
+			return
+		}
+
+		items, err := models.GetRoomChecklist(db, room.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve checklist"})
+			return
+		}
+
+		blocks, err := models.NewSettingRepository(db).GetBool(models.SettingChecklistBlocksCompletion, false)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve setting"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"items":             items,
+			"open_required":     len(models.OpenRequiredItems(items)),
+			"blocks_completion": blocks,
+		})
+	}
+}
+
+type roomChecklistItemRequest struct {
+	Label       string `json:"label" binding:"required,max=100"`
+	Description string `json:"description"`
+	Assignee    string `json:"assignee" binding:"required,oneof=hospital facility"`
+	Required    bool   `json:"required"`
+}
+
+// AddRoomChecklistItem handles POST /api/rooms/:id/checklist for items this room needs
+// beyond its template
+func AddRoomChecklistItem(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !canTrigger(c, workflow.MessageRooms, workflow.OpEditChecklist) {
+			return
+		}
+
+		room := roomForParty(c, db)
+		if room == nil {
+			return
+		}
+
+		if err := workflow.MessageRooms.Allow(room.Status, workflow.OpEditChecklist, roleOf(c)); err != nil {
+			writeRoomClosedError(c, err)
+			return
+		}
+
+		var req roomChecklistItemRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format", "details": err.Error()})
+			return
+		}
+
+		userID, _ := c.Get("userID")
+		addedBy := userID.(int)
+		item := &models.RoomChecklistItem{
+			RoomID:      room.ID,
+			Label:       strings.TrimSpace(req.Label),
+			Description: optionalString(req.Description),
+			Assignee:    req.Assignee,
+			Required:    req.Required,
+			AddedBy:     &addedBy,
+		}
+		if err := models.CreateRoomChecklistItem(db, item); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add checklist item"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, item)
+	}
+}
+
+// checklistItemOfRoom loads the item in the :itemId path parameter and checks that it
+// belongs to room. It writes the error response itself and returns nil otherwise.
+func checklistItemOfRoom(c *gin.Context, db *sql.DB, room *models.MessageRoom) *models.RoomChecklistItem {
+	itemID, err := strconv.Atoi(c.Param("itemId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid checklist item ID"})
+		return nil
+	}
+
+	item, err := models.GetRoomChecklistItemByID(db, itemID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve checklist item"})
+		return nil
+	}
+
+	if item == nil || item.RoomID != room.ID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Checklist item not found"})
+		return nil
+	}
+
+	return item
+}
+
+// UpdateRoomChecklistItem handles PUT /api/rooms/:id/checklist/:itemId. Either party
+// can reassign an item, link it to a file of the room (file_id 0 unlinks it) and check
+// or uncheck it.
+func UpdateRoomChecklistItem(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !canTrigger(c, workflow.MessageRooms, workflow.OpEditChecklist) {
+			return
+		}
+
+		room := roomForParty(c, db)
+		if room == nil {
+			return
+		}
+
+		if err := workflow.MessageRooms.Allow(room.Status, workflow.OpEditChecklist, roleOf(c)); err != nil {
+			writeRoomClosedError(c, err)
+			return
+		}
+
+		item := checklistItemOfRoom(c, db, room)
+		if item == nil {
+			return
+		}
+
+		var req struct {
+			Assignee *string `json:"assignee" binding:"omitempty,oneof=hospital facility"`
+			FileID   *int    `json:"file_id"`
+			Checked  *bool   `json:"checked"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format", "details": err.Error()})
+			return
+		}
+
+		if req.Assignee != nil {
+			item.Assignee = *req.Assignee
+		}
+
+		if req.FileID != nil {
+			if *req.FileID == 0 {
+				item.FileID, item.FileName = nil, nil
+			} else {
+				file, err := models.GetRoomFileByID(db, *req.FileID)
+				if err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve file"})
+					return
+				}
+				if file == nil || file.RoomID != room.ID {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "File not found in this room"})
+					return
+				}
+				item.FileID, item.FileName = &file.ID, &file.FileName
+			}
+		}
+
+		userID, _ := c.Get("userID")
+		if req.Checked != nil && *req.Checked != (item.CheckedAt != nil) {
+			if *req.Checked {
+				now := time.Now()
+				checkedBy := userID.(int)
+				item.CheckedAt, item.CheckedBy = &now, &checkedBy
+			} else {
+				item.CheckedAt, item.CheckedBy = nil, nil
+			}
+		}
+
+		if err := models.UpdateRoomChecklistItem(db, item); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update checklist item"})
+			return
+		}
+
+		c.JSON(http.StatusOK, item)
+	}
+}
+
+// DeleteRoomChecklistItem handles DELETE /api/rooms/:id/checklist/:itemId for items
+// added in the room
+func DeleteRoomChecklistItem(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !canTrigger(c, workflow.MessageRooms, workflow.OpEditChecklist) {
+			return
+		}
+
+		room := roomForParty(c, db)
+		if room == nil {
+			return
+		}
+
+		if err := workflow.MessageRooms.Allow(room.Status, workflow.OpEditChecklist, roleOf(c)); err != nil {
+			writeRoomClosedError(c, err)
+			return
+		}
+
+		item := checklistItemOfRoom(c, db, room)
+		if item == nil {
+			return
+		}
+
+		if err := models.DeleteRoomChecklistItem(db, item.ID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				c.JSON(http.StatusConflict, gin.H{"error": "Items from the checklist template cannot be removed"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove checklist item"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Checklist item removed"})
+	}
+}
+
+type handoffTemplateItemRequest struct {
+	FacilityTypeID *int   `json:"facility_type_id"`
+	Label          string `json:"label" binding:"required,max=100"`
+	Description    string `json:"description"`
+	Assignee       string `json:"assignee" binding:"required,oneof=hospital facility"`
+	Required       *bool  `json:"required"`
+	SortOrder      int    `json:"sort_order"`
+}
+
+func (req handoffTemplateItemRequest) applyTo(item *models.HandoffTemplateItem) {
+	item.Label = strings.TrimSpace(req.Label)
+	item.Description = optionalString(req.Description)
+	item.Assignee = req.Assignee
+	item.Required = req.Required == nil || *req.Required
+	item.SortOrder = req.SortOrder
+}
+
+// AdminGetHandoffTemplate handles GET /api/admin/handoff-templates. It returns the
+// template of ?facility_type_id, or the default template without it.
+func AdminGetHandoffTemplate(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var facilityTypeID *int
+		if raw := c.Query("facility_type_id"); raw != "" {
+			id, err := strconv.Atoi(raw)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid facility type ID"})
+				return
+			}
+			facilityTypeID = &id
+		}
+
+		items, err := models.GetHandoffTemplateItems(db, facilityTypeID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve checklist template"})
+			return
+		}
+
+		c.JSON(http.StatusOK, items)
+	}
+}
+
+// AdminCreateHandoffTemplateItem handles POST /api/admin/handoff-templates
+func AdminCreateHandoffTemplateItem(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req handoffTemplateItemRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format", "details": err.Error()})
+			return
+		}
+
+		if req.FacilityTypeID != nil {
+			if _, err := models.NewFacilityTypeRepository(db).GetByID(*req.FacilityTypeID); err != nil {
+				writeFacilityTypeAdminError(c, err)
+				return
+			}
+		}
+
+		item := &models.HandoffTemplateItem{FacilityTypeID: req.FacilityTypeID}
+		req.applyTo(item)
+		if err := models.CreateHandoffTemplateItem(db, item); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create checklist template item"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, item)
+	}
+}
+
+// AdminUpdateHandoffTemplateItem handles PUT /api/admin/handoff-templates/:id. The
+// facility type of an item cannot be changed; checklists already copied are unaffected.
+func AdminUpdateHandoffTemplateItem(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid checklist template item ID"})
+			return
+		}
+
+		var req handoffTemplateItemRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format", "details": err.Error()})
+			return
+		}
+
+		item, err := models.GetHandoffTemplateItemByID(db, id)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve checklist template item"})
+			return
+		}
+
+		if item == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Checklist template item not found"})
+			return
+		}
+
+		req.applyTo(item)
+		if err := models.UpdateHandoffTemplateItem(db, item); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update checklist template item"})
+			return
+		}
+
+		c.JSON(http.StatusOK, item)
+	}
+}
+
+// AdminDeleteHandoffTemplateItem handles DELETE /api/admin/handoff-templates/:id
+func AdminDeleteHandoffTemplateItem(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid checklist template item ID"})
+			return
+		}
+
+		if err := models.DeleteHandoffTemplateItem(db, id); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Checklist template item not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete checklist template item"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Checklist template item deleted"})
+	}
+}
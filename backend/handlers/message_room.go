@@ -2,7 +2,9 @@ package handlers
 
 import (
 	"database/sql"
+	"errors"
 	"io"
+	"log"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -11,6 +13,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/social-worker-platform/backend/models"
+	"github.com/social-worker-platform/backend/workflow"
 )
 
 // GetMessageRooms handles GET /api/rooms
@@ -125,10 +128,26 @@ func GetMessageRoomByID(db *sql.DB) gin.HandlerFunc {
 			return
 		}
 
+		// Get bed hold (optional)
+		hold, err := models.GetActiveBedHoldByRoomID(db, roomID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve bed hold"})
+			return
+		}
+
+		// Get reopen request awaiting an answer (optional)
+		reopen, err := models.GetPendingRoomReopenRequest(db, roomID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve reopen request"})
+			return
+		}
+
 		c.JSON(http.StatusOK, gin.H{
-			"room":     room,
-			"messages": messages,
-			"files":    files,
+			"room":           room,
+			"messages":       messages,
+			"files":          files,
+			"bed_hold":       hold,
+			"reopen_request": reopen,
 		})
 	}
 }
@@ -161,9 +180,9 @@ func SendMessage(db *sql.DB) gin.HandlerFunc {
 			return
 		}
 
-		// Check if room is closed (only rejected blocks messages, completed allows follow-up)
-		if room.Status == "rejected" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Room is closed"})
+		// Rejected rooms are closed; completed rooms stay open for follow-up
+		if err := workflow.MessageRooms.Allow(room.Status, workflow.OpSendMessage, roleOf(c)); err != nil {
+			writeRoomClosedError(c, err)
 			return
 		}
 
@@ -241,9 +260,8 @@ func UploadRoomFile(db *sql.DB) gin.HandlerFunc {
 			return
 		}
 
-		// Check if room is closed
-		if room.Status == "rejected" || room.Status == "completed" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Room is closed"})
+		if err := workflow.MessageRooms.Allow(room.Status, workflow.OpUploadFile, roleOf(c)); err != nil {
+			writeRoomClosedError(c, err)
 			return
 		}
 
@@ -594,9 +612,7 @@ func AcceptRoom(db *sql.DB) gin.HandlerFunc {
 			return
 		}
 
-		role, exists := c.Get("userRole")
-		if !exists || role != "facility" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Only facility users can accept placement"})
+		if !canTrigger(c, workflow.MessageRooms, workflow.ActionAccept) {
 			return
 		}
 
@@ -620,19 +636,44 @@ func AcceptRoom(db *sql.DB) gin.HandlerFunc {
 			return
 		}
 
-		// Can only accept from negotiating status
-		if room.Status != "negotiating" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Room is not in negotiating status"})
+		subject := &workflow.Subject{UserID: userID.(int), RequestID: room.RequestID, RoomID: roomID}
+		transition, err := workflow.MessageRooms.Fire(room.Status, workflow.ActionAccept, roleOf(c), subject)
+		if err != nil {
+			writeWorkflowError(c, err)
 			return
 		}
 
-		// Update status to accepted (still active for document exchange)
-		if err := models.UpdateMessageRoomStatus(db, roomID, "accepted"); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to accept room"})
+		// Optionally hold a bed of the chosen room type while admission is arranged
+		var req struct {
+			RoomTypeID *int `json:"room_type_id"`
+			HoldHours  int  `json:"hold_hours" binding:"omitempty,min=1"`
+		}
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		var hold *models.BedHold
+		if req.RoomTypeID != nil {
+			hold = holdBed(c, db, room, bedHoldRequest{RoomTypeID: *req.RoomTypeID, HoldHours: req.HoldHours})
+			if hold == nil {
+				return
+			}
+		}
+
+		// Accepted rooms stay open for document exchange
+		if !applyTransition(c, db, transition, subject, "Failed to accept room", func(tx *sql.Tx) error {
+			return models.UpdateMessageRoomStatus(tx, roomID, room.Status, transition.To)
+		}) {
+			if hold != nil {
+				models.ReleaseBedHold(db, roomID)
+			}
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{"message": "Placement accepted"})
+		c.JSON(http.StatusOK, gin.H{"message": "Placement accepted", "bed_hold": hold})
 	}
 }
 
@@ -645,9 +686,7 @@ func RejectRoom(db *sql.DB) gin.HandlerFunc {
 			return
 		}
 
-		role, exists := c.Get("userRole")
-		if !exists || role != "facility" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Only facility users can reject placement"})
+		if !canTrigger(c, workflow.MessageRooms, workflow.ActionReject) {
 			return
 		}
 
@@ -671,15 +710,20 @@ func RejectRoom(db *sql.DB) gin.HandlerFunc {
 			return
 		}
 
-		// Can only reject from negotiating status
-		if room.Status != "negotiating" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Room is not in negotiating status"})
+		subject := &workflow.Subject{UserID: userID.(int), RequestID: room.RequestID, RoomID: roomID}
+		if !bindStatusReason(c, subject) {
+			return
+		}
+		transition, err := workflow.MessageRooms.Fire(room.Status, workflow.ActionReject, roleOf(c), subject)
+		if err != nil {
+			writeWorkflowError(c, err)
 			return
 		}
 
-		// Update status to rejected (inactive)
-		if err := models.UpdateMessageRoomStatus(db, roomID, "rejected"); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reject room"})
+		// Gives back any bed held for this placement
+		if !applyTransition(c, db, transition, subject, "Failed to reject room", func(tx *sql.Tx) error {
+			return models.UpdateMessageRoomStatus(tx, roomID, room.Status, transition.To)
+		}) {
 			return
 		}
 
@@ -687,6 +731,48 @@ func RejectRoom(db *sql.DB) gin.HandlerFunc {
 	}
 }
 
+// WithdrawRoom handles POST /api/rooms/:id/withdraw. The hospital withdraws the
+// placement request being arranged in the room, which closes the room as well.
+func WithdrawRoom(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userID")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		if !canTrigger(c, workflow.MessageRooms, workflow.ActionWithdraw) {
+			return
+		}
+
+		room, err := models.GetMessageRoomByID(db, c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve room"})
+			return
+		}
+
+		if room == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Room not found"})
+			return
+		}
+
+		// Check authorization
+		hospital, err := models.GetHospitalByUserID(db, userID.(int))
+		if err != nil || hospital == nil || hospital.ID != room.HospitalID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+			return
+		}
+
+		req, err := models.GetPlacementRequestByID(db, room.RequestID)
+		if err != nil || req == nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve request"})
+			return
+		}
+
+		withdrawRequest(c, db, req)
+	}
+}
+
 // CompleteRoom handles POST /api/rooms/:id/complete
 func CompleteRoom(db *sql.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -715,60 +801,129 @@ func CompleteRoom(db *sql.DB) gin.HandlerFunc {
 			return
 		}
 
-		// Can only complete from accepted status
-		if room.Status != "accepted" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Room must be accepted before completion"})
+		if err := workflow.MessageRooms.Allow(room.Status, workflow.OpMarkComplete, roleOf(c)); err != nil {
+			writeWorkflowError(c, err)
 			return
 		}
 
-		// Update completion flag based on role
+		// Only a party of the room can mark it complete
 		if role == "hospital" {
 			hospital, err := models.GetHospitalByUserID(db, userID.(int))
 			if err != nil || hospital == nil || hospital.ID != room.HospitalID {
 				c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
 				return
 			}
-
-			if err := models.UpdateHospitalCompletion(db, roomID, true); err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark as complete"})
-				return
-			}
-
-			// Check if both parties completed
-			room, _ = models.GetMessageRoomByID(db, roomID)
-			if room.FacilityCompleted {
-				// Both completed, close the room
-				models.UpdateMessageRoomStatus(db, roomID, "completed")
-			}
-
 		} else if role == "facility" {
 			facility, err := models.GetFacilityByUserID(db, userID.(int))
 			if err != nil || facility == nil || facility.ID != room.FacilityID {
 				c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
 				return
 			}
-
-			if err := models.UpdateFacilityCompletion(db, roomID, true); err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark as complete"})
-				return
-			}
-
-			// Check if both parties completed
-			room, _ = models.GetMessageRoomByID(db, roomID)
-			if room.HospitalCompleted {
-				// Both completed, close the room
-				models.UpdateMessageRoomStatus(db, roomID, "completed")
-			}
-
 		} else {
 			c.JSON(http.StatusForbidden, gin.H{"error": "Invalid role"})
 			return
 		}
 
+		checklistBlocks, err := models.NewSettingRepository(db).GetBool(models.SettingChecklistBlocksCompletion, false)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check handoff checklist"})
+			return
+		}
+
+		var transition *workflow.Transition
+		var subject *workflow.Subject
+		err = inTransaction(db, func(tx *sql.Tx) error {
+			var err error
+			transition, subject, err = completeRoom(tx, roomID, userID.(int), roleOf(c), checklistBlocks)
+			return err
+		})
+		var refused *workflow.Error
+		if errors.As(err, &refused) && refused.Guard == workflow.GuardChecklistDone {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "Required handoff checklist items are not checked yet",
+				"details": err.Error(),
+			})
+			return
+		}
+		if err != nil {
+			writeTransitionError(c, err, "Failed to mark as complete")
+			return
+		}
+
+		if transition != nil {
+			if err := transition.RunEffects(db, subject); err != nil {
+				log.Printf("Failed to run effects of completing room %s: %v", roomID, err)
+			}
+		}
+
 		c.JSON(http.StatusOK, gin.H{"message": "Marked as complete"})
 	}
 }
 
+// completeRoom marks the room complete for the side of role and closes the room once
+// both parties did, turning the bed held for the placement into an occupied bed. It
+// returns a nil transition while the other party has not marked the room complete yet.
+// When checklistBlocks is set, neither party can mark it while required handoff
+// checklist items are unchecked.
+func completeRoom(tx *sql.Tx, roomID string, userID int, role string, checklistBlocks bool) (*workflow.Transition, *workflow.Subject, error) {
+	var err error
+	if role == "hospital" {
+		err = models.UpdateHospitalCompletion(tx, roomID, true)
+	} else {
+		err = models.UpdateFacilityCompletion(tx, roomID, true)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	room, err := models.LockMessageRoomCompletion(tx, roomID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	openItems := 0
+	if checklistBlocks {
+		if openItems, err = models.LockOpenRequiredItems(tx, roomID); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	subject := &workflow.Subject{
+		UserID:              userID,
+		RequestID:           room.RequestID,
+		RoomID:              roomID,
+		HospitalCompleted:   room.HospitalCompleted,
+		FacilityCompleted:   room.FacilityCompleted,
+		ChecklistIncomplete: openItems > 0,
+	}
+	transition, err := workflow.MessageRooms.Fire(room.Status, workflow.ActionComplete, role, subject)
+	var refused *workflow.Error
+	if errors.As(err, &refused) && refused.Guard == workflow.GuardBothCompleted {
+		return nil, subject, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := models.UpdateMessageRoomStatus(tx, roomID, room.Status, transition.To); err != nil {
+		return nil, nil, err
+	}
+	if err := transition.Apply(tx, subject); err != nil {
+		return nil, nil, err
+	}
+	return transition, subject, nil
+}
+
+// writeRoomClosedError responds to an operation refused by the room workflow, reporting
+// a room whose status does not allow it as closed
+func writeRoomClosedError(c *gin.Context, err error) {
+	if errors.Is(err, workflow.ErrInvalidState) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Room is closed"})
+		return
+	}
+	writeWorkflowError(c, err)
+}
+
 // CancelCompletion handles POST /api/rooms/:id/cancel-completion
 func CancelCompletion(db *sql.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -797,9 +952,9 @@ func CancelCompletion(db *sql.DB) gin.HandlerFunc {
 			return
 		}
 
-		// Can only cancel if room is still accepted (not completed)
-		if room.Status != "accepted" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot cancel completion for this room"})
+		// Completion can be cancelled until both parties have completed
+		if err := workflow.MessageRooms.Allow(room.Status, workflow.OpCancelCompletion, roleOf(c)); err != nil {
+			writeWorkflowError(c, err)
 			return
 		}
 
@@ -2,12 +2,16 @@ package handlers
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/social-worker-platform/backend/models"
+	"github.com/social-worker-platform/backend/workflow"
 )
 
 // CreatePlacementRequest handles POST /api/requests
@@ -34,10 +38,19 @@ func CreatePlacementRequest(db *sql.DB) gin.HandlerFunc {
 		}
 
 		var req struct {
-			FacilityID       int    `json:"facility_id" binding:"required"`
-			PatientAge       int    `json:"patient_age" binding:"required"`
-			PatientGender    string `json:"patient_gender" binding:"required"`
-			MedicalCondition string `json:"medical_condition" binding:"required"`
+			FacilityID         int     `json:"facility_id" binding:"required"`
+			PatientAge         int     `json:"patient_age"`
+			PatientGender      string  `json:"patient_gender"`
+			MedicalCondition   string  `json:"medical_condition"`
+			PatientCareLevel   *string `json:"patient_care_level"`
+			PatientHasDementia *bool   `json:"patient_has_dementia"`
+			// Urgency defaults to routine
+			Urgency             *string `json:"urgency"`
+			TargetDischargeDate *string `json:"target_discharge_date"`
+			// Draft keeps the request with the hospital until it is submitted
+			Draft bool `json:"draft"`
+			// TemplateID fills in the details left empty from one of the hospital's templates
+			TemplateID *int `json:"template_id"`
 		}
 
 		if err := c.ShouldBindJSON(&req); err != nil {
@@ -45,6 +58,40 @@ func CreatePlacementRequest(db *sql.DB) gin.HandlerFunc {
 			return
 		}
 
+		if req.TemplateID != nil {
+			template := ownRequestTemplate(c, db, hospital, *req.TemplateID)
+			if template == nil {
+				return
+			}
+			if req.MedicalCondition == "" {
+				req.MedicalCondition = template.MedicalCondition
+			}
+			if req.PatientCareLevel == nil {
+				req.PatientCareLevel = template.PatientCareLevel
+			}
+			if req.PatientHasDementia == nil {
+				req.PatientHasDementia = template.PatientHasDementia
+			}
+		}
+
+		careLevel, err := patientCareLevel(req.PatientCareLevel)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		urgency, err := requestUrgency(req.Urgency)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		dischargeDate, err := targetDischargeDate(req.TargetDischargeDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
 		// Validate facility exists
 		facility, err := models.GetFacilityByID(db, req.FacilityID)
 		if err != nil || facility == nil {
@@ -58,14 +105,43 @@ func CreatePlacementRequest(db *sql.DB) gin.HandlerFunc {
 			PatientAge:       req.PatientAge,
 			PatientGender:    req.PatientGender,
 			MedicalCondition: req.MedicalCondition,
-			Status:           "pending",
+			Status:           workflow.PlacementRequests.Initial,
+
+			PatientCareLevel:   careLevel,
+			PatientHasDementia: req.PatientHasDementia,
+
+			Urgency:             urgency,
+			TargetDischargeDate: dischargeDate,
+		}
+
+		subject := &workflow.Subject{UserID: userID.(int)}
+		if !req.Draft {
+			transition := submitTransition(c, placementReq, subject)
+			if transition == nil {
+				return
+			}
+			placementReq.Status = transition.To
 		}
 
-		if err := models.CreatePlacementRequest(db, placementReq); err != nil {
+		err = inTransaction(db, func(tx *sql.Tx) error {
+			if err := models.CreatePlacementRequest(tx, placementReq); err != nil {
+				return err
+			}
+			subject.RequestID = placementReq.ID
+			return workflow.RecordCreated(tx, workflow.PlacementRequests, placementReq.Status, roleOf(c), subject)
+		})
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create placement request"})
 			return
 		}
 
+		// The request is created either way; warnings tell the hospital the patient may not qualify
+		warnings, err := models.CheckPlacementEligibility(db, placementReq)
+		if err != nil {
+			log.Printf("Failed to check eligibility for request %d: %v", placementReq.ID, err)
+		}
+		placementReq.EligibilityWarnings = warnings
+
 		// Mark request as read for the creator (so their own request doesn't show as unread)
 		models.MarkRequestAsRead(db, placementReq.ID, userID.(int))
 
@@ -73,7 +149,250 @@ func CreatePlacementRequest(db *sql.DB) gin.HandlerFunc {
 	}
 }
 
-// GetPlacementRequests handles GET /api/requests
+// submitTransition checks that req may be submitted to its facility, listing the missing
+// patient details if not. It writes the error response itself and returns nil when the
+// request cannot be submitted.
+func submitTransition(c *gin.Context, req *models.PlacementRequest, subject *workflow.Subject) *workflow.Transition {
+	missing := models.MissingRequestFields(req)
+	subject.RequestComplete = len(missing) == 0
+
+	transition, err := workflow.PlacementRequests.Fire(req.Status, workflow.ActionSubmit, roleOf(c), subject)
+	if errors.Is(err, workflow.ErrGuardNotSatisfied) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Request is incomplete", "missing_fields": missing})
+		return nil
+	}
+	if err != nil {
+		writeWorkflowError(c, err)
+		return nil
+	}
+	return transition
+}
+
+// SubmitPlacementRequest handles POST /api/requests/:id/submit. The hospital sends a
+// draft to its facility, after which it shows up in the facility's requests.
+func SubmitPlacementRequest(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !canTrigger(c, workflow.PlacementRequests, workflow.ActionSubmit) {
+			return
+		}
+
+		req := placementRequestForUser(c, db)
+		if req == nil {
+			return
+		}
+
+		userID, _ := c.Get("userID")
+		subject := &workflow.Subject{UserID: userID.(int), RequestID: req.ID}
+		transition := submitTransition(c, req, subject)
+		if transition == nil {
+			return
+		}
+
+		if !applyTransition(c, db, transition, subject, "Failed to submit request", func(tx *sql.Tx) error {
+			return models.UpdatePlacementRequestStatus(tx, req.ID, req.Status, transition.To)
+		}) {
+			return
+		}
+
+		submitted, err := models.GetPlacementRequestByID(db, req.ID)
+		if err != nil || submitted == nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve request"})
+			return
+		}
+
+		warnings, err := models.CheckPlacementEligibility(db, submitted)
+		if err != nil {
+			log.Printf("Failed to check eligibility for request %d: %v", submitted.ID, err)
+		}
+		submitted.EligibilityWarnings = warnings
+
+		c.JSON(http.StatusOK, submitted)
+	}
+}
+
+// saveDraft applies an autosave of a draft. Only the fields sent are changed, so clients
+// can save whatever the user has typed so far; an empty patient_care_level clears it.
+func saveDraft(c *gin.Context, db *sql.DB, req *models.PlacementRequest) {
+	var draftReq struct {
+		FacilityID          *int    `json:"facility_id"`
+		PatientAge          *int    `json:"patient_age"`
+		PatientGender       *string `json:"patient_gender"`
+		MedicalCondition    *string `json:"medical_condition"`
+		PatientCareLevel    *string `json:"patient_care_level"`
+		PatientHasDementia  *bool   `json:"patient_has_dementia"`
+		Urgency             *string `json:"urgency"`
+		TargetDischargeDate *string `json:"target_discharge_date"`
+	}
+
+	if err := c.ShouldBindJSON(&draftReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if draftReq.FacilityID != nil && *draftReq.FacilityID != req.FacilityID {
+		facility, err := models.GetFacilityByID(db, *draftReq.FacilityID)
+		if err != nil || facility == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Facility not found"})
+			return
+		}
+		req.FacilityID = facility.ID
+		req.FacilityName = facility.Name
+	}
+	if draftReq.PatientAge != nil {
+		req.PatientAge = *draftReq.PatientAge
+	}
+	if draftReq.PatientGender != nil {
+		req.PatientGender = *draftReq.PatientGender
+	}
+	if draftReq.MedicalCondition != nil {
+		req.MedicalCondition = *draftReq.MedicalCondition
+	}
+	if draftReq.PatientCareLevel != nil {
+		careLevel, err := patientCareLevel(draftReq.PatientCareLevel)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		req.PatientCareLevel = careLevel
+	}
+	if draftReq.PatientHasDementia != nil {
+		req.PatientHasDementia = draftReq.PatientHasDementia
+	}
+	if !applyUrgencyAndDischargeDate(c, req, draftReq.Urgency, draftReq.TargetDischargeDate) {
+		return
+	}
+
+	if err := models.SaveDraftPlacementRequest(db, req); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Request is no longer a draft"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save draft"})
+		return
+	}
+
+	c.JSON(http.StatusOK, req)
+}
+
+// patientCareLevel validates an optional care level; empty means unknown
+func patientCareLevel(level *string) (*string, error) {
+	if level == nil || *level == "" {
+		return nil, nil
+	}
+	if !models.IsCareLevel(*level) {
+		return nil, fmt.Errorf("unknown care level: %s", *level)
+	}
+	return level, nil
+}
+
+// requestUrgency validates an optional urgency; empty means routine
+func requestUrgency(urgency *string) (string, error) {
+	if urgency == nil || *urgency == "" {
+		return models.DefaultRequestUrgency, nil
+	}
+	if !models.IsRequestUrgency(*urgency) {
+		return "", fmt.Errorf("unknown urgency: %s", *urgency)
+	}
+	return *urgency, nil
+}
+
+// targetDischargeDate parses an optional YYYY-MM-DD date; empty means none
+func targetDischargeDate(date *string) (*time.Time, error) {
+	if date == nil || *date == "" {
+		return nil, nil
+	}
+	parsed, err := parseDate(*date)
+	if err != nil {
+		return nil, fmt.Errorf("target_discharge_date must be YYYY-MM-DD")
+	}
+	return &parsed, nil
+}
+
+// parseDate reads a date sent as YYYY-MM-DD, or as the RFC 3339 timestamp dates are
+// serialized as, so a client can send back a date it received unchanged
+func parseDate(value string) (time.Time, error) {
+	if date, err := time.Parse("2006-01-02", value); err == nil {
+		return date, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), nil
+}
+
+// applyUrgencyAndDischargeDate changes the urgency and target discharge date of req to
+// the values sent, keeping those not sent; an empty target_discharge_date clears it. It
+// writes the error response itself and returns false when a value is invalid.
+func applyUrgencyAndDischargeDate(c *gin.Context, req *models.PlacementRequest, urgency, dischargeDate *string) bool {
+	if urgency != nil {
+		u, err := requestUrgency(urgency)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return false
+		}
+		req.Urgency = u
+	}
+	if dischargeDate != nil {
+		date, err := targetDischargeDate(dischargeDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return false
+		}
+		req.TargetDischargeDate = date
+	}
+	return true
+}
+
+// requestListQuery filters and sorts the facility's request list
+type requestListQuery struct {
+	Urgency string `form:"urgency"`
+	Status  string `form:"status"`
+	// Requests to be discharged on or before this date (YYYY-MM-DD)
+	DischargeBy  string `form:"discharge_by"`
+	NearDeadline bool   `form:"near_deadline"`
+	SortBy       string `form:"sort_by"`    // created_at, urgency, target_discharge_date
+	SortOrder    string `form:"sort_order"` // asc, desc
+}
+
+// toParams validates the query and converts it to list parameters
+func (q requestListQuery) toParams() (models.RequestListParams, error) {
+	if q.Urgency != "" && !models.IsRequestUrgency(q.Urgency) {
+		return models.RequestListParams{}, fmt.Errorf("unknown urgency: %s", q.Urgency)
+	}
+	if q.Status != "" && workflow.PlacementRequests.State(q.Status) == nil {
+		return models.RequestListParams{}, fmt.Errorf("unknown status: %s", q.Status)
+	}
+	switch q.SortBy {
+	case "", "created_at", "urgency", "target_discharge_date":
+	default:
+		return models.RequestListParams{}, fmt.Errorf("unknown sort_by: %s", q.SortBy)
+	}
+	switch q.SortOrder {
+	case "", "asc", "desc":
+	default:
+		return models.RequestListParams{}, fmt.Errorf("sort_order must be asc or desc")
+	}
+
+	params := models.RequestListParams{
+		Urgency:      q.Urgency,
+		Status:       q.Status,
+		NearDeadline: q.NearDeadline,
+		SortBy:       q.SortBy,
+		SortOrder:    q.SortOrder,
+	}
+	if q.DischargeBy != "" {
+		date, err := time.Parse("2006-01-02", q.DischargeBy)
+		if err != nil {
+			return models.RequestListParams{}, fmt.Errorf("discharge_by must be YYYY-MM-DD")
+		}
+		params.DischargeBy = &date
+	}
+	return params, nil
+}
+
+// GetPlacementRequests handles GET /api/requests. Facilities can filter and sort their
+// requests by urgency and target discharge date (see requestListQuery).
 func GetPlacementRequests(db *sql.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID, exists := c.Get("userID")
@@ -105,8 +424,18 @@ func GetPlacementRequests(db *sql.DB) gin.HandlerFunc {
 				c.JSON(http.StatusNotFound, gin.H{"error": "Facility not found"})
 				return
 			}
+			var query requestListQuery
+			if err := c.ShouldBindQuery(&query); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query", "details": err.Error()})
+				return
+			}
+			params, err := query.toParams()
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
 			log.Printf("Fetching requests for facility ID: %d", facility.ID)
-			requests, err = models.GetPlacementRequestsByFacilityID(db, facility.ID)
+			requests, err = models.GetPlacementRequestsByFacilityID(db, facility.ID, params)
 		} else {
 			c.JSON(http.StatusForbidden, gin.H{"error": "Invalid role"})
 			return
@@ -130,55 +459,121 @@ func GetPlacementRequests(db *sql.DB) gin.HandlerFunc {
 // GetPlacementRequestByID handles GET /api/requests/:id
 func GetPlacementRequestByID(db *sql.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		userID, exists := c.Get("userID")
-		if !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		req := placementRequestForUser(c, db)
+		if req == nil {
 			return
 		}
 
-		role, exists := c.Get("userRole")
-		if !exists {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Role not found"})
+		history, err := models.GetStatusHistoryByRequestID(db, req.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve request history"})
 			return
 		}
+		req.History = history
 
-		id, err := strconv.Atoi(c.Param("id"))
+		files, err := models.GetRequestFiles(db, req.ID)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request ID"})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve request files"})
 			return
 		}
+		req.CarriedFiles = files
 
-		req, err := models.GetPlacementRequestByID(db, id)
+		conditions, err := models.GetRequestConditions(db, req.ID)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve request"})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve request conditions"})
 			return
 		}
+		req.Conditions = conditions
+
+		// The other facilities tried for the patient are the hospital's business only
+		if roleOf(c) == "hospital" {
+			attempts, err := models.GetReferralAttempts(db, req.ID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve referral attempts"})
+				return
+			}
+			req.Attempts = attempts
+		}
+
+		c.JSON(http.StatusOK, req)
+	}
+}
 
+// GetPlacementRequestHistory handles GET /api/requests/:id/history. It lists the status
+// changes of the request and its message room with who made them and why, oldest first.
+func GetPlacementRequestHistory(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req := placementRequestForUser(c, db)
 		if req == nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Request not found"})
 			return
 		}
 
-		// Check authorization
-		if role == "hospital" {
-			hospital, err := models.GetHospitalByUserID(db, userID.(int))
-			if err != nil || hospital == nil || hospital.ID != req.HospitalID {
-				c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
-				return
-			}
-		} else if role == "facility" {
-			facility, err := models.GetFacilityByUserID(db, userID.(int))
-			if err != nil || facility == nil || facility.ID != req.FacilityID {
-				c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
-				return
-			}
-		} else {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Invalid role"})
+		history, err := models.GetStatusHistoryByRequestID(db, req.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve request history"})
 			return
 		}
 
-		c.JSON(http.StatusOK, req)
+		c.JSON(http.StatusOK, history)
+	}
+}
+
+// placementRequestForUser loads the request in the :id path parameter if the user is
+// its hospital or facility. It writes the error response itself and returns nil otherwise.
+func placementRequestForUser(c *gin.Context, db *sql.DB) *models.PlacementRequest {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return nil
+	}
+
+	role, exists := c.Get("userRole")
+	if !exists {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Role not found"})
+		return nil
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request ID"})
+		return nil
+	}
+
+	req, err := models.GetPlacementRequestByID(db, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve request"})
+		return nil
+	}
+
+	if req == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Request not found"})
+		return nil
 	}
+
+	// Check authorization
+	if role == "hospital" {
+		hospital, err := models.GetHospitalByUserID(db, userID.(int))
+		if err != nil || hospital == nil || hospital.ID != req.HospitalID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+			return nil
+		}
+	} else if role == "facility" {
+		facility, err := models.GetFacilityByUserID(db, userID.(int))
+		if err != nil || facility == nil || facility.ID != req.FacilityID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+			return nil
+		}
+		// Drafts have not been sent to the facility yet
+		if req.SubmittedAt == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Request not found"})
+			return nil
+		}
+	} else {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Invalid role"})
+		return nil
+	}
+
+	return req
 }
 
 // AcceptPlacementRequest handles POST /api/requests/:id/accept
@@ -190,9 +585,7 @@ func AcceptPlacementRequest(db *sql.DB) gin.HandlerFunc {
 			return
 		}
 
-		role, exists := c.Get("userRole")
-		if !exists || role != "facility" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Only facility users can accept requests"})
+		if !canTrigger(c, workflow.PlacementRequests, workflow.ActionAccept) {
 			return
 		}
 
@@ -220,37 +613,23 @@ func AcceptPlacementRequest(db *sql.DB) gin.HandlerFunc {
 			return
 		}
 
-		// Check if request is pending
-		if req.Status != "pending" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Request is not pending"})
-			return
-		}
-
-		// Update status to accepted
-		if err := models.UpdatePlacementRequestStatus(db, id, "accepted"); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to accept request"})
+		subject := &workflow.Subject{UserID: userID.(int), RequestID: id}
+		transition, err := workflow.PlacementRequests.Fire(req.Status, workflow.ActionAccept, roleOf(c), subject)
+		if err != nil {
+			writeWorkflowError(c, err)
 			return
 		}
 
-		// Create message room
-		room := &models.MessageRoom{
-			RequestID:  id,
-			HospitalID: req.HospitalID,
-			FacilityID: req.FacilityID,
-			Status:     "negotiating",
-		}
-
-		if err := models.CreateMessageRoom(db, room); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create message room"})
+		// The room is opened by the transition, which sets subject.RoomID
+		if !applyTransition(c, db, transition, subject, "Failed to accept request", func(tx *sql.Tx) error {
+			return models.UpdatePlacementRequestStatus(tx, id, req.Status, transition.To)
+		}) {
 			return
 		}
 
-		// Mark request as read for the facility user (so their own action doesn't show as unread)
-		models.MarkRequestAsRead(db, id, userID.(int))
-
 		c.JSON(http.StatusOK, gin.H{
 			"message": "Request accepted",
-			"room_id": room.ID,
+			"room_id": subject.RoomID,
 		})
 	}
 }
@@ -264,9 +643,7 @@ func RejectPlacementRequest(db *sql.DB) gin.HandlerFunc {
 			return
 		}
 
-		role, exists := c.Get("userRole")
-		if !exists || role != "facility" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Only facility users can reject requests"})
+		if !canTrigger(c, workflow.PlacementRequests, workflow.ActionReject) {
 			return
 		}
 
@@ -294,20 +671,21 @@ func RejectPlacementRequest(db *sql.DB) gin.HandlerFunc {
 			return
 		}
 
-		// Check if request is pending
-		if req.Status != "pending" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Request is not pending"})
+		subject := &workflow.Subject{UserID: userID.(int), RequestID: id}
+		if !bindStatusReason(c, subject) {
 			return
 		}
-
-		// Update status to rejected
-		if err := models.UpdatePlacementRequestStatus(db, id, "rejected"); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reject request"})
+		transition, err := workflow.PlacementRequests.Fire(req.Status, workflow.ActionReject, roleOf(c), subject)
+		if err != nil {
+			writeWorkflowError(c, err)
 			return
 		}
 
-		// Mark request as read for the facility user (so their own action doesn't show as unread)
-		models.MarkRequestAsRead(db, id, userID.(int))
+		if !applyTransition(c, db, transition, subject, "Failed to reject request", func(tx *sql.Tx) error {
+			return models.UpdatePlacementRequestStatus(tx, id, req.Status, transition.To)
+		}) {
+			return
+		}
 
 		c.JSON(http.StatusOK, gin.H{"message": "Request rejected"})
 	}
@@ -322,9 +700,7 @@ func UpdatePlacementRequest(db *sql.DB) gin.HandlerFunc {
 			return
 		}
 
-		role, exists := c.Get("userRole")
-		if !exists || role != "hospital" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Only hospital users can update requests"})
+		if !canTrigger(c, workflow.PlacementRequests, workflow.OpUpdate) {
 			return
 		}
 
@@ -352,16 +728,25 @@ func UpdatePlacementRequest(db *sql.DB) gin.HandlerFunc {
 			return
 		}
 
-		// Can only update pending requests
-		if req.Status != "pending" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Can only update pending requests"})
+		if err := workflow.PlacementRequests.Allow(req.Status, workflow.OpUpdate, roleOf(c)); err != nil {
+			writeWorkflowError(c, err)
+			return
+		}
+
+		if req.Status == workflow.RequestDraft {
+			saveDraft(c, db, req)
 			return
 		}
 
 		var updateReq struct {
-			PatientAge       int    `json:"patient_age" binding:"required"`
-			PatientGender    string `json:"patient_gender" binding:"required"`
-			MedicalCondition string `json:"medical_condition" binding:"required"`
+			PatientAge         int     `json:"patient_age" binding:"required"`
+			PatientGender      string  `json:"patient_gender" binding:"required"`
+			MedicalCondition   string  `json:"medical_condition" binding:"required"`
+			PatientCareLevel   *string `json:"patient_care_level"`
+			PatientHasDementia *bool   `json:"patient_has_dementia"`
+			// Left unchanged when not sent
+			Urgency             *string `json:"urgency"`
+			TargetDischargeDate *string `json:"target_discharge_date"`
 		}
 
 		if err := c.ShouldBindJSON(&updateReq); err != nil {
@@ -369,7 +754,22 @@ func UpdatePlacementRequest(db *sql.DB) gin.HandlerFunc {
 			return
 		}
 
-		if err := models.UpdatePlacementRequest(db, id, updateReq.PatientAge, updateReq.PatientGender, updateReq.MedicalCondition); err != nil {
+		careLevel, err := patientCareLevel(updateReq.PatientCareLevel)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		req.PatientAge = updateReq.PatientAge
+		req.PatientGender = updateReq.PatientGender
+		req.MedicalCondition = updateReq.MedicalCondition
+		req.PatientCareLevel = careLevel
+		req.PatientHasDementia = updateReq.PatientHasDementia
+		if !applyUrgencyAndDischargeDate(c, req, updateReq.Urgency, updateReq.TargetDischargeDate) {
+			return
+		}
+
+		if err := models.UpdatePlacementRequest(db, req); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update request"})
 			return
 		}
@@ -380,57 +780,90 @@ func UpdatePlacementRequest(db *sql.DB) gin.HandlerFunc {
 	}
 }
 
-// CancelPlacementRequest handles DELETE /api/requests/:id
-func CancelPlacementRequest(db *sql.DB) gin.HandlerFunc {
+// WithdrawPlacementRequest handles POST /api/requests/:id/withdraw and DELETE /api/requests/:id.
+// The hospital takes back a request it no longer needs, e.g. because the patient was
+// readmitted or chose another facility. The request is kept as withdrawn.
+func WithdrawPlacementRequest(db *sql.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		userID, exists := c.Get("userID")
-		if !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		if !canTrigger(c, workflow.PlacementRequests, workflow.ActionWithdraw) {
 			return
 		}
 
-		role, exists := c.Get("userRole")
-		if !exists || role != "hospital" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Only hospital users can cancel requests"})
+		req := placementRequestForUser(c, db)
+		if req == nil {
 			return
 		}
 
-		id, err := strconv.Atoi(c.Param("id"))
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request ID"})
-			return
-		}
+		withdrawRequest(c, db, req)
+	}
+}
 
-		req, err := models.GetPlacementRequestByID(db, id)
+// withdrawRequest withdraws req and its open message room with the optional reason in
+// the body, and writes the response
+func withdrawRequest(c *gin.Context, db *sql.DB, req *models.PlacementRequest) {
+	userID, _ := c.Get("userID")
+
+	room, err := models.GetMessageRoomByRequestID(db, req.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve room"})
+		return
+	}
+
+	subject := &workflow.Subject{
+		UserID:     userID.(int),
+		RequestID:  req.ID,
+		RoomClosed: room != nil && workflow.MessageRooms.State(room.Status).Closed,
+	}
+	if !bindStatusReason(c, subject) {
+		return
+	}
+
+	transition, err := workflow.PlacementRequests.Fire(req.Status, workflow.ActionWithdraw, roleOf(c), subject)
+	if err != nil {
+		writeWorkflowError(c, err)
+		return
+	}
+
+	var roomTransition *workflow.Transition
+	roomSubject := &workflow.Subject{UserID: userID.(int), RequestID: req.ID, Reason: subject.Reason}
+	if room != nil {
+		roomSubject.RoomID = room.ID
+		roomTransition, err = workflow.MessageRooms.Fire(room.Status, workflow.ActionWithdraw, roleOf(c), roomSubject)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve request"})
+			writeWorkflowError(c, err)
 			return
 		}
+	}
 
-		if req == nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Request not found"})
-			return
+	// The request and its room are withdrawn together or not at all
+	err = inTransaction(db, func(tx *sql.Tx) error {
+		if err := models.WithdrawPlacementRequest(tx, req.ID, req.Status, optionalString(subject.Reason)); err != nil {
+			return err
 		}
-
-		// Check authorization
-		hospital, err := models.GetHospitalByUserID(db, userID.(int))
-		if err != nil || hospital == nil || hospital.ID != req.HospitalID {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
-			return
+		if err := transition.Apply(tx, subject); err != nil {
+			return err
 		}
-
-		// Can only cancel pending requests
-		if req.Status != "pending" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Can only cancel pending requests"})
-			return
+		if roomTransition == nil {
+			return nil
 		}
-
-		// Delete the request
-		if err := models.DeletePlacementRequest(db, id); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel request"})
-			return
+		if err := models.UpdateMessageRoomStatus(tx, room.ID, room.Status, roomTransition.To); err != nil {
+			return err
 		}
+		return roomTransition.Apply(tx, roomSubject)
+	})
+	if err != nil {
+		writeTransitionError(c, err, "Failed to withdraw request")
+		return
+	}
 
-		c.JSON(http.StatusOK, gin.H{"message": "Request cancelled"})
+	if err := transition.RunEffects(db, subject); err != nil {
+		log.Printf("Failed to run effects of withdrawing request %d: %v", req.ID, err)
+	}
+	if roomTransition != nil {
+		if err := roomTransition.RunEffects(db, roomSubject); err != nil {
+			log.Printf("Failed to run effects of withdrawing room %s: %v", room.ID, err)
+		}
 	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Request withdrawn"})
 }
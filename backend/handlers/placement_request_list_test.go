@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/social-worker-platform/backend/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestListQuery_ToParams(t *testing.T) {
+	t.Run("filters and sort", func(t *testing.T) {
+		query := requestListQuery{
+			Urgency:      "urgent",
+			Status:       "pending",
+			DischargeBy:  "2026-11-01",
+			NearDeadline: true,
+			SortBy:       "target_discharge_date",
+			SortOrder:    "asc",
+		}
+		params, err := query.toParams()
+		require.NoError(t, err)
+
+		dischargeBy := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+		assert.Equal(t, models.RequestListParams{
+			Urgency:      "urgent",
+			Status:       "pending",
+			DischargeBy:  &dischargeBy,
+			NearDeadline: true,
+			SortBy:       "target_discharge_date",
+			SortOrder:    "asc",
+		}, params)
+	})
+
+	t.Run("no query lists everything newest first", func(t *testing.T) {
+		params, err := requestListQuery{}.toParams()
+		require.NoError(t, err)
+		assert.Equal(t, models.RequestListParams{}, params)
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		invalid := []requestListQuery{
+			{Urgency: "whenever"},
+			{Status: "archived"},
+			{DischargeBy: "11/01/2026"},
+			{SortBy: "patient_age"},
+			{SortOrder: "up"},
+		}
+		for _, query := range invalid {
+			_, err := query.toParams()
+			assert.Error(t, err, query)
+		}
+	})
+}
+
+func TestRequestUrgency(t *testing.T) {
+	urgency, err := requestUrgency(nil)
+	require.NoError(t, err)
+	assert.Equal(t, models.DefaultRequestUrgency, urgency)
+
+	soon := "soon"
+	urgency, err = requestUrgency(&soon)
+	require.NoError(t, err)
+	assert.Equal(t, "soon", urgency)
+
+	unknown := "asap"
+	_, err = requestUrgency(&unknown)
+	assert.Error(t, err)
+
+	empty := ""
+	date, err := targetDischargeDate(&empty)
+	require.NoError(t, err)
+	assert.Nil(t, date)
+
+	bad := "2026-13-01"
+	_, err = targetDischargeDate(&bad)
+	assert.Error(t, err)
+}
+
+func TestTargetDischargeDate(t *testing.T) {
+	want := time.Date(2026, 11, 15, 0, 0, 0, 0, time.UTC)
+
+	for _, value := range []string{"2026-11-15", "2026-11-15T00:00:00Z", "2026-11-15T00:00:00+09:00"} {
+		date, err := targetDischargeDate(&value)
+		require.NoError(t, err, value)
+		assert.Equal(t, want, *date, value)
+	}
+
+	// A date read from a response can be sent back unchanged
+	sent := want.Format(time.RFC3339)
+	date, err := targetDischargeDate(&sent)
+	require.NoError(t, err)
+	assert.Equal(t, want, *date)
+
+	empty := ""
+	date, err = targetDischargeDate(&empty)
+	assert.NoError(t, err)
+	assert.Nil(t, date)
+
+	invalid := "15/11/2026"
+	_, err = targetDischargeDate(&invalid)
+	assert.Error(t, err)
+}
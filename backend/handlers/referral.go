@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/social-worker-platform/backend/models"
+	"github.com/social-worker-platform/backend/workflow"
+)
+
+// copyRoomFile copies the stored file of a room file next to the room uploads, so the
+// copy outlives the original being deleted
+func copyRoomFile(file *models.RoomFile) (string, error) {
+	uploadDir := "./uploads/rooms"
+	if err := os.MkdirAll(uploadDir, 0755); err != nil {
+		return "", err
+	}
+
+	src, err := os.Open(file.FilePath)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	savePath := filepath.Join(uploadDir, strconv.FormatInt(time.Now().UnixNano(), 10)+"_"+filepath.Base(file.FileName))
+	dst, err := os.Create(savePath)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		os.Remove(savePath)
+		return "", err
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(savePath)
+		return "", err
+	}
+
+	return savePath, nil
+}
+
+// ForwardPlacementRequest handles POST /api/requests/:id/forward. The hospital sends
+// the patient of a request to another facility without entering the details again,
+// carrying over files it picks from the original request's room. The new request
+// links back to the original.
+func ForwardPlacementRequest(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !canTrigger(c, workflow.PlacementRequests, workflow.OpForward) {
+			return
+		}
+
+		original := placementRequestForUser(c, db)
+		if original == nil {
+			return
+		}
+
+		if err := workflow.PlacementRequests.Allow(original.Status, workflow.OpForward, roleOf(c)); err != nil {
+			writeWorkflowError(c, err)
+			return
+		}
+
+		var req struct {
+			FacilityID int   `json:"facility_id" binding:"required"`
+			FileIDs    []int `json:"file_ids"`
+			// Draft keeps the new request with the hospital, e.g. to update the details first
+			Draft bool `json:"draft"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format", "details": err.Error()})
+			return
+		}
+
+		if req.FacilityID == original.FacilityID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Choose a different facility to forward the request to"})
+			return
+		}
+
+		facility, err := models.GetFacilityByID(db, req.FacilityID)
+		if err != nil || facility == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Facility not found"})
+			return
+		}
+
+		// Only files of the original request's room can be carried over
+		roomFiles := make([]*models.RoomFile, 0, len(req.FileIDs))
+		for _, fileID := range req.FileIDs {
+			file, err := models.GetRoomFileByID(db, fileID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve file"})
+				return
+			}
+			if file == nil || original.RoomID == nil || file.RoomID != *original.RoomID {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "File not found in the room of this request", "details": fmt.Sprintf("file_id %d", fileID)})
+				return
+			}
+			roomFiles = append(roomFiles, file)
+		}
+
+		userID, _ := c.Get("userID")
+		forwarded := &models.PlacementRequest{
+			HospitalID:          original.HospitalID,
+			FacilityID:          req.FacilityID,
+			PatientAge:          original.PatientAge,
+			PatientGender:       original.PatientGender,
+			MedicalCondition:    original.MedicalCondition,
+			PatientCareLevel:    original.PatientCareLevel,
+			PatientHasDementia:  original.PatientHasDementia,
+			Urgency:             original.Urgency,
+			TargetDischargeDate: original.TargetDischargeDate,
+			Status:              workflow.PlacementRequests.Initial,
+			ForwardedFromID:     &original.ID,
+		}
+
+		subject := &workflow.Subject{UserID: userID.(int)}
+		if !req.Draft {
+			transition := submitTransition(c, forwarded, subject)
+			if transition == nil {
+				return
+			}
+			forwarded.Status = transition.To
+		}
+
+		files := make([]*models.RequestFile, 0, len(roomFiles))
+		for _, file := range roomFiles {
+			path, err := copyRoomFile(file)
+			if err != nil {
+				log.Printf("Failed to copy room file %d: %v", file.ID, err)
+				removeRequestFiles(files)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to copy file", "details": file.FileName})
+				return
+			}
+			sourceID := file.ID
+			files = append(files, &models.RequestFile{
+				SourceFileID: &sourceID,
+				SenderID:     userID.(int),
+				FileName:     file.FileName,
+				FilePath:     path,
+				FileType:     file.FileType,
+				FileSize:     file.FileSize,
+			})
+		}
+
+		subject.Reason = fmt.Sprintf("依頼 #%d から転送", original.ID)
+		err = inTransaction(db, func(tx *sql.Tx) error {
+			if err := models.ForwardPlacementRequest(tx, forwarded, files); err != nil {
+				return err
+			}
+			subject.RequestID = forwarded.ID
+			return workflow.RecordCreated(tx, workflow.PlacementRequests, forwarded.Status, roleOf(c), subject)
+		})
+		if err != nil {
+			removeRequestFiles(files)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to forward placement request"})
+			return
+		}
+
+		warnings, err := models.CheckPlacementEligibility(db, forwarded)
+		if err != nil {
+			log.Printf("Failed to check eligibility for request %d: %v", forwarded.ID, err)
+		}
+		forwarded.EligibilityWarnings = warnings
+
+		models.MarkRequestAsRead(db, forwarded.ID, userID.(int))
+
+		c.JSON(http.StatusCreated, forwarded)
+	}
+}
+
+// removeRequestFiles deletes copies made for a forward that failed
+func removeRequestFiles(files []*models.RequestFile) {
+	for _, f := range files {
+		if err := os.Remove(f.FilePath); err != nil && !os.IsNotExist(err) {
+			log.Printf("Failed to remove copied file %s: %v", f.FilePath, err)
+		}
+	}
+}
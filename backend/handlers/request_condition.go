@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/social-worker-platform/backend/models"
+	"github.com/social-worker-platform/backend/workflow"
+)
+
+type requestConditionsRequest struct {
+	EarliestDate *string `json:"earliest_date"`
+	RoomTypeID   *int    `json:"room_type_id"`
+	MonthlyFee   *int    `json:"monthly_fee"`
+	Terms        string  `json:"terms"`
+}
+
+type respondToConditionsRequest struct {
+	Note string `json:"note"`
+}
+
+// AcceptPlacementRequestWithConditions handles POST /api/requests/:id/accept-with-conditions.
+// The facility accepts a pending request only on the conditions in the body, e.g. a shared
+// room from a later date. The room opens once the hospital accepts the conditions.
+func AcceptPlacementRequestWithConditions(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !canTrigger(c, workflow.PlacementRequests, workflow.ActionAcceptConditional) {
+			return
+		}
+
+		req := placementRequestForUser(c, db)
+		if req == nil {
+			return
+		}
+
+		var body requestConditionsRequest
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format", "details": err.Error()})
+			return
+		}
+
+		userID, _ := c.Get("userID")
+		proposedBy := userID.(int)
+		conditions := &models.RequestCondition{
+			RequestID:  req.ID,
+			ProposedBy: &proposedBy,
+			RoomTypeID: body.RoomTypeID,
+			MonthlyFee: body.MonthlyFee,
+			Terms:      optionalString(strings.TrimSpace(body.Terms)),
+		}
+		if body.EarliestDate != nil && *body.EarliestDate != "" {
+			date, err := parseDate(*body.EarliestDate)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "earliest_date must be YYYY-MM-DD"})
+				return
+			}
+			conditions.EarliestDate = &date
+		}
+		if conditions.MonthlyFee != nil && *conditions.MonthlyFee < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "monthly_fee must not be negative"})
+			return
+		}
+		if conditions.Empty() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": models.ErrConditionsEmpty.Error()})
+			return
+		}
+		if !checkFacilityRoomType(c, db, req.FacilityID, conditions.RoomTypeID) {
+			return
+		}
+
+		subject := &workflow.Subject{UserID: userID.(int), RequestID: req.ID}
+		transition, err := workflow.PlacementRequests.Fire(req.Status, workflow.ActionAcceptConditional, roleOf(c), subject)
+		if err != nil {
+			writeWorkflowError(c, err)
+			return
+		}
+
+		if !applyTransition(c, db, transition, subject, "Failed to accept request", func(tx *sql.Tx) error {
+			if err := models.CreateRequestCondition(tx, conditions); err != nil {
+				return err
+			}
+			// The history shows what was asked for
+			subject.Reason = conditions.Summary()
+			return models.UpdatePlacementRequestStatus(tx, req.ID, req.Status, transition.To)
+		}) {
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message":    "Request accepted with conditions",
+			"conditions": conditions,
+		})
+	}
+}
+
+// conditionsToAnswer checks that the hospital may answer the request's conditions by
+// action and loads them with the optional note in the body. It writes the error
+// response itself and returns a nil transition when the hospital may not proceed.
+func conditionsToAnswer(c *gin.Context, db *sql.DB, action string) (*models.PlacementRequest, *models.RequestCondition, *workflow.Transition, *workflow.Subject) {
+	if !canTrigger(c, workflow.PlacementRequests, action) {
+		return nil, nil, nil, nil
+	}
+
+	req := placementRequestForUser(c, db)
+	if req == nil {
+		return nil, nil, nil, nil
+	}
+
+	var body respondToConditionsRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format", "details": err.Error()})
+			return nil, nil, nil, nil
+		}
+	}
+
+	userID, _ := c.Get("userID")
+	subject := &workflow.Subject{UserID: userID.(int), RequestID: req.ID, Reason: strings.TrimSpace(body.Note)}
+	transition, err := workflow.PlacementRequests.Fire(req.Status, action, roleOf(c), subject)
+	if err != nil {
+		writeWorkflowError(c, err)
+		return nil, nil, nil, nil
+	}
+
+	conditions, err := models.GetProposedRequestCondition(db, req.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve conditions"})
+		return nil, nil, nil, nil
+	}
+	if conditions == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "No conditions are awaiting an answer"})
+		return nil, nil, nil, nil
+	}
+
+	return req, conditions, transition, subject
+}
+
+// respondToConditions records the hospital's answer to the conditions and moves the
+// request on in tx
+func respondToConditions(tx *sql.Tx, req *models.PlacementRequest, conditions *models.RequestCondition, status string, transition *workflow.Transition, subject *workflow.Subject) error {
+	if err := models.RespondToRequestCondition(tx, conditions.ID, status, subject.UserID, optionalString(subject.Reason)); err != nil {
+		return err
+	}
+	return models.UpdatePlacementRequestStatus(tx, req.ID, req.Status, transition.To)
+}
+
+// AcceptRequestConditions handles POST /api/requests/:id/conditions/accept. The hospital
+// agrees to the facility's conditions, which accepts the request and opens its room.
+func AcceptRequestConditions(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req, conditions, transition, subject := conditionsToAnswer(c, db, workflow.ActionAcceptConditions)
+		if transition == nil {
+			return
+		}
+
+		// The room is opened by the transition, which sets subject.RoomID
+		if !applyTransition(c, db, transition, subject, "Failed to accept conditions", func(tx *sql.Tx) error {
+			return respondToConditions(tx, req, conditions, models.ConditionsAccepted, transition, subject)
+		}) {
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Conditions accepted",
+			"room_id": subject.RoomID,
+		})
+	}
+}
+
+// DeclineRequestConditions handles POST /api/requests/:id/conditions/decline. The request
+// ends up rejected, so the hospital can still waitlist or forward it.
+func DeclineRequestConditions(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req, conditions, transition, subject := conditionsToAnswer(c, db, workflow.ActionDeclineConditions)
+		if transition == nil {
+			return
+		}
+
+		if !applyTransition(c, db, transition, subject, "Failed to decline conditions", func(tx *sql.Tx) error {
+			return respondToConditions(tx, req, conditions, models.ConditionsDeclined, transition, subject)
+		}) {
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Conditions declined"})
+	}
+}
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/social-worker-platform/backend/models"
+)
+
+type requestTemplateRequest struct {
+	Name               string  `json:"name" binding:"required,max=100"`
+	MedicalCondition   string  `json:"medical_condition"`
+	PatientCareLevel   *string `json:"patient_care_level"`
+	PatientHasDementia *bool   `json:"patient_has_dementia"`
+}
+
+// ownRequestTemplate loads a template and checks that it belongs to the hospital.
+// It writes the error response itself and returns nil when the caller may not use it.
+func ownRequestTemplate(c *gin.Context, db *sql.DB, hospital *models.Hospital, id int) *models.RequestTemplate {
+	template, err := models.GetRequestTemplateByID(db, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve template"})
+		return nil
+	}
+
+	if template == nil || template.HospitalID != hospital.ID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return nil
+	}
+
+	return template
+}
+
+// requestTemplateParam loads the template in the :id path parameter
+func requestTemplateParam(c *gin.Context, db *sql.DB, hospital *models.Hospital) *models.RequestTemplate {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template ID"})
+		return nil
+	}
+	return ownRequestTemplate(c, db, hospital, id)
+}
+
+// bindRequestTemplate reads and validates a template body into t
+func bindRequestTemplate(c *gin.Context, t *models.RequestTemplate) bool {
+	var req requestTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+
+	careLevel, err := patientCareLevel(req.PatientCareLevel)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+
+	t.Name = req.Name
+	t.MedicalCondition = req.MedicalCondition
+	t.PatientCareLevel = careLevel
+	t.PatientHasDementia = req.PatientHasDementia
+	return true
+}
+
+func writeRequestTemplateError(c *gin.Context, err error, message string) {
+	if errors.Is(err, models.ErrRequestTemplateNameTaken) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+}
+
+// GetRequestTemplates handles GET /api/request-templates
+func GetRequestTemplates(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		hospital := hospitalOfUser(c, db)
+		if hospital == nil {
+			return
+		}
+
+		templates, err := models.GetRequestTemplatesByHospitalID(db, hospital.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve templates"})
+			return
+		}
+
+		c.JSON(http.StatusOK, templates)
+	}
+}
+
+// CreateRequestTemplate handles POST /api/request-templates
+func CreateRequestTemplate(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		hospital := hospitalOfUser(c, db)
+		if hospital == nil {
+			return
+		}
+
+		template := &models.RequestTemplate{HospitalID: hospital.ID}
+		if !bindRequestTemplate(c, template) {
+			return
+		}
+
+		if err := models.CreateRequestTemplate(db, template); err != nil {
+			writeRequestTemplateError(c, err, "Failed to create template")
+			return
+		}
+
+		c.JSON(http.StatusCreated, template)
+	}
+}
+
+// GetRequestTemplate handles GET /api/request-templates/:id
+func GetRequestTemplate(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		hospital := hospitalOfUser(c, db)
+		if hospital == nil {
+			return
+		}
+
+		template := requestTemplateParam(c, db, hospital)
+		if template == nil {
+			return
+		}
+
+		c.JSON(http.StatusOK, template)
+	}
+}
+
+// UpdateRequestTemplate handles PUT /api/request-templates/:id
+func UpdateRequestTemplate(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		hospital := hospitalOfUser(c, db)
+		if hospital == nil {
+			return
+		}
+
+		template := requestTemplateParam(c, db, hospital)
+		if template == nil {
+			return
+		}
+
+		if !bindRequestTemplate(c, template) {
+			return
+		}
+
+		if err := models.UpdateRequestTemplate(db, template); err != nil {
+			writeRequestTemplateError(c, err, "Failed to update template")
+			return
+		}
+
+		c.JSON(http.StatusOK, template)
+	}
+}
+
+// DeleteRequestTemplate handles DELETE /api/request-templates/:id
+func DeleteRequestTemplate(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		hospital := hospitalOfUser(c, db)
+		if hospital == nil {
+			return
+		}
+
+		template := requestTemplateParam(c, db, hospital)
+		if template == nil {
+			return
+		}
+
+		if err := models.DeleteRequestTemplate(db, template.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete template"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Template deleted"})
+	}
+}
@@ -0,0 +1,308 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/social-worker-platform/backend/models"
+	"github.com/social-worker-platform/backend/workflow"
+)
+
+// roomForParty loads the room in the :id path parameter if the user is its hospital or
+// facility. It writes the error response itself and returns nil otherwise.
+func roomForParty(c *gin.Context, db *sql.DB) *models.MessageRoom {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return nil
+	}
+
+	room, err := models.GetMessageRoomByID(db, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve room"})
+		return nil
+	}
+
+	if room == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Room not found"})
+		return nil
+	}
+
+	// Check authorization
+	switch roleOf(c) {
+	case "hospital":
+		hospital, err := models.GetHospitalByUserID(db, userID.(int))
+		if err != nil || hospital == nil || hospital.ID != room.HospitalID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+			return nil
+		}
+	case "facility":
+		facility, err := models.GetFacilityByUserID(db, userID.(int))
+		if err != nil || facility == nil || facility.ID != room.FacilityID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+			return nil
+		}
+	default:
+		c.JSON(http.StatusForbidden, gin.H{"error": "Invalid role"})
+		return nil
+	}
+
+	return room
+}
+
+// pendingReopenRequest loads the room's pending reopen request and checks whether the
+// caller is the party that made it. It writes the error response itself and returns nil
+// when there is none.
+func pendingReopenRequest(c *gin.Context, db *sql.DB, room *models.MessageRoom) *models.RoomReopenRequest {
+	pending, err := models.GetPendingRoomReopenRequest(db, room.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve reopen request"})
+		return nil
+	}
+
+	if pending == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No reopen request is pending"})
+		return nil
+	}
+
+	return pending
+}
+
+// partyLabel names a party in system messages
+func partyLabel(role string) string {
+	if role == "hospital" {
+		return "病院"
+	}
+	return "施設"
+}
+
+// postSystemMessage posts a status message to the room, logging failures
+func postSystemMessage(db *sql.DB, roomID string, senderID int, text string) {
+	msg := &models.Message{RoomID: roomID, SenderID: senderID, MessageText: text, IsSystem: true}
+	if err := models.CreateMessage(db, msg); err != nil {
+		log.Printf("Failed to post system message to room %s: %v", roomID, err)
+	}
+}
+
+// RequestRoomReopen handles POST /api/rooms/:id/reopen. Either party asks to reopen a
+// completed or rejected room; the room reopens once the other party confirms.
+func RequestRoomReopen(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !canTrigger(c, workflow.MessageRooms, workflow.OpRequestReopen) {
+			return
+		}
+
+		room := roomForParty(c, db)
+		if room == nil {
+			return
+		}
+
+		if err := workflow.MessageRooms.Allow(room.Status, workflow.OpRequestReopen, roleOf(c)); err != nil {
+			writeWorkflowError(c, err)
+			return
+		}
+
+		userID, _ := c.Get("userID")
+		subject := &workflow.Subject{}
+		if !bindStatusReason(c, subject) {
+			return
+		}
+
+		requestedBy := userID.(int)
+		reopen := &models.RoomReopenRequest{
+			RoomID:        room.ID,
+			RequestedBy:   &requestedBy,
+			RequestedRole: roleOf(c),
+			Reason:        optionalString(subject.Reason),
+		}
+		if err := models.CreateRoomReopenRequest(db, reopen); err != nil {
+			if errors.Is(err, models.ErrReopenAlreadyRequested) {
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to request reopening"})
+			return
+		}
+
+		text := partyLabel(reopen.RequestedRole) + "がルームの再開を依頼しました。承認すると再開されます。"
+		if subject.Reason != "" {
+			text += "\n理由: " + subject.Reason
+		}
+		postSystemMessage(db, room.ID, requestedBy, text)
+
+		c.JSON(http.StatusCreated, reopen)
+	}
+}
+
+// ConfirmRoomReopen handles POST /api/rooms/:id/reopen/confirm. The other party agrees
+// to the pending reopen request and the room moves back to an active status.
+func ConfirmRoomReopen(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !canTrigger(c, workflow.MessageRooms, workflow.ActionReopen) {
+			return
+		}
+
+		room := roomForParty(c, db)
+		if room == nil {
+			return
+		}
+
+		pending := pendingReopenRequest(c, db, room)
+		if pending == nil {
+			return
+		}
+
+		if pending.RequestedRole == roleOf(c) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only the other party can confirm this reopen request"})
+			return
+		}
+
+		userID, _ := c.Get("userID")
+		subject := &workflow.Subject{
+			UserID:          userID.(int),
+			RequestID:       room.RequestID,
+			RoomID:          room.ID,
+			ReopenConfirmed: true,
+		}
+		if pending.Reason != nil {
+			subject.Reason = *pending.Reason
+		}
+
+		transition, err := workflow.MessageRooms.Fire(room.Status, workflow.ActionReopen, roleOf(c), subject)
+		if err != nil {
+			writeWorkflowError(c, err)
+			return
+		}
+
+		if !applyTransition(c, db, transition, subject, "Failed to reopen room", func(tx *sql.Tx) error {
+			return models.ReopenMessageRoom(tx, room.ID, room.Status, transition.To, &pending.ID, userID.(int))
+		}) {
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Room reopened", "status": transition.To})
+	}
+}
+
+// DeclineRoomReopen handles POST /api/rooms/:id/reopen/decline. The other party turns
+// down the pending reopen request and the room stays closed.
+func DeclineRoomReopen(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		closeRoomReopen(c, db, models.ReopenDeclined)
+	}
+}
+
+// CancelRoomReopen handles DELETE /api/rooms/:id/reopen. The party that asked to reopen
+// the room takes the request back.
+func CancelRoomReopen(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		closeRoomReopen(c, db, models.ReopenCancelled)
+	}
+}
+
+// closeRoomReopen answers the pending reopen request with status: declined by the other
+// party or cancelled by the requester
+func closeRoomReopen(c *gin.Context, db *sql.DB, status string) {
+	if !canTrigger(c, workflow.MessageRooms, workflow.OpRequestReopen) {
+		return
+	}
+
+	room := roomForParty(c, db)
+	if room == nil {
+		return
+	}
+
+	pending := pendingReopenRequest(c, db, room)
+	if pending == nil {
+		return
+	}
+
+	ownRequest := pending.RequestedRole == roleOf(c)
+	if status == models.ReopenCancelled && !ownRequest {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the party that requested reopening can cancel it"})
+		return
+	}
+	if status == models.ReopenDeclined && ownRequest {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the other party can decline this reopen request"})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	if err := models.CloseRoomReopenRequest(db, pending.ID, status, userID.(int)); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Reopen request is no longer pending"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to answer reopen request"})
+		return
+	}
+
+	if status == models.ReopenDeclined {
+		postSystemMessage(db, room.ID, userID.(int), partyLabel(roleOf(c))+"がルームの再開を見送りました。")
+	} else {
+		postSystemMessage(db, room.ID, userID.(int), partyLabel(roleOf(c))+"がルームの再開の依頼を取り消しました。")
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Reopen request " + status})
+}
+
+// AdminReopenRoom handles POST /api/admin/rooms/:id/reopen. An admin reopens a closed
+// room without waiting for the parties, e.g. when one of them cannot be reached.
+func AdminReopenRoom(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userID")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		room, err := models.GetMessageRoomByID(db, c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve room"})
+			return
+		}
+
+		if room == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Room not found"})
+			return
+		}
+
+		subject := &workflow.Subject{
+			UserID:        userID.(int),
+			RequestID:     room.RequestID,
+			RoomID:        room.ID,
+			AdminOverride: true,
+		}
+		if !bindStatusReason(c, subject) {
+			return
+		}
+
+		transition, err := workflow.MessageRooms.Fire(room.Status, workflow.ActionReopen, roleOf(c), subject)
+		if err != nil {
+			writeWorkflowError(c, err)
+			return
+		}
+
+		// A pending request from one of the parties is settled by the override
+		pending, err := models.GetPendingRoomReopenRequest(db, room.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve reopen request"})
+			return
+		}
+		var pendingID *int
+		if pending != nil {
+			pendingID = &pending.ID
+		}
+
+		if !applyTransition(c, db, transition, subject, "Failed to reopen room", func(tx *sql.Tx) error {
+			return models.ReopenMessageRoom(tx, room.ID, room.Status, transition.To, pendingID, userID.(int))
+		}) {
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Room reopened", "status": transition.To})
+	}
+}
@@ -0,0 +1,289 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/social-worker-platform/backend/models"
+	"github.com/social-worker-platform/backend/workflow"
+)
+
+// scheduleEventLabels names event kinds in system messages and calendar feeds
+var scheduleEventLabels = map[string]string{
+	models.ScheduleInterview: "入居前面談",
+	models.ScheduleAdmission: "入居",
+	models.ScheduleTransport: "移送",
+}
+
+// japanTime is the zone times are shown in within system messages
+var japanTime = time.FixedZone("JST", 9*60*60)
+
+func formatScheduleTime(t time.Time) string {
+	return t.In(japanTime).Format("2006年1月2日 15:04")
+}
+
+type scheduleSlotRequest struct {
+	StartsAt time.Time  `json:"starts_at" binding:"required"`
+	EndsAt   *time.Time `json:"ends_at"`
+}
+
+type scheduleProposalRequest struct {
+	EventType string                `json:"event_type" binding:"required"`
+	Slots     []scheduleSlotRequest `json:"slots" binding:"required"`
+	Location  string                `json:"location"`
+	Note      string                `json:"note"`
+}
+
+// GetRoomSchedule handles GET /api/rooms/:id/schedule
+func GetRoomSchedule(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		room := roomForParty(c, db)
+		if room == nil {
+			return
+		}
+
+		proposals, err := models.GetScheduleProposalsByRoomID(db, room.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve schedule proposals"})
+			return
+		}
+
+		events, err := models.GetRoomEventsByRoomID(db, room.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve room events"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"proposals": proposals, "events": events})
+	}
+}
+
+// ProposeSchedule handles POST /api/rooms/:id/schedule/proposals. One party offers
+// candidate times for an interview, the admission or the transport.
+func ProposeSchedule(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !canTrigger(c, workflow.MessageRooms, workflow.OpSchedule) {
+			return
+		}
+
+		room := roomForParty(c, db)
+		if room == nil {
+			return
+		}
+
+		if err := workflow.MessageRooms.Allow(room.Status, workflow.OpSchedule, roleOf(c)); err != nil {
+			writeWorkflowError(c, err)
+			return
+		}
+
+		var req scheduleProposalRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+			return
+		}
+
+		if !models.ValidScheduleEventType(req.EventType) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event type", "details": "event_type must be interview, admission or transport"})
+			return
+		}
+
+		if len(req.Slots) == 0 || len(req.Slots) > models.MaxProposalSlots {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Propose between 1 and %d slots", models.MaxProposalSlots)})
+			return
+		}
+
+		now := time.Now()
+		slots := make([]*models.ScheduleSlot, len(req.Slots))
+		for i, s := range req.Slots {
+			if !s.StartsAt.After(now) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Slots must start in the future", "details": fmt.Sprintf("slots[%d]", i)})
+				return
+			}
+			if s.EndsAt != nil && !s.EndsAt.After(s.StartsAt) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Slots must end after they start", "details": fmt.Sprintf("slots[%d]", i)})
+				return
+			}
+			slots[i] = &models.ScheduleSlot{StartsAt: s.StartsAt, EndsAt: s.EndsAt}
+		}
+
+		userID, _ := c.Get("userID")
+		proposedBy := userID.(int)
+		proposal := &models.ScheduleProposal{
+			RoomID:       room.ID,
+			EventType:    req.EventType,
+			ProposedBy:   &proposedBy,
+			ProposedRole: roleOf(c),
+			Location:     optionalString(req.Location),
+			Note:         optionalString(req.Note),
+			Slots:        slots,
+		}
+		if err := models.CreateScheduleProposal(db, proposal); err != nil {
+			if errors.Is(err, models.ErrProposalPending) {
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create schedule proposal"})
+			return
+		}
+
+		postSystemMessage(db, room.ID, proposedBy, fmt.Sprintf("%sが%sの候補日時を%d件提案しました。",
+			partyLabel(proposal.ProposedRole), scheduleEventLabels[proposal.EventType], len(slots)))
+
+		c.JSON(http.StatusCreated, proposal)
+	}
+}
+
+// scheduleProposalOfRoom loads the proposal in the :proposalId path parameter and checks
+// that it belongs to room and still awaits an answer. It writes the error response itself
+// and returns nil otherwise.
+func scheduleProposalOfRoom(c *gin.Context, db *sql.DB, room *models.MessageRoom) *models.ScheduleProposal {
+	proposalID, err := strconv.Atoi(c.Param("proposalId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid proposal ID"})
+		return nil
+	}
+
+	proposal, err := models.GetScheduleProposalByID(db, proposalID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve schedule proposal"})
+		return nil
+	}
+
+	if proposal == nil || proposal.RoomID != room.ID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Schedule proposal not found"})
+		return nil
+	}
+
+	if proposal.Status != models.ProposalPending {
+		c.JSON(http.StatusConflict, gin.H{"error": "Schedule proposal is no longer pending"})
+		return nil
+	}
+
+	return proposal
+}
+
+// AcceptScheduleProposal handles POST /api/rooms/:id/schedule/proposals/:proposalId/accept.
+// The other party picks one of the proposed slots, which becomes the room's event.
+func AcceptScheduleProposal(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !canTrigger(c, workflow.MessageRooms, workflow.OpSchedule) {
+			return
+		}
+
+		room := roomForParty(c, db)
+		if room == nil {
+			return
+		}
+
+		if err := workflow.MessageRooms.Allow(room.Status, workflow.OpSchedule, roleOf(c)); err != nil {
+			writeWorkflowError(c, err)
+			return
+		}
+
+		proposal := scheduleProposalOfRoom(c, db, room)
+		if proposal == nil {
+			return
+		}
+
+		if proposal.ProposedRole == roleOf(c) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only the other party can accept this proposal"})
+			return
+		}
+
+		var req struct {
+			SlotID int `json:"slot_id" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+			return
+		}
+
+		userID, _ := c.Get("userID")
+		event, err := models.AcceptScheduleProposal(db, proposal.ID, req.SlotID, userID.(int))
+		if err != nil {
+			if errors.Is(err, models.ErrSlotNotFound) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			if errors.Is(err, sql.ErrNoRows) {
+				c.JSON(http.StatusConflict, gin.H{"error": "Schedule proposal is no longer pending"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to accept schedule proposal"})
+			return
+		}
+
+		postSystemMessage(db, room.ID, userID.(int), fmt.Sprintf("%sの日時が%sに確定しました。",
+			scheduleEventLabels[event.EventType], formatScheduleTime(event.StartsAt)))
+
+		c.JSON(http.StatusOK, event)
+	}
+}
+
+// DeclineScheduleProposal handles POST /api/rooms/:id/schedule/proposals/:proposalId/decline.
+// The other party turns down all proposed slots, e.g. to propose other times.
+func DeclineScheduleProposal(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		closeScheduleProposal(c, db, models.ProposalDeclined)
+	}
+}
+
+// CancelScheduleProposal handles DELETE /api/rooms/:id/schedule/proposals/:proposalId.
+// The proposing party takes the proposal back.
+func CancelScheduleProposal(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		closeScheduleProposal(c, db, models.ProposalCancelled)
+	}
+}
+
+// closeScheduleProposal answers a pending proposal with status: declined by the other
+// party or cancelled by the proposer
+func closeScheduleProposal(c *gin.Context, db *sql.DB, status string) {
+	if !canTrigger(c, workflow.MessageRooms, workflow.OpSchedule) {
+		return
+	}
+
+	room := roomForParty(c, db)
+	if room == nil {
+		return
+	}
+
+	proposal := scheduleProposalOfRoom(c, db, room)
+	if proposal == nil {
+		return
+	}
+
+	ownProposal := proposal.ProposedRole == roleOf(c)
+	if status == models.ProposalCancelled && !ownProposal {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the proposing party can cancel this proposal"})
+		return
+	}
+	if status == models.ProposalDeclined && ownProposal {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the other party can decline this proposal"})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	if err := models.CloseScheduleProposal(db, proposal.ID, status, userID.(int)); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Schedule proposal is no longer pending"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to answer schedule proposal"})
+		return
+	}
+
+	label := scheduleEventLabels[proposal.EventType]
+	if status == models.ProposalDeclined {
+		postSystemMessage(db, room.ID, userID.(int), partyLabel(roleOf(c))+"が"+label+"の候補日時を見送りました。")
+	} else {
+		postSystemMessage(db, room.ID, userID.(int), partyLabel(roleOf(c))+"が"+label+"の日程の提案を取り消しました。")
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Schedule proposal " + status})
+}
@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/social-worker-platform/backend/models"
+	"github.com/social-worker-platform/backend/services"
+)
+
+type SavedSearchHandler struct {
+	searchRepo   *models.SavedSearchRepository
+	facilityRepo *models.FacilityRepository
+	hospitalRepo *models.HospitalRepository
+	alerter      *services.SavedSearchAlerter
+}
+
+func NewSavedSearchHandler(searchRepo *models.SavedSearchRepository, facilityRepo *models.FacilityRepository, hospitalRepo *models.HospitalRepository, alerter *services.SavedSearchAlerter) *SavedSearchHandler {
+	return &SavedSearchHandler{
+		searchRepo:   searchRepo,
+		facilityRepo: facilityRepo,
+		hospitalRepo: hospitalRepo,
+		alerter:      alerter,
+	}
+}
+
+type SavedSearchRequest struct {
+	Name        string                `json:"name" binding:"required,max=100"`
+	NotifyEmail bool                  `json:"notify_email"`
+	Criteria    SearchFacilityRequest `json:"criteria"`
+}
+
+// SavedSearchMatchResponse is a facility that newly matched a saved search
+type SavedSearchMatchResponse struct {
+	*models.Facility
+	MatchedAt time.Time `json:"matched_at"`
+}
+
+// currentHospital returns the hospital of the calling user.
+// It writes the error response itself and returns nil when there is none.
+func (h *SavedSearchHandler) currentHospital(c *gin.Context) *models.Hospital {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return nil
+	}
+
+	hospital, err := h.hospitalRepo.GetByUserID(userID.(int))
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Hospital not found"})
+		return nil
+	}
+
+	return hospital
+}
+
+// ownSavedSearch loads the saved search in the path and checks that it belongs to the caller's hospital.
+// It writes the error response itself and returns nil when the caller may not proceed.
+func (h *SavedSearchHandler) ownSavedSearch(c *gin.Context) *models.SavedSearch {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid saved search ID"})
+		return nil
+	}
+
+	hospital := h.currentHospital(c)
+	if hospital == nil {
+		return nil
+	}
+
+	search, err := h.searchRepo.GetByID(id)
+	if err != nil || search.HospitalID != hospital.ID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Saved search not found"})
+		return nil
+	}
+
+	return search
+}
+
+// refreshBaseline records the current matches of a new or edited search so that only
+// later changes are reported as new
+func (h *SavedSearchHandler) refreshBaseline(search *models.SavedSearch) {
+	if _, err := h.alerter.Refresh(search); err != nil {
+		log.Printf("Failed to record matches of saved search %d: %v", search.ID, err)
+	}
+}
+
+// Create handles POST /api/saved-searches
+func (h *SavedSearchHandler) Create(c *gin.Context) {
+	hospital := h.currentHospital(c)
+	if hospital == nil {
+		return
+	}
+
+	var req SavedSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format", "details": err.Error()})
+		return
+	}
+
+	criteria, err := req.Criteria.toParams()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	search := &models.SavedSearch{
+		HospitalID:  hospital.ID,
+		UserID:      hospital.UserID,
+		Name:        req.Name,
+		Criteria:    criteria,
+		NotifyEmail: req.NotifyEmail,
+	}
+	if err := h.searchRepo.Create(search); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save search"})
+		return
+	}
+
+	h.refreshBaseline(search)
+
+	c.JSON(http.StatusCreated, search)
+}
+
+// List handles GET /api/saved-searches
+func (h *SavedSearchHandler) List(c *gin.Context) {
+	hospital := h.currentHospital(c)
+	if hospital == nil {
+		return
+	}
+
+	searches, err := h.searchRepo.GetByHospitalID(hospital.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve saved searches"})
+		return
+	}
+
+	c.JSON(http.StatusOK, searches)
+}
+
+// GetByID handles GET /api/saved-searches/:id
+func (h *SavedSearchHandler) GetByID(c *gin.Context) {
+	search := h.ownSavedSearch(c)
+	if search == nil {
+		return
+	}
+
+	c.JSON(http.StatusOK, search)
+}
+
+// Update handles PUT /api/saved-searches/:id
+func (h *SavedSearchHandler) Update(c *gin.Context) {
+	search := h.ownSavedSearch(c)
+	if search == nil {
+		return
+	}
+
+	var req SavedSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format", "details": err.Error()})
+		return
+	}
+
+	criteria, err := req.Criteria.toParams()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	search.Name = req.Name
+	search.NotifyEmail = req.NotifyEmail
+	search.Criteria = criteria
+	if err := h.searchRepo.Update(search); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update saved search"})
+		return
+	}
+
+	if search.LastCheckedAt == nil {
+		h.refreshBaseline(search)
+	}
+
+	c.JSON(http.StatusOK, search)
+}
+
+// Delete handles DELETE /api/saved-searches/:id
+func (h *SavedSearchHandler) Delete(c *gin.Context) {
+	search := h.ownSavedSearch(c)
+	if search == nil {
+		return
+	}
+
+	if err := h.searchRepo.Delete(search.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete saved search"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Saved search deleted"})
+}
+
+// GetMatches handles GET /api/saved-searches/:id/matches
+// It returns the facilities that newly matched since the search was last viewed and
+// marks the search as viewed unless mark_viewed=false is given.
+func (h *SavedSearchHandler) GetMatches(c *gin.Context) {
+	search := h.ownSavedSearch(c)
+	if search == nil {
+		return
+	}
+
+	matches, err := h.searchRepo.GetMatchesSince(search.ID, search.LastViewedAt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve matches"})
+		return
+	}
+
+	facilities := make([]SavedSearchMatchResponse, 0, len(matches))
+	for _, match := range matches {
+		facility, err := h.facilityRepo.GetByID(match.FacilityID)
+		if err != nil {
+			continue
+		}
+		facilities = append(facilities, SavedSearchMatchResponse{Facility: facility, MatchedAt: match.MatchedAt})
+	}
+
+	if c.Query("mark_viewed") != "false" {
+		if err := h.searchRepo.MarkViewed(search.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update saved search"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"since":      search.LastViewedAt,
+		"facilities": facilities,
+	})
+}
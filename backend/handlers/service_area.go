@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/social-worker-platform/backend/models"
+)
+
+// GetServiceAreas returns the municipalities a facility accepts residents from
+func (h *FacilityHandler) GetServiceAreas(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid facility ID"})
+		return
+	}
+
+	areas, err := h.facilityRepo.GetServiceAreas(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get service areas"})
+		return
+	}
+
+	c.JSON(http.StatusOK, areas)
+}
+
+type UpdateServiceAreasRequest struct {
+	ServiceAreas []models.FacilityServiceAreaInput `json:"service_areas" binding:"dive"`
+}
+
+// UpdateServiceAreas replaces the service areas of a facility. An empty list removes
+// the restriction.
+func (h *FacilityHandler) UpdateServiceAreas(c *gin.Context) {
+	id, ok := h.authorizeFacilityEdit(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateServiceAreasRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format", "details": err.Error()})
+		return
+	}
+
+	if err := h.facilityRepo.ReplaceServiceAreas(id, req.ServiceAreas); err != nil {
+		if errors.Is(err, models.ErrInvalidMunicipalityCode) || errors.Is(err, models.ErrDuplicateServiceArea) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update service areas"})
+		return
+	}
+
+	areas, err := h.facilityRepo.GetServiceAreas(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get updated service areas"})
+		return
+	}
+
+	c.JSON(http.StatusOK, areas)
+}
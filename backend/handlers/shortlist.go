@@ -0,0 +1,505 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/social-worker-platform/backend/models"
+	"github.com/social-worker-platform/backend/workflow"
+)
+
+type shortlistRequest struct {
+	Name        string  `json:"name" binding:"required,max=100"`
+	Description *string `json:"description"`
+}
+
+type shortlistFacilityRequest struct {
+	Note *string `json:"note"`
+}
+
+// hospitalOfUser returns the hospital of the calling hospital user.
+// It writes the error response itself and returns nil when the caller is not a hospital user.
+func hospitalOfUser(c *gin.Context, db *sql.DB) *models.Hospital {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return nil
+	}
+
+	role, exists := c.Get("userRole")
+	if !exists || role != "hospital" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only hospital users can manage shortlists"})
+		return nil
+	}
+
+	hospital, err := models.GetHospitalByUserID(db, userID.(int))
+	if err != nil || hospital == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Hospital not found for this user"})
+		return nil
+	}
+
+	return hospital
+}
+
+// ownShortlist loads the shortlist in the path and checks that it belongs to the hospital.
+// It writes the error response itself and returns nil when the caller may not proceed.
+func ownShortlist(c *gin.Context, db *sql.DB, hospital *models.Hospital) *models.Shortlist {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shortlist ID"})
+		return nil
+	}
+
+	shortlist, err := models.GetShortlistByID(db, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve shortlist"})
+		return nil
+	}
+
+	if shortlist == nil || shortlist.HospitalID != hospital.ID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Shortlist not found"})
+		return nil
+	}
+
+	return shortlist
+}
+
+// facilityIDParam parses :facilityId and checks that the facility exists
+func facilityIDParam(c *gin.Context, db *sql.DB) (int, bool) {
+	facilityID, err := strconv.Atoi(c.Param("facilityId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid facility ID"})
+		return 0, false
+	}
+
+	facility, err := models.GetFacilityByID(db, facilityID)
+	if err != nil || facility == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Facility not found"})
+		return 0, false
+	}
+
+	return facilityID, true
+}
+
+// bindNote reads the optional {"note": ...} body of add/update calls
+func bindNote(c *gin.Context) (*string, bool) {
+	var req shortlistFacilityRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return nil, false
+		}
+	}
+	return req.Note, true
+}
+
+// GetShortlists handles GET /api/shortlists
+func GetShortlists(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		hospital := hospitalOfUser(c, db)
+		if hospital == nil {
+			return
+		}
+
+		shortlists, err := models.GetShortlistsByHospitalID(db, hospital.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve shortlists"})
+			return
+		}
+
+		c.JSON(http.StatusOK, shortlists)
+	}
+}
+
+// CreateShortlist handles POST /api/shortlists
+func CreateShortlist(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		hospital := hospitalOfUser(c, db)
+		if hospital == nil {
+			return
+		}
+
+		var req shortlistRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		shortlist := &models.Shortlist{
+			HospitalID:  hospital.ID,
+			Name:        req.Name,
+			Description: req.Description,
+		}
+		if err := models.CreateShortlist(db, shortlist); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create shortlist"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, shortlist)
+	}
+}
+
+// GetShortlist handles GET /api/shortlists/:id (includes the listed facilities)
+func GetShortlist(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		hospital := hospitalOfUser(c, db)
+		if hospital == nil {
+			return
+		}
+
+		shortlist := ownShortlist(c, db, hospital)
+		if shortlist == nil {
+			return
+		}
+
+		facilities, err := models.GetShortlistFacilities(db, shortlist.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve shortlist"})
+			return
+		}
+		shortlist.Facilities = facilities
+
+		c.JSON(http.StatusOK, shortlist)
+	}
+}
+
+// UpdateShortlist handles PUT /api/shortlists/:id
+func UpdateShortlist(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		hospital := hospitalOfUser(c, db)
+		if hospital == nil {
+			return
+		}
+
+		shortlist := ownShortlist(c, db, hospital)
+		if shortlist == nil {
+			return
+		}
+
+		var req shortlistRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := models.UpdateShortlist(db, shortlist.ID, req.Name, req.Description); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update shortlist"})
+			return
+		}
+
+		updated, err := models.GetShortlistByID(db, shortlist.ID)
+		if err != nil || updated == nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve shortlist"})
+			return
+		}
+
+		c.JSON(http.StatusOK, updated)
+	}
+}
+
+// DeleteShortlist handles DELETE /api/shortlists/:id
+func DeleteShortlist(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		hospital := hospitalOfUser(c, db)
+		if hospital == nil {
+			return
+		}
+
+		shortlist := ownShortlist(c, db, hospital)
+		if shortlist == nil {
+			return
+		}
+
+		if shortlist.IsFavorites {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "The favorites list cannot be deleted"})
+			return
+		}
+
+		if err := models.DeleteShortlist(db, shortlist.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete shortlist"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Shortlist deleted"})
+	}
+}
+
+// SaveShortlistFacility handles PUT /api/shortlists/:id/facilities/:facilityId (add or update the note)
+func SaveShortlistFacility(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		hospital := hospitalOfUser(c, db)
+		if hospital == nil {
+			return
+		}
+
+		shortlist := ownShortlist(c, db, hospital)
+		if shortlist == nil {
+			return
+		}
+
+		facilityID, ok := facilityIDParam(c, db)
+		if !ok {
+			return
+		}
+
+		note, ok := bindNote(c)
+		if !ok {
+			return
+		}
+
+		if err := models.SaveShortlistFacility(db, shortlist.ID, facilityID, note); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save facility to shortlist"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Facility saved to shortlist"})
+	}
+}
+
+// RemoveShortlistFacility handles DELETE /api/shortlists/:id/facilities/:facilityId
+func RemoveShortlistFacility(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		hospital := hospitalOfUser(c, db)
+		if hospital == nil {
+			return
+		}
+
+		shortlist := ownShortlist(c, db, hospital)
+		if shortlist == nil {
+			return
+		}
+
+		facilityID, err := strconv.Atoi(c.Param("facilityId"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid facility ID"})
+			return
+		}
+
+		if err := models.RemoveShortlistFacility(db, shortlist.ID, facilityID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Facility is not on this shortlist"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove facility from shortlist"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Facility removed from shortlist"})
+	}
+}
+
+// CreateShortlistRequests handles POST /api/shortlists/:id/requests
+// It sends the same placement request to every facility on the shortlist.
+func CreateShortlistRequests(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := c.Get("userID")
+
+		hospital := hospitalOfUser(c, db)
+		if hospital == nil {
+			return
+		}
+
+		shortlist := ownShortlist(c, db, hospital)
+		if shortlist == nil {
+			return
+		}
+
+		var req struct {
+			PatientAge          int     `json:"patient_age" binding:"required"`
+			PatientGender       string  `json:"patient_gender" binding:"required"`
+			MedicalCondition    string  `json:"medical_condition" binding:"required"`
+			PatientCareLevel    *string `json:"patient_care_level"`
+			PatientHasDementia  *bool   `json:"patient_has_dementia"`
+			Urgency             *string `json:"urgency"`
+			TargetDischargeDate *string `json:"target_discharge_date"`
+		}
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		careLevel, err := patientCareLevel(req.PatientCareLevel)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		urgency, err := requestUrgency(req.Urgency)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		dischargeDate, err := targetDischargeDate(req.TargetDischargeDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		facilities, err := models.GetShortlistFacilities(db, shortlist.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve shortlist"})
+			return
+		}
+
+		if len(facilities) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Shortlist has no facilities"})
+			return
+		}
+
+		placementReqs := make([]*models.PlacementRequest, 0, len(facilities))
+		for _, f := range facilities {
+			placementReqs = append(placementReqs, &models.PlacementRequest{
+				HospitalID:       hospital.ID,
+				FacilityID:       f.FacilityID,
+				PatientAge:       req.PatientAge,
+				PatientGender:    req.PatientGender,
+				MedicalCondition: req.MedicalCondition,
+				Status:           workflow.PlacementRequests.Initial,
+				HospitalName:     hospital.Name,
+				FacilityName:     f.FacilityName,
+
+				PatientCareLevel:   careLevel,
+				PatientHasDementia: req.PatientHasDementia,
+
+				Urgency:             urgency,
+				TargetDischargeDate: dischargeDate,
+			})
+		}
+
+		// The requests are submitted right away
+		transition := submitTransition(c, placementReqs[0], &workflow.Subject{UserID: userID.(int)})
+		if transition == nil {
+			return
+		}
+		for _, pr := range placementReqs {
+			pr.Status = transition.To
+		}
+
+		err = inTransaction(db, func(tx *sql.Tx) error {
+			if err := models.CreatePlacementRequests(tx, placementReqs); err != nil {
+				return err
+			}
+			for _, pr := range placementReqs {
+				subject := &workflow.Subject{UserID: userID.(int), RequestID: pr.ID}
+				if err := workflow.RecordCreated(tx, workflow.PlacementRequests, pr.Status, roleOf(c), subject); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create placement requests"})
+			return
+		}
+
+		// Mark requests as read for the creator (so their own requests don't show as unread)
+		for _, pr := range placementReqs {
+			models.MarkRequestAsRead(db, pr.ID, userID.(int))
+
+			warnings, err := models.CheckPlacementEligibility(db, pr)
+			if err != nil {
+				log.Printf("Failed to check eligibility for request %d: %v", pr.ID, err)
+			}
+			pr.EligibilityWarnings = warnings
+		}
+
+		c.JSON(http.StatusCreated, placementReqs)
+	}
+}
+
+// GetFavorites handles GET /api/favorites
+func GetFavorites(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		hospital := hospitalOfUser(c, db)
+		if hospital == nil {
+			return
+		}
+
+		favorites, err := models.GetFavoritesShortlist(db, hospital.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve favorites"})
+			return
+		}
+
+		facilities, err := models.GetShortlistFacilities(db, favorites.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve favorites"})
+			return
+		}
+		favorites.Facilities = facilities
+
+		c.JSON(http.StatusOK, favorites)
+	}
+}
+
+// AddFavorite handles PUT /api/favorites/:facilityId
+func AddFavorite(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		hospital := hospitalOfUser(c, db)
+		if hospital == nil {
+			return
+		}
+
+		facilityID, ok := facilityIDParam(c, db)
+		if !ok {
+			return
+		}
+
+		note, ok := bindNote(c)
+		if !ok {
+			return
+		}
+
+		favorites, err := models.GetFavoritesShortlist(db, hospital.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve favorites"})
+			return
+		}
+
+		if err := models.SaveShortlistFacility(db, favorites.ID, facilityID, note); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add favorite"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Facility added to favorites"})
+	}
+}
+
+// RemoveFavorite handles DELETE /api/favorites/:facilityId
+func RemoveFavorite(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		hospital := hospitalOfUser(c, db)
+		if hospital == nil {
+			return
+		}
+
+		facilityID, err := strconv.Atoi(c.Param("facilityId"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid facility ID"})
+			return
+		}
+
+		favorites, err := models.GetFavoritesShortlist(db, hospital.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve favorites"})
+			return
+		}
+
+		if err := models.RemoveShortlistFacility(db, favorites.ID, facilityID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Facility is not a favorite"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove favorite"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Facility removed from favorites"})
+	}
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/social-worker-platform/backend/config"
+	"github.com/social-worker-platform/backend/middleware"
+	"github.com/social-worker-platform/backend/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShortlistFacilityAddAndRemove(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping database tests in short mode")
+	}
+
+	os.Setenv("JWT_SECRET", "test-secret-key")
+	defer os.Unsetenv("JWT_SECRET")
+
+	db := config.SetupTestDatabase(t)
+	defer config.TeardownTestDatabase(t, db)
+
+	userRepo := models.NewUserRepository(db)
+	hospitalUser, err := createTestUser(t, userRepo, "shortlist-hospital@test.com", "hospital")
+	require.NoError(t, err)
+	hospital, err := models.NewHospitalRepository(db).Create(hospitalUser.ID, "Test Hospital", "Address", "123-456")
+	require.NoError(t, err)
+	facilityUser, err := createTestUser(t, userRepo, "shortlist-facility@test.com", "facility")
+	require.NoError(t, err)
+	facility, err := models.NewFacilityRepository(db).Create(facilityUser.ID, "Test Facility", "Address", "123-456", 10, "None")
+	require.NoError(t, err)
+
+	shortlist := &models.Shortlist{HospitalID: hospital.ID, Name: "候補"}
+	require.NoError(t, models.CreateShortlist(db, shortlist))
+
+	token, _ := middleware.GenerateToken(hospitalUser.ID, hospitalUser.Email, hospitalUser.Role)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.PUT("/api/shortlists/:id/facilities/:facilityId", middleware.AuthMiddleware(), SaveShortlistFacility(db))
+	router.DELETE("/api/shortlists/:id/facilities/:facilityId", middleware.AuthMiddleware(), RemoveShortlistFacility(db))
+
+	send := func(method string) int {
+		req, _ := http.NewRequest(method, fmt.Sprintf("/api/shortlists/%d/facilities/%d", shortlist.ID, facility.ID), nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	t.Run("adding twice keeps one entry", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, send("PUT"))
+		assert.Equal(t, http.StatusOK, send("PUT"))
+
+		facilities, err := models.GetShortlistFacilities(db, shortlist.ID)
+		require.NoError(t, err)
+		assert.Len(t, facilities, 1)
+	})
+
+	t.Run("removing twice", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, send("DELETE"))
+		assert.Equal(t, http.StatusNotFound, send("DELETE"))
+	})
+}
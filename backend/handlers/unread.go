@@ -43,7 +43,11 @@ func GetUnreadCounts(db *sql.DB) gin.HandlerFunc {
 			entityID = facility.ID
 		} else {
 			// Admin or other roles have no unread counts
-			c.JSON(http.StatusOK, models.UnreadCounts{Messages: 0, Requests: 0})
+			counts := models.UnreadCounts{RequestsByUrgency: make(map[string]int, len(models.RequestUrgencies))}
+			for _, u := range models.RequestUrgencies {
+				counts.RequestsByUrgency[u] = 0
+			}
+			c.JSON(http.StatusOK, counts)
 			return
 		}
 
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/social-worker-platform/backend/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetUnreadCounts_Admin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/unread", func(c *gin.Context) {
+		c.Set("userID", 1)
+		c.Set("userRole", "admin")
+	}, GetUnreadCounts(nil))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/unread", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	// Every urgency is reported, as for hospitals and facilities
+	var counts models.UnreadCounts
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &counts))
+	assert.Len(t, counts.RequestsByUrgency, len(models.RequestUrgencies))
+	for _, u := range models.RequestUrgencies {
+		assert.Equal(t, 0, counts.RequestsByUrgency[u], u)
+	}
+}
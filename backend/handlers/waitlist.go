@@ -0,0 +1,428 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/social-worker-platform/backend/models"
+	"github.com/social-worker-platform/backend/workflow"
+)
+
+type addToWaitlistRequest struct {
+	RoomTypeID   *int    `json:"room_type_id"`
+	Priority     string  `json:"priority"`
+	PriorityNote *string `json:"priority_note"`
+}
+
+// updateWaitlistEntryRequest carries the fields either party may change. Hospitals set
+// the priority; facilities set the room type and when they expect a bed to open up.
+type updateWaitlistEntryRequest struct {
+	RoomTypeID            *int    `json:"room_type_id"`
+	ExpectedAvailableDate *string `json:"expected_available_date"`
+	Priority              *string `json:"priority"`
+	PriorityNote          *string `json:"priority_note"`
+}
+
+type reorderWaitlistRequest struct {
+	EntryIDs []int `json:"entry_ids" binding:"required"`
+}
+
+// checkFacilityRoomType checks that a room type given for a facility, e.g. the room type
+// of a waitlist entry or of the conditions a facility proposes, belongs to the facility.
+// It writes the error response itself and returns false when it does not.
+func checkFacilityRoomType(c *gin.Context, db *sql.DB, facilityID int, roomTypeID *int) bool {
+	if roomTypeID == nil {
+		return true
+	}
+	ok, err := models.RoomTypeBelongsToFacility(db, facilityID, *roomTypeID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check room type"})
+		return false
+	}
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Room type not found"})
+		return false
+	}
+	return true
+}
+
+// waitlistEntryForUser loads the entry in the path and checks that the caller is its
+// facility or the hospital that made the request. It writes the error response itself
+// and returns nil when the caller may not proceed.
+func waitlistEntryForUser(c *gin.Context, db *sql.DB) *models.WaitlistEntry {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return nil
+	}
+	role, _ := c.Get("userRole")
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid waitlist entry ID"})
+		return nil
+	}
+
+	entry, err := models.GetWaitlistEntryByID(db, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve waitlist entry"})
+		return nil
+	}
+	if entry == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Waitlist entry not found"})
+		return nil
+	}
+
+	allowed := false
+	switch role {
+	case "facility":
+		facility, err := models.GetFacilityByUserID(db, userID.(int))
+		allowed = err == nil && facility != nil && facility.ID == entry.FacilityID
+	case "hospital":
+		hospital, err := models.GetHospitalByUserID(db, userID.(int))
+		allowed = err == nil && hospital != nil && hospital.ID == entry.HospitalID
+	}
+	if !allowed {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return nil
+	}
+
+	return entry
+}
+
+// waitlistTransition checks that the caller may move the entry's placement request by
+// action. It writes the error response itself and returns a nil transition when not.
+func waitlistTransition(c *gin.Context, db *sql.DB, entry *models.WaitlistEntry, action string) (*workflow.Transition, *workflow.Subject) {
+	req, err := models.GetPlacementRequestByID(db, entry.RequestID)
+	if err != nil || req == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve request"})
+		return nil, nil
+	}
+
+	userID, _ := c.Get("userID")
+	subject := &workflow.Subject{UserID: userID.(int), RequestID: req.ID}
+	transition, err := workflow.PlacementRequests.Fire(req.Status, action, roleOf(c), subject)
+	if err != nil {
+		writeWorkflowError(c, err)
+		return nil, nil
+	}
+	return transition, subject
+}
+
+func writeWaitlistError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, models.ErrWaitlistEntryNotActive):
+		c.JSON(http.StatusConflict, gin.H{"error": "Waitlist entry is no longer active"})
+	case errors.Is(err, models.ErrInvalidWaitlistOrder):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
+
+// AddToWaitlist handles POST /api/requests/:id/waitlist. The hospital puts a pending or
+// rejected request on the facility's waitlist instead of giving up on the facility.
+func AddToWaitlist(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userID")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		if !canTrigger(c, workflow.PlacementRequests, workflow.ActionWaitlist) {
+			return
+		}
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request ID"})
+			return
+		}
+
+		var body addToWaitlistRequest
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&body); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format", "details": err.Error()})
+				return
+			}
+		}
+		if body.Priority == "" {
+			body.Priority = "normal"
+		}
+		if !models.IsWaitlistPriority(body.Priority) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "priority must be one of urgent, high, normal"})
+			return
+		}
+
+		req, err := models.GetPlacementRequestByID(db, id)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve request"})
+			return
+		}
+		if req == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Request not found"})
+			return
+		}
+
+		hospital, err := models.GetHospitalByUserID(db, userID.(int))
+		if err != nil || hospital == nil || hospital.ID != req.HospitalID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+			return
+		}
+
+		subject := &workflow.Subject{UserID: userID.(int), RequestID: id}
+		transition, err := workflow.PlacementRequests.Fire(req.Status, workflow.ActionWaitlist, roleOf(c), subject)
+		if err != nil {
+			writeWorkflowError(c, err)
+			return
+		}
+
+		if !checkFacilityRoomType(c, db, req.FacilityID, body.RoomTypeID) {
+			return
+		}
+
+		entry := &models.WaitlistEntry{
+			RequestID:    id,
+			FacilityID:   req.FacilityID,
+			RoomTypeID:   body.RoomTypeID,
+			Priority:     body.Priority,
+			PriorityNote: body.PriorityNote,
+		}
+		if !applyTransition(c, db, transition, subject, "Failed to add request to waitlist", func(tx *sql.Tx) error {
+			return models.AddToWaitlist(tx, entry, req.Status)
+		}) {
+			return
+		}
+
+		created, err := models.GetWaitlistEntryByID(db, entry.ID)
+		if err != nil || created == nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve waitlist entry"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, created)
+	}
+}
+
+// GetWaitlist handles GET /api/waitlist. Facilities get their waitlist in position order
+// (include_closed=true adds converted and removed entries); hospitals get their active entries.
+func GetWaitlist(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userID")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		role, _ := c.Get("userRole")
+
+		var entries []*models.WaitlistEntry
+		var err error
+		switch role {
+		case "facility":
+			facility, ferr := models.GetFacilityByUserID(db, userID.(int))
+			if ferr != nil || facility == nil {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Facility not found for this user"})
+				return
+			}
+			entries, err = models.GetWaitlistByFacilityID(db, facility.ID, c.Query("include_closed") == "true")
+		case "hospital":
+			hospital, herr := models.GetHospitalByUserID(db, userID.(int))
+			if herr != nil || hospital == nil {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Hospital not found for this user"})
+				return
+			}
+			entries, err = models.GetWaitlistByHospitalID(db, hospital.ID)
+		default:
+			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+			return
+		}
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve waitlist"})
+			return
+		}
+
+		c.JSON(http.StatusOK, entries)
+	}
+}
+
+// ReorderWaitlist handles PUT /api/waitlist/order. The body lists every active entry
+// of the facility's waitlist, first position first.
+func ReorderWaitlist(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userID")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		role, exists := c.Get("userRole")
+		if !exists || role != "facility" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only facility users can reorder the waitlist"})
+			return
+		}
+
+		var req reorderWaitlistRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format", "details": err.Error()})
+			return
+		}
+
+		facility, err := models.GetFacilityByUserID(db, userID.(int))
+		if err != nil || facility == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Facility not found for this user"})
+			return
+		}
+
+		if err := models.ReorderWaitlist(db, facility.ID, req.EntryIDs); err != nil {
+			writeWaitlistError(c, err, "Failed to reorder waitlist")
+			return
+		}
+
+		entries, err := models.GetWaitlistByFacilityID(db, facility.ID, false)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve waitlist"})
+			return
+		}
+
+		c.JSON(http.StatusOK, entries)
+	}
+}
+
+// UpdateWaitlistEntry handles PUT /api/waitlist/:id
+func UpdateWaitlistEntry(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		entry := waitlistEntryForUser(c, db)
+		if entry == nil {
+			return
+		}
+		if !entry.IsActive() {
+			c.JSON(http.StatusConflict, gin.H{"error": "Waitlist entry is no longer active"})
+			return
+		}
+
+		var req updateWaitlistEntryRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format", "details": err.Error()})
+			return
+		}
+
+		role, _ := c.Get("userRole")
+		if role == "facility" {
+			if req.Priority != nil || req.PriorityNote != nil {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Only the hospital can change the priority"})
+				return
+			}
+			if req.RoomTypeID != nil {
+				if !checkFacilityRoomType(c, db, entry.FacilityID, req.RoomTypeID) {
+					return
+				}
+				entry.RoomTypeID = req.RoomTypeID
+			}
+			if req.ExpectedAvailableDate != nil {
+				if *req.ExpectedAvailableDate == "" {
+					entry.ExpectedAvailableDate = nil
+				} else {
+					date, err := parseDate(*req.ExpectedAvailableDate)
+					if err != nil {
+						c.JSON(http.StatusBadRequest, gin.H{"error": "expected_available_date must be YYYY-MM-DD"})
+						return
+					}
+					entry.ExpectedAvailableDate = &date
+				}
+			}
+		} else {
+			if req.RoomTypeID != nil || req.ExpectedAvailableDate != nil {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Only the facility can change the room type and expected date"})
+				return
+			}
+			if req.Priority != nil {
+				if !models.IsWaitlistPriority(*req.Priority) {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "priority must be one of urgent, high, normal"})
+					return
+				}
+				entry.Priority = *req.Priority
+			}
+			if req.PriorityNote != nil {
+				entry.PriorityNote = optionalString(*req.PriorityNote)
+			}
+		}
+
+		if err := models.UpdateWaitlistEntry(db, entry); err != nil {
+			writeWaitlistError(c, err, "Failed to update waitlist entry")
+			return
+		}
+
+		updated, err := models.GetWaitlistEntryByID(db, entry.ID)
+		if err != nil || updated == nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve waitlist entry"})
+			return
+		}
+
+		c.JSON(http.StatusOK, updated)
+	}
+}
+
+// ConvertWaitlistEntry handles POST /api/waitlist/:id/convert. The facility accepts the
+// waitlisted request and a negotiation room is opened for it.
+func ConvertWaitlistEntry(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !canTrigger(c, workflow.PlacementRequests, workflow.ActionConvert) {
+			return
+		}
+
+		entry := waitlistEntryForUser(c, db)
+		if entry == nil {
+			return
+		}
+
+		transition, subject := waitlistTransition(c, db, entry, workflow.ActionConvert)
+		if transition == nil {
+			return
+		}
+
+		// The room is opened by the transition, which sets subject.RoomID
+		if !applyTransition(c, db, transition, subject, "Failed to convert waitlist entry", func(tx *sql.Tx) error {
+			return models.ConvertWaitlistEntry(tx, entry.ID, subject.From())
+		}) {
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Waitlist entry converted",
+			"room_id": subject.RoomID,
+		})
+	}
+}
+
+// RemoveWaitlistEntry handles DELETE /api/waitlist/:id. Either party may take the entry
+// off the waitlist; the placement request is closed as rejected.
+func RemoveWaitlistEntry(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		entry := waitlistEntryForUser(c, db)
+		if entry == nil {
+			return
+		}
+
+		transition, subject := waitlistTransition(c, db, entry, workflow.ActionRemove)
+		if transition == nil {
+			return
+		}
+		if !bindStatusReason(c, subject) {
+			return
+		}
+
+		if !applyTransition(c, db, transition, subject, "Failed to remove waitlist entry", func(tx *sql.Tx) error {
+			return models.RemoveFromWaitlist(tx, entry.ID, subject.From())
+		}) {
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Removed from waitlist"})
+	}
+}
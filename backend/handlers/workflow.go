@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/social-worker-platform/backend/models"
+	"github.com/social-worker-platform/backend/workflow"
+)
+
+// GetWorkflow handles GET /api/workflow. It returns the states, transitions and
+// operations of placement requests and message rooms so clients can decide which
+// actions to offer without duplicating the rules.
+func GetWorkflow(c *gin.Context) {
+	machines := make(map[string]*workflow.Machine, len(workflow.Machines))
+	for _, m := range workflow.Machines {
+		machines[m.Name] = m
+	}
+	c.JSON(http.StatusOK, machines)
+}
+
+// writeWorkflowError responds to an action the workflow refused
+func writeWorkflowError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, workflow.ErrRoleNotAllowed):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Your role cannot perform this action", "details": err.Error()})
+	case errors.Is(err, workflow.ErrInvalidState):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "This action is not allowed in the current status", "details": err.Error()})
+	case errors.Is(err, workflow.ErrGuardNotSatisfied):
+		c.JSON(http.StatusConflict, gin.H{"error": "Requirements for this action are not met", "details": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Workflow error", "details": err.Error()})
+	}
+}
+
+// roleOf returns the role of the authenticated user
+func roleOf(c *gin.Context) string {
+	role, _ := c.Get("userRole")
+	name, _ := role.(string)
+	return name
+}
+
+// canTrigger checks that the user's role may perform action at all, writing the error if not
+func canTrigger(c *gin.Context, m *workflow.Machine, action string) bool {
+	if err := m.CanTrigger(action, roleOf(c)); err != nil {
+		writeWorkflowError(c, err)
+		return false
+	}
+	return true
+}
+
+// bindStatusReason reads the optional {"reason": "..."} body of a status change into
+// subject so it is kept in the status history. It writes the error response itself.
+func bindStatusReason(c *gin.Context, subject *workflow.Subject) bool {
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format", "details": err.Error()})
+			return false
+		}
+	}
+	subject.Reason = strings.TrimSpace(req.Reason)
+	return true
+}
+
+// inTransaction runs fn in a transaction that is committed if fn succeeds and rolled
+// back otherwise
+func inTransaction(db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// applyTransition saves a status change with save and applies transition in the same
+// transaction, so the history and the required effects are never missing from a saved
+// status. The other effects run afterwards. It writes the error response itself, using
+// failure for unexpected errors, and returns false when nothing was saved.
+func applyTransition(c *gin.Context, db *sql.DB, transition *workflow.Transition, subject *workflow.Subject, failure string, save func(tx *sql.Tx) error) bool {
+	err := inTransaction(db, func(tx *sql.Tx) error {
+		if err := save(tx); err != nil {
+			return err
+		}
+		return transition.Apply(tx, subject)
+	})
+	if err != nil {
+		writeTransitionError(c, err, failure)
+		return false
+	}
+
+	if err := transition.RunEffects(db, subject); err != nil {
+		log.Printf("Failed to run effects of %s on request %d: %v", transition.Action, subject.RequestID, err)
+	}
+	return true
+}
+
+// writeTransitionError responds to a status change that could not be saved, including
+// one the workflow refused once the record was read in the transaction. A record
+// another user moved on since it was read gets 409, so of two concurrent actions only
+// the first one succeeds.
+func writeTransitionError(c *gin.Context, err error, failure string) {
+	var refused *workflow.Error
+	switch {
+	case errors.As(err, &refused):
+		writeWorkflowError(c, err)
+	case errors.Is(err, models.ErrStatusChanged):
+		c.JSON(http.StatusConflict, gin.H{"error": "Status was changed by someone else, reload and try again"})
+	case errors.Is(err, models.ErrWaitlistEntryNotActive):
+		c.JSON(http.StatusConflict, gin.H{"error": "Waitlist entry is no longer active"})
+	case errors.Is(err, models.ErrReopenNotPending):
+		c.JSON(http.StatusConflict, gin.H{"error": "Reopen request is no longer pending"})
+	case errors.Is(err, models.ErrNoBedAvailable):
+		c.JSON(http.StatusConflict, gin.H{"error": "No bed available for the held room type"})
+	case errors.Is(err, models.ErrConditionsAlreadyProposed):
+		c.JSON(http.StatusConflict, gin.H{"error": models.ErrConditionsAlreadyProposed.Error()})
+	case errors.Is(err, models.ErrConditionsAnswered):
+		c.JSON(http.StatusConflict, gin.H{"error": "Conditions were already answered"})
+	default:
+		log.Printf("%s: %v", failure, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": failure})
+	}
+}
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_bed_holds_expires_at;
+DROP INDEX IF EXISTS idx_bed_holds_room_type;
+DROP INDEX IF EXISTS idx_bed_holds_active_room;
+DROP TABLE IF EXISTS bed_holds;
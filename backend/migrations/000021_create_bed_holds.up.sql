@@ -0,0 +1,23 @@
+-- 受け入れ調整中の仮押さえ（ベッドホールド）テーブル
+-- held: 仮押さえ中 / converted: 入居確定（空き数から差し引き済み）
+-- released: 解除 / expired: 期限切れ
+
+CREATE TABLE IF NOT EXISTS bed_holds (
+    id SERIAL PRIMARY KEY,
+    room_id UUID NOT NULL REFERENCES message_rooms(id) ON DELETE CASCADE,
+    room_type_id INTEGER NOT NULL REFERENCES facility_room_types(id) ON DELETE CASCADE,
+    facility_id INTEGER NOT NULL REFERENCES facilities(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'held' CHECK (status IN ('held', 'converted', 'released', 'expired')),
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 1つのルームにつき有効な仮押さえは1件まで
+CREATE UNIQUE INDEX idx_bed_holds_active_room ON bed_holds(room_id) WHERE status = 'held';
+CREATE INDEX idx_bed_holds_room_type ON bed_holds(room_type_id) WHERE status = 'held';
+CREATE INDEX idx_bed_holds_expires_at ON bed_holds(expires_at) WHERE status = 'held';
+
+COMMENT ON TABLE bed_holds IS '受け入れ調整中の部屋種別ごとの仮押さえ';
+COMMENT ON COLUMN bed_holds.status IS '仮押さえ状態（held, converted, released, expired）';
+COMMENT ON COLUMN bed_holds.expires_at IS '仮押さえの有効期限';
@@ -0,0 +1 @@
+ALTER TABLE facility_room_types DROP COLUMN IF EXISTS version;
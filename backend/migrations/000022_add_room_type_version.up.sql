@@ -0,0 +1,4 @@
+-- 部屋種別の楽観的排他制御用バージョン番号
+ALTER TABLE facility_room_types ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+
+COMMENT ON COLUMN facility_room_types.version IS '更新ごとに加算されるバージョン番号（ETag/If-Match用）';
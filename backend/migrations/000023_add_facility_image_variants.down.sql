@@ -0,0 +1,3 @@
+ALTER TABLE facility_images DROP COLUMN IF EXISTS storage_dir;
+ALTER TABLE facility_images DROP COLUMN IF EXISTS card_url;
+ALTER TABLE facility_images DROP COLUMN IF EXISTS thumbnail_url;
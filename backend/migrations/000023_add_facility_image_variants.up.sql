@@ -0,0 +1,8 @@
+-- アップロード画像のリサイズ版URLと保存先
+ALTER TABLE facility_images ADD COLUMN IF NOT EXISTS thumbnail_url TEXT;
+ALTER TABLE facility_images ADD COLUMN IF NOT EXISTS card_url TEXT;
+ALTER TABLE facility_images ADD COLUMN IF NOT EXISTS storage_dir TEXT;
+
+COMMENT ON COLUMN facility_images.thumbnail_url IS 'サムネイル画像URL（アップロード画像のみ）';
+COMMENT ON COLUMN facility_images.card_url IS 'カード表示用画像URL（アップロード画像のみ）';
+COMMENT ON COLUMN facility_images.storage_dir IS 'アップロード画像の保存ディレクトリ（外部URLの場合はNULL）';
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS app_settings;
+DROP INDEX IF EXISTS idx_facility_changes_pending;
+DROP INDEX IF EXISTS idx_facility_changes_facility;
+DROP TABLE IF EXISTS facility_changes;
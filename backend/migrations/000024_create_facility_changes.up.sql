@@ -0,0 +1,31 @@
+-- 施設プロフィールの変更履歴
+-- applied: 反映済み / pending: 承認待ち / approved: 承認・反映済み / rejected: 却下
+CREATE TABLE IF NOT EXISTS facility_changes (
+    id SERIAL PRIMARY KEY,
+    facility_id INTEGER NOT NULL REFERENCES facilities(id) ON DELETE CASCADE,
+    changed_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'applied' CHECK (status IN ('applied', 'pending', 'approved', 'rejected')),
+    changes JSONB NOT NULL DEFAULT '[]',
+    snapshot JSONB,
+    reviewed_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    reviewed_at TIMESTAMP,
+    review_comment TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_facility_changes_facility ON facility_changes(facility_id, created_at);
+CREATE INDEX idx_facility_changes_pending ON facility_changes(status) WHERE status = 'pending';
+
+COMMENT ON TABLE facility_changes IS '施設プロフィールの変更履歴と承認待ちの変更';
+COMMENT ON COLUMN facility_changes.changes IS '項目ごとの変更内容（field, old, new）';
+COMMENT ON COLUMN facility_changes.snapshot IS '反映後のプロフィール全体（反映済みの変更のみ）';
+
+-- 管理者が変更できるアプリケーション設定
+CREATE TABLE IF NOT EXISTS app_settings (
+    key VARCHAR(100) PRIMARY KEY,
+    value TEXT NOT NULL,
+    updated_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE app_settings IS '管理者が設定するアプリケーション設定';
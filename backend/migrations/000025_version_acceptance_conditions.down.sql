@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_facilities_acceptance_conditions_json;
+
+UPDATE facilities SET acceptance_conditions_json = acceptance_conditions_json - 'version';
+
+COMMENT ON COLUMN facilities.acceptance_conditions_json IS '受け入れ条件（JSON形式）';
@@ -0,0 +1,9 @@
+-- 受け入れ条件JSONにスキーマバージョンを記録する（既存データはバージョン1）
+UPDATE facilities
+SET acceptance_conditions_json = COALESCE(acceptance_conditions_json, '{}'::jsonb) || '{"version": 1}'::jsonb
+WHERE acceptance_conditions_json IS NULL OR NOT acceptance_conditions_json ? 'version';
+
+-- 受け入れ条件による検索（@> 演算子）用
+CREATE INDEX IF NOT EXISTS idx_facilities_acceptance_conditions_json ON facilities USING GIN (acceptance_conditions_json);
+
+COMMENT ON COLUMN facilities.acceptance_conditions_json IS '受け入れ条件（JSON形式、versionキーにスキーマバージョン）';
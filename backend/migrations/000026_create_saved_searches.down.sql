@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS saved_search_matches;
+DROP INDEX IF EXISTS idx_saved_searches_hospital;
+DROP TABLE IF EXISTS saved_searches;
+DROP INDEX IF EXISTS idx_notifications_user_unread;
+DROP INDEX IF EXISTS idx_notifications_user;
+DROP TABLE IF EXISTS notifications;
@@ -0,0 +1,44 @@
+-- アプリ内通知
+CREATE TABLE IF NOT EXISTS notifications (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type VARCHAR(50) NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    body TEXT,
+    link VARCHAR(255),
+    data JSONB,
+    read_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_notifications_user ON notifications(user_id, created_at DESC);
+CREATE INDEX idx_notifications_user_unread ON notifications(user_id) WHERE read_at IS NULL;
+
+-- 病院が保存した施設検索条件
+CREATE TABLE IF NOT EXISTS saved_searches (
+    id SERIAL PRIMARY KEY,
+    hospital_id INTEGER NOT NULL REFERENCES hospitals(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    criteria JSONB NOT NULL DEFAULT '{}',
+    notify_email BOOLEAN NOT NULL DEFAULT false,
+    last_viewed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_checked_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_saved_searches_hospital ON saved_searches(hospital_id);
+
+-- 保存検索に現在一致している施設（新たに一致した施設の検出に使用）
+CREATE TABLE IF NOT EXISTS saved_search_matches (
+    saved_search_id INTEGER NOT NULL REFERENCES saved_searches(id) ON DELETE CASCADE,
+    facility_id INTEGER NOT NULL REFERENCES facilities(id) ON DELETE CASCADE,
+    matched_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (saved_search_id, facility_id)
+);
+
+COMMENT ON TABLE saved_searches IS '保存された施設検索条件';
+COMMENT ON COLUMN saved_searches.criteria IS '検索条件（FacilitySearchParams）';
+COMMENT ON COLUMN saved_searches.last_checked_at IS '最後に一致判定を行った日時（NULLの場合は次回の判定で通知しない）';
+COMMENT ON TABLE saved_search_matches IS '保存検索に一致している施設';
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_shortlist_facilities_facility;
+DROP TABLE IF EXISTS shortlist_facilities;
+DROP INDEX IF EXISTS idx_shortlists_favorites;
+DROP INDEX IF EXISTS idx_shortlists_hospital;
+DROP TABLE IF EXISTS shortlists;
@@ -0,0 +1,29 @@
+-- 病院ごとの施設リスト（お気に入り・患者ごとの候補リスト）
+CREATE TABLE IF NOT EXISTS shortlists (
+    id SERIAL PRIMARY KEY,
+    hospital_id INTEGER NOT NULL REFERENCES hospitals(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    is_favorites BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_shortlists_hospital ON shortlists(hospital_id);
+-- お気に入りリストは病院ごとに1つ
+CREATE UNIQUE INDEX idx_shortlists_favorites ON shortlists(hospital_id) WHERE is_favorites;
+
+CREATE TABLE IF NOT EXISTS shortlist_facilities (
+    shortlist_id INTEGER NOT NULL REFERENCES shortlists(id) ON DELETE CASCADE,
+    facility_id INTEGER NOT NULL REFERENCES facilities(id) ON DELETE CASCADE,
+    note TEXT,
+    added_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (shortlist_id, facility_id)
+);
+
+CREATE INDEX idx_shortlist_facilities_facility ON shortlist_facilities(facility_id);
+
+COMMENT ON TABLE shortlists IS '病院が管理する施設リスト';
+COMMENT ON COLUMN shortlists.is_favorites IS 'お気に入りリスト（検索結果のis_favoriteに使用）';
+COMMENT ON COLUMN shortlist_facilities.note IS '施設ごとのメモ';
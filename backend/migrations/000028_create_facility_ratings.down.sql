@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_facility_ratings_hospital;
+DROP INDEX IF EXISTS idx_facility_ratings_facility;
+DROP TABLE IF EXISTS facility_ratings;
@@ -0,0 +1,28 @@
+-- 入所完了後の病院による施設評価（1ルームにつき1件）
+CREATE TABLE IF NOT EXISTS facility_ratings (
+    id SERIAL PRIMARY KEY,
+    room_id UUID NOT NULL UNIQUE REFERENCES message_rooms(id) ON DELETE CASCADE,
+    facility_id INTEGER NOT NULL REFERENCES facilities(id) ON DELETE CASCADE,
+    hospital_id INTEGER NOT NULL REFERENCES hospitals(id) ON DELETE CASCADE,
+    rated_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    responsiveness SMALLINT NOT NULL CHECK (responsiveness BETWEEN 1 AND 5),
+    accuracy SMALLINT NOT NULL CHECK (accuracy BETWEEN 1 AND 5),
+    outcome SMALLINT NOT NULL CHECK (outcome BETWEEN 1 AND 5),
+    comment TEXT,
+    is_hidden BOOLEAN NOT NULL DEFAULT false,
+    moderation_reason TEXT,
+    moderated_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    moderated_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_facility_ratings_facility ON facility_ratings(facility_id) WHERE NOT is_hidden;
+CREATE INDEX idx_facility_ratings_hospital ON facility_ratings(hospital_id);
+
+COMMENT ON TABLE facility_ratings IS '病院による施設評価';
+COMMENT ON COLUMN facility_ratings.responsiveness IS '対応の速さ（1〜5）';
+COMMENT ON COLUMN facility_ratings.accuracy IS '受け入れ条件の正確さ（1〜5）';
+COMMENT ON COLUMN facility_ratings.outcome IS '入所後の結果（1〜5）';
+COMMENT ON COLUMN facility_ratings.comment IS 'コメント（評価した病院内でのみ公開）';
+COMMENT ON COLUMN facility_ratings.is_hidden IS '管理者により非表示（集計からも除外）';
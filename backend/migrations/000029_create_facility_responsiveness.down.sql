@@ -0,0 +1,2 @@
+DROP MATERIALIZED VIEW IF EXISTS facility_responsiveness;
+ALTER TABLE placement_requests DROP COLUMN IF EXISTS responded_at;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_facility_fee_schedules_room_type;
+DROP TABLE IF EXISTS facility_fee_schedules;
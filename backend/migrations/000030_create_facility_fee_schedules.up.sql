@@ -0,0 +1,29 @@
+-- 施設・部屋種別ごとの料金体系
+-- room_type_id が NULL の行は部屋種別ごとの設定がない場合に使う施設共通の料金
+CREATE TABLE IF NOT EXISTS facility_fee_schedules (
+    id SERIAL PRIMARY KEY,
+    facility_id INTEGER NOT NULL REFERENCES facilities(id) ON DELETE CASCADE,
+    room_type_id INTEGER REFERENCES facility_room_types(id) ON DELETE CASCADE,
+    deposit INTEGER NOT NULL DEFAULT 0 CHECK (deposit >= 0),
+    rent INTEGER NOT NULL DEFAULT 0 CHECK (rent >= 0),
+    management_fee INTEGER NOT NULL DEFAULT 0 CHECK (management_fee >= 0),
+    food_cost INTEGER NOT NULL DEFAULT 0 CHECK (food_cost >= 0),
+    other_fee INTEGER NOT NULL DEFAULT 0 CHECK (other_fee >= 0),
+    care_copays JSONB NOT NULL DEFAULT '{}',
+    income_tier_reductions JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_facility_fee_schedules_room_type
+    ON facility_fee_schedules(facility_id, COALESCE(room_type_id, 0));
+
+COMMENT ON TABLE facility_fee_schedules IS '施設の料金体系（月額は円）';
+COMMENT ON COLUMN facility_fee_schedules.room_type_id IS '部屋種別（NULLは施設共通）';
+COMMENT ON COLUMN facility_fee_schedules.deposit IS '入居一時金';
+COMMENT ON COLUMN facility_fee_schedules.rent IS '居住費（家賃）';
+COMMENT ON COLUMN facility_fee_schedules.management_fee IS '管理費';
+COMMENT ON COLUMN facility_fee_schedules.food_cost IS '食費';
+COMMENT ON COLUMN facility_fee_schedules.other_fee IS 'その他の月額費用';
+COMMENT ON COLUMN facility_fee_schedules.care_copays IS '要介護度ごとの介護保険自己負担額（月額）';
+COMMENT ON COLUMN facility_fee_schedules.income_tier_reductions IS '所得段階ごとの居住費・食費の負担限度額（月額）';
@@ -0,0 +1,7 @@
+ALTER TABLE placement_requests DROP COLUMN IF EXISTS patient_has_dementia;
+ALTER TABLE placement_requests DROP COLUMN IF EXISTS patient_care_level;
+ALTER TABLE facilities DROP COLUMN IF EXISTS type_attributes;
+DROP INDEX IF EXISTS idx_facilities_facility_type;
+ALTER TABLE facilities DROP CONSTRAINT IF EXISTS fk_facilities_facility_type;
+ALTER TABLE facilities ALTER COLUMN facility_type DROP NOT NULL;
+DROP TABLE IF EXISTS facility_types;
@@ -0,0 +1,62 @@
+-- 管理者が管理する施設種別マスタ
+CREATE TABLE IF NOT EXISTS facility_types (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL UNIQUE,
+    short_name VARCHAR(20),
+    description TEXT,
+    attributes JSONB NOT NULL DEFAULT '[]',
+    eligibility JSONB NOT NULL DEFAULT '{}',
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE facility_types IS '施設種別マスタ';
+COMMENT ON COLUMN facility_types.short_name IS '略称（特養、老健など）';
+COMMENT ON COLUMN facility_types.attributes IS '種別固有項目の定義（key, label, type）';
+COMMENT ON COLUMN facility_types.eligibility IS '入所要件（最低要介護度、認知症の診断、最低年齢）';
+COMMENT ON COLUMN facility_types.is_active IS '無効な種別は新たに設定できない';
+
+INSERT INTO facility_types (name, short_name, description, attributes, eligibility, sort_order) VALUES
+    ('特別養護老人ホーム', '特養', '常時介護が必要な方が入所する介護老人福祉施設',
+     '[{"key": "unit_care", "label": "ユニット型", "type": "boolean"}]',
+     '{"min_care_level": "care_3"}', 10),
+    ('介護老人保健施設', '老健', 'リハビリテーションによる在宅復帰を目指す施設',
+     '[{"key": "rehab_staff_count", "label": "リハビリ専門職数", "type": "integer"}, {"key": "target_stay_months", "label": "入所期間の目安（月）", "type": "integer"}]',
+     '{"min_care_level": "care_1"}', 20),
+    ('介護医療院', NULL, '長期療養のための医療と介護を一体的に提供する施設',
+     '[{"key": "category", "label": "類型（I型・II型）", "type": "string"}]',
+     '{"min_care_level": "care_1"}', 30),
+    ('有料老人ホーム', NULL, '介護付き・住宅型・健康型の民間施設',
+     '[{"key": "category", "label": "類型（介護付き・住宅型・健康型）", "type": "string"}]',
+     '{}', 40),
+    ('サービス付き高齢者向け住宅', 'サ高住', '安否確認・生活相談サービスのある賃貸住宅',
+     '[{"key": "care_service_onsite", "label": "介護サービス事業所併設", "type": "boolean"}]',
+     '{"min_age": 60}', 50),
+    ('グループホーム', NULL, '認知症の方が少人数で共同生活を送る住まい',
+     '[{"key": "unit_count", "label": "ユニット数", "type": "integer"}]',
+     '{"min_care_level": "support_2", "requires_dementia": true}', 60),
+    ('介護施設', NULL, 'その他の介護施設', '[]', '{}', 100)
+ON CONFLICT (name) DO NOTHING;
+
+-- 既存データの種別をマスタに取り込む
+INSERT INTO facility_types (name, sort_order)
+SELECT DISTINCT facility_type, 100 FROM facilities WHERE facility_type IS NOT NULL
+ON CONFLICT (name) DO NOTHING;
+
+UPDATE facilities SET facility_type = '介護施設' WHERE facility_type IS NULL;
+ALTER TABLE facilities ALTER COLUMN facility_type SET NOT NULL;
+ALTER TABLE facilities
+    ADD CONSTRAINT fk_facilities_facility_type
+    FOREIGN KEY (facility_type) REFERENCES facility_types(name) ON UPDATE CASCADE;
+CREATE INDEX idx_facilities_facility_type ON facilities(facility_type);
+
+ALTER TABLE facilities ADD COLUMN IF NOT EXISTS type_attributes JSONB NOT NULL DEFAULT '{}';
+COMMENT ON COLUMN facilities.type_attributes IS '施設種別ごとの固有項目の値';
+
+-- 入所要件の判定に使う患者情報
+ALTER TABLE placement_requests ADD COLUMN IF NOT EXISTS patient_care_level VARCHAR(20);
+ALTER TABLE placement_requests ADD COLUMN IF NOT EXISTS patient_has_dementia BOOLEAN;
+COMMENT ON COLUMN placement_requests.patient_care_level IS '患者の要介護度（support_1〜care_5）';
+COMMENT ON COLUMN placement_requests.patient_has_dementia IS '認知症の診断の有無';
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_facility_service_areas_municipality;
+DROP TABLE IF EXISTS facility_service_areas;
@@ -0,0 +1,15 @@
+-- 施設の対象地域（地域密着型サービスなど入居者の住所地が限定される施設向け）
+-- 対象地域が登録されていない施設は地域を限定しない
+CREATE TABLE IF NOT EXISTS facility_service_areas (
+    facility_id INTEGER NOT NULL REFERENCES facilities(id) ON DELETE CASCADE,
+    municipality_code CHAR(5) NOT NULL CHECK (municipality_code ~ '^[0-9]{5}$'),
+    municipality_name VARCHAR(100),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (facility_id, municipality_code)
+);
+
+CREATE INDEX idx_facility_service_areas_municipality ON facility_service_areas(municipality_code);
+
+COMMENT ON TABLE facility_service_areas IS '施設の対象地域';
+COMMENT ON COLUMN facility_service_areas.municipality_code IS '市区町村コード（全国地方公共団体コードの先頭5桁）';
+COMMENT ON COLUMN facility_service_areas.municipality_name IS '市区町村名（表示用）';
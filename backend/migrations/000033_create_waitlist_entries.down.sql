@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_waitlist_entries_facility_position;
+DROP TABLE IF EXISTS waitlist_entries;
+
+UPDATE placement_requests SET status = 'pending' WHERE status = 'waitlisted';
+ALTER TABLE placement_requests DROP CONSTRAINT IF EXISTS placement_requests_status_check;
+ALTER TABLE placement_requests ADD CONSTRAINT placement_requests_status_check
+    CHECK (status IN ('pending', 'accepted', 'rejected'));
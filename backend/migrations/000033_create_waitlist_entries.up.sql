@@ -0,0 +1,35 @@
+-- 施設の待機リスト
+-- 満床の施設に対して、病院が入居依頼を待機リストに登録する
+-- waiting: 待機中 / notified: 空き発生を通知済み
+-- converted: 受け入れ調整（メッセージルーム）へ移行済み / removed: 待機リストから削除
+
+ALTER TABLE placement_requests DROP CONSTRAINT IF EXISTS placement_requests_status_check;
+ALTER TABLE placement_requests ADD CONSTRAINT placement_requests_status_check
+    CHECK (status IN ('pending', 'accepted', 'rejected', 'waitlisted'));
+
+CREATE TABLE IF NOT EXISTS waitlist_entries (
+    id SERIAL PRIMARY KEY,
+    request_id INTEGER NOT NULL UNIQUE REFERENCES placement_requests(id) ON DELETE CASCADE,
+    facility_id INTEGER NOT NULL REFERENCES facilities(id) ON DELETE CASCADE,
+    room_type_id INTEGER REFERENCES facility_room_types(id) ON DELETE SET NULL,
+    position INTEGER NOT NULL,
+    priority VARCHAR(10) NOT NULL DEFAULT 'normal' CHECK (priority IN ('urgent', 'high', 'normal')),
+    priority_note TEXT,
+    expected_available_date DATE,
+    status VARCHAR(20) NOT NULL DEFAULT 'waiting' CHECK (status IN ('waiting', 'notified', 'converted', 'removed')),
+    notified_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_waitlist_entries_facility_position ON waitlist_entries(facility_id, position)
+    WHERE status IN ('waiting', 'notified');
+
+COMMENT ON TABLE waitlist_entries IS '施設の待機リスト';
+COMMENT ON COLUMN waitlist_entries.room_type_id IS '希望する部屋種別（NULLは種別を問わない）';
+COMMENT ON COLUMN waitlist_entries.position IS '待機順位（1が先頭、施設が並べ替える）';
+COMMENT ON COLUMN waitlist_entries.priority IS '病院が申告する緊急度（urgent, high, normal）';
+COMMENT ON COLUMN waitlist_entries.priority_note IS '緊急度の補足（退院予定日など）';
+COMMENT ON COLUMN waitlist_entries.expected_available_date IS '施設が見込む空き発生日';
+COMMENT ON COLUMN waitlist_entries.status IS '待機状態（waiting, notified, converted, removed）';
+COMMENT ON COLUMN waitlist_entries.notified_at IS '空き発生を通知した日時';
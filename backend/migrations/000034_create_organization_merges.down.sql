@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS duplicate_dismissals;
+DROP INDEX IF EXISTS idx_organization_merges_kind;
+DROP TABLE IF EXISTS organization_merges;
+
+ALTER TABLE hospitals DROP COLUMN IF EXISTS merged_into_id;
+ALTER TABLE facilities DROP COLUMN IF EXISTS merged_into_id;
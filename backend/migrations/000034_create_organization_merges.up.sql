@@ -0,0 +1,37 @@
+-- 重複登録された施設・病院の統合
+-- 統合された側のレコードは削除せず、統合先を記録してアカウントを無効化する
+
+ALTER TABLE facilities ADD COLUMN IF NOT EXISTS merged_into_id INTEGER REFERENCES facilities(id);
+ALTER TABLE hospitals ADD COLUMN IF NOT EXISTS merged_into_id INTEGER REFERENCES hospitals(id);
+
+COMMENT ON COLUMN facilities.merged_into_id IS '統合先の施設（重複として統合済みの場合）';
+COMMENT ON COLUMN hospitals.merged_into_id IS '統合先の病院（重複として統合済みの場合）';
+
+CREATE TABLE IF NOT EXISTS organization_merges (
+    id SERIAL PRIMARY KEY,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('facility', 'hospital')),
+    survivor_id INTEGER NOT NULL,
+    duplicate_id INTEGER NOT NULL,
+    merged_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    moved JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_organization_merges_kind ON organization_merges(kind, created_at DESC);
+
+-- 重複候補から除外した組み合わせ（first_id < second_id）
+CREATE TABLE IF NOT EXISTS duplicate_dismissals (
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('facility', 'hospital')),
+    first_id INTEGER NOT NULL,
+    second_id INTEGER NOT NULL,
+    dismissed_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (kind, first_id, second_id),
+    CHECK (first_id < second_id)
+);
+
+COMMENT ON TABLE organization_merges IS '施設・病院の統合履歴';
+COMMENT ON COLUMN organization_merges.survivor_id IS '統合先（残す側）のID';
+COMMENT ON COLUMN organization_merges.duplicate_id IS '統合元（無効化した側）のID';
+COMMENT ON COLUMN organization_merges.moved IS '統合先へ移したデータの件数';
+COMMENT ON TABLE duplicate_dismissals IS '管理者が重複ではないと判断した組み合わせ';
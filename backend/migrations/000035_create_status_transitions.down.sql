@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS trigger_prevent_status_transition_update ON status_transitions;
+DROP FUNCTION IF EXISTS prevent_status_transition_update();
+DROP INDEX IF EXISTS idx_status_transitions_to_status;
+DROP INDEX IF EXISTS idx_status_transitions_request;
+DROP TABLE IF EXISTS status_transitions;
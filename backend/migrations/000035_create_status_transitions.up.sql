@@ -0,0 +1,55 @@
+-- 入居依頼・メッセージルームの状態遷移履歴（追記のみ）
+-- entity: placement_request / message_room
+-- from_status が NULL の行は作成時の状態を表す
+
+CREATE TABLE IF NOT EXISTS status_transitions (
+    id SERIAL PRIMARY KEY,
+    entity VARCHAR(30) NOT NULL CHECK (entity IN ('placement_request', 'message_room')),
+    request_id INTEGER NOT NULL REFERENCES placement_requests(id) ON DELETE CASCADE,
+    room_id UUID REFERENCES message_rooms(id) ON DELETE CASCADE,
+    from_status VARCHAR(50),
+    to_status VARCHAR(50) NOT NULL,
+    action VARCHAR(50),
+    actor_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    actor_role VARCHAR(50),
+    reason TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((entity = 'message_room') = (room_id IS NOT NULL))
+);
+
+CREATE INDEX idx_status_transitions_request ON status_transitions(request_id, created_at, id);
+CREATE INDEX idx_status_transitions_to_status ON status_transitions(entity, to_status, created_at);
+
+-- 履歴は書き換えない（依頼の削除に伴う削除のみ許可）
+CREATE OR REPLACE FUNCTION prevent_status_transition_update()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'status_transitions is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trigger_prevent_status_transition_update
+BEFORE UPDATE ON status_transitions
+FOR EACH ROW
+EXECUTE FUNCTION prevent_status_transition_update();
+
+-- 既存データの履歴を作成日時・回答日時・更新日時から復元する（操作者は不明）
+INSERT INTO status_transitions (entity, request_id, from_status, to_status, created_at)
+SELECT 'placement_request', id, NULL, 'pending', created_at FROM placement_requests;
+
+INSERT INTO status_transitions (entity, request_id, from_status, to_status, created_at)
+SELECT 'placement_request', id, 'pending', status, COALESCE(responded_at, updated_at)
+FROM placement_requests WHERE status <> 'pending';
+
+INSERT INTO status_transitions (entity, request_id, room_id, from_status, to_status, created_at)
+SELECT 'message_room', request_id, id, NULL, 'negotiating', created_at FROM message_rooms;
+
+INSERT INTO status_transitions (entity, request_id, room_id, from_status, to_status, created_at)
+SELECT 'message_room', request_id, id, 'negotiating', status, updated_at
+FROM message_rooms WHERE status <> 'negotiating';
+
+COMMENT ON TABLE status_transitions IS '入居依頼・メッセージルームの状態遷移履歴（追記のみ）';
+COMMENT ON COLUMN status_transitions.from_status IS '遷移前の状態（NULLは作成）';
+COMMENT ON COLUMN status_transitions.action IS '遷移を起こした操作（accept, reject など）';
+COMMENT ON COLUMN status_transitions.actor_id IS '操作した利用者（NULLはシステムまたは不明）';
+COMMENT ON COLUMN status_transitions.reason IS '操作時に入力された理由';
@@ -0,0 +1,75 @@
+-- 入居依頼の下書きと依頼テンプレート
+-- draft: 病院のみが閲覧できる下書き（提出すると pending になり施設に届く）
+
+ALTER TABLE placement_requests DROP CONSTRAINT IF EXISTS placement_requests_status_check;
+ALTER TABLE placement_requests ADD CONSTRAINT placement_requests_status_check
+    CHECK (status IN ('draft', 'pending', 'accepted', 'rejected', 'waitlisted'));
+
+ALTER TABLE placement_requests ADD COLUMN IF NOT EXISTS submitted_at TIMESTAMP;
+UPDATE placement_requests SET submitted_at = created_at WHERE submitted_at IS NULL;
+
+COMMENT ON COLUMN placement_requests.submitted_at IS '施設に提出した日時（下書きはNULL）';
+
+-- 対応速度は提出からの時間で測り、下書きは数えない
+DROP MATERIALIZED VIEW IF EXISTS facility_responsiveness;
+
+CREATE MATERIALIZED VIEW facility_responsiveness AS
+WITH request_stats AS (
+    SELECT facility_id,
+           COUNT(*) AS request_count,
+           COUNT(responded_at) AS responded_count,
+           COUNT(*) FILTER (WHERE status = 'accepted' AND responded_at IS NOT NULL) AS accepted_count,
+           percentile_cont(0.5) WITHIN GROUP (
+               ORDER BY EXTRACT(EPOCH FROM responded_at - submitted_at) / 3600.0
+           ) FILTER (WHERE responded_at IS NOT NULL) AS median_response_hours
+    FROM placement_requests
+    WHERE submitted_at IS NOT NULL
+    GROUP BY facility_id
+),
+first_messages AS (
+    -- ルーム作成から施設側の最初のメッセージまでの時間
+    SELECT mr.facility_id,
+           EXTRACT(EPOCH FROM MIN(m.created_at) - mr.created_at) / 3600.0 AS hours
+    FROM message_rooms mr
+    JOIN facilities f ON f.id = mr.facility_id
+    JOIN messages m ON m.room_id = mr.id AND m.sender_id = f.user_id
+    GROUP BY mr.id, mr.facility_id, mr.created_at
+),
+message_stats AS (
+    SELECT facility_id,
+           percentile_cont(0.5) WITHIN GROUP (ORDER BY hours) AS median_first_message_hours
+    FROM first_messages
+    GROUP BY facility_id
+)
+SELECT rs.facility_id,
+       rs.request_count,
+       rs.responded_count,
+       rs.median_response_hours,
+       rs.accepted_count::double precision / NULLIF(rs.responded_count, 0) AS acceptance_rate,
+       ms.median_first_message_hours,
+       CURRENT_TIMESTAMP::timestamp AS refreshed_at
+FROM request_stats rs
+LEFT JOIN message_stats ms ON ms.facility_id = rs.facility_id;
+
+CREATE UNIQUE INDEX idx_facility_responsiveness_facility ON facility_responsiveness(facility_id);
+
+COMMENT ON MATERIALIZED VIEW facility_responsiveness IS '施設の対応速度指標';
+COMMENT ON COLUMN facility_responsiveness.median_response_hours IS '依頼提出から回答までの時間の中央値（時間）';
+COMMENT ON COLUMN facility_responsiveness.acceptance_rate IS '回答済み依頼に占める受入の割合';
+COMMENT ON COLUMN facility_responsiveness.median_first_message_hours IS 'ルーム作成から施設の最初のメッセージまでの時間の中央値（時間）';
+
+-- 病院ごとの依頼テンプレート（病棟の定型の病状説明など）
+CREATE TABLE IF NOT EXISTS request_templates (
+    id SERIAL PRIMARY KEY,
+    hospital_id INTEGER NOT NULL REFERENCES hospitals(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    medical_condition TEXT NOT NULL DEFAULT '',
+    patient_care_level VARCHAR(20),
+    patient_has_dementia BOOLEAN,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (hospital_id, name)
+);
+
+COMMENT ON TABLE request_templates IS '病院が繰り返し使う入居依頼の定型内容';
+COMMENT ON COLUMN request_templates.name IS 'テンプレート名（病院内で一意）';
@@ -0,0 +1,15 @@
+ALTER TABLE messages DROP COLUMN IF EXISTS is_system;
+
+-- 取り下げた依頼・ルームは取り下げ前の扱いに近い状態に戻す
+UPDATE message_rooms SET status = 'rejected' WHERE status = 'withdrawn';
+ALTER TABLE message_rooms DROP CONSTRAINT IF EXISTS message_rooms_status_check;
+ALTER TABLE message_rooms ADD CONSTRAINT message_rooms_status_check
+    CHECK (status IN ('negotiating', 'accepted', 'completed', 'rejected'));
+
+DELETE FROM placement_requests WHERE status = 'withdrawn' AND submitted_at IS NULL;
+UPDATE placement_requests SET status = 'rejected' WHERE status = 'withdrawn';
+ALTER TABLE placement_requests DROP COLUMN IF EXISTS withdrawal_reason;
+ALTER TABLE placement_requests DROP COLUMN IF EXISTS withdrawn_at;
+ALTER TABLE placement_requests DROP CONSTRAINT IF EXISTS placement_requests_status_check;
+ALTER TABLE placement_requests ADD CONSTRAINT placement_requests_status_check
+    CHECK (status IN ('draft', 'pending', 'accepted', 'rejected', 'waitlisted'));
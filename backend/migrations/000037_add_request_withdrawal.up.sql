@@ -0,0 +1,21 @@
+-- 病院による入居依頼・メッセージルームの取り下げ
+-- withdrawn: 病院が取り下げた（依頼は削除せず履歴を残す）
+
+ALTER TABLE placement_requests DROP CONSTRAINT IF EXISTS placement_requests_status_check;
+ALTER TABLE placement_requests ADD CONSTRAINT placement_requests_status_check
+    CHECK (status IN ('draft', 'pending', 'accepted', 'rejected', 'waitlisted', 'withdrawn'));
+
+ALTER TABLE placement_requests ADD COLUMN IF NOT EXISTS withdrawn_at TIMESTAMP;
+ALTER TABLE placement_requests ADD COLUMN IF NOT EXISTS withdrawal_reason TEXT;
+
+COMMENT ON COLUMN placement_requests.withdrawn_at IS '病院が取り下げた日時';
+COMMENT ON COLUMN placement_requests.withdrawal_reason IS '取り下げの理由（死亡・再入院・他施設に決定など）';
+
+ALTER TABLE message_rooms DROP CONSTRAINT IF EXISTS message_rooms_status_check;
+ALTER TABLE message_rooms ADD CONSTRAINT message_rooms_status_check
+    CHECK (status IN ('negotiating', 'accepted', 'completed', 'rejected', 'withdrawn'));
+
+-- システムが投稿したメッセージ（取り下げの通知など）。sender_id は操作した利用者
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS is_system BOOLEAN NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN messages.is_system IS 'システムメッセージ（利用者が入力したものではない）';
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_room_reopen_requests_pending;
+DROP TABLE IF EXISTS room_reopen_requests;
@@ -0,0 +1,22 @@
+-- 完了・受け入れ不可になったメッセージルームの再開依頼
+-- 一方の当事者が依頼し、相手方が承認すると再開する（管理者は承認なしで再開できる）
+-- pending: 承認待ち / confirmed: 再開済み / declined: 相手方が拒否 / cancelled: 依頼者が取り消し
+
+CREATE TABLE IF NOT EXISTS room_reopen_requests (
+    id SERIAL PRIMARY KEY,
+    room_id UUID NOT NULL REFERENCES message_rooms(id) ON DELETE CASCADE,
+    requested_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    requested_role VARCHAR(20) NOT NULL CHECK (requested_role IN ('hospital', 'facility')),
+    reason TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'confirmed', 'declined', 'cancelled')),
+    responded_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    responded_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 承認待ちの依頼はルームごとに1つ
+CREATE UNIQUE INDEX idx_room_reopen_requests_pending ON room_reopen_requests(room_id) WHERE status = 'pending';
+
+COMMENT ON TABLE room_reopen_requests IS 'メッセージルームの再開依頼';
+COMMENT ON COLUMN room_reopen_requests.requested_role IS '依頼した当事者（hospital / facility）';
+COMMENT ON COLUMN room_reopen_requests.responded_by IS '承認・拒否した利用者（管理者による再開を含む）';
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS calendar_feeds;
+DROP TABLE IF EXISTS room_events;
+ALTER TABLE IF EXISTS schedule_proposals DROP CONSTRAINT IF EXISTS schedule_proposals_accepted_slot_fkey;
+DROP TABLE IF EXISTS schedule_proposal_slots;
+DROP TABLE IF EXISTS schedule_proposals;
@@ -0,0 +1,75 @@
+-- メッセージルーム内の日程調整
+-- 一方が候補日時（スロット）を提案し、相手方が1つを選んで確定する
+-- interview: 入居前面談 / admission: 入居日時 / transport: 移送（搬送）
+-- pending: 回答待ち / accepted: 確定 / declined: 見送り / cancelled: 提案者が取り消し
+
+CREATE TABLE IF NOT EXISTS schedule_proposals (
+    id SERIAL PRIMARY KEY,
+    room_id UUID NOT NULL REFERENCES message_rooms(id) ON DELETE CASCADE,
+    event_type VARCHAR(20) NOT NULL CHECK (event_type IN ('interview', 'admission', 'transport')),
+    proposed_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    proposed_role VARCHAR(20) NOT NULL CHECK (proposed_role IN ('hospital', 'facility')),
+    location TEXT,
+    note TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'accepted', 'declined', 'cancelled')),
+    accepted_slot_id INTEGER,
+    responded_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    responded_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 1つのルームにつき種別ごとに回答待ちの提案は1件まで
+CREATE UNIQUE INDEX idx_schedule_proposals_pending ON schedule_proposals(room_id, event_type) WHERE status = 'pending';
+
+-- 日時はカレンダー連携のためタイムゾーン付きで保存する
+CREATE TABLE IF NOT EXISTS schedule_proposal_slots (
+    id SERIAL PRIMARY KEY,
+    proposal_id INTEGER NOT NULL REFERENCES schedule_proposals(id) ON DELETE CASCADE,
+    starts_at TIMESTAMPTZ NOT NULL,
+    ends_at TIMESTAMPTZ,
+    CHECK (ends_at IS NULL OR ends_at > starts_at)
+);
+
+CREATE INDEX idx_schedule_proposal_slots_proposal ON schedule_proposal_slots(proposal_id);
+
+ALTER TABLE schedule_proposals ADD CONSTRAINT schedule_proposals_accepted_slot_fkey
+    FOREIGN KEY (accepted_slot_id) REFERENCES schedule_proposal_slots(id) ON DELETE SET NULL;
+
+-- 確定した予定（種別ごとに1件、再調整で確定し直すと上書き）
+CREATE TABLE IF NOT EXISTS room_events (
+    id SERIAL PRIMARY KEY,
+    room_id UUID NOT NULL REFERENCES message_rooms(id) ON DELETE CASCADE,
+    event_type VARCHAR(20) NOT NULL CHECK (event_type IN ('interview', 'admission', 'transport')),
+    starts_at TIMESTAMPTZ NOT NULL,
+    ends_at TIMESTAMPTZ,
+    location TEXT,
+    note TEXT,
+    proposal_id INTEGER REFERENCES schedule_proposals(id) ON DELETE SET NULL,
+    confirmed_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (room_id, event_type)
+);
+
+CREATE INDEX idx_room_events_starts_at ON room_events(starts_at);
+
+-- 組織ごとのiCalendarフィード（カレンダーアプリは認証ヘッダーを送れないためURL内のトークンで認証する）
+CREATE TABLE IF NOT EXISTS calendar_feeds (
+    id SERIAL PRIMARY KEY,
+    hospital_id INTEGER UNIQUE REFERENCES hospitals(id) ON DELETE CASCADE,
+    facility_id INTEGER UNIQUE REFERENCES facilities(id) ON DELETE CASCADE,
+    token VARCHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((hospital_id IS NULL) <> (facility_id IS NULL))
+);
+
+COMMENT ON TABLE schedule_proposals IS 'メッセージルームでの日程の提案';
+COMMENT ON COLUMN schedule_proposals.event_type IS '予定の種別（interview, admission, transport）';
+COMMENT ON COLUMN schedule_proposals.proposed_role IS '提案した側（hospital, facility）';
+COMMENT ON COLUMN schedule_proposals.status IS '提案の状態（pending, accepted, declined, cancelled）';
+COMMENT ON COLUMN schedule_proposals.accepted_slot_id IS '相手方が選んだ候補日時';
+COMMENT ON TABLE schedule_proposal_slots IS '日程の提案に含まれる候補日時';
+COMMENT ON TABLE room_events IS 'メッセージルームで確定した予定';
+COMMENT ON COLUMN room_events.proposal_id IS '確定のもとになった提案';
+COMMENT ON TABLE calendar_feeds IS '病院・施設ごとのiCalendarフィードのトークン';
+COMMENT ON COLUMN calendar_feeds.token IS 'フィードURLに含める秘密のトークン（再発行で無効化）';
@@ -0,0 +1,3 @@
+DELETE FROM app_settings WHERE key = 'handoff_checklist_blocks_completion';
+DROP TABLE IF EXISTS room_checklist_items;
+DROP TABLE IF EXISTS handoff_template_items;
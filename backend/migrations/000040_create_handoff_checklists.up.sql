@@ -0,0 +1,63 @@
+-- 退院・入居の引き継ぎチェックリスト
+-- 施設種別ごとのテンプレート項目を、ルームの受け入れ承認時にルームへ複製する
+-- assignee: 準備する側（hospital: 病院 / facility: 施設）
+
+CREATE TABLE IF NOT EXISTS handoff_template_items (
+    id SERIAL PRIMARY KEY,
+    facility_type_id INTEGER REFERENCES facility_types(id) ON DELETE CASCADE,
+    label VARCHAR(100) NOT NULL,
+    description TEXT,
+    assignee VARCHAR(20) NOT NULL CHECK (assignee IN ('hospital', 'facility')),
+    required BOOLEAN NOT NULL DEFAULT true,
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_handoff_template_items_type ON handoff_template_items(facility_type_id, sort_order);
+
+CREATE TABLE IF NOT EXISTS room_checklist_items (
+    id SERIAL PRIMARY KEY,
+    room_id UUID NOT NULL REFERENCES message_rooms(id) ON DELETE CASCADE,
+    template_item_id INTEGER REFERENCES handoff_template_items(id) ON DELETE SET NULL,
+    label VARCHAR(100) NOT NULL,
+    description TEXT,
+    assignee VARCHAR(20) NOT NULL CHECK (assignee IN ('hospital', 'facility')),
+    required BOOLEAN NOT NULL DEFAULT true,
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    file_id INTEGER REFERENCES room_files(id) ON DELETE SET NULL,
+    checked_at TIMESTAMP,
+    checked_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    added_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_room_checklist_items_room ON room_checklist_items(room_id, sort_order);
+
+COMMENT ON TABLE handoff_template_items IS '引き継ぎチェックリストのテンプレート項目';
+COMMENT ON COLUMN handoff_template_items.facility_type_id IS '対象の施設種別（NULLは項目のない種別に使う標準テンプレート）';
+COMMENT ON COLUMN handoff_template_items.assignee IS '準備する側（hospital, facility）';
+COMMENT ON COLUMN handoff_template_items.required IS '完了前にチェックが必要な項目';
+COMMENT ON TABLE room_checklist_items IS 'ルームごとの引き継ぎチェックリスト';
+COMMENT ON COLUMN room_checklist_items.template_item_id IS '複製元のテンプレート項目';
+COMMENT ON COLUMN room_checklist_items.added_by IS 'ルームで項目を追加したユーザー（テンプレートから複製した項目はNULL）';
+COMMENT ON COLUMN room_checklist_items.file_id IS '項目に対応する添付ファイル';
+COMMENT ON COLUMN room_checklist_items.checked_at IS 'チェックした日時（NULLは未チェック）';
+
+-- 標準テンプレート
+INSERT INTO handoff_template_items (facility_type_id, label, description, assignee, required, sort_order) VALUES
+    (NULL, '診療情報提供書', '主治医が作成したもの', 'hospital', true, 10),
+    (NULL, '看護サマリー', NULL, 'hospital', true, 20),
+    (NULL, '服薬リスト', '退院時処方とお薬手帳の写し', 'hospital', true, 30),
+    (NULL, '保険証のコピー', '健康保険証・介護保険被保険者証', 'hospital', true, 40),
+    (NULL, 'ケアプラン', '担当ケアマネジャーのケアプラン', 'hospital', false, 50),
+    (NULL, '入居契約書', NULL, 'facility', true, 60),
+    (NULL, '重要事項説明書', NULL, 'facility', true, 70);
+
+-- 受け入れ承認済みのルームにも標準テンプレートを複製する
+INSERT INTO room_checklist_items (room_id, template_item_id, label, description, assignee, required, sort_order)
+SELECT mr.id, t.id, t.label, t.description, t.assignee, t.required, t.sort_order
+FROM message_rooms mr
+CROSS JOIN handoff_template_items t
+WHERE mr.status = 'accepted' AND t.facility_type_id IS NULL;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS placement_request_files;
+DROP INDEX IF EXISTS idx_placement_requests_forwarded_from;
+ALTER TABLE placement_requests DROP COLUMN IF EXISTS forwarded_from_id;
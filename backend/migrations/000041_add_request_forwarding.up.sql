@@ -0,0 +1,31 @@
+-- 入居依頼の転送
+-- お断りなどの後、患者情報を複製して別の施設への依頼を作成する
+-- 転送元の依頼をたどることで同じ患者の紹介の経緯を確認できる
+
+ALTER TABLE placement_requests ADD COLUMN IF NOT EXISTS forwarded_from_id INTEGER
+    REFERENCES placement_requests(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_placement_requests_forwarded_from ON placement_requests(forwarded_from_id)
+    WHERE forwarded_from_id IS NOT NULL;
+
+COMMENT ON COLUMN placement_requests.forwarded_from_id IS '転送元の依頼（NULLは新規の依頼）';
+
+-- 転送時に病院が選んだ添付ファイルの複製
+-- 施設が依頼を受け入れてメッセージルームが作成されると、ルームの添付ファイルになる
+CREATE TABLE IF NOT EXISTS placement_request_files (
+    id SERIAL PRIMARY KEY,
+    request_id INTEGER NOT NULL REFERENCES placement_requests(id) ON DELETE CASCADE,
+    source_file_id INTEGER REFERENCES room_files(id) ON DELETE SET NULL,
+    sender_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    file_name VARCHAR(255) NOT NULL,
+    file_path TEXT NOT NULL,
+    file_type VARCHAR(100),
+    file_size BIGINT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_placement_request_files_request ON placement_request_files(request_id);
+
+COMMENT ON TABLE placement_request_files IS '転送した依頼に引き継ぐ添付ファイル';
+COMMENT ON COLUMN placement_request_files.source_file_id IS '複製元のルームの添付ファイル';
+COMMENT ON COLUMN placement_request_files.file_path IS '複製したファイルの保存先（複製元が削除されても残る）';
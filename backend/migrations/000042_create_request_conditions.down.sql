@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS request_conditions;
+
+-- 条件の回答待ちだった依頼は施設の回答待ちに戻す
+UPDATE placement_requests SET status = 'pending' WHERE status = 'conditionally_accepted';
+ALTER TABLE placement_requests DROP CONSTRAINT IF EXISTS placement_requests_status_check;
+ALTER TABLE placement_requests ADD CONSTRAINT placement_requests_status_check
+    CHECK (status IN ('draft', 'pending', 'accepted', 'rejected', 'waitlisted', 'withdrawn'));
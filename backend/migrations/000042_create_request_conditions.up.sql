@@ -0,0 +1,35 @@
+-- 施設による条件付き受け入れ（例: 「15日以降・2人部屋なら受け入れ可」「保証金にご家族が同意すれば可」）
+-- 病院が条件を承諾するとメッセージルームが作成され、断ると依頼はお断りになる
+-- proposed: 病院の回答待ち / accepted: 承諾 / declined: 病院が断った / withdrawn: 回答前に依頼が取り下げられた
+
+ALTER TABLE placement_requests DROP CONSTRAINT IF EXISTS placement_requests_status_check;
+ALTER TABLE placement_requests ADD CONSTRAINT placement_requests_status_check
+    CHECK (status IN ('draft', 'pending', 'conditionally_accepted', 'accepted', 'rejected', 'waitlisted', 'withdrawn'));
+
+CREATE TABLE IF NOT EXISTS request_conditions (
+    id SERIAL PRIMARY KEY,
+    request_id INTEGER NOT NULL REFERENCES placement_requests(id) ON DELETE CASCADE,
+    proposed_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    earliest_date DATE,
+    room_type_id INTEGER REFERENCES facility_room_types(id) ON DELETE SET NULL,
+    monthly_fee INTEGER CHECK (monthly_fee >= 0),
+    terms TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'proposed' CHECK (status IN ('proposed', 'accepted', 'declined', 'withdrawn')),
+    responded_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    response_note TEXT,
+    responded_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (earliest_date IS NOT NULL OR room_type_id IS NOT NULL OR monthly_fee IS NOT NULL OR terms IS NOT NULL)
+);
+
+CREATE INDEX idx_request_conditions_request ON request_conditions(request_id);
+
+-- 回答待ちの条件は依頼ごとに1つ
+CREATE UNIQUE INDEX idx_request_conditions_proposed ON request_conditions(request_id) WHERE status = 'proposed';
+
+COMMENT ON TABLE request_conditions IS '施設が提示した受け入れ条件と病院の回答';
+COMMENT ON COLUMN request_conditions.earliest_date IS '受け入れ可能な最も早い日';
+COMMENT ON COLUMN request_conditions.room_type_id IS '受け入れ可能な部屋種別';
+COMMENT ON COLUMN request_conditions.monthly_fee IS '提示する月額料金（円）';
+COMMENT ON COLUMN request_conditions.terms IS 'その他の条件（保証金への同意など）';
+COMMENT ON COLUMN request_conditions.response_note IS '病院が回答に添えたコメント';
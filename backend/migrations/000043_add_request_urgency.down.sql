@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_placement_requests_target_discharge_date;
+DROP INDEX IF EXISTS idx_placement_requests_facility_urgency;
+ALTER TABLE placement_requests DROP COLUMN IF EXISTS target_discharge_date;
+ALTER TABLE placement_requests DROP COLUMN IF EXISTS urgency;
@@ -0,0 +1,14 @@
+-- 入居依頼の緊急度と退院予定日
+-- 施設は緊急度・退院予定日で依頼を並べ替え・絞り込みでき、退院予定日が近いのに受け入れが決まっていない依頼には印が付く
+-- routine: 通常 / soon: 早めに / urgent: 至急
+
+ALTER TABLE placement_requests ADD COLUMN IF NOT EXISTS urgency VARCHAR(20) NOT NULL DEFAULT 'routine'
+    CHECK (urgency IN ('routine', 'soon', 'urgent'));
+ALTER TABLE placement_requests ADD COLUMN IF NOT EXISTS target_discharge_date DATE;
+
+CREATE INDEX idx_placement_requests_facility_urgency ON placement_requests(facility_id, urgency);
+CREATE INDEX idx_placement_requests_target_discharge_date ON placement_requests(target_discharge_date)
+    WHERE target_discharge_date IS NOT NULL;
+
+COMMENT ON COLUMN placement_requests.urgency IS '緊急度（routine: 通常 / soon: 早めに / urgent: 至急）';
+COMMENT ON COLUMN placement_requests.target_discharge_date IS '退院予定日（この日までに受け入れ先を決めたい）';
@@ -0,0 +1,5 @@
+ALTER TABLE request_conditions DROP CONSTRAINT IF EXISTS request_conditions_check;
+-- 部屋種別が削除された条件が残っている場合があるため、既存の行は検証しない
+ALTER TABLE request_conditions ADD CONSTRAINT request_conditions_check
+    CHECK (earliest_date IS NOT NULL OR room_type_id IS NOT NULL OR monthly_fee IS NOT NULL OR terms IS NOT NULL) NOT VALID;
+ALTER TABLE request_conditions DROP COLUMN IF EXISTS room_type_name;
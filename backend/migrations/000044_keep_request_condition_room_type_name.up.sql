@@ -0,0 +1,16 @@
+-- 受け入れ条件の部屋種別名を条件と一緒に保存する
+-- 部屋種別が削除されると room_type_id は NULL になるが、条件の内容は部屋種別名で残り、
+-- 部屋種別だけを条件にしていた場合も CHECK 制約に反しない
+
+ALTER TABLE request_conditions ADD COLUMN IF NOT EXISTS room_type_name VARCHAR(50);
+
+UPDATE request_conditions rc SET room_type_name = frt.room_type
+FROM facility_room_types frt
+WHERE rc.room_type_id = frt.id AND rc.room_type_name IS NULL;
+
+ALTER TABLE request_conditions DROP CONSTRAINT IF EXISTS request_conditions_check;
+ALTER TABLE request_conditions ADD CONSTRAINT request_conditions_check
+    CHECK (earliest_date IS NOT NULL OR room_type_id IS NOT NULL OR room_type_name IS NOT NULL
+           OR monthly_fee IS NOT NULL OR terms IS NOT NULL);
+
+COMMENT ON COLUMN request_conditions.room_type_name IS '条件を提示した時点の部屋種別名（部屋種別が削除されても残る）';
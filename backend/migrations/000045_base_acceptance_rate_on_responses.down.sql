@@ -0,0 +1,46 @@
+DROP MATERIALIZED VIEW IF EXISTS facility_responsiveness;
+
+CREATE MATERIALIZED VIEW facility_responsiveness AS
+WITH request_stats AS (
+    SELECT facility_id,
+           COUNT(*) AS request_count,
+           COUNT(responded_at) AS responded_count,
+           COUNT(*) FILTER (WHERE status = 'accepted' AND responded_at IS NOT NULL) AS accepted_count,
+           percentile_cont(0.5) WITHIN GROUP (
+               ORDER BY EXTRACT(EPOCH FROM responded_at - submitted_at) / 3600.0
+           ) FILTER (WHERE responded_at IS NOT NULL) AS median_response_hours
+    FROM placement_requests
+    WHERE submitted_at IS NOT NULL
+    GROUP BY facility_id
+),
+first_messages AS (
+    -- ルーム作成から施設側の最初のメッセージまでの時間
+    SELECT mr.facility_id,
+           EXTRACT(EPOCH FROM MIN(m.created_at) - mr.created_at) / 3600.0 AS hours
+    FROM message_rooms mr
+    JOIN facilities f ON f.id = mr.facility_id
+    JOIN messages m ON m.room_id = mr.id AND m.sender_id = f.user_id
+    GROUP BY mr.id, mr.facility_id, mr.created_at
+),
+message_stats AS (
+    SELECT facility_id,
+           percentile_cont(0.5) WITHIN GROUP (ORDER BY hours) AS median_first_message_hours
+    FROM first_messages
+    GROUP BY facility_id
+)
+SELECT rs.facility_id,
+       rs.request_count,
+       rs.responded_count,
+       rs.median_response_hours,
+       rs.accepted_count::double precision / NULLIF(rs.responded_count, 0) AS acceptance_rate,
+       ms.median_first_message_hours,
+       CURRENT_TIMESTAMP::timestamp AS refreshed_at
+FROM request_stats rs
+LEFT JOIN message_stats ms ON ms.facility_id = rs.facility_id;
+
+CREATE UNIQUE INDEX idx_facility_responsiveness_facility ON facility_responsiveness(facility_id);
+
+COMMENT ON MATERIALIZED VIEW facility_responsiveness IS '施設の対応速度指標';
+COMMENT ON COLUMN facility_responsiveness.median_response_hours IS '依頼提出から回答までの時間の中央値（時間）';
+COMMENT ON COLUMN facility_responsiveness.acceptance_rate IS '回答済み依頼に占める受入の割合';
+COMMENT ON COLUMN facility_responsiveness.median_first_message_hours IS 'ルーム作成から施設の最初のメッセージまでの時間の中央値（時間）';
@@ -0,0 +1,62 @@
+-- 受入率を施設が回答した時点の状態から数える
+-- 依頼の現在の状態で数えると、受入後に取り下げ・転送された依頼や、条件付き受入を病院が断った依頼が
+-- 受入から外れてしまうため、状態遷移履歴のうち responded_at を記録した遷移（最後の回答）を使う
+
+DROP MATERIALIZED VIEW IF EXISTS facility_responsiveness;
+
+CREATE MATERIALIZED VIEW facility_responsiveness AS
+WITH responses AS (
+    -- 条件付き受入への病院の回答は施設の回答ではないため除く
+    SELECT DISTINCT ON (request_id) request_id, to_status
+    FROM status_transitions
+    WHERE entity = 'placement_request'
+      AND to_status IN ('accepted', 'rejected', 'conditionally_accepted')
+      AND from_status IS DISTINCT FROM 'conditionally_accepted'
+    ORDER BY request_id, created_at DESC, id DESC
+),
+request_stats AS (
+    SELECT pr.facility_id,
+           COUNT(*) AS request_count,
+           COUNT(pr.responded_at) AS responded_count,
+           COUNT(*) FILTER (
+               WHERE r.to_status IN ('accepted', 'conditionally_accepted') AND pr.responded_at IS NOT NULL
+           ) AS accepted_count,
+           percentile_cont(0.5) WITHIN GROUP (
+               ORDER BY EXTRACT(EPOCH FROM pr.responded_at - pr.submitted_at) / 3600.0
+           ) FILTER (WHERE pr.responded_at IS NOT NULL) AS median_response_hours
+    FROM placement_requests pr
+    LEFT JOIN responses r ON r.request_id = pr.id
+    WHERE pr.submitted_at IS NOT NULL
+    GROUP BY pr.facility_id
+),
+first_messages AS (
+    -- ルーム作成から施設側の最初のメッセージまでの時間
+    SELECT mr.facility_id,
+           EXTRACT(EPOCH FROM MIN(m.created_at) - mr.created_at) / 3600.0 AS hours
+    FROM message_rooms mr
+    JOIN facilities f ON f.id = mr.facility_id
+    JOIN messages m ON m.room_id = mr.id AND m.sender_id = f.user_id
+    GROUP BY mr.id, mr.facility_id, mr.created_at
+),
+message_stats AS (
+    SELECT facility_id,
+           percentile_cont(0.5) WITHIN GROUP (ORDER BY hours) AS median_first_message_hours
+    FROM first_messages
+    GROUP BY facility_id
+)
+SELECT rs.facility_id,
+       rs.request_count,
+       rs.responded_count,
+       rs.median_response_hours,
+       rs.accepted_count::double precision / NULLIF(rs.responded_count, 0) AS acceptance_rate,
+       ms.median_first_message_hours,
+       CURRENT_TIMESTAMP::timestamp AS refreshed_at
+FROM request_stats rs
+LEFT JOIN message_stats ms ON ms.facility_id = rs.facility_id;
+
+CREATE UNIQUE INDEX idx_facility_responsiveness_facility ON facility_responsiveness(facility_id);
+
+COMMENT ON MATERIALIZED VIEW facility_responsiveness IS '施設の対応速度指標';
+COMMENT ON COLUMN facility_responsiveness.median_response_hours IS '依頼提出から回答までの時間の中央値（時間）';
+COMMENT ON COLUMN facility_responsiveness.acceptance_rate IS '回答済み依頼のうち施設が受入（条件付きを含む）と回答した割合';
+COMMENT ON COLUMN facility_responsiveness.median_first_message_hours IS 'ルーム作成から施設の最初のメッセージまでの時間の中央値（時間）';
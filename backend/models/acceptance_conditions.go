@@ -0,0 +1,98 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// AcceptanceConditionsVersion is the current version of the acceptance conditions schema.
+// Version 1 had the original eight medical care flags; version 2 added insulin, stoma,
+// MRSA, terminal care and psychiatric care.
+const AcceptanceConditionsVersion = 2
+
+// acceptanceConditionsVersionKey stores the schema version inside acceptance_conditions_json
+const acceptanceConditionsVersionKey = "version"
+
+// AcceptanceCondition is one medical care need a facility can accept
+type AcceptanceCondition struct {
+	Key          string `json:"key"`
+	Label        string `json:"label"`
+	SinceVersion int    `json:"since_version"`
+}
+
+// AcceptanceConditionSchema lists every condition that can be stored and searched.
+// Add new conditions at the end and bump AcceptanceConditionsVersion.
+var AcceptanceConditionSchema = []AcceptanceCondition{
+	{Key: "ventilator", Label: "人工呼吸器", SinceVersion: 1},
+	{Key: "iv_antibiotics", Label: "点滴（抗生剤）", SinceVersion: 1},
+	{Key: "tube_feeding", Label: "経管栄養", SinceVersion: 1},
+	{Key: "tracheostomy", Label: "気管切開", SinceVersion: 1},
+	{Key: "dialysis", Label: "透析", SinceVersion: 1},
+	{Key: "oxygen", Label: "在宅酸素", SinceVersion: 1},
+	{Key: "pressure_ulcer", Label: "褥瘡", SinceVersion: 1},
+	{Key: "dementia", Label: "認知症", SinceVersion: 1},
+	{Key: "insulin", Label: "インスリン", SinceVersion: 2},
+	{Key: "stoma", Label: "ストーマ", SinceVersion: 2},
+	{Key: "mrsa", Label: "MRSA", SinceVersion: 2},
+	{Key: "terminal_care", Label: "看取り", SinceVersion: 2},
+	{Key: "psychiatric", Label: "精神疾患", SinceVersion: 2},
+}
+
+// IsAcceptanceCondition reports whether key is defined in the schema
+func IsAcceptanceCondition(key string) bool {
+	for _, cond := range AcceptanceConditionSchema {
+		if cond.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeAcceptanceConditions validates acceptance conditions against the schema and
+// returns them stamped with the current schema version. Every key must be a known
+// condition with a boolean value; conditions that are left out are treated as unknown.
+func NormalizeAcceptanceConditions(raw json.RawMessage) (json.RawMessage, error) {
+	if len(bytes.TrimSpace(raw)) == 0 || bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+		raw = json.RawMessage(`{}`)
+	}
+
+	var input map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &input); err != nil {
+		return nil, fmt.Errorf("acceptance_conditions_json must be an object")
+	}
+
+	conditions := map[string]interface{}{}
+	var unknown []string
+	for key, value := range input {
+		if key == acceptanceConditionsVersionKey {
+			var version int
+			if err := json.Unmarshal(value, &version); err != nil || version < 1 || version > AcceptanceConditionsVersion {
+				return nil, fmt.Errorf("unsupported acceptance conditions version: %s", value)
+			}
+			continue
+		}
+		if !IsAcceptanceCondition(key) {
+			unknown = append(unknown, key)
+			continue
+		}
+		var accepted bool
+		if err := json.Unmarshal(value, &accepted); err != nil {
+			return nil, fmt.Errorf("acceptance condition %s must be true or false", key)
+		}
+		conditions[key] = accepted
+	}
+
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown acceptance conditions: %v", unknown)
+	}
+
+	conditions[acceptanceConditionsVersionKey] = AcceptanceConditionsVersion
+	out, err := json.Marshal(conditions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode acceptance conditions: %w", err)
+	}
+	return out, nil
+}
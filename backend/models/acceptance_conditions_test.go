@@ -0,0 +1,50 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeAcceptanceConditions(t *testing.T) {
+	t.Run("stamps the current version", func(t *testing.T) {
+		out, err := NormalizeAcceptanceConditions(json.RawMessage(`{"ventilator": true, "insulin": false, "version": 1}`))
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"ventilator": true, "insulin": false, "version": 2}`, string(out))
+	})
+
+	t.Run("empty input", func(t *testing.T) {
+		for _, raw := range []string{``, `null`, `{}`} {
+			out, err := NormalizeAcceptanceConditions(json.RawMessage(raw))
+			require.NoError(t, err, raw)
+			assert.JSONEq(t, `{"version": 2}`, string(out))
+		}
+	})
+
+	t.Run("rejects invalid input", func(t *testing.T) {
+		invalid := []string{
+			`[]`,
+			`{"ventilator": "yes"}`,
+			`{"teleportation": true}`,
+			`{"version": 99}`,
+			`{"version": "2"}`,
+		}
+		for _, raw := range invalid {
+			_, err := NormalizeAcceptanceConditions(json.RawMessage(raw))
+			assert.Error(t, err, raw)
+		}
+	})
+}
+
+func TestAcceptanceConditionSchema(t *testing.T) {
+	seen := map[string]bool{}
+	for _, cond := range AcceptanceConditionSchema {
+		assert.False(t, seen[cond.Key], "duplicate key %s", cond.Key)
+		seen[cond.Key] = true
+		assert.NotEqual(t, acceptanceConditionsVersionKey, cond.Key)
+		assert.NotEmpty(t, cond.Label)
+		assert.True(t, cond.SinceVersion >= 1 && cond.SinceVersion <= AcceptanceConditionsVersion, cond.Key)
+	}
+}
@@ -0,0 +1,202 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// DefaultBedHoldDuration is how long a bed stays held when no explicit duration is given
+const DefaultBedHoldDuration = 72 * time.Hour
+
+// ErrNoBedAvailable is returned when every unit of a room type is already taken or held
+var ErrNoBedAvailable = errors.New("no bed available for this room type")
+
+type BedHold struct {
+	ID         int       `json:"id"`
+	RoomID     string    `json:"room_id"`
+	RoomTypeID int       `json:"room_type_id"`
+	FacilityID int       `json:"facility_id"`
+	RoomType   string    `json:"room_type,omitempty"`
+	Status     string    `json:"status"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// CreateBedHold holds one unit of a facility room type for a message room.
+// The room type row is locked so concurrent holds cannot oversell the remaining units.
+func CreateBedHold(db *sql.DB, hold *BedHold) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var available int
+	err = tx.QueryRow(`
+		SELECT available FROM facility_room_types
+		WHERE id = $1 AND facility_id = $2
+		FOR UPDATE
+	`, hold.RoomTypeID, hold.FacilityID).Scan(&available)
+	if err != nil {
+		return err
+	}
+
+	// The room's own hold is replaced below, so it does not take a bed from itself
+	var held int
+	err = tx.QueryRow(`
+		SELECT COUNT(*) FROM bed_holds
+		WHERE room_type_id = $1 AND room_id <> $2 AND status = 'held' AND expires_at > CURRENT_TIMESTAMP
+	`, hold.RoomTypeID, hold.RoomID).Scan(&held)
+	if err != nil {
+		return err
+	}
+
+	if available-held < 1 {
+		return ErrNoBedAvailable
+	}
+
+	// A room has at most one active hold; replace any stale one
+	_, err = tx.Exec(`
+		UPDATE bed_holds SET status = 'released', updated_at = CURRENT_TIMESTAMP
+		WHERE room_id = $1 AND status = 'held'
+	`, hold.RoomID)
+	if err != nil {
+		return err
+	}
+
+	hold.Status = "held"
+	err = tx.QueryRow(`
+		INSERT INTO bed_holds (room_id, room_type_id, facility_id, status, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at, updated_at
+	`, hold.RoomID, hold.RoomTypeID, hold.FacilityID, hold.Status, hold.ExpiresAt).Scan(
+		&hold.ID, &hold.CreatedAt, &hold.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetActiveBedHoldByRoomID retrieves the hold currently in effect for a room
+func GetActiveBedHoldByRoomID(db *sql.DB, roomID string) (*BedHold, error) {
+	hold := &BedHold{}
+	query := `
+		SELECT bh.id, bh.room_id, bh.room_type_id, bh.facility_id, frt.room_type,
+		       bh.status, bh.expires_at, bh.created_at, bh.updated_at
+		FROM bed_holds bh
+		JOIN facility_room_types frt ON bh.room_type_id = frt.id
+		WHERE bh.room_id = $1 AND bh.status = 'held' AND bh.expires_at > CURRENT_TIMESTAMP
+	`
+	err := db.QueryRow(query, roomID).Scan(
+		&hold.ID,
+		&hold.RoomID,
+		&hold.RoomTypeID,
+		&hold.FacilityID,
+		&hold.RoomType,
+		&hold.Status,
+		&hold.ExpiresAt,
+		&hold.CreatedAt,
+		&hold.UpdatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return hold, err
+}
+
+// ReleaseBedHold releases the active hold of a room, if any
+func ReleaseBedHold(db execer, roomID string) error {
+	query := `
+		UPDATE bed_holds
+		SET status = 'released', updated_at = CURRENT_TIMESTAMP
+		WHERE room_id = $1 AND status = 'held'
+	`
+	_, err := db.Exec(query, roomID)
+	return err
+}
+
+// ConvertBedHold turns the hold of a room into an occupied bed and decrements the room
+// type's available count. Call it with the transaction that completes the room, so the
+// hold stays locked until the room is completed. A hold that expired before the room was
+// completed is converted too, as the placement still takes a bed. Returns (false, nil)
+// when the room has no hold to convert and ErrNoBedAvailable, leaving the hold as it is,
+// when the room type has no bed left that is not held for another room, e.g. because
+// staff lowered its available count or the bed was held again after the hold expired.
+func ConvertBedHold(tx Querier, roomID string) (bool, error) {
+	var holdID, roomTypeID int
+	err := tx.QueryRow(`
+		SELECT id, room_type_id FROM bed_holds
+		WHERE room_id = $1 AND status IN ('held', 'expired')
+		ORDER BY created_at DESC
+		LIMIT 1
+		FOR UPDATE
+	`, roomID).Scan(&holdID, &roomTypeID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	var available int
+	err = tx.QueryRow(`
+		SELECT available FROM facility_room_types WHERE id = $1 FOR UPDATE
+	`, roomTypeID).Scan(&available)
+	if err != nil {
+		return false, err
+	}
+
+	var heldElsewhere int
+	err = tx.QueryRow(`
+		SELECT COUNT(*) FROM bed_holds
+		WHERE room_type_id = $1 AND room_id <> $2 AND status = 'held' AND expires_at > CURRENT_TIMESTAMP
+	`, roomTypeID, roomID).Scan(&heldElsewhere)
+	if err != nil {
+		return false, err
+	}
+
+	if available-heldElsewhere < 1 {
+		return false, ErrNoBedAvailable
+	}
+
+	// The trigger on facility_room_types keeps facilities.available_beds in sync
+	_, err = tx.Exec(`
+		UPDATE facility_room_types
+		SET available = available - 1, version = version + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`, roomTypeID)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE bed_holds SET status = 'converted', updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`, holdID)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// ExpireBedHolds marks every hold past its expiry as expired and returns how many were affected
+func ExpireBedHolds(db *sql.DB) (int64, error) {
+	query := `
+		UPDATE bed_holds
+		SET status = 'expired', updated_at = CURRENT_TIMESTAMP
+		WHERE status = 'held' AND expires_at <= CURRENT_TIMESTAMP
+	`
+	result, err := db.Exec(query)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,194 @@
+package models
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/social-worker-platform/backend/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createTestBedHold holds the only bed of a new room type for the room of a new request
+func createTestBedHold(t *testing.T, db *sql.DB, expiresAt time.Time) *BedHold {
+	t.Helper()
+
+	req := createTestPlacementRequest(t, db)
+	room, err := CreateMessageRoomForRequest(db, req.ID, "accepted")
+	require.NoError(t, err)
+
+	roomType, err := NewFacilityRepository(db).CreateRoomType(req.FacilityID, FacilityRoomTypeInput{RoomType: "個室", Capacity: 1, Available: 1})
+	require.NoError(t, err)
+
+	hold := &BedHold{RoomID: room.ID, RoomTypeID: roomType.ID, FacilityID: req.FacilityID, ExpiresAt: expiresAt}
+	require.NoError(t, CreateBedHold(db, hold))
+	return hold
+}
+
+func convertBedHold(t *testing.T, db *sql.DB, roomID string) (bool, error) {
+	t.Helper()
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	converted, err := ConvertBedHold(tx, roomID)
+	if err != nil {
+		return converted, err
+	}
+	return converted, tx.Commit()
+}
+
+func TestConvertBedHold(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping database tests in short mode")
+	}
+
+	db := config.SetupTestDatabase(t)
+	defer config.TeardownTestDatabase(t, db)
+
+	repo := NewFacilityRepository(db)
+
+	t.Run("takes the held bed", func(t *testing.T) {
+		hold := createTestBedHold(t, db, time.Now().Add(time.Hour))
+		before, err := repo.GetRoomTypeByID(hold.FacilityID, hold.RoomTypeID)
+		require.NoError(t, err)
+
+		converted, err := convertBedHold(t, db, hold.RoomID)
+		require.NoError(t, err)
+		assert.True(t, converted)
+
+		roomType, err := repo.GetRoomTypeByID(hold.FacilityID, hold.RoomTypeID)
+		require.NoError(t, err)
+		assert.Equal(t, 0, roomType.Available)
+		assert.Equal(t, 0, roomType.Held)
+		assert.Equal(t, before.Version+1, roomType.Version, "a save based on the count before is refused")
+
+		active, err := GetActiveBedHoldByRoomID(db, hold.RoomID)
+		require.NoError(t, err)
+		assert.Nil(t, active)
+	})
+
+	t.Run("fails when no bed is available", func(t *testing.T) {
+		hold := createTestBedHold(t, db, time.Now().Add(time.Hour))
+		// Staff took the last bed by hand while it was held
+		_, err := db.Exec(`UPDATE facility_room_types SET available = 0 WHERE id = $1`, hold.RoomTypeID)
+		require.NoError(t, err)
+
+		converted, err := convertBedHold(t, db, hold.RoomID)
+		assert.ErrorIs(t, err, ErrNoBedAvailable)
+		assert.False(t, converted)
+
+		active, err := GetActiveBedHoldByRoomID(db, hold.RoomID)
+		require.NoError(t, err)
+		require.NotNil(t, active, "the hold is not marked converted")
+		assert.Equal(t, "held", active.Status)
+	})
+
+	t.Run("takes the bed of an expired hold", func(t *testing.T) {
+		hold := createTestBedHold(t, db, time.Now().Add(-time.Minute))
+		_, err := ExpireBedHolds(db)
+		require.NoError(t, err)
+
+		converted, err := convertBedHold(t, db, hold.RoomID)
+		require.NoError(t, err)
+		assert.True(t, converted)
+
+		roomType, err := repo.GetRoomTypeByID(hold.FacilityID, hold.RoomTypeID)
+		require.NoError(t, err)
+		assert.Equal(t, 0, roomType.Available)
+	})
+
+	t.Run("fails when the bed of an expired hold was held again", func(t *testing.T) {
+		hold := createTestBedHold(t, db, time.Now().Add(-time.Minute))
+
+		req := createTestPlacementRequest(t, db)
+		room, err := CreateMessageRoomForRequest(db, req.ID, "accepted")
+		require.NoError(t, err)
+		other := &BedHold{RoomID: room.ID, RoomTypeID: hold.RoomTypeID, FacilityID: hold.FacilityID, ExpiresAt: time.Now().Add(time.Hour)}
+		require.NoError(t, CreateBedHold(db, other))
+
+		converted, err := convertBedHold(t, db, hold.RoomID)
+		assert.ErrorIs(t, err, ErrNoBedAvailable)
+		assert.False(t, converted)
+	})
+
+	t.Run("without a hold", func(t *testing.T) {
+		req := createTestPlacementRequest(t, db)
+		room, err := CreateMessageRoomForRequest(db, req.ID, "accepted")
+		require.NoError(t, err)
+
+		converted, err := convertBedHold(t, db, room.ID)
+		require.NoError(t, err)
+		assert.False(t, converted)
+	})
+}
+
+func TestCreateBedHold(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping database tests in short mode")
+	}
+
+	db := config.SetupTestDatabase(t)
+	defer config.TeardownTestDatabase(t, db)
+
+	hold := createTestBedHold(t, db, time.Now().Add(time.Hour))
+
+	t.Run("the last bed is taken by another room", func(t *testing.T) {
+		req := createTestPlacementRequest(t, db)
+		room, err := CreateMessageRoomForRequest(db, req.ID, "accepted")
+		require.NoError(t, err)
+
+		other := &BedHold{RoomID: room.ID, RoomTypeID: hold.RoomTypeID, FacilityID: hold.FacilityID, ExpiresAt: time.Now().Add(time.Hour)}
+		assert.ErrorIs(t, CreateBedHold(db, other), ErrNoBedAvailable)
+	})
+
+	t.Run("the same room holds the last bed again", func(t *testing.T) {
+		again := &BedHold{RoomID: hold.RoomID, RoomTypeID: hold.RoomTypeID, FacilityID: hold.FacilityID, ExpiresAt: time.Now().Add(2 * time.Hour)}
+		require.NoError(t, CreateBedHold(db, again))
+
+		active, err := GetActiveBedHoldByRoomID(db, hold.RoomID)
+		require.NoError(t, err)
+		require.NotNil(t, active)
+		assert.Equal(t, again.ID, active.ID, "the new hold replaces the old one")
+	})
+}
+
+func TestExpireBedHolds(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping database tests in short mode")
+	}
+
+	db := config.SetupTestDatabase(t)
+	defer config.TeardownTestDatabase(t, db)
+
+	expired := createTestBedHold(t, db, time.Now().Add(-time.Minute))
+	current := createTestBedHold(t, db, time.Now().Add(time.Hour))
+
+	// A hold past its expiry no longer counts before the sweep marks it
+	active, err := GetActiveBedHoldByRoomID(db, expired.RoomID)
+	require.NoError(t, err)
+	assert.Nil(t, active)
+
+	n, err := ExpireBedHolds(db)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	var status string
+	require.NoError(t, db.QueryRow(`SELECT status FROM bed_holds WHERE id = $1`, expired.ID).Scan(&status))
+	assert.Equal(t, "expired", status)
+
+	active, err = GetActiveBedHoldByRoomID(db, current.RoomID)
+	require.NoError(t, err)
+	require.NotNil(t, active)
+	assert.Equal(t, current.ID, active.ID)
+
+	// The bed is free again
+	again := &BedHold{RoomID: expired.RoomID, RoomTypeID: expired.RoomTypeID, FacilityID: expired.FacilityID, ExpiresAt: time.Now().Add(time.Hour)}
+	assert.NoError(t, CreateBedHold(db, again))
+
+	n, err = ExpireBedHolds(db)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
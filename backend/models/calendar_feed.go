@@ -0,0 +1,91 @@
+package models
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"time"
+)
+
+// CalendarFeed is the secret token of an organization's iCalendar feed. Exactly one of
+// HospitalID and FacilityID is set.
+type CalendarFeed struct {
+	ID         int       `json:"id"`
+	HospitalID *int      `json:"hospital_id,omitempty"`
+	FacilityID *int      `json:"facility_id,omitempty"`
+	Token      string    `json:"token"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// newFeedToken returns a random token for a feed URL
+func newFeedToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// feedOwnerColumn is the calendar_feeds column holding the organization of role
+func feedOwnerColumn(role string) string {
+	if role == "hospital" {
+		return "hospital_id"
+	}
+	return "facility_id"
+}
+
+// GetOrCreateCalendarFeed returns the feed of the hospital or facility orgID, creating
+// it on first use. role is "hospital" or "facility".
+func GetOrCreateCalendarFeed(db *sql.DB, role string, orgID int) (*CalendarFeed, error) {
+	token, err := newFeedToken()
+	if err != nil {
+		return nil, err
+	}
+
+	column := feedOwnerColumn(role)
+	// The no-op update makes RETURNING yield the existing row
+	return scanCalendarFeed(db.QueryRow(`
+		INSERT INTO calendar_feeds (`+column+`, token)
+		VALUES ($1, $2)
+		ON CONFLICT (`+column+`) DO UPDATE SET `+column+` = EXCLUDED.`+column+`
+		RETURNING id, hospital_id, facility_id, token, created_at
+	`, orgID, token))
+}
+
+// RotateCalendarFeed gives the organization's feed a new token so the old URL stops
+// working, e.g. after it was shared by mistake
+func RotateCalendarFeed(db *sql.DB, role string, orgID int) (*CalendarFeed, error) {
+	token, err := newFeedToken()
+	if err != nil {
+		return nil, err
+	}
+
+	column := feedOwnerColumn(role)
+	return scanCalendarFeed(db.QueryRow(`
+		INSERT INTO calendar_feeds (`+column+`, token)
+		VALUES ($1, $2)
+		ON CONFLICT (`+column+`) DO UPDATE SET token = EXCLUDED.token, created_at = CURRENT_TIMESTAMP
+		RETURNING id, hospital_id, facility_id, token, created_at
+	`, orgID, token))
+}
+
+// GetCalendarFeedByToken returns the feed with token, or nil
+func GetCalendarFeedByToken(db *sql.DB, token string) (*CalendarFeed, error) {
+	feed, err := scanCalendarFeed(db.QueryRow(`
+		SELECT id, hospital_id, facility_id, token, created_at
+		FROM calendar_feeds
+		WHERE token = $1
+	`, token))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return feed, err
+}
+
+func scanCalendarFeed(row rowScanner) (*CalendarFeed, error) {
+	feed := &CalendarFeed{}
+	if err := row.Scan(&feed.ID, &feed.HospitalID, &feed.FacilityID, &feed.Token, &feed.CreatedAt); err != nil {
+		return nil, err
+	}
+	return feed, nil
+}
@@ -41,7 +41,7 @@ func TestProperty_DocumentPersistenceRoundTrip(t *testing.T) {
 			}
 
 			// Create document
-			document, err := documentRepo.Create(sender.ID, recipient.ID, title, filePath, docType)
+			document, err := documentRepo.Create(sender.ID, recipient.ID, title, filePath, docType, "")
 			if err != nil {
 				userRepo.Delete(sender.ID)
 				userRepo.Delete(recipient.ID)
@@ -93,7 +93,7 @@ func TestProperty_DocumentPersistenceRoundTrip(t *testing.T) {
 			}
 
 			// Create document
-			document, err := documentRepo.Create(sender.ID, recipient.ID, title, filePath, docType)
+			document, err := documentRepo.Create(sender.ID, recipient.ID, title, filePath, docType, "")
 			if err != nil {
 				userRepo.Delete(sender.ID)
 				userRepo.Delete(recipient.ID)
@@ -39,7 +39,7 @@ func TestDocumentRepository_ErrorHandling(t *testing.T) {
 		user, err := userRepo.Create("recipient@example.com", "password", "facility")
 		assert.NoError(t, err)
 
-		document, err := documentRepo.Create(99999, user.ID, "Test Doc", "/path/file", "referral")
+		document, err := documentRepo.Create(99999, user.ID, "Test Doc", "/path/file", "referral", "")
 		assert.Error(t, err)
 		assert.Nil(t, document)
 	})
@@ -48,7 +48,7 @@ func TestDocumentRepository_ErrorHandling(t *testing.T) {
 		user, err := userRepo.Create("sender@example.com", "password", "hospital")
 		assert.NoError(t, err)
 
-		document, err := documentRepo.Create(user.ID, 99999, "Test Doc", "/path/file", "referral")
+		document, err := documentRepo.Create(user.ID, 99999, "Test Doc", "/path/file", "referral", "")
 		assert.Error(t, err)
 		assert.Nil(t, document)
 	})
@@ -68,7 +68,7 @@ func TestDocumentRepository_ErrorHandling(t *testing.T) {
 		assert.NoError(t, err)
 
 		// Create document
-		document, err := documentRepo.Create(sender.ID, recipient.ID, "Patient Referral", "/uploads/referral.pdf", "referral")
+		document, err := documentRepo.Create(sender.ID, recipient.ID, "Patient Referral", "/uploads/referral.pdf", "referral", "")
 		assert.NoError(t, err)
 		assert.NotNil(t, document)
 		assert.Equal(t, "Patient Referral", document.Title)
@@ -1,70 +1,89 @@
 package models
 
 import (
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/lib/pq"
 )
 
 type Facility struct {
-	ID                      int              `json:"id"`
-	UserID                  int              `json:"user_id"`
-	Name                    string           `json:"name"`
-	Address                 string           `json:"address"`
-	Phone                   string           `json:"phone"`
-	BedCapacity             int              `json:"bed_capacity"`
-	AvailableBeds           int              `json:"available_beds"`
-	AcceptanceConditions    string           `json:"acceptance_conditions"`
-	Latitude                *float64         `json:"latitude,omitempty"`
-	Longitude               *float64         `json:"longitude,omitempty"`
-	MonthlyFee              *int             `json:"monthly_fee,omitempty"`
-	MedicineCost            *int             `json:"medicine_cost,omitempty"`
-	Distance                *float64         `json:"distance,omitempty"`
-	FacilityType            string           `json:"facility_type,omitempty"`
-	AcceptanceConditionsJSON json.RawMessage `json:"acceptance_conditions_json,omitempty"`
-	Description             *string          `json:"description,omitempty"`
-	ContactName             *string          `json:"contact_name,omitempty"`
-	ContactHours            *string          `json:"contact_hours,omitempty"`
-	Images                  []*FacilityImage `json:"images,omitempty"`
-	CreatedAt               time.Time        `json:"created_at"`
-	UpdatedAt               time.Time        `json:"updated_at"`
+	ID                       int                     `json:"id"`
+	UserID                   int                     `json:"user_id"`
+	Name                     string                  `json:"name"`
+	Address                  string                  `json:"address"`
+	Phone                    string                  `json:"phone"`
+	BedCapacity              int                     `json:"bed_capacity"`
+	AvailableBeds            int                     `json:"available_beds"`
+	AcceptanceConditions     string                  `json:"acceptance_conditions"`
+	Latitude                 *float64                `json:"latitude,omitempty"`
+	Longitude                *float64                `json:"longitude,omitempty"`
+	MonthlyFee               *int                    `json:"monthly_fee,omitempty"`
+	MedicineCost             *int                    `json:"medicine_cost,omitempty"`
+	Distance                 *float64                `json:"distance,omitempty"`
+	TravelMinutes            *float64                `json:"travel_minutes,omitempty"` // estimated by car, set in search results when sorting by travel time
+	IsFavorite               *bool                   `json:"is_favorite,omitempty"`    // set in search results for hospitals
+	Responsiveness           *FacilityResponsiveness `json:"responsiveness,omitempty"`
+	EstimatedMonthlyTotal    *int                    `json:"estimated_monthly_total,omitempty"` // set in search results when an estimate is requested
+	FacilityType             string                  `json:"facility_type,omitempty"`
+	AcceptanceConditionsJSON json.RawMessage         `json:"acceptance_conditions_json,omitempty"`
+	TypeAttributes           json.RawMessage         `json:"type_attributes,omitempty"` // values for the attributes defined by the facility type
+	Description              *string                 `json:"description,omitempty"`
+	ContactName              *string                 `json:"contact_name,omitempty"`
+	ContactHours             *string                 `json:"contact_hours,omitempty"`
+	Images                   []*FacilityImage        `json:"images,omitempty"`
+	ServiceAreas             []*FacilityServiceArea  `json:"service_areas,omitempty"` // empty when residents are accepted from anywhere
+	CreatedAt                time.Time               `json:"created_at"`
+	UpdatedAt                time.Time               `json:"updated_at"`
 }
 
 type FacilityImage struct {
-	ID         int       `json:"id"`
-	FacilityID int       `json:"facility_id"`
-	ImageURL   string    `json:"image_url"`
-	ImageType  string    `json:"image_type"`
-	SortOrder  int       `json:"sort_order"`
-	Caption    *string   `json:"caption,omitempty"`
-	CreatedAt  time.Time `json:"created_at"`
+	ID           int       `json:"id"`
+	FacilityID   int       `json:"facility_id"`
+	ImageURL     string    `json:"image_url"`
+	ThumbnailURL *string   `json:"thumbnail_url,omitempty"`
+	CardURL      *string   `json:"card_url,omitempty"`
+	ImageType    string    `json:"image_type"`
+	SortOrder    int       `json:"sort_order"`
+	Caption      *string   `json:"caption,omitempty"`
+	StorageDir   *string   `json:"-"` // set only for images uploaded to this server
+	CreatedAt    time.Time `json:"created_at"`
 }
 
+// FacilitySearchParams is also stored as the criteria of saved searches, hence the JSON tags
 type FacilitySearchParams struct {
-	Name             string
-	Address          string
-	HasAvailableBeds bool
-	UserLatitude     *float64
-	UserLongitude    *float64
-	MaxDistanceKm    *float64
-	MinMonthlyFee    *int
-	MaxMonthlyFee    *int
-	MinMedicineCost  *int
-	MaxMedicineCost  *int
-	SortBy           string // distance, monthly_fee, medicine_cost, available_beds
-	SortOrder        string // asc, desc
-	// Acceptance conditions filters
-	Ventilator    *bool
-	IvAntibiotics *bool
-	TubeFeeding   *bool
-	Tracheostomy  *bool
-	Dialysis      *bool
-	Oxygen        *bool
-	PressureUlcer *bool
-	Dementia      *bool
+	Name             string   `json:"name,omitempty"`
+	Address          string   `json:"address,omitempty"`
+	HasAvailableBeds bool     `json:"has_available_beds,omitempty"`
+	UserLatitude     *float64 `json:"latitude,omitempty"`
+	UserLongitude    *float64 `json:"longitude,omitempty"`
+	MaxDistanceKm    *float64 `json:"max_distance_km,omitempty"`
+	MinMonthlyFee    *int     `json:"min_monthly_fee,omitempty"`
+	MaxMonthlyFee    *int     `json:"max_monthly_fee,omitempty"`
+	MinMedicineCost  *int     `json:"min_medicine_cost,omitempty"`
+	MaxMedicineCost  *int     `json:"max_medicine_cost,omitempty"`
+	SortBy           string   `json:"sort_by,omitempty"`    // distance, monthly_fee, medicine_cost, available_beds, estimated_total, responsiveness (travel_time is sorted by the handler)
+	SortOrder        string   `json:"sort_order,omitempty"` // asc, desc
+	// Municipality code of the patient's residence; facilities with service areas must cover it
+	ResidenceMunicipalityCode string `json:"residence_municipality_code,omitempty"`
+	// Facility type names, any of which matches (see FacilityTypeRepository)
+	FacilityTypes []string `json:"facility_types,omitempty"`
+	// Acceptance condition keys (see AcceptanceConditionSchema) the facility must accept
+	AcceptanceConditions []string `json:"acceptance_conditions,omitempty"`
+	// Fee estimate inputs (see FeeSchedule.Estimate). When any is set, search results carry
+	// the cheapest estimated monthly total over the facility's fee schedules.
+	EstimateCareLevel  string `json:"care_level,omitempty"`
+	EstimateIncomeTier string `json:"income_tier,omitempty"`
+	MaxEstimatedTotal  *int   `json:"max_estimated_total,omitempty"`
+	// User whose hospital favorites are reported as is_favorite (0 to skip)
+	FavoritesForUserID int `json:"-"`
 }
 
 type FacilityWithEmail struct {
@@ -98,11 +117,15 @@ func (r *FacilityRepository) Create(userID int, name, address, phone string, bed
 	query := `
 		INSERT INTO facilities (user_id, name, address, phone, bed_capacity, available_beds, acceptance_conditions)
 		VALUES ($1, $2, $3, $4, $5, $6, $7)
-		RETURNING id, user_id, name, address, phone, bed_capacity, available_beds, acceptance_conditions, created_at, updated_at
+		RETURNING id, user_id, name, address, phone, bed_capacity, available_beds, acceptance_conditions,
+		          COALESCE(facility_type, '介護施設'), COALESCE(acceptance_conditions_json, '{}'), type_attributes,
+		          description, contact_name, contact_hours, created_at, updated_at
 	`
 	err := r.db.QueryRow(query, userID, name, address, phone, bedCapacity, 0, acceptanceConditions).Scan(
 		&facility.ID, &facility.UserID, &facility.Name, &facility.Address,
 		&facility.Phone, &facility.BedCapacity, &facility.AvailableBeds, &facility.AcceptanceConditions,
+		&facility.FacilityType, &facility.AcceptanceConditionsJSON, &facility.TypeAttributes,
+		&facility.Description, &facility.ContactName, &facility.ContactHours,
 		&facility.CreatedAt, &facility.UpdatedAt,
 	)
 	if err != nil {
@@ -119,20 +142,23 @@ func (r *FacilityRepository) GetByID(id int) (*Facility, error) {
 		       bed_capacity, available_beds, COALESCE(acceptance_conditions, '') as acceptance_conditions,
 		       latitude, longitude, monthly_fee, medicine_cost,
 		       COALESCE(facility_type, '介護施設') as facility_type,
-		       COALESCE(acceptance_conditions_json, '{}') as acceptance_conditions_json,
+		       COALESCE(acceptance_conditions_json, '{}') as acceptance_conditions_json, type_attributes,
 		       description, contact_name, contact_hours,
-		       created_at, updated_at
+		       created_at, updated_at,
+		       ` + responsivenessSelect + `
 		FROM facilities
+		LEFT JOIN facility_responsiveness fr ON fr.facility_id = facilities.id
 		WHERE id = $1
 	`
-	err := r.db.QueryRow(query, id).Scan(
+	var responsiveness responsivenessScan
+	err := r.db.QueryRow(query, id).Scan(append([]interface{}{
 		&facility.ID, &facility.UserID, &facility.Name, &facility.Address,
 		&facility.Phone, &facility.BedCapacity, &facility.AvailableBeds, &facility.AcceptanceConditions,
 		&facility.Latitude, &facility.Longitude, &facility.MonthlyFee, &facility.MedicineCost,
-		&facility.FacilityType, &facility.AcceptanceConditionsJSON,
+		&facility.FacilityType, &facility.AcceptanceConditionsJSON, &facility.TypeAttributes,
 		&facility.Description, &facility.ContactName, &facility.ContactHours,
 		&facility.CreatedAt, &facility.UpdatedAt,
-	)
+	}, responsiveness.dest()...)...)
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("facility not found")
 	}
@@ -140,6 +166,7 @@ func (r *FacilityRepository) GetByID(id int) (*Facility, error) {
 		fmt.Printf("DEBUG GetByID error for id %d: %v\n", id, err)
 		return nil, fmt.Errorf("failed to get facility: %w", err)
 	}
+	facility.Responsiveness = responsiveness.value()
 
 	// Load facility images
 	images, err := r.GetImagesByFacilityID(id)
@@ -147,13 +174,17 @@ func (r *FacilityRepository) GetByID(id int) (*Facility, error) {
 		facility.Images = images
 	}
 
+	if areas, err := r.GetServiceAreas(facility.ID); err == nil && len(areas) > 0 {
+		facility.ServiceAreas = areas
+	}
+
 	return facility, nil
 }
 
 func (r *FacilityRepository) GetImagesByFacilityID(facilityID int) ([]*FacilityImage, error) {
 	query := `
-		SELECT id, facility_id, image_url, COALESCE(image_type, 'exterior') as image_type,
-		       sort_order, caption, created_at
+		SELECT id, facility_id, image_url, thumbnail_url, card_url, COALESCE(image_type, 'exterior') as image_type,
+		       sort_order, caption, storage_dir, created_at
 		FROM facility_images
 		WHERE facility_id = $1
 		ORDER BY sort_order ASC
@@ -167,8 +198,8 @@ func (r *FacilityRepository) GetImagesByFacilityID(facilityID int) ([]*FacilityI
 	images := []*FacilityImage{}
 	for rows.Next() {
 		img := &FacilityImage{}
-		err := rows.Scan(&img.ID, &img.FacilityID, &img.ImageURL, &img.ImageType,
-			&img.SortOrder, &img.Caption, &img.CreatedAt)
+		err := rows.Scan(&img.ID, &img.FacilityID, &img.ImageURL, &img.ThumbnailURL, &img.CardURL,
+			&img.ImageType, &img.SortOrder, &img.Caption, &img.StorageDir, &img.CreatedAt)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan facility image: %w", err)
 		}
@@ -185,26 +216,30 @@ func (r *FacilityRepository) GetByUserID(userID int) (*Facility, error) {
 		       bed_capacity, available_beds, COALESCE(acceptance_conditions, '') as acceptance_conditions,
 		       latitude, longitude, monthly_fee, medicine_cost,
 		       COALESCE(facility_type, '介護施設') as facility_type,
-		       COALESCE(acceptance_conditions_json, '{}') as acceptance_conditions_json,
+		       COALESCE(acceptance_conditions_json, '{}') as acceptance_conditions_json, type_attributes,
 		       description, contact_name, contact_hours,
-		       created_at, updated_at
+		       created_at, updated_at,
+		       ` + responsivenessSelect + `
 		FROM facilities
+		LEFT JOIN facility_responsiveness fr ON fr.facility_id = facilities.id
 		WHERE user_id = $1
 	`
-	err := r.db.QueryRow(query, userID).Scan(
+	var responsiveness responsivenessScan
+	err := r.db.QueryRow(query, userID).Scan(append([]interface{}{
 		&facility.ID, &facility.UserID, &facility.Name, &facility.Address,
 		&facility.Phone, &facility.BedCapacity, &facility.AvailableBeds, &facility.AcceptanceConditions,
 		&facility.Latitude, &facility.Longitude, &facility.MonthlyFee, &facility.MedicineCost,
-		&facility.FacilityType, &facility.AcceptanceConditionsJSON,
+		&facility.FacilityType, &facility.AcceptanceConditionsJSON, &facility.TypeAttributes,
 		&facility.Description, &facility.ContactName, &facility.ContactHours,
 		&facility.CreatedAt, &facility.UpdatedAt,
-	)
+	}, responsiveness.dest()...)...)
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("facility not found")
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get facility: %w", err)
 	}
+	facility.Responsiveness = responsiveness.value()
 
 	// Load facility images
 	images, err := r.GetImagesByFacilityID(facility.ID)
@@ -212,6 +247,10 @@ func (r *FacilityRepository) GetByUserID(userID int) (*Facility, error) {
 		facility.Images = images
 	}
 
+	if areas, err := r.GetServiceAreas(facility.ID); err == nil && len(areas) > 0 {
+		facility.ServiceAreas = areas
+	}
+
 	return facility, nil
 }
 
@@ -224,6 +263,7 @@ func (r *FacilityRepository) Search(name, address string, hasAvailableBeds bool)
 		WHERE ($1 = '' OR name ILIKE '%' || $1 || '%')
 		  AND ($2 = '' OR address ILIKE '%' || $2 || '%')
 		  AND ($3 = false OR available_beds > 0)
+		  AND merged_into_id IS NULL
 		ORDER BY created_at DESC
 	`
 	rows, err := r.db.Query(query, name, address, hasAvailableBeds)
@@ -256,7 +296,8 @@ func (r *FacilityRepository) SearchAdvanced(params FacilitySearchParams) ([]*Fac
 	baseSelect := `
 		SELECT id, user_id, name, COALESCE(address, '') as address, COALESCE(phone, '') as phone,
 		       bed_capacity, available_beds, COALESCE(acceptance_conditions, '') as acceptance_conditions,
-		       latitude, longitude, monthly_fee, medicine_cost, created_at, updated_at`
+		       latitude, longitude, monthly_fee, medicine_cost, facility_type, created_at, updated_at,
+		       ` + responsivenessSelect
 
 	var distanceSelect string
 	if params.UserLatitude != nil && params.UserLongitude != nil {
@@ -278,7 +319,9 @@ func (r *FacilityRepository) SearchAdvanced(params FacilitySearchParams) ([]*Fac
 
 	whereClause := `
 		FROM facilities
-		WHERE ($1 = '' OR name ILIKE '%' || $1 || '%')
+		LEFT JOIN facility_responsiveness fr ON fr.facility_id = facilities.id
+		WHERE merged_into_id IS NULL
+		  AND ($1 = '' OR name ILIKE '%' || $1 || '%')
 		  AND ($2 = '' OR address ILIKE '%' || $2 || '%')
 		  AND ($3 = false OR available_beds > 0)
 		  AND ($4::integer IS NULL OR monthly_fee >= $4)
@@ -301,30 +344,40 @@ func (r *FacilityRepository) SearchAdvanced(params FacilitySearchParams) ([]*Fac
 			*params.UserLatitude, *params.UserLongitude, *params.UserLatitude, *params.MaxDistanceKm)
 	}
 
-	// Add acceptance conditions filters (JSONB)
-	if params.Ventilator != nil && *params.Ventilator {
-		whereClause += ` AND (acceptance_conditions_json->>'ventilator')::boolean = true`
-	}
-	if params.IvAntibiotics != nil && *params.IvAntibiotics {
-		whereClause += ` AND (acceptance_conditions_json->>'iv_antibiotics')::boolean = true`
-	}
-	if params.TubeFeeding != nil && *params.TubeFeeding {
-		whereClause += ` AND (acceptance_conditions_json->>'tube_feeding')::boolean = true`
-	}
-	if params.Tracheostomy != nil && *params.Tracheostomy {
-		whereClause += ` AND (acceptance_conditions_json->>'tracheostomy')::boolean = true`
-	}
-	if params.Dialysis != nil && *params.Dialysis {
-		whereClause += ` AND (acceptance_conditions_json->>'dialysis')::boolean = true`
+	args := []interface{}{
+		params.Name,
+		params.Address,
+		params.HasAvailableBeds,
+		nilIntToInterface(params.MinMonthlyFee),
+		nilIntToInterface(params.MaxMonthlyFee),
+		nilIntToInterface(params.MinMedicineCost),
+		nilIntToInterface(params.MaxMedicineCost),
 	}
-	if params.Oxygen != nil && *params.Oxygen {
-		whereClause += ` AND (acceptance_conditions_json->>'oxygen')::boolean = true`
+
+	if params.ResidenceMunicipalityCode != "" {
+		code, err := NormalizeMunicipalityCode(params.ResidenceMunicipalityCode)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, code)
+		whereClause += fmt.Sprintf(`
+		  AND (NOT EXISTS (SELECT 1 FROM facility_service_areas sa WHERE sa.facility_id = facilities.id)
+		       OR EXISTS (SELECT 1 FROM facility_service_areas sa WHERE sa.facility_id = facilities.id AND sa.municipality_code = $%d))`, len(args))
 	}
-	if params.PressureUlcer != nil && *params.PressureUlcer {
-		whereClause += ` AND (acceptance_conditions_json->>'pressure_ulcer')::boolean = true`
+
+	if len(params.FacilityTypes) > 0 {
+		args = append(args, pq.Array(params.FacilityTypes))
+		whereClause += fmt.Sprintf(` AND facility_type = ANY($%d)`, len(args))
 	}
-	if params.Dementia != nil && *params.Dementia {
-		whereClause += ` AND (acceptance_conditions_json->>'dementia')::boolean = true`
+
+	// Add acceptance conditions filters (JSONB containment, uses the GIN index)
+	for _, key := range params.AcceptanceConditions {
+		if !IsAcceptanceCondition(key) {
+			return nil, fmt.Errorf("unknown acceptance condition: %s", key)
+		}
+		condition, _ := json.Marshal(map[string]bool{key: true})
+		args = append(args, string(condition))
+		whereClause += fmt.Sprintf(` AND acceptance_conditions_json @> $%d::jsonb`, len(args))
 	}
 
 	// Build ORDER BY clause based on sort parameters
@@ -347,6 +400,11 @@ func (r *FacilityRepository) SearchAdvanced(params FacilitySearchParams) ([]*Fac
 		orderClause = fmt.Sprintf(" ORDER BY medicine_cost %s NULLS LAST, created_at DESC", sortOrder)
 	case "available_beds":
 		orderClause = fmt.Sprintf(" ORDER BY available_beds %s, created_at DESC", sortOrder)
+	case "estimated_total":
+		orderClause = fmt.Sprintf(" ORDER BY estimated_monthly_total %s NULLS LAST, created_at DESC", sortOrder)
+	case "responsiveness":
+		// Fastest median response first by default; facilities without history go last
+		orderClause = fmt.Sprintf(" ORDER BY fr.median_response_hours %s NULLS LAST, fr.acceptance_rate DESC NULLS LAST, created_at DESC", sortOrder)
 	default:
 		// Default: if location provided, sort by distance; otherwise by created_at
 		if params.UserLatitude != nil && params.UserLongitude != nil {
@@ -356,18 +414,36 @@ func (r *FacilityRepository) SearchAdvanced(params FacilitySearchParams) ([]*Fac
 		}
 	}
 
-	query := baseSelect + distanceSelect + whereClause + orderClause
+	// Cheapest estimated monthly total; facilities without a fee schedule don't match max_estimated_total
+	estimateSelect := ", NULL::integer as estimated_monthly_total"
+	if params.EstimateCareLevel != "" || params.EstimateIncomeTier != "" || params.MaxEstimatedTotal != nil {
+		args = append(args, params.EstimateCareLevel, params.EstimateIncomeTier)
+		estimate := fmt.Sprintf(`(
+			SELECT MIN%s FROM facility_fee_schedules fs WHERE fs.facility_id = facilities.id
+		)`, estimatedTotalSQL(len(args)-1, len(args)))
+		estimateSelect = ", " + estimate + " as estimated_monthly_total"
+		if params.MaxEstimatedTotal != nil {
+			args = append(args, *params.MaxEstimatedTotal)
+			whereClause += fmt.Sprintf(` AND %s <= $%d`, estimate, len(args))
+		}
+	}
+
+	favoriteSelect := ", NULL::boolean as is_favorite"
+	if params.FavoritesForUserID > 0 {
+		args = append(args, params.FavoritesForUserID)
+		favoriteSelect = fmt.Sprintf(`,
+			EXISTS (
+				SELECT 1 FROM shortlist_facilities sf
+				JOIN shortlists s ON s.id = sf.shortlist_id
+				JOIN hospitals h ON h.id = s.hospital_id
+				WHERE s.is_favorites AND h.user_id = $%d AND sf.facility_id = facilities.id
+			) as is_favorite`, len(args))
+	}
+
+	query := baseSelect + distanceSelect + favoriteSelect + estimateSelect + whereClause + orderClause
 
 	// Execute query
-	rows, err := r.db.Query(query,
-		params.Name,
-		params.Address,
-		params.HasAvailableBeds,
-		nilIntToInterface(params.MinMonthlyFee),
-		nilIntToInterface(params.MaxMonthlyFee),
-		nilIntToInterface(params.MinMedicineCost),
-		nilIntToInterface(params.MaxMedicineCost),
-	)
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		fmt.Printf("DEBUG SearchAdvanced query error: %v\n", err)
 		return nil, fmt.Errorf("failed to search facilities: %w", err)
@@ -377,16 +453,20 @@ func (r *FacilityRepository) SearchAdvanced(params FacilitySearchParams) ([]*Fac
 	facilities := []*Facility{}
 	for rows.Next() {
 		facility := &Facility{}
-		err := rows.Scan(
+		var responsiveness responsivenessScan
+		dest := []interface{}{
 			&facility.ID, &facility.UserID, &facility.Name, &facility.Address,
 			&facility.Phone, &facility.BedCapacity, &facility.AvailableBeds, &facility.AcceptanceConditions,
 			&facility.Latitude, &facility.Longitude, &facility.MonthlyFee, &facility.MedicineCost,
-			&facility.CreatedAt, &facility.UpdatedAt, &facility.Distance,
-		)
+			&facility.FacilityType, &facility.CreatedAt, &facility.UpdatedAt,
+		}
+		dest = append(dest, responsiveness.dest()...)
+		err := rows.Scan(append(dest, &facility.Distance, &facility.IsFavorite, &facility.EstimatedMonthlyTotal)...)
 		if err != nil {
 			fmt.Printf("DEBUG SearchAdvanced scan error: %v\n", err)
 			return nil, fmt.Errorf("failed to scan facility: %w", err)
 		}
+		facility.Responsiveness = responsiveness.value()
 		facilities = append(facilities, facility)
 	}
 
@@ -409,6 +489,7 @@ func (r *FacilityRepository) GetAll() ([]*Facility, error) {
 		       bed_capacity, available_beds, COALESCE(acceptance_conditions, '') as acceptance_conditions,
 		       latitude, longitude, monthly_fee, medicine_cost, created_at, updated_at
 		FROM facilities
+		WHERE merged_into_id IS NULL
 		ORDER BY created_at DESC
 	`
 	rows, err := r.db.Query(query)
@@ -453,8 +534,8 @@ func (r *FacilityRepository) loadImagesForFacilities(facilities []*Facility) {
 
 	// Query all images for these facilities (only first image per facility for list view)
 	query := `
-		SELECT DISTINCT ON (facility_id) id, facility_id, image_url, COALESCE(image_type, 'exterior') as image_type,
-		       sort_order, caption, created_at
+		SELECT DISTINCT ON (facility_id) id, facility_id, image_url, thumbnail_url, card_url,
+		       COALESCE(image_type, 'exterior') as image_type, sort_order, caption, storage_dir, created_at
 		FROM facility_images
 		WHERE facility_id = ANY($1)
 		ORDER BY facility_id, sort_order ASC
@@ -467,8 +548,8 @@ func (r *FacilityRepository) loadImagesForFacilities(facilities []*Facility) {
 
 	for rows.Next() {
 		img := &FacilityImage{}
-		err := rows.Scan(&img.ID, &img.FacilityID, &img.ImageURL, &img.ImageType,
-			&img.SortOrder, &img.Caption, &img.CreatedAt)
+		err := rows.Scan(&img.ID, &img.FacilityID, &img.ImageURL, &img.ThumbnailURL, &img.CardURL,
+			&img.ImageType, &img.SortOrder, &img.Caption, &img.StorageDir, &img.CreatedAt)
 		if err != nil {
 			continue
 		}
@@ -512,18 +593,52 @@ func (r *FacilityRepository) GetAllWithEmail() ([]*FacilityWithEmail, error) {
 }
 
 func (r *FacilityRepository) Update(facility *Facility) error {
+	if err := updateFacility(r.db, facility); err != nil {
+		return err
+	}
+	return updateFacilityBeds(r.db, facility)
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// Querier is satisfied by both *sql.DB and *sql.Tx, for work that can join the
+// transaction of a status change
+type Querier interface {
+	execer
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// updateFacility saves the profile of a facility except its bed counts, which room types
+// and bed holds change as well and are saved with updateFacilityBeds
+func updateFacility(db execer, facility *Facility) error {
 	query := `
 		UPDATE facilities
-		SET name = $1, address = $2, phone = $3, bed_capacity = $4,
-		    available_beds = $5, acceptance_conditions = $6,
-		    latitude = $7, longitude = $8, monthly_fee = $9, medicine_cost = $10,
+		SET name = $1, address = $2, phone = $3, acceptance_conditions = $4,
+		    latitude = $5, longitude = $6, monthly_fee = $7, medicine_cost = $8,
+		    facility_type = COALESCE(NULLIF($9, ''), facility_type),
+		    acceptance_conditions_json = COALESCE($10::jsonb, acceptance_conditions_json),
+		    description = $11, contact_name = $12, contact_hours = $13,
+		    type_attributes = COALESCE($14::jsonb, type_attributes),
 		    updated_at = CURRENT_TIMESTAMP
-		WHERE id = $11
+		WHERE id = $15
 	`
-	result, err := r.db.Exec(query, facility.Name, facility.Address, facility.Phone,
-		facility.BedCapacity, facility.AvailableBeds, facility.AcceptanceConditions,
+	var conditions, typeAttributes interface{}
+	if len(facility.AcceptanceConditionsJSON) > 0 {
+		conditions = string(facility.AcceptanceConditionsJSON)
+	}
+	if len(facility.TypeAttributes) > 0 {
+		typeAttributes = string(facility.TypeAttributes)
+	}
+	result, err := db.Exec(query, facility.Name, facility.Address, facility.Phone,
+		facility.AcceptanceConditions,
 		facility.Latitude, facility.Longitude, facility.MonthlyFee, facility.MedicineCost,
-		facility.ID)
+		facility.FacilityType, conditions,
+		facility.Description, facility.ContactName, facility.ContactHours,
+		typeAttributes, facility.ID)
 	if err != nil {
 		return fmt.Errorf("failed to update facility: %w", err)
 	}
@@ -539,6 +654,19 @@ func (r *FacilityRepository) Update(facility *Facility) error {
 	return nil
 }
 
+// updateFacilityBeds saves the bed counts of a facility. Only call it when they were
+// edited, so counts changed since the facility was read are not written back.
+func updateFacilityBeds(db execer, facility *Facility) error {
+	_, err := db.Exec(`
+		UPDATE facilities SET bed_capacity = $1, available_beds = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $3
+	`, facility.BedCapacity, facility.AvailableBeds, facility.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update facility beds: %w", err)
+	}
+	return nil
+}
+
 func (r *FacilityRepository) Delete(id int) error {
 	query := `DELETE FROM facilities WHERE id = $1`
 	result, err := r.db.Exec(query, id)
@@ -569,33 +697,84 @@ func GetFacilityByUserID(db *sql.DB, userID int) (*Facility, error) {
 	return repo.GetByUserID(userID)
 }
 
-// UpdateFacilityImages replaces all images for a facility with the provided list
-func (r *FacilityRepository) UpdateFacilityImages(facilityID int, images []FacilityImageInput) error {
+// UpdateFacilityImages replaces all images for a facility with the provided list.
+// Images uploaded to this server keep their resized variants when they stay in the list;
+// the storage directories of uploaded images dropped from the list are returned for cleanup.
+func (r *FacilityRepository) UpdateFacilityImages(facilityID int, images []FacilityImageInput) ([]string, error) {
 	tx, err := r.db.Begin()
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
+	// Remember variants of uploaded images so they survive reordering
+	rows, err := tx.Query(`
+		SELECT image_url, thumbnail_url, card_url, storage_dir
+		FROM facility_images
+		WHERE facility_id = $1 AND storage_dir IS NOT NULL
+	`, facilityID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get existing images: %w", err)
+	}
+	uploaded := map[string]*FacilityImage{}
+	for rows.Next() {
+		img := &FacilityImage{}
+		if err := rows.Scan(&img.ImageURL, &img.ThumbnailURL, &img.CardURL, &img.StorageDir); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan existing image: %w", err)
+		}
+		uploaded[img.ImageURL] = img
+	}
+	rows.Close()
+
 	// Delete existing images for this facility
 	_, err = tx.Exec("DELETE FROM facility_images WHERE facility_id = $1", facilityID)
 	if err != nil {
-		return fmt.Errorf("failed to delete existing images: %w", err)
+		return nil, fmt.Errorf("failed to delete existing images: %w", err)
 	}
 
 	// Insert new images
 	for i, img := range images {
+		var thumbnailURL, cardURL, storageDir *string
+		if existing, ok := uploaded[img.ImageURL]; ok {
+			thumbnailURL, cardURL, storageDir = existing.ThumbnailURL, existing.CardURL, existing.StorageDir
+			delete(uploaded, img.ImageURL)
+		}
+
 		_, err = tx.Exec(`
-			INSERT INTO facility_images (facility_id, image_url, image_type, sort_order, caption)
-			VALUES ($1, $2, $3, $4, $5)
-		`, facilityID, img.ImageURL, img.ImageType, i, img.Caption)
+			INSERT INTO facility_images (facility_id, image_url, thumbnail_url, card_url, image_type, sort_order, caption, storage_dir)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		`, facilityID, img.ImageURL, thumbnailURL, cardURL, img.ImageType, i, img.Caption, storageDir)
 		if err != nil {
-			return fmt.Errorf("failed to insert image: %w", err)
+			return nil, fmt.Errorf("failed to insert image: %w", err)
 		}
 	}
 
 	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	removed := []string{}
+	for _, img := range uploaded {
+		removed = append(removed, *img.StorageDir)
+	}
+
+	return removed, nil
+}
+
+// AddFacilityImage appends an image after the facility's current last image
+func (r *FacilityRepository) AddFacilityImage(img *FacilityImage) error {
+	query := `
+		INSERT INTO facility_images (facility_id, image_url, thumbnail_url, card_url, image_type, sort_order, caption, storage_dir)
+		VALUES ($1, $2, $3, $4, $5,
+		        (SELECT COALESCE(MAX(sort_order) + 1, 0) FROM facility_images WHERE facility_id = $1),
+		        $6, $7)
+		RETURNING id, sort_order, created_at
+	`
+	err := r.db.QueryRow(query, img.FacilityID, img.ImageURL, img.ThumbnailURL, img.CardURL,
+		img.ImageType, img.Caption, img.StorageDir).Scan(&img.ID, &img.SortOrder, &img.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add facility image: %w", err)
 	}
 
 	return nil
@@ -608,6 +787,21 @@ type FacilityImageInput struct {
 	Caption   *string `json:"caption,omitempty"`
 }
 
+// ErrRoomTypeNotFound is returned when a room type does not exist for the facility
+var ErrRoomTypeNotFound = errors.New("room type not found")
+
+// ErrRoomTypeVersionConflict is returned when a room type was changed since the caller read it
+var ErrRoomTypeVersionConflict = errors.New("room type was modified by another user")
+
+// ErrInvalidRoomType wraps the validation errors of room type input
+var ErrInvalidRoomType = errors.New("invalid room type")
+
+// ErrRoomTypeExists is returned when a facility already has a room type of that name
+var ErrRoomTypeExists = errors.New("room type already exists")
+
+// ErrRoomTypeHasHolds is returned when a room type with beds held for placements in progress would be deleted
+var ErrRoomTypeHasHolds = errors.New("room type has beds held for placements in progress")
+
 // FacilityRoomType represents a room type with its capacity and availability
 type FacilityRoomType struct {
 	ID          int       `json:"id"`
@@ -615,8 +809,10 @@ type FacilityRoomType struct {
 	RoomType    string    `json:"room_type"`
 	Capacity    int       `json:"capacity"`
 	Available   int       `json:"available"`
+	Held        int       `json:"held"` // units currently held for placements in negotiation
 	MonthlyFee  *int      `json:"monthly_fee,omitempty"`
 	Description *string   `json:"description,omitempty"`
+	Version     int       `json:"version"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 }
@@ -630,12 +826,29 @@ type FacilityRoomTypeInput struct {
 	Description *string `json:"description,omitempty"`
 }
 
+const roomTypeSelect = `
+		SELECT id, facility_id, room_type, capacity, available,
+		       (SELECT COUNT(*) FROM bed_holds bh
+		        WHERE bh.room_type_id = facility_room_types.id
+		          AND bh.status = 'held' AND bh.expires_at > CURRENT_TIMESTAMP) as held,
+		       monthly_fee, description, version, created_at, updated_at
+		FROM facility_room_types`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanRoomType(row rowScanner) (*FacilityRoomType, error) {
+	rt := &FacilityRoomType{}
+	err := row.Scan(&rt.ID, &rt.FacilityID, &rt.RoomType, &rt.Capacity,
+		&rt.Available, &rt.Held, &rt.MonthlyFee, &rt.Description, &rt.Version,
+		&rt.CreatedAt, &rt.UpdatedAt)
+	return rt, err
+}
+
 // GetRoomTypesByFacilityID returns all room types for a facility
 func (r *FacilityRepository) GetRoomTypesByFacilityID(facilityID int) ([]*FacilityRoomType, error) {
-	query := `
-		SELECT id, facility_id, room_type, capacity, available,
-		       monthly_fee, description, created_at, updated_at
-		FROM facility_room_types
+	query := roomTypeSelect + `
 		WHERE facility_id = $1
 		ORDER BY room_type ASC
 	`
@@ -647,9 +860,7 @@ func (r *FacilityRepository) GetRoomTypesByFacilityID(facilityID int) ([]*Facili
 
 	roomTypes := []*FacilityRoomType{}
 	for rows.Next() {
-		rt := &FacilityRoomType{}
-		err := rows.Scan(&rt.ID, &rt.FacilityID, &rt.RoomType, &rt.Capacity,
-			&rt.Available, &rt.MonthlyFee, &rt.Description, &rt.CreatedAt, &rt.UpdatedAt)
+		rt, err := scanRoomType(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan room type: %w", err)
 		}
@@ -659,40 +870,338 @@ func (r *FacilityRepository) GetRoomTypesByFacilityID(facilityID int) ([]*Facili
 	return roomTypes, nil
 }
 
-// UpdateRoomTypes replaces all room types for a facility
-func (r *FacilityRepository) UpdateRoomTypes(facilityID int, roomTypes []FacilityRoomTypeInput) error {
+// GetRoomTypeByID returns a single room type of a facility
+func (r *FacilityRepository) GetRoomTypeByID(facilityID, roomTypeID int) (*FacilityRoomType, error) {
+	query := roomTypeSelect + `
+		WHERE id = $1 AND facility_id = $2
+	`
+	rt, err := scanRoomType(r.db.QueryRow(query, roomTypeID, facilityID))
+	if err == sql.ErrNoRows {
+		return nil, ErrRoomTypeNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get room type: %w", err)
+	}
+
+	return rt, nil
+}
+
+// RoomTypesVersion identifies the state of a facility's room type list, so a save of the
+// whole list can tell whether any room type was added, changed or removed since it was read
+func RoomTypesVersion(roomTypes []*FacilityRoomType) string {
+	ids := make([]string, 0, len(roomTypes))
+	for _, rt := range roomTypes {
+		ids = append(ids, fmt.Sprintf("%d:%d", rt.ID, rt.Version))
+	}
+	sort.Strings(ids)
+
+	sum := sha256.Sum256([]byte(strings.Join(ids, ",")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// UpdateRoomTypes replaces the room type list of a facility if it is still at expectedVersion,
+// as returned by RoomTypesVersion.
+// Rows are matched by room_type name so existing IDs (and bed holds on them) survive the save.
+// Room types with beds held for placements in progress cannot be removed.
+func (r *FacilityRepository) UpdateRoomTypes(facilityID int, expectedVersion string, roomTypes []FacilityRoomTypeInput) error {
 	tx, err := r.db.Begin()
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
-	// Delete existing room types for this facility
-	_, err = tx.Exec("DELETE FROM facility_room_types WHERE facility_id = $1", facilityID)
+	// Lock the facility so room types cannot be added next to the save
+	_, err = tx.Exec(`SELECT id FROM facilities WHERE id = $1 FOR UPDATE`, facilityID)
 	if err != nil {
-		return fmt.Errorf("failed to delete existing room types: %w", err)
+		return fmt.Errorf("failed to lock facility: %w", err)
+	}
+
+	rows, err := tx.Query(`
+		SELECT id, version FROM facility_room_types
+		WHERE facility_id = $1
+		FOR UPDATE
+	`, facilityID)
+	if err != nil {
+		return fmt.Errorf("failed to get room types: %w", err)
+	}
+	current := []*FacilityRoomType{}
+	for rows.Next() {
+		rt := &FacilityRoomType{}
+		if err := rows.Scan(&rt.ID, &rt.Version); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan room type: %w", err)
+		}
+		current = append(current, rt)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to get room types: %w", err)
+	}
+	if RoomTypesVersion(current) != expectedVersion {
+		return ErrRoomTypeVersionConflict
 	}
 
-	// Insert new room types
+	names := make([]string, 0, len(roomTypes))
 	for _, rt := range roomTypes {
 		// Validate: available cannot exceed capacity
 		if rt.Available > rt.Capacity {
-			return fmt.Errorf("available (%d) cannot exceed capacity (%d) for room type '%s'",
+			return fmt.Errorf("%w: available (%d) cannot exceed capacity (%d) for room type '%s'", ErrInvalidRoomType,
 				rt.Available, rt.Capacity, rt.RoomType)
 		}
 
 		_, err = tx.Exec(`
 			INSERT INTO facility_room_types (facility_id, room_type, capacity, available, monthly_fee, description)
 			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (facility_id, room_type) DO UPDATE SET
+				capacity = EXCLUDED.capacity,
+				available = EXCLUDED.available,
+				monthly_fee = EXCLUDED.monthly_fee,
+				description = EXCLUDED.description,
+				version = facility_room_types.version + 1,
+				updated_at = CURRENT_TIMESTAMP
 		`, facilityID, rt.RoomType, rt.Capacity, rt.Available, rt.MonthlyFee, rt.Description)
 		if err != nil {
-			return fmt.Errorf("failed to insert room type: %w", err)
+			return fmt.Errorf("failed to save room type: %w", err)
 		}
+		names = append(names, rt.RoomType)
 	}
 
-	// The trigger will automatically update facilities.available_beds
-	// But we also need to update bed_capacity
+	var removed string
+	var held int
+	err = tx.QueryRow(`
+		SELECT rt.room_type, COUNT(*) FROM bed_holds bh
+		JOIN facility_room_types rt ON rt.id = bh.room_type_id
+		WHERE rt.facility_id = $1 AND NOT (rt.room_type = ANY($2))
+		  AND bh.status = 'held' AND bh.expires_at > CURRENT_TIMESTAMP
+		GROUP BY rt.room_type
+		ORDER BY rt.room_type
+		LIMIT 1
+	`, facilityID, pq.Array(names)).Scan(&removed, &held)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to check bed holds: %w", err)
+	}
+	if err == nil {
+		return fmt.Errorf("%w: %d bed(s) of '%s'", ErrRoomTypeHasHolds, held, removed)
+	}
+
+	// Remove room types that are no longer in the list
+	_, err = tx.Exec(`
+		DELETE FROM facility_room_types
+		WHERE facility_id = $1 AND NOT (room_type = ANY($2))
+	`, facilityID, pq.Array(names))
+	if err != nil {
+		return fmt.Errorf("failed to delete removed room types: %w", err)
+	}
+
+	if err := syncBedCapacity(tx, facilityID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// CreateRoomType adds a single room type to a facility
+func (r *FacilityRepository) CreateRoomType(facilityID int, input FacilityRoomTypeInput) (*FacilityRoomType, error) {
+	if input.Available > input.Capacity {
+		return nil, fmt.Errorf("%w: available (%d) cannot exceed capacity (%d) for room type '%s'", ErrInvalidRoomType,
+			input.Available, input.Capacity, input.RoomType)
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRow(`
+		INSERT INTO facility_room_types (facility_id, room_type, capacity, available, monthly_fee, description)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`, facilityID, input.RoomType, input.Capacity, input.Available, input.MonthlyFee, input.Description).Scan(&id)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return nil, fmt.Errorf("%w: '%s'", ErrRoomTypeExists, input.RoomType)
+		}
+		return nil, fmt.Errorf("failed to create room type: %w", err)
+	}
+
+	if err := syncBedCapacity(tx, facilityID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return r.GetRoomTypeByID(facilityID, id)
+}
+
+// UpdateRoomType updates a single room type if it is still at expectedVersion
+func (r *FacilityRepository) UpdateRoomType(facilityID, roomTypeID, expectedVersion int, input FacilityRoomTypeInput) (*FacilityRoomType, error) {
+	if input.Available > input.Capacity {
+		return nil, fmt.Errorf("%w: available (%d) cannot exceed capacity (%d) for room type '%s'", ErrInvalidRoomType,
+			input.Available, input.Capacity, input.RoomType)
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE facility_room_types
+		SET room_type = $1, capacity = $2, available = $3, monthly_fee = $4, description = $5,
+		    version = version + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $6 AND facility_id = $7 AND version = $8
+	`, input.RoomType, input.Capacity, input.Available, input.MonthlyFee, input.Description,
+		roomTypeID, facilityID, expectedVersion)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return nil, fmt.Errorf("%w: '%s'", ErrRoomTypeExists, input.RoomType)
+		}
+		return nil, fmt.Errorf("failed to update room type: %w", err)
+	}
+
+	if err := checkRoomTypeWrite(tx, result, facilityID, roomTypeID); err != nil {
+		return nil, err
+	}
+
+	if err := syncBedCapacity(tx, facilityID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return r.GetRoomTypeByID(facilityID, roomTypeID)
+}
+
+// DeleteRoomType removes a single room type if it is still at expectedVersion
+func (r *FacilityRepository) DeleteRoomType(facilityID, roomTypeID, expectedVersion int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var held int
+	err = tx.QueryRow(`
+		SELECT COUNT(*) FROM bed_holds
+		WHERE room_type_id = $1 AND status = 'held' AND expires_at > CURRENT_TIMESTAMP
+	`, roomTypeID).Scan(&held)
+	if err != nil {
+		return fmt.Errorf("failed to check bed holds: %w", err)
+	}
+	if held > 0 {
+		return fmt.Errorf("%w: %d bed(s)", ErrRoomTypeHasHolds, held)
+	}
+
+	result, err := tx.Exec(`
+		DELETE FROM facility_room_types
+		WHERE id = $1 AND facility_id = $2 AND version = $3
+	`, roomTypeID, facilityID, expectedVersion)
+	if err != nil {
+		return fmt.Errorf("failed to delete room type: %w", err)
+	}
+
+	if err := checkRoomTypeWrite(tx, result, facilityID, roomTypeID); err != nil {
+		return err
+	}
+
+	if err := syncBedCapacity(tx, facilityID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// AdjustRoomTypeAvailable adds delta to the available count of a room type.
+// When expectedVersion is nil the adjustment is applied to whatever the current count is.
+func (r *FacilityRepository) AdjustRoomTypeAvailable(facilityID, roomTypeID, delta int, expectedVersion *int) (*FacilityRoomType, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var available, capacity, version int
+	err = tx.QueryRow(`
+		SELECT available, capacity, version FROM facility_room_types
+		WHERE id = $1 AND facility_id = $2
+		FOR UPDATE
+	`, roomTypeID, facilityID).Scan(&available, &capacity, &version)
+	if err == sql.ErrNoRows {
+		return nil, ErrRoomTypeNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get room type: %w", err)
+	}
+
+	if expectedVersion != nil && *expectedVersion != version {
+		return nil, ErrRoomTypeVersionConflict
+	}
+
+	newAvailable := available + delta
+	if newAvailable < 0 || newAvailable > capacity {
+		return nil, fmt.Errorf("%w: available must stay between 0 and %d (currently %d)", ErrInvalidRoomType, capacity, available)
+	}
+
+	// The trigger on facility_room_types keeps facilities.available_beds in sync
 	_, err = tx.Exec(`
+		UPDATE facility_room_types
+		SET available = $1, version = version + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+	`, newAvailable, roomTypeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update available: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return r.GetRoomTypeByID(facilityID, roomTypeID)
+}
+
+// checkRoomTypeWrite tells a missing room type apart from a stale version when a guarded write touched no rows
+func checkRoomTypeWrite(tx *sql.Tx, result sql.Result, facilityID, roomTypeID int) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected > 0 {
+		return nil
+	}
+
+	var exists bool
+	err = tx.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM facility_room_types WHERE id = $1 AND facility_id = $2)
+	`, roomTypeID, facilityID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check room type: %w", err)
+	}
+	if !exists {
+		return ErrRoomTypeNotFound
+	}
+
+	return ErrRoomTypeVersionConflict
+}
+
+// syncBedCapacity recalculates facilities.bed_capacity from its room types.
+// available_beds is maintained by the trigger on facility_room_types.
+func syncBedCapacity(tx *sql.Tx, facilityID int) error {
+	_, err := tx.Exec(`
 		UPDATE facilities SET
 			bed_capacity = (SELECT COALESCE(SUM(capacity), 0) FROM facility_room_types WHERE facility_id = $1),
 			updated_at = CURRENT_TIMESTAMP
@@ -702,9 +1211,5 @@ func (r *FacilityRepository) UpdateRoomTypes(facilityID int, roomTypes []Facilit
 		return fmt.Errorf("failed to update facility bed_capacity: %w", err)
 	}
 
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
-	}
-
 	return nil
 }
@@ -0,0 +1,558 @@
+package models
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Facility change statuses
+const (
+	FacilityChangeApplied  = "applied"
+	FacilityChangePending  = "pending"
+	FacilityChangeApproved = "approved"
+	FacilityChangeRejected = "rejected"
+)
+
+var (
+	ErrFacilityChangeNotFound   = errors.New("facility change not found")
+	ErrFacilityChangeNotPending = errors.New("facility change is not pending")
+	ErrFacilityChangeStale      = errors.New("facility was changed since the change was made")
+	ErrFacilityChangeMerged     = errors.New("facility has been merged into another facility")
+)
+
+// SensitiveFacilityFields are held for admin approval while moderation is enabled
+var SensitiveFacilityFields = map[string]bool{
+	"monthly_fee":                true,
+	"medicine_cost":              true,
+	"facility_type":              true,
+	"acceptance_conditions":      true,
+	"acceptance_conditions_json": true,
+}
+
+// FieldChange is one field-level difference between two facility profiles
+type FieldChange struct {
+	Field string          `json:"field"`
+	Old   json.RawMessage `json:"old"`
+	New   json.RawMessage `json:"new"`
+}
+
+// FacilityChange is a versioned record of an edit to a facility profile
+type FacilityChange struct {
+	ID             int             `json:"id"`
+	FacilityID     int             `json:"facility_id"`
+	FacilityName   string          `json:"facility_name,omitempty"`
+	ChangedBy      *int            `json:"changed_by,omitempty"`
+	ChangedByEmail *string         `json:"changed_by_email,omitempty"`
+	Status         string          `json:"status"`
+	Changes        []FieldChange   `json:"changes"`
+	Snapshot       json.RawMessage `json:"snapshot,omitempty"`
+	ReviewedBy     *int            `json:"reviewed_by,omitempty"`
+	ReviewedAt     *time.Time      `json:"reviewed_at,omitempty"`
+	ReviewComment  *string         `json:"review_comment,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+// FacilityProfile is the editable part of a facility that is tracked in the change history
+type FacilityProfile struct {
+	Name                     string          `json:"name"`
+	Address                  string          `json:"address"`
+	Phone                    string          `json:"phone"`
+	BedCapacity              int             `json:"bed_capacity"`
+	AvailableBeds            int             `json:"available_beds"`
+	AcceptanceConditions     string          `json:"acceptance_conditions"`
+	Latitude                 *float64        `json:"latitude"`
+	Longitude                *float64        `json:"longitude"`
+	MonthlyFee               *int            `json:"monthly_fee"`
+	MedicineCost             *int            `json:"medicine_cost"`
+	FacilityType             string          `json:"facility_type"`
+	AcceptanceConditionsJSON json.RawMessage `json:"acceptance_conditions_json"`
+	TypeAttributes           json.RawMessage `json:"type_attributes"`
+	Description              *string         `json:"description"`
+	ContactName              *string         `json:"contact_name"`
+	ContactHours             *string         `json:"contact_hours"`
+}
+
+// ProfileOf copies the tracked fields of a facility
+func ProfileOf(f *Facility) FacilityProfile {
+	return FacilityProfile{
+		Name:                     f.Name,
+		Address:                  f.Address,
+		Phone:                    f.Phone,
+		BedCapacity:              f.BedCapacity,
+		AvailableBeds:            f.AvailableBeds,
+		AcceptanceConditions:     f.AcceptanceConditions,
+		Latitude:                 f.Latitude,
+		Longitude:                f.Longitude,
+		MonthlyFee:               f.MonthlyFee,
+		MedicineCost:             f.MedicineCost,
+		FacilityType:             f.FacilityType,
+		AcceptanceConditionsJSON: f.AcceptanceConditionsJSON,
+		TypeAttributes:           f.TypeAttributes,
+		Description:              f.Description,
+		ContactName:              f.ContactName,
+		ContactHours:             f.ContactHours,
+	}
+}
+
+// ApplyTo writes the profile back onto a facility
+func (p FacilityProfile) ApplyTo(f *Facility) {
+	f.Name = p.Name
+	f.Address = p.Address
+	f.Phone = p.Phone
+	f.BedCapacity = p.BedCapacity
+	f.AvailableBeds = p.AvailableBeds
+	f.AcceptanceConditions = p.AcceptanceConditions
+	f.Latitude = p.Latitude
+	f.Longitude = p.Longitude
+	f.MonthlyFee = p.MonthlyFee
+	f.MedicineCost = p.MedicineCost
+	f.FacilityType = p.FacilityType
+	f.AcceptanceConditionsJSON = p.AcceptanceConditionsJSON
+	f.TypeAttributes = p.TypeAttributes
+	f.Description = p.Description
+	f.ContactName = p.ContactName
+	f.ContactHours = p.ContactHours
+}
+
+func profileFields(p FacilityProfile) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// canonicalJSON re-encodes a value so that key order and whitespace don't affect comparison
+func canonicalJSON(raw json.RawMessage) json.RawMessage {
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return raw
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		return raw
+	}
+	return out
+}
+
+// DiffProfiles lists the fields that differ between two profiles, ordered by field name
+func DiffProfiles(before, after FacilityProfile) ([]FieldChange, error) {
+	oldFields, err := profileFields(before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode profile: %w", err)
+	}
+	newFields, err := profileFields(after)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode profile: %w", err)
+	}
+
+	names := make([]string, 0, len(newFields))
+	for name := range newFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	changes := []FieldChange{}
+	for _, name := range names {
+		oldValue := canonicalJSON(oldFields[name])
+		newValue := canonicalJSON(newFields[name])
+		if !bytes.Equal(oldValue, newValue) {
+			changes = append(changes, FieldChange{Field: name, Old: oldValue, New: newValue})
+		}
+	}
+
+	return changes, nil
+}
+
+// ApplyFieldChanges sets each changed field of the profile to its new value,
+// or to its old value when revert is true
+func ApplyFieldChanges(p FacilityProfile, changes []FieldChange, revert bool) (FacilityProfile, error) {
+	fields, err := profileFields(p)
+	if err != nil {
+		return p, fmt.Errorf("failed to encode profile: %w", err)
+	}
+
+	for _, change := range changes {
+		if _, ok := fields[change.Field]; !ok {
+			return p, fmt.Errorf("unknown facility field: %s", change.Field)
+		}
+		if revert {
+			fields[change.Field] = change.Old
+		} else {
+			fields[change.Field] = change.New
+		}
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return p, fmt.Errorf("failed to encode profile: %w", err)
+	}
+	var result FacilityProfile
+	if err := json.Unmarshal(data, &result); err != nil {
+		return p, fmt.Errorf("failed to decode profile: %w", err)
+	}
+	// RawMessage keeps a literal null instead of leaving the field empty
+	if string(result.AcceptanceConditionsJSON) == "null" {
+		result.AcceptanceConditionsJSON = nil
+	}
+	if string(result.TypeAttributes) == "null" {
+		result.TypeAttributes = nil
+	}
+	return result, nil
+}
+
+// StaleFieldChanges lists the fields of changes whose value in the profile is no longer the
+// old value the change was made against
+func StaleFieldChanges(p FacilityProfile, changes []FieldChange) ([]string, error) {
+	fields, err := profileFields(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode profile: %w", err)
+	}
+
+	stale := []string{}
+	for _, change := range changes {
+		current, ok := fields[change.Field]
+		if !ok {
+			return nil, fmt.Errorf("unknown facility field: %s", change.Field)
+		}
+		if !bytes.Equal(canonicalJSON(current), canonicalJSON(change.Old)) {
+			stale = append(stale, change.Field)
+		}
+	}
+	return stale, nil
+}
+
+// SplitSensitiveChanges separates changes that need admin approval from the rest
+func SplitSensitiveChanges(changes []FieldChange) (sensitive, other []FieldChange) {
+	for _, change := range changes {
+		if SensitiveFacilityFields[change.Field] {
+			sensitive = append(sensitive, change)
+		} else {
+			other = append(other, change)
+		}
+	}
+	return sensitive, other
+}
+
+// UpdateWithHistory saves the facility and records the change history in one transaction.
+// applied are the changes already made to facility; pending are held for admin approval.
+// The changes are saved onto the facility as it is now, so fields edited since it was read
+// are kept, and ErrFacilityChangeStale is returned when a changed field itself was edited
+// since. facility is updated to what was saved. Either returned record is nil when the
+// corresponding list is empty.
+func (r *FacilityRepository) UpdateWithHistory(facility *Facility, changedBy int, applied, pending []FieldChange) (*FacilityChange, *FacilityChange, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Hold off other edits of the facility, including bed holds and room type changes
+	// that update its bed counts, until the change is saved
+	_, err = tx.Exec(`SELECT id FROM facilities WHERE id = $1 FOR UPDATE`, facility.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to lock facility: %w", err)
+	}
+
+	current, err := r.GetByID(facility.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	all := make([]FieldChange, 0, len(applied)+len(pending))
+	stale, err := StaleFieldChanges(ProfileOf(current), append(append(all, applied...), pending...))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(stale) > 0 {
+		return nil, nil, fmt.Errorf("%w: %s", ErrFacilityChangeStale, strings.Join(stale, ", "))
+	}
+
+	var appliedChange, pendingChange *FacilityChange
+
+	if len(applied) > 0 {
+		profile, err := ApplyFieldChanges(ProfileOf(current), applied, false)
+		if err != nil {
+			return nil, nil, err
+		}
+		profile.ApplyTo(current)
+
+		if err := updateFacility(tx, current); err != nil {
+			return nil, nil, err
+		}
+		if changesBeds(applied) {
+			if err := updateFacilityBeds(tx, current); err != nil {
+				return nil, nil, err
+			}
+		}
+		appliedChange, err = insertFacilityChange(tx, current.ID, changedBy, FacilityChangeApplied, applied, current)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if len(pending) > 0 {
+		pendingChange, err = insertFacilityChange(tx, current.ID, changedBy, FacilityChangePending, pending, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	*facility = *current
+	return appliedChange, pendingChange, nil
+}
+
+// changesBeds reports whether changes edit the bed counts of a facility
+func changesBeds(changes []FieldChange) bool {
+	for _, change := range changes {
+		if change.Field == "bed_capacity" || change.Field == "available_beds" {
+			return true
+		}
+	}
+	return false
+}
+
+// insertFacilityChange records a change; snapshotOf is the facility after the change, or nil for pending changes
+func insertFacilityChange(tx *sql.Tx, facilityID, changedBy int, status string, changes []FieldChange, snapshotOf *Facility) (*FacilityChange, error) {
+	changesJSON, err := json.Marshal(changes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode changes: %w", err)
+	}
+
+	var snapshot []byte
+	if snapshotOf != nil {
+		if snapshot, err = json.Marshal(ProfileOf(snapshotOf)); err != nil {
+			return nil, fmt.Errorf("failed to encode snapshot: %w", err)
+		}
+	}
+
+	change := &FacilityChange{
+		FacilityID: facilityID,
+		ChangedBy:  &changedBy,
+		Status:     status,
+		Changes:    changes,
+		Snapshot:   snapshot,
+	}
+	query := `
+		INSERT INTO facility_changes (facility_id, changed_by, status, changes, snapshot)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+	err = tx.QueryRow(query, facilityID, changedBy, status, changesJSON, nullableJSON(snapshot)).Scan(&change.ID, &change.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record facility change: %w", err)
+	}
+
+	return change, nil
+}
+
+func nullableJSON(data []byte) interface{} {
+	if data == nil {
+		return nil
+	}
+	return data
+}
+
+const facilityChangeSelect = `
+	SELECT fc.id, fc.facility_id, f.name, fc.changed_by, u.email, fc.status, fc.changes, fc.snapshot,
+	       fc.reviewed_by, fc.reviewed_at, fc.review_comment, fc.created_at
+	FROM facility_changes fc
+	JOIN facilities f ON f.id = fc.facility_id
+	LEFT JOIN users u ON u.id = fc.changed_by
+`
+
+func scanFacilityChange(row rowScanner) (*FacilityChange, error) {
+	change := &FacilityChange{}
+	var changesJSON, snapshot []byte
+	err := row.Scan(
+		&change.ID, &change.FacilityID, &change.FacilityName, &change.ChangedBy, &change.ChangedByEmail,
+		&change.Status, &changesJSON, &snapshot,
+		&change.ReviewedBy, &change.ReviewedAt, &change.ReviewComment, &change.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(changesJSON, &change.Changes); err != nil {
+		return nil, fmt.Errorf("failed to decode changes: %w", err)
+	}
+	if snapshot != nil {
+		change.Snapshot = snapshot
+	}
+	return change, nil
+}
+
+func (r *FacilityRepository) queryFacilityChanges(query string, args ...interface{}) ([]*FacilityChange, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get facility changes: %w", err)
+	}
+	defer rows.Close()
+
+	changes := []*FacilityChange{}
+	for rows.Next() {
+		change, err := scanFacilityChange(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan facility change: %w", err)
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, rows.Err()
+}
+
+// GetChangesByFacilityID returns the change history of a facility, newest first
+func (r *FacilityRepository) GetChangesByFacilityID(facilityID int) ([]*FacilityChange, error) {
+	return r.queryFacilityChanges(facilityChangeSelect+` WHERE fc.facility_id = $1 ORDER BY fc.id DESC`, facilityID)
+}
+
+// GetChangesByStatus returns changes of every facility with the given status, oldest first
+func (r *FacilityRepository) GetChangesByStatus(status string) ([]*FacilityChange, error) {
+	return r.queryFacilityChanges(facilityChangeSelect+` WHERE fc.status = $1 ORDER BY fc.id`, status)
+}
+
+// GetChangeByID returns a single facility change
+func (r *FacilityRepository) GetChangeByID(id int) (*FacilityChange, error) {
+	change, err := scanFacilityChange(r.db.QueryRow(facilityChangeSelect+` WHERE fc.id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrFacilityChangeNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get facility change: %w", err)
+	}
+	return change, nil
+}
+
+// GetLatestSnapshotChange returns the most recent change of a facility that carries a snapshot
+func (r *FacilityRepository) GetLatestSnapshotChange(facilityID int) (*FacilityChange, error) {
+	query := facilityChangeSelect + ` WHERE fc.facility_id = $1 AND fc.snapshot IS NOT NULL ORDER BY fc.id DESC LIMIT 1`
+	change, err := scanFacilityChange(r.db.QueryRow(query, facilityID))
+	if err == sql.ErrNoRows {
+		return nil, ErrFacilityChangeNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get facility change: %w", err)
+	}
+	return change, nil
+}
+
+// ApproveChange applies a pending change to the facility and marks it approved.
+// It returns ErrFacilityChangeStale when a changed field no longer holds the value the
+// change was made against, so an approval never overwrites a later edit, and
+// ErrFacilityChangeMerged when the facility has been merged into another.
+func (r *FacilityRepository) ApproveChange(changeID, reviewerID int) (*FacilityChange, *Facility, error) {
+	change, err := r.GetChangeByID(changeID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if change.Status != FacilityChangePending {
+		return nil, nil, ErrFacilityChangeNotPending
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Hold off other edits of the facility until the approval is saved
+	var mergedInto *int
+	err = tx.QueryRow(`SELECT merged_into_id FROM facilities WHERE id = $1 FOR UPDATE`, change.FacilityID).Scan(&mergedInto)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to lock facility: %w", err)
+	}
+	if mergedInto != nil {
+		return nil, nil, ErrFacilityChangeMerged
+	}
+
+	facility, err := r.GetByID(change.FacilityID)
+	if err != nil {
+		return nil, nil, err
+	}
+	stale, err := StaleFieldChanges(ProfileOf(facility), change.Changes)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(stale) > 0 {
+		return nil, nil, fmt.Errorf("%w: %s", ErrFacilityChangeStale, strings.Join(stale, ", "))
+	}
+
+	profile, err := ApplyFieldChanges(ProfileOf(facility), change.Changes, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	profile.ApplyTo(facility)
+
+	snapshot, err := json.Marshal(ProfileOf(facility))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+
+	// Only one reviewer can win the transition out of pending
+	query := `
+		UPDATE facility_changes
+		SET status = $1, reviewed_by = $2, reviewed_at = CURRENT_TIMESTAMP, snapshot = $3
+		WHERE id = $4 AND status = $5
+		RETURNING reviewed_at
+	`
+	err = tx.QueryRow(query, FacilityChangeApproved, reviewerID, snapshot, changeID, FacilityChangePending).Scan(&change.ReviewedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil, ErrFacilityChangeNotPending
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to approve facility change: %w", err)
+	}
+
+	if err := updateFacility(tx, facility); err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	change.Status = FacilityChangeApproved
+	change.ReviewedBy = &reviewerID
+	change.Snapshot = snapshot
+	return change, facility, nil
+}
+
+// RejectChange discards a pending change, keeping it in the history with the reviewer's comment
+func (r *FacilityRepository) RejectChange(changeID, reviewerID int, comment string) (*FacilityChange, error) {
+	query := `
+		UPDATE facility_changes
+		SET status = $1, reviewed_by = $2, reviewed_at = CURRENT_TIMESTAMP, review_comment = NULLIF($3, '')
+		WHERE id = $4 AND status = $5
+	`
+	result, err := r.db.Exec(query, FacilityChangeRejected, reviewerID, comment, changeID, FacilityChangePending)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reject facility change: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	change, err := r.GetChangeByID(changeID)
+	if err != nil {
+		return nil, err
+	}
+	if rowsAffected == 0 {
+		return nil, ErrFacilityChangeNotPending
+	}
+
+	return change, nil
+}
@@ -0,0 +1,113 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffProfiles(t *testing.T) {
+	fee := 150000
+	newFee := 160000
+	before := FacilityProfile{
+		Name:                     "Sakura",
+		BedCapacity:              20,
+		MonthlyFee:               &fee,
+		AcceptanceConditionsJSON: json.RawMessage(`{"oxygen": true, "dialysis": false}`),
+	}
+
+	t.Run("no changes", func(t *testing.T) {
+		// Key order and whitespace in JSON columns are not changes
+		after := before
+		after.AcceptanceConditionsJSON = json.RawMessage(`{"dialysis":false,"oxygen":true}`)
+
+		changes, err := DiffProfiles(before, after)
+		require.NoError(t, err)
+		assert.Empty(t, changes)
+	})
+
+	t.Run("changed fields", func(t *testing.T) {
+		after := before
+		after.Name = "Sakura Home"
+		after.MonthlyFee = &newFee
+		after.Description = nil
+
+		changes, err := DiffProfiles(before, after)
+		require.NoError(t, err)
+		require.Len(t, changes, 2)
+
+		assert.Equal(t, "monthly_fee", changes[0].Field)
+		assert.JSONEq(t, `150000`, string(changes[0].Old))
+		assert.JSONEq(t, `160000`, string(changes[0].New))
+		assert.Equal(t, "name", changes[1].Field)
+		assert.JSONEq(t, `"Sakura"`, string(changes[1].Old))
+		assert.JSONEq(t, `"Sakura Home"`, string(changes[1].New))
+	})
+
+	t.Run("nil to value", func(t *testing.T) {
+		after := before
+		after.MonthlyFee = nil
+
+		changes, err := DiffProfiles(before, after)
+		require.NoError(t, err)
+		require.Len(t, changes, 1)
+		assert.JSONEq(t, `null`, string(changes[0].New))
+	})
+}
+
+func TestApplyFieldChanges(t *testing.T) {
+	fee := 150000
+	newFee := 160000
+	before := FacilityProfile{Name: "Sakura", MonthlyFee: &fee, FacilityType: "介護施設"}
+	after := FacilityProfile{Name: "Sakura Home", MonthlyFee: &newFee, FacilityType: "有料老人ホーム"}
+
+	changes, err := DiffProfiles(before, after)
+	require.NoError(t, err)
+
+	sensitive, other := SplitSensitiveChanges(changes)
+	require.Len(t, sensitive, 2)
+	require.Len(t, other, 1)
+	assert.Equal(t, "name", other[0].Field)
+
+	// Reverting the held fields keeps the rest of the edit
+	reverted, err := ApplyFieldChanges(after, sensitive, true)
+	require.NoError(t, err)
+	assert.Equal(t, "Sakura Home", reverted.Name)
+	assert.Equal(t, fee, *reverted.MonthlyFee)
+	assert.Equal(t, "介護施設", reverted.FacilityType)
+
+	// Approving them later applies the new values
+	approved, err := ApplyFieldChanges(reverted, sensitive, false)
+	require.NoError(t, err)
+	assert.Equal(t, after, approved)
+
+	_, err = ApplyFieldChanges(before, []FieldChange{{Field: "password", New: json.RawMessage(`"x"`)}}, false)
+	assert.Error(t, err)
+}
+
+func TestStaleFieldChanges(t *testing.T) {
+	fee := 150000
+	newFee := 160000
+	before := FacilityProfile{Name: "Sakura", MonthlyFee: &fee, FacilityType: "介護施設"}
+	after := FacilityProfile{Name: "Sakura", MonthlyFee: &newFee, FacilityType: "有料老人ホーム"}
+
+	changes, err := DiffProfiles(before, after)
+	require.NoError(t, err)
+
+	stale, err := StaleFieldChanges(before, changes)
+	require.NoError(t, err)
+	assert.Empty(t, stale)
+
+	// The fee was edited again after the change was held for approval
+	otherFee := 155000
+	edited := before
+	edited.MonthlyFee = &otherFee
+	stale, err = StaleFieldChanges(edited, changes)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"monthly_fee"}, stale)
+
+	_, err = StaleFieldChanges(before, []FieldChange{{Field: "password", Old: json.RawMessage(`"x"`)}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,497 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/text/width"
+)
+
+// Facility import actions
+const (
+	ImportActionCreate    = "create"
+	ImportActionUpdate    = "update"
+	ImportActionUnchanged = "unchanged"
+)
+
+// ImportColumn is a column of the facility import/export sheet. Headers are matched
+// against the Japanese label (as exported) or the key, ignoring case and spaces.
+type ImportColumn struct {
+	Key     string
+	Label   string
+	Aliases []string
+}
+
+// FacilityImportColumns lists the exported columns in order. A password column
+// (パスワード) is also accepted on import for new accounts but never exported.
+var FacilityImportColumns = []ImportColumn{
+	{Key: "email", Label: "メールアドレス", Aliases: []string{"ログインid", "e-mail"}},
+	{Key: "name", Label: "施設名", Aliases: []string{"事業所名", "名称"}},
+	{Key: "facility_type", Label: "施設種別", Aliases: []string{"種別", "サービス種別"}},
+	{Key: "address", Label: "住所", Aliases: []string{"所在地"}},
+	{Key: "phone", Label: "電話番号", Aliases: []string{"電話", "tel"}},
+	{Key: "bed_capacity", Label: "定員", Aliases: []string{"入所定員", "病床数"}},
+	{Key: "available_beds", Label: "空床数", Aliases: []string{"空き"}},
+	{Key: "monthly_fee", Label: "月額費用", Aliases: []string{"月額"}},
+	{Key: "medicine_cost", Label: "薬代"},
+	{Key: "latitude", Label: "緯度"},
+	{Key: "longitude", Label: "経度"},
+	{Key: "acceptance_conditions", Label: "受け入れ条件"},
+	{Key: "description", Label: "施設紹介"},
+	{Key: "contact_name", Label: "担当者名", Aliases: []string{"担当者"}},
+	{Key: "contact_hours", Label: "連絡可能時間"},
+}
+
+var importPasswordColumn = ImportColumn{Key: "password", Label: "パスワード"}
+
+var ErrImportMissingColumns = errors.New("required columns are missing")
+
+// FacilityImportError is a problem with one cell or row of an import file.
+// Line is the 1-based line in the file; 0 means the whole file.
+type FacilityImportError struct {
+	Line    int    `json:"line"`
+	Column  string `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// FacilityImportRecord is one facility row of an import or export sheet. After
+// planning it also says what the import will do with it.
+type FacilityImportRecord struct {
+	Line                 int      `json:"line"`
+	Email                string   `json:"email"`
+	Password             string   `json:"-"`
+	Name                 string   `json:"name"`
+	FacilityType         string   `json:"facility_type,omitempty"`
+	Address              string   `json:"address"`
+	Phone                string   `json:"phone"`
+	BedCapacity          int      `json:"bed_capacity"`
+	AvailableBeds        int      `json:"available_beds"`
+	MonthlyFee           *int     `json:"monthly_fee,omitempty"`
+	MedicineCost         *int     `json:"medicine_cost,omitempty"`
+	Latitude             *float64 `json:"latitude,omitempty"`
+	Longitude            *float64 `json:"longitude,omitempty"`
+	AcceptanceConditions string   `json:"acceptance_conditions"`
+	Description          *string  `json:"description,omitempty"`
+	ContactName          *string  `json:"contact_name,omitempty"`
+	ContactHours         *string  `json:"contact_hours,omitempty"`
+
+	Action          string        `json:"action,omitempty"`
+	UserID          int           `json:"user_id,omitempty"`
+	FacilityID      int           `json:"facility_id,omitempty"`
+	Changes         []FieldChange `json:"changes,omitempty"`
+	Warnings        []string      `json:"warnings,omitempty"`
+	InitialPassword string        `json:"initial_password,omitempty"` // generated for new accounts without a password column
+	Facility        *Facility     `json:"-"`                          // the facility as it will be saved
+}
+
+// ImportAccount is the existing user (and facility) an import row's email refers to
+type ImportAccount struct {
+	UserID     int
+	Role       string
+	FacilityID *int
+}
+
+func normalizeHeader(s string) string {
+	s = width.Fold.String(strings.TrimSpace(strings.TrimPrefix(s, "\ufeff")))
+	s = strings.ToLower(strings.ReplaceAll(strings.ReplaceAll(s, " ", ""), "　", ""))
+	return s
+}
+
+// matchImportColumns maps each known column key to its index in the header row.
+// Unknown headers are returned so the caller can report them.
+func matchImportColumns(header []string) (map[string]int, []string) {
+	lookup := map[string]string{}
+	for _, col := range append(FacilityImportColumns, importPasswordColumn) {
+		lookup[normalizeHeader(col.Key)] = col.Key
+		lookup[normalizeHeader(col.Label)] = col.Key
+		for _, alias := range col.Aliases {
+			lookup[normalizeHeader(alias)] = col.Key
+		}
+	}
+
+	indexes := map[string]int{}
+	ignored := []string{}
+	for i, h := range header {
+		if strings.TrimSpace(h) == "" {
+			continue
+		}
+		key, ok := lookup[normalizeHeader(h)]
+		if !ok {
+			ignored = append(ignored, h)
+			continue
+		}
+		if _, dup := indexes[key]; !dup {
+			indexes[key] = i
+		}
+	}
+	return indexes, ignored
+}
+
+// importNumber reads a number written the way directories tend to: full-width
+// digits, thousands separators and a trailing unit
+func importNumber(s string) string {
+	s = width.Fold.String(strings.TrimSpace(s))
+	s = strings.ReplaceAll(s, ",", "")
+	for _, unit := range []string{"円", "床", "人", "名"} {
+		s = strings.TrimSuffix(s, unit)
+	}
+	return strings.TrimSpace(s)
+}
+
+// ParseFacilityImport reads the header row and data rows of an import sheet. Rows with
+// errors are left out of the records. Blank rows are skipped. Headers that match no
+// column are returned as ignored.
+func ParseFacilityImport(rows [][]string) ([]*FacilityImportRecord, []FacilityImportError, []string, error) {
+	if len(rows) == 0 {
+		return nil, nil, nil, fmt.Errorf("%w: the file is empty", ErrImportMissingColumns)
+	}
+
+	indexes, ignored := matchImportColumns(rows[0])
+	missing := []string{}
+	for _, key := range []string{"email", "name"} {
+		if _, ok := indexes[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, nil, ignored, fmt.Errorf("%w: %s", ErrImportMissingColumns, strings.Join(missing, ", "))
+	}
+
+	records := []*FacilityImportRecord{}
+	rowErrors := []FacilityImportError{}
+	seenEmails := map[string]int{}
+
+	for i, row := range rows[1:] {
+		line := i + 2
+		cell := func(key string) string {
+			idx, ok := indexes[key]
+			if !ok || idx >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[idx])
+		}
+
+		blank := true
+		for _, v := range row {
+			if strings.TrimSpace(v) != "" {
+				blank = false
+				break
+			}
+		}
+		if blank {
+			continue
+		}
+
+		errCount := len(rowErrors)
+		fail := func(column, format string, args ...interface{}) {
+			rowErrors = append(rowErrors, FacilityImportError{Line: line, Column: column, Message: fmt.Sprintf(format, args...)})
+		}
+		optionalInt := func(key string) *int {
+			v := importNumber(cell(key))
+			if v == "" {
+				return nil
+			}
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				fail(key, "must be a non-negative whole number: %q", cell(key))
+				return nil
+			}
+			return &n
+		}
+		optionalFloat := func(key string, min, max float64) *float64 {
+			v := importNumber(cell(key))
+			if v == "" {
+				return nil
+			}
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil || f < min || f > max {
+				fail(key, "must be a number between %g and %g: %q", min, max, cell(key))
+				return nil
+			}
+			return &f
+		}
+		optionalText := func(key string) *string {
+			if v := cell(key); v != "" {
+				return &v
+			}
+			return nil
+		}
+
+		rec := &FacilityImportRecord{
+			Line:                 line,
+			Email:                strings.ToLower(width.Fold.String(cell("email"))),
+			Password:             cell("password"),
+			Name:                 cell("name"),
+			FacilityType:         cell("facility_type"),
+			Address:              cell("address"),
+			Phone:                width.Fold.String(cell("phone")),
+			MonthlyFee:           optionalInt("monthly_fee"),
+			MedicineCost:         optionalInt("medicine_cost"),
+			Latitude:             optionalFloat("latitude", -90, 90),
+			Longitude:            optionalFloat("longitude", -180, 180),
+			AcceptanceConditions: cell("acceptance_conditions"),
+			Description:          optionalText("description"),
+			ContactName:          optionalText("contact_name"),
+			ContactHours:         optionalText("contact_hours"),
+		}
+
+		if rec.Email == "" {
+			fail("email", "is required")
+		} else if addr, err := mail.ParseAddress(rec.Email); err != nil || addr.Address != rec.Email {
+			fail("email", "is not a valid email address: %q", rec.Email)
+		} else if first, dup := seenEmails[rec.Email]; dup {
+			fail("email", "is already used on line %d", first)
+		} else {
+			seenEmails[rec.Email] = line
+		}
+		if rec.Name == "" {
+			fail("name", "is required")
+		}
+		if rec.Password != "" && len(rec.Password) < 8 {
+			fail("password", "must be at least 8 characters")
+		}
+		if (rec.Latitude == nil) != (rec.Longitude == nil) && len(rowErrors) == errCount {
+			fail("latitude", "latitude and longitude must be given together")
+		}
+		if v := optionalInt("bed_capacity"); v != nil {
+			rec.BedCapacity = *v
+		}
+		if v := optionalInt("available_beds"); v != nil {
+			rec.AvailableBeds = *v
+		}
+		if rec.AvailableBeds > rec.BedCapacity {
+			fail("available_beds", "(%d) cannot exceed bed_capacity (%d)", rec.AvailableBeds, rec.BedCapacity)
+		}
+
+		if len(rowErrors) == errCount {
+			records = append(records, rec)
+		}
+	}
+
+	return records, rowErrors, ignored, nil
+}
+
+// ApplyTo writes the record onto a facility. Blank facility types and coordinates
+// keep the facility's current values. Bed counts are left alone for facilities with
+// room types, whose totals are derived from the room types.
+func (rec *FacilityImportRecord) ApplyTo(f *Facility, hasRoomTypes bool) {
+	f.Name = rec.Name
+	f.Address = rec.Address
+	f.Phone = rec.Phone
+	f.MonthlyFee = rec.MonthlyFee
+	f.MedicineCost = rec.MedicineCost
+	f.AcceptanceConditions = rec.AcceptanceConditions
+	f.Description = rec.Description
+	f.ContactName = rec.ContactName
+	f.ContactHours = rec.ContactHours
+	if rec.FacilityType != "" {
+		f.FacilityType = rec.FacilityType
+	}
+	if rec.Latitude != nil && rec.Longitude != nil {
+		f.Latitude = rec.Latitude
+		f.Longitude = rec.Longitude
+	}
+	if !hasRoomTypes {
+		f.BedCapacity = rec.BedCapacity
+		f.AvailableBeds = rec.AvailableBeds
+	}
+}
+
+// ImportHeader returns the header row of an export sheet
+func ImportHeader() []string {
+	header := make([]string, len(FacilityImportColumns))
+	for i, col := range FacilityImportColumns {
+		header[i] = col.Label
+	}
+	return header
+}
+
+// Cells returns the record as a sheet row in the order of FacilityImportColumns
+func (rec *FacilityImportRecord) Cells() []string {
+	text := func(s *string) string {
+		if s == nil {
+			return ""
+		}
+		return *s
+	}
+	number := func(n *int) string {
+		if n == nil {
+			return ""
+		}
+		return strconv.Itoa(*n)
+	}
+	coordinate := func(f *float64) string {
+		if f == nil {
+			return ""
+		}
+		return strconv.FormatFloat(*f, 'f', -1, 64)
+	}
+
+	return []string{
+		rec.Email,
+		rec.Name,
+		rec.FacilityType,
+		rec.Address,
+		rec.Phone,
+		strconv.Itoa(rec.BedCapacity),
+		strconv.Itoa(rec.AvailableBeds),
+		number(rec.MonthlyFee),
+		number(rec.MedicineCost),
+		coordinate(rec.Latitude),
+		coordinate(rec.Longitude),
+		rec.AcceptanceConditions,
+		text(rec.Description),
+		text(rec.ContactName),
+		text(rec.ContactHours),
+	}
+}
+
+// ResolveImportFacilityType returns the facility type named by its full or short name.
+// Directories often use short names such as 特養.
+func (r *FacilityRepository) ResolveImportFacilityType(name string) (*FacilityType, error) {
+	t, err := getFacilityType(r.db, `WHERE name = $1`, name)
+	if errors.Is(err, ErrFacilityTypeNotFound) {
+		t, err = getFacilityType(r.db, `WHERE short_name = $1 ORDER BY sort_order LIMIT 1`, name)
+	}
+	return t, err
+}
+
+// LookupImportAccounts returns the existing users, by email, of an import
+func (r *FacilityRepository) LookupImportAccounts(emails []string) (map[string]*ImportAccount, error) {
+	rows, err := r.db.Query(`
+		SELECT LOWER(u.email), u.id, u.role, f.id
+		FROM users u
+		LEFT JOIN facilities f ON f.user_id = u.id
+		WHERE LOWER(u.email) = ANY($1)
+	`, pq.Array(emails))
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up accounts: %w", err)
+	}
+	defer rows.Close()
+
+	accounts := map[string]*ImportAccount{}
+	for rows.Next() {
+		var email string
+		a := &ImportAccount{}
+		if err := rows.Scan(&email, &a.UserID, &a.Role, &a.FacilityID); err != nil {
+			return nil, fmt.Errorf("failed to scan account: %w", err)
+		}
+		accounts[email] = a
+	}
+
+	return accounts, rows.Err()
+}
+
+// HasRoomTypes reports whether a facility manages its beds through room types
+func (r *FacilityRepository) HasRoomTypes(facilityID int) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM facility_room_types WHERE facility_id = $1)`, facilityID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check room types: %w", err)
+	}
+	return exists, nil
+}
+
+// ImportFacilities saves planned import records in one transaction: new accounts and
+// facilities are created, and updates are recorded in the facility change history
+func (r *FacilityRepository) ImportFacilities(records []*FacilityImportRecord, changedBy int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, rec := range records {
+		switch rec.Action {
+		case ImportActionCreate:
+			if err := insertImportedFacility(tx, rec); err != nil {
+				return fmt.Errorf("line %d: %w", rec.Line, err)
+			}
+		case ImportActionUpdate:
+			if err := updateFacility(tx, rec.Facility); err != nil {
+				return fmt.Errorf("line %d: %w", rec.Line, err)
+			}
+			if err := updateFacilityBeds(tx, rec.Facility); err != nil {
+				return fmt.Errorf("line %d: %w", rec.Line, err)
+			}
+			if _, err := insertFacilityChange(tx, rec.FacilityID, changedBy, FacilityChangeApplied, rec.Changes, rec.Facility); err != nil {
+				return fmt.Errorf("line %d: %w", rec.Line, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func insertImportedFacility(tx *sql.Tx, rec *FacilityImportRecord) error {
+	if rec.UserID == 0 {
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(rec.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return fmt.Errorf("failed to hash password: %w", err)
+		}
+		err = tx.QueryRow(`
+			INSERT INTO users (email, password_hash, role) VALUES ($1, $2, 'facility') RETURNING id
+		`, rec.Email, string(hashedPassword)).Scan(&rec.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+	}
+
+	f := rec.Facility
+	f.UserID = rec.UserID
+	err := tx.QueryRow(`
+		INSERT INTO facilities (user_id, name, address, phone, bed_capacity, available_beds, acceptance_conditions,
+		                        latitude, longitude, monthly_fee, medicine_cost, facility_type,
+		                        description, contact_name, contact_hours, type_attributes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, '{}')
+		RETURNING id
+	`, f.UserID, f.Name, f.Address, f.Phone, f.BedCapacity, f.AvailableBeds, f.AcceptanceConditions,
+		f.Latitude, f.Longitude, f.MonthlyFee, f.MedicineCost, f.FacilityType,
+		f.Description, f.ContactName, f.ContactHours).Scan(&f.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create facility: %w", err)
+	}
+	rec.FacilityID = f.ID
+
+	return nil
+}
+
+// ExportFacilities returns every facility with its login email as import records
+func (r *FacilityRepository) ExportFacilities() ([]*FacilityImportRecord, error) {
+	rows, err := r.db.Query(`
+		SELECT f.id, u.email, f.name, f.facility_type, COALESCE(f.address, ''), COALESCE(f.phone, ''),
+		       f.bed_capacity, f.available_beds, f.monthly_fee, f.medicine_cost, f.latitude, f.longitude,
+		       COALESCE(f.acceptance_conditions, ''), f.description, f.contact_name, f.contact_hours
+		FROM facilities f
+		JOIN users u ON f.user_id = u.id
+		ORDER BY f.id ASC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export facilities: %w", err)
+	}
+	defer rows.Close()
+
+	records := []*FacilityImportRecord{}
+	for rows.Next() {
+		rec := &FacilityImportRecord{}
+		err := rows.Scan(&rec.FacilityID, &rec.Email, &rec.Name, &rec.FacilityType, &rec.Address, &rec.Phone,
+			&rec.BedCapacity, &rec.AvailableBeds, &rec.MonthlyFee, &rec.MedicineCost, &rec.Latitude, &rec.Longitude,
+			&rec.AcceptanceConditions, &rec.Description, &rec.ContactName, &rec.ContactHours)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan facility: %w", err)
+		}
+		records = append(records, rec)
+	}
+
+	return records, rows.Err()
+}
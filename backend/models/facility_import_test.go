@@ -0,0 +1,92 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFacilityImport(t *testing.T) {
+	rows := [][]string{
+		{"\ufeffメールアドレス", "事業所名", "所在地", "定員", "空床数", "月額費用", "緯度", "経度", "施設種別", "備考"},
+		{"Sakura@Example.com", "さくら苑", "東京都世田谷区北沢2-19-12", "３０", "2", "180,000円", "35.6604", "139.6681", "特養", "ignored"},
+		{"", "", "", "", "", "", "", "", "", ""},
+		{"not-an-email", "ひまわり", "", "20", "", "", "", "", "", ""},
+		{"sakura@example.com", "さくら苑 別館", "", "", "", "", "", "", "", ""},
+		{"momiji@example.com", "もみじ", "", "10", "12", "", "35.1", "", "", ""},
+		{"kaede@example.com", "かえで", "", "-1", "", "abc", "", "", "", ""},
+	}
+
+	records, rowErrors, ignored, err := ParseFacilityImport(rows)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"備考"}, ignored)
+
+	require.Len(t, records, 1)
+	rec := records[0]
+	assert.Equal(t, 2, rec.Line)
+	assert.Equal(t, "sakura@example.com", rec.Email)
+	assert.Equal(t, "さくら苑", rec.Name)
+	assert.Equal(t, "特養", rec.FacilityType)
+	assert.Equal(t, 30, rec.BedCapacity)
+	assert.Equal(t, 2, rec.AvailableBeds)
+	require.NotNil(t, rec.MonthlyFee)
+	assert.Equal(t, 180000, *rec.MonthlyFee)
+	require.NotNil(t, rec.Latitude)
+	assert.Equal(t, 35.6604, *rec.Latitude)
+
+	lines := map[int][]string{}
+	for _, e := range rowErrors {
+		lines[e.Line] = append(lines[e.Line], e.Column)
+	}
+	assert.Equal(t, map[int][]string{
+		4: {"email"},
+		5: {"email"}, // duplicate of line 2
+		6: {"latitude", "available_beds"},
+		7: {"monthly_fee", "bed_capacity"},
+	}, lines)
+}
+
+func TestParseFacilityImport_MissingColumns(t *testing.T) {
+	_, _, _, err := ParseFacilityImport([][]string{{"施設名", "住所"}})
+	assert.ErrorIs(t, err, ErrImportMissingColumns)
+
+	_, _, _, err = ParseFacilityImport(nil)
+	assert.ErrorIs(t, err, ErrImportMissingColumns)
+}
+
+func TestFacilityImportRecord_ExportRoundTrip(t *testing.T) {
+	fee, lat, lng := 150000, 35.6896, 139.6987
+	desc := "説明"
+	rec := &FacilityImportRecord{
+		Email: "hinoki@example.com", Name: "ひのき", FacilityType: "グループホーム", Address: "東京都新宿区西新宿1-26-2",
+		Phone: "03-1111-2222", BedCapacity: 18, AvailableBeds: 1, MonthlyFee: &fee,
+		Latitude: &lat, Longitude: &lng, AcceptanceConditions: "認知症対応", Description: &desc,
+	}
+
+	records, rowErrors, ignored, err := ParseFacilityImport([][]string{ImportHeader(), rec.Cells()})
+	require.NoError(t, err)
+	assert.Empty(t, rowErrors)
+	assert.Empty(t, ignored)
+	require.Len(t, records, 1)
+
+	got := records[0]
+	got.Line = 0
+	assert.Equal(t, rec, got)
+}
+
+func TestFacilityImportRecord_ApplyTo(t *testing.T) {
+	lat, lng := 35.0, 139.0
+	f := &Facility{FacilityType: "介護施設", BedCapacity: 40, AvailableBeds: 3, Latitude: &lat, Longitude: &lng}
+	rec := &FacilityImportRecord{Name: "新名称", BedCapacity: 10, AvailableBeds: 1}
+
+	rec.ApplyTo(f, true)
+	assert.Equal(t, "新名称", f.Name)
+	assert.Equal(t, "介護施設", f.FacilityType, "blank type keeps the current type")
+	assert.Equal(t, &lat, f.Latitude, "blank coordinates keep the current ones")
+	assert.Equal(t, 40, f.BedCapacity, "room types own the bed counts")
+
+	rec.ApplyTo(f, false)
+	assert.Equal(t, 10, f.BedCapacity)
+	assert.Equal(t, 1, f.AvailableBeds)
+}
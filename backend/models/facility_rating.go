@@ -0,0 +1,185 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// FacilityRating is a hospital's post-completion rating of a placement.
+// Comment is only shown to the rating hospital and admins.
+type FacilityRating struct {
+	ID               int        `json:"id"`
+	RoomID           string     `json:"room_id,omitempty"`
+	FacilityID       int        `json:"facility_id"`
+	FacilityName     string     `json:"facility_name,omitempty"`
+	HospitalID       int        `json:"hospital_id,omitempty"`
+	HospitalName     string     `json:"hospital_name,omitempty"`
+	RatedBy          *int       `json:"rated_by,omitempty"`
+	Responsiveness   int        `json:"responsiveness"`
+	Accuracy         int        `json:"accuracy"`
+	Outcome          int        `json:"outcome"`
+	Comment          *string    `json:"comment,omitempty"`
+	IsHidden         bool       `json:"is_hidden"`
+	ModerationReason *string    `json:"moderation_reason,omitempty"`
+	ModeratedBy      *int       `json:"moderated_by,omitempty"`
+	ModeratedAt      *time.Time `json:"moderated_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// FacilityRatingSummary aggregates the visible ratings of a facility
+type FacilityRatingSummary struct {
+	FacilityID     int      `json:"facility_id"`
+	Count          int      `json:"count"`
+	Responsiveness *float64 `json:"responsiveness,omitempty"`
+	Accuracy       *float64 `json:"accuracy,omitempty"`
+	Outcome        *float64 `json:"outcome,omitempty"`
+	Overall        *float64 `json:"overall,omitempty"`
+}
+
+const facilityRatingSelect = `
+	SELECT r.id, r.room_id, r.facility_id, f.name, r.hospital_id, h.name, r.rated_by,
+	       r.responsiveness, r.accuracy, r.outcome, r.comment,
+	       r.is_hidden, r.moderation_reason, r.moderated_by, r.moderated_at,
+	       r.created_at, r.updated_at
+	FROM facility_ratings r
+	JOIN facilities f ON f.id = r.facility_id
+	JOIN hospitals h ON h.id = r.hospital_id
+`
+
+func scanFacilityRating(row rowScanner) (*FacilityRating, error) {
+	r := &FacilityRating{}
+	err := row.Scan(
+		&r.ID, &r.RoomID, &r.FacilityID, &r.FacilityName, &r.HospitalID, &r.HospitalName, &r.RatedBy,
+		&r.Responsiveness, &r.Accuracy, &r.Outcome, &r.Comment,
+		&r.IsHidden, &r.ModerationReason, &r.ModeratedBy, &r.ModeratedAt,
+		&r.CreatedAt, &r.UpdatedAt,
+	)
+	return r, err
+}
+
+func queryFacilityRatings(db *sql.DB, query string, args ...interface{}) ([]*FacilityRating, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ratings := []*FacilityRating{}
+	for rows.Next() {
+		r, err := scanFacilityRating(rows)
+		if err != nil {
+			return nil, err
+		}
+		ratings = append(ratings, r)
+	}
+
+	return ratings, rows.Err()
+}
+
+// SaveFacilityRating creates the rating of a room or replaces the existing one.
+// Editing a rating keeps any moderation decision.
+func SaveFacilityRating(db *sql.DB, r *FacilityRating) error {
+	query := `
+		INSERT INTO facility_ratings (room_id, facility_id, hospital_id, rated_by, responsiveness, accuracy, outcome, comment)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (room_id) DO UPDATE
+		SET rated_by = EXCLUDED.rated_by, responsiveness = EXCLUDED.responsiveness,
+		    accuracy = EXCLUDED.accuracy, outcome = EXCLUDED.outcome, comment = EXCLUDED.comment,
+		    updated_at = CURRENT_TIMESTAMP
+		RETURNING id, is_hidden, created_at, updated_at
+	`
+	return db.QueryRow(
+		query,
+		r.RoomID,
+		r.FacilityID,
+		r.HospitalID,
+		r.RatedBy,
+		r.Responsiveness,
+		r.Accuracy,
+		r.Outcome,
+		r.Comment,
+	).Scan(&r.ID, &r.IsHidden, &r.CreatedAt, &r.UpdatedAt)
+}
+
+// GetFacilityRatingByRoomID retrieves the rating of a room
+func GetFacilityRatingByRoomID(db *sql.DB, roomID string) (*FacilityRating, error) {
+	r, err := scanFacilityRating(db.QueryRow(facilityRatingSelect+` WHERE r.room_id = $1`, roomID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetFacilityRatingByID retrieves a rating by ID
+func GetFacilityRatingByID(db *sql.DB, id int) (*FacilityRating, error) {
+	r, err := scanFacilityRating(db.QueryRow(facilityRatingSelect+` WHERE r.id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetVisibleFacilityRatings retrieves the ratings of a facility that are not hidden, newest first
+func GetVisibleFacilityRatings(db *sql.DB, facilityID int) ([]*FacilityRating, error) {
+	return queryFacilityRatings(db, facilityRatingSelect+` WHERE r.facility_id = $1 AND NOT r.is_hidden ORDER BY r.created_at DESC`, facilityID)
+}
+
+// GetAllFacilityRatings retrieves ratings for moderation, optionally filtered by hidden state
+func GetAllFacilityRatings(db *sql.DB, hidden *bool) ([]*FacilityRating, error) {
+	if hidden != nil {
+		return queryFacilityRatings(db, facilityRatingSelect+` WHERE r.is_hidden = $1 ORDER BY r.created_at DESC`, *hidden)
+	}
+	return queryFacilityRatings(db, facilityRatingSelect+` ORDER BY r.created_at DESC`)
+}
+
+// GetFacilityRatingSummary aggregates the visible ratings of a facility
+func GetFacilityRatingSummary(db *sql.DB, facilityID int) (*FacilityRatingSummary, error) {
+	summary := &FacilityRatingSummary{FacilityID: facilityID}
+	query := `
+		SELECT COUNT(*),
+		       ROUND(AVG(responsiveness), 2)::float8,
+		       ROUND(AVG(accuracy), 2)::float8,
+		       ROUND(AVG(outcome), 2)::float8,
+		       ROUND(AVG((responsiveness + accuracy + outcome) / 3.0), 2)::float8
+		FROM facility_ratings
+		WHERE facility_id = $1 AND NOT is_hidden
+	`
+	err := db.QueryRow(query, facilityID).Scan(
+		&summary.Count, &summary.Responsiveness, &summary.Accuracy, &summary.Outcome, &summary.Overall,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// ModerateFacilityRating hides or restores a rating
+func ModerateFacilityRating(db *sql.DB, id int, hidden bool, reason *string, moderatorID int) error {
+	query := `
+		UPDATE facility_ratings
+		SET is_hidden = $1, moderation_reason = $2, moderated_by = $3, moderated_at = CURRENT_TIMESTAMP
+		WHERE id = $4
+	`
+	result, err := db.Exec(query, hidden, reason, moderatorID, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
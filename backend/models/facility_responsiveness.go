@@ -0,0 +1,69 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// FacilityResponsiveness holds metrics computed from past requests and room messages.
+// Values come from the facility_responsiveness materialized view and lag behind by
+// up to one refresh interval.
+type FacilityResponsiveness struct {
+	RequestCount            int       `json:"request_count"`
+	RespondedCount          int       `json:"responded_count"`
+	MedianResponseHours     *float64  `json:"median_response_hours,omitempty"`      // request creation to accept/reject
+	AcceptanceRate          *float64  `json:"acceptance_rate,omitempty"`            // accepted / responded, 0-1
+	MedianFirstMessageHours *float64  `json:"median_first_message_hours,omitempty"` // room creation to the facility's first message
+	RefreshedAt             time.Time `json:"refreshed_at"`
+}
+
+// responsivenessSelect lists the view columns in the order expected by responsivenessScan.
+// Queries must LEFT JOIN facility_responsiveness fr ON fr.facility_id = facilities.id.
+const responsivenessSelect = `fr.request_count, fr.responded_count, fr.median_response_hours,
+		       fr.acceptance_rate, fr.median_first_message_hours, fr.refreshed_at`
+
+// responsivenessScan receives the nullable columns of a LEFT JOINed metrics row
+type responsivenessScan struct {
+	requestCount            sql.NullInt64
+	respondedCount          sql.NullInt64
+	medianResponseHours     sql.NullFloat64
+	acceptanceRate          sql.NullFloat64
+	medianFirstMessageHours sql.NullFloat64
+	refreshedAt             sql.NullTime
+}
+
+func (s *responsivenessScan) dest() []interface{} {
+	return []interface{}{
+		&s.requestCount, &s.respondedCount, &s.medianResponseHours,
+		&s.acceptanceRate, &s.medianFirstMessageHours, &s.refreshedAt,
+	}
+}
+
+// value returns nil for facilities that have not received any request yet
+func (s *responsivenessScan) value() *FacilityResponsiveness {
+	if !s.requestCount.Valid {
+		return nil
+	}
+	return &FacilityResponsiveness{
+		RequestCount:            int(s.requestCount.Int64),
+		RespondedCount:          int(s.respondedCount.Int64),
+		MedianResponseHours:     nullFloat(s.medianResponseHours),
+		AcceptanceRate:          nullFloat(s.acceptanceRate),
+		MedianFirstMessageHours: nullFloat(s.medianFirstMessageHours),
+		RefreshedAt:             s.refreshedAt.Time,
+	}
+}
+
+func nullFloat(v sql.NullFloat64) *float64 {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Float64
+}
+
+// RefreshFacilityResponsiveness recomputes the facility_responsiveness view without
+// blocking concurrent searches
+func RefreshFacilityResponsiveness(db *sql.DB) error {
+	_, err := db.Exec(`REFRESH MATERIALIZED VIEW CONCURRENTLY facility_responsiveness`)
+	return err
+}
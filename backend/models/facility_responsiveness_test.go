@@ -0,0 +1,66 @@
+package models
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/social-worker-platform/backend/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponsivenessScan_NoHistory(t *testing.T) {
+	var s responsivenessScan
+	assert.Nil(t, s.value())
+}
+
+func TestResponsivenessScan_Value(t *testing.T) {
+	refreshed := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	s := responsivenessScan{
+		requestCount:        sql.NullInt64{Int64: 4, Valid: true},
+		respondedCount:      sql.NullInt64{Int64: 3, Valid: true},
+		medianResponseHours: sql.NullFloat64{Float64: 5.5, Valid: true},
+		acceptanceRate:      sql.NullFloat64{Float64: 2.0 / 3.0, Valid: true},
+		refreshedAt:         sql.NullTime{Time: refreshed, Valid: true},
+	}
+
+	r := s.value()
+	require.NotNil(t, r)
+	assert.Equal(t, 4, r.RequestCount)
+	assert.Equal(t, 3, r.RespondedCount)
+	require.NotNil(t, r.MedianResponseHours)
+	assert.Equal(t, 5.5, *r.MedianResponseHours)
+	assert.InDelta(t, 0.667, *r.AcceptanceRate, 0.001)
+	assert.Nil(t, r.MedianFirstMessageHours)
+	assert.Equal(t, refreshed, r.RefreshedAt)
+}
+
+func TestRefreshFacilityResponsiveness_CountsRecordedResponse(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping database tests in short mode")
+	}
+
+	db := config.SetupTestDatabase(t)
+	defer config.TeardownTestDatabase(t, db)
+
+	req := createTestPlacementRequest(t, db)
+	moveRequest := func(from, to string) {
+		require.NoError(t, UpdatePlacementRequestStatus(db, req.ID, from, to))
+		require.NoError(t, RecordStatusTransition(db, &StatusTransition{
+			Entity: StatusEntityRequest, RequestID: req.ID, FromStatus: &from, ToStatus: to,
+		}))
+	}
+
+	// The hospital withdrawing after the facility accepted does not undo the acceptance
+	moveRequest("pending", "accepted")
+	moveRequest("accepted", "withdrawn")
+	require.NoError(t, RefreshFacilityResponsiveness(db))
+
+	facility, err := NewFacilityRepository(db).GetByID(req.FacilityID)
+	require.NoError(t, err)
+	require.NotNil(t, facility.Responsiveness)
+	assert.Equal(t, 1, facility.Responsiveness.RespondedCount)
+	require.NotNil(t, facility.Responsiveness.AcceptanceRate)
+	assert.Equal(t, 1.0, *facility.Responsiveness.AcceptanceRate)
+}
@@ -2,9 +2,11 @@ package models
 
 import (
 	"testing"
+	"time"
 
 	"github.com/social-worker-platform/backend/config"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestFacilityRepository_ErrorHandling(t *testing.T) {
@@ -61,7 +63,7 @@ func TestFacilityRepository_ErrorHandling(t *testing.T) {
 	})
 
 	t.Run("Search returns empty list when no facilities match", func(t *testing.T) {
-		facilities, err := facilityRepo.Search("NonExistentFacility", "", 0)
+		facilities, err := facilityRepo.Search("NonExistentFacility", "", false)
 		assert.NoError(t, err)
 		assert.NotNil(t, facilities)
 		assert.Empty(t, facilities)
@@ -104,7 +106,7 @@ func TestFacilityRepository_ErrorHandling(t *testing.T) {
 		assert.Equal(t, 150, updated.BedCapacity)
 
 		// Search
-		results, err := facilityRepo.Search("Updated", "", 0)
+		results, err := facilityRepo.Search("Updated", "", false)
 		assert.NoError(t, err)
 		assert.Len(t, results, 1)
 		assert.Equal(t, "Updated Facility", results[0].Name)
@@ -119,3 +121,134 @@ func TestFacilityRepository_ErrorHandling(t *testing.T) {
 		assert.Nil(t, deleted)
 	})
 }
+
+func TestRoomTypesVersion(t *testing.T) {
+	list := []*FacilityRoomType{{ID: 1, Version: 1}, {ID: 2, Version: 3}}
+
+	assert.Equal(t, RoomTypesVersion(list), RoomTypesVersion([]*FacilityRoomType{list[1], list[0]}), "order does not matter")
+	assert.NotEqual(t, RoomTypesVersion(list), RoomTypesVersion([]*FacilityRoomType{{ID: 1, Version: 2}, {ID: 2, Version: 3}}), "changed")
+	assert.NotEqual(t, RoomTypesVersion(list), RoomTypesVersion(list[:1]), "removed")
+	assert.NotEqual(t, RoomTypesVersion(list), RoomTypesVersion(nil), "emptied")
+}
+
+func TestUpdateRoomTypes(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping database tests in short mode")
+	}
+
+	db := config.SetupTestDatabase(t)
+	defer config.TeardownTestDatabase(t, db)
+
+	repo := NewFacilityRepository(db)
+	single := FacilityRoomTypeInput{RoomType: "個室", Capacity: 1, Available: 1}
+	shared := FacilityRoomTypeInput{RoomType: "多床室", Capacity: 4, Available: 2}
+
+	versionOf := func(facilityID int) string {
+		roomTypes, err := repo.GetRoomTypesByFacilityID(facilityID)
+		require.NoError(t, err)
+		return RoomTypesVersion(roomTypes)
+	}
+
+	t.Run("saves the list read at the version", func(t *testing.T) {
+		req := createTestPlacementRequest(t, db)
+
+		require.NoError(t, repo.UpdateRoomTypes(req.FacilityID, versionOf(req.FacilityID), []FacilityRoomTypeInput{single, shared}))
+		require.NoError(t, repo.UpdateRoomTypes(req.FacilityID, versionOf(req.FacilityID), []FacilityRoomTypeInput{shared}))
+
+		roomTypes, err := repo.GetRoomTypesByFacilityID(req.FacilityID)
+		require.NoError(t, err)
+		require.Len(t, roomTypes, 1)
+		assert.Equal(t, "多床室", roomTypes[0].RoomType)
+	})
+
+	t.Run("refuses a list read before another change", func(t *testing.T) {
+		req := createTestPlacementRequest(t, db)
+		stale := versionOf(req.FacilityID)
+
+		_, err := repo.CreateRoomType(req.FacilityID, single)
+		require.NoError(t, err)
+
+		err = repo.UpdateRoomTypes(req.FacilityID, stale, []FacilityRoomTypeInput{shared})
+		assert.ErrorIs(t, err, ErrRoomTypeVersionConflict)
+
+		roomTypes, err := repo.GetRoomTypesByFacilityID(req.FacilityID)
+		require.NoError(t, err)
+		require.Len(t, roomTypes, 1)
+		assert.Equal(t, "個室", roomTypes[0].RoomType)
+	})
+
+	t.Run("refuses to remove a room type with held beds", func(t *testing.T) {
+		hold := createTestBedHold(t, db, time.Now().Add(time.Hour))
+
+		err := repo.UpdateRoomTypes(hold.FacilityID, versionOf(hold.FacilityID), []FacilityRoomTypeInput{shared})
+		assert.ErrorIs(t, err, ErrRoomTypeHasHolds)
+
+		_, err = repo.GetRoomTypeByID(hold.FacilityID, hold.RoomTypeID)
+		assert.NoError(t, err)
+	})
+
+	t.Run("rejects more available than capacity", func(t *testing.T) {
+		req := createTestPlacementRequest(t, db)
+
+		err := repo.UpdateRoomTypes(req.FacilityID, versionOf(req.FacilityID), []FacilityRoomTypeInput{{RoomType: "個室", Capacity: 1, Available: 2}})
+		assert.ErrorIs(t, err, ErrInvalidRoomType)
+	})
+}
+
+func TestUpdateWithHistoryKeepsConcurrentChanges(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping database tests in short mode")
+	}
+
+	db := config.SetupTestDatabase(t)
+	defer config.TeardownTestDatabase(t, db)
+
+	repo := NewFacilityRepository(db)
+
+	// editedName returns the change of the facility's name the profile form makes
+	editedName := func(t *testing.T, facility *Facility, name string) []FieldChange {
+		before := ProfileOf(facility)
+		facility.Name = name
+		changes, err := DiffProfiles(before, ProfileOf(facility))
+		require.NoError(t, err)
+		return changes
+	}
+
+	t.Run("a bed taken while the form was open is kept", func(t *testing.T) {
+		hold := createTestBedHold(t, db, time.Now().Add(time.Hour))
+		facility, err := repo.GetByID(hold.FacilityID)
+		require.NoError(t, err)
+		require.Equal(t, 1, facility.AvailableBeds)
+
+		converted, err := convertBedHold(t, db, hold.RoomID)
+		require.NoError(t, err)
+		require.True(t, converted)
+
+		_, _, err = repo.UpdateWithHistory(facility, facility.UserID, editedName(t, facility, "Renamed Facility"), nil)
+		require.NoError(t, err)
+		assert.Equal(t, 0, facility.AvailableBeds, "the saved facility is returned")
+
+		stored, err := repo.GetByID(hold.FacilityID)
+		require.NoError(t, err)
+		assert.Equal(t, "Renamed Facility", stored.Name)
+		assert.Equal(t, 0, stored.AvailableBeds)
+	})
+
+	t.Run("a field edited since it was read is refused", func(t *testing.T) {
+		req := createTestPlacementRequest(t, db)
+		facility, err := repo.GetByID(req.FacilityID)
+		require.NoError(t, err)
+
+		other, err := repo.GetByID(req.FacilityID)
+		require.NoError(t, err)
+		_, _, err = repo.UpdateWithHistory(other, other.UserID, editedName(t, other, "First Name"), nil)
+		require.NoError(t, err)
+
+		_, _, err = repo.UpdateWithHistory(facility, facility.UserID, editedName(t, facility, "Second Name"), nil)
+		assert.ErrorIs(t, err, ErrFacilityChangeStale)
+
+		stored, err := repo.GetByID(req.FacilityID)
+		require.NoError(t, err)
+		assert.Equal(t, "First Name", stored.Name)
+	})
+}
@@ -0,0 +1,445 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Facility type attribute value types
+const (
+	AttributeBoolean = "boolean"
+	AttributeInteger = "integer"
+	AttributeString  = "string"
+)
+
+var (
+	ErrFacilityTypeNotFound = errors.New("facility type not found")
+	ErrFacilityTypeInactive = errors.New("facility type is no longer available")
+	ErrFacilityTypeInUse    = errors.New("facility type is used by facilities")
+	ErrFacilityTypeExists   = errors.New("facility type already exists")
+)
+
+// FacilityTypeAttribute defines a type-specific field stored in facilities.type_attributes
+type FacilityTypeAttribute struct {
+	Key   string `json:"key" binding:"required"`
+	Label string `json:"label" binding:"required"`
+	Type  string `json:"type" binding:"required,oneof=boolean integer string"`
+}
+
+// EligibilityRules are the admission requirements of a facility type. Zero values mean
+// no requirement.
+type EligibilityRules struct {
+	MinCareLevel     string `json:"min_care_level,omitempty"` // see CareLevels
+	RequiresDementia bool   `json:"requires_dementia,omitempty"`
+	MinAge           int    `json:"min_age,omitempty"`
+}
+
+// FacilityType is an entry of the admin-managed facility type taxonomy
+type FacilityType struct {
+	ID          int                     `json:"id"`
+	Name        string                  `json:"name"`
+	ShortName   *string                 `json:"short_name,omitempty"`
+	Description *string                 `json:"description,omitempty"`
+	Attributes  []FacilityTypeAttribute `json:"attributes"`
+	Eligibility EligibilityRules        `json:"eligibility"`
+	SortOrder   int                     `json:"sort_order"`
+	IsActive    bool                    `json:"is_active"`
+	CreatedAt   time.Time               `json:"created_at"`
+	UpdatedAt   time.Time               `json:"updated_at"`
+}
+
+// PatientProfile is the patient information eligibility rules are checked against
+type PatientProfile struct {
+	Age         int
+	CareLevel   *string
+	HasDementia *bool
+}
+
+// EligibilityWarning explains why a patient may not be eligible for a facility type
+type EligibilityWarning struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// careLevelRank orders care levels from support_1 (0) to care_5 (6); -1 when unknown
+func careLevelRank(key string) int {
+	for i, level := range CareLevels {
+		if level.Key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func careLevelLabel(key string) string {
+	if i := careLevelRank(key); i >= 0 {
+		return CareLevels[i].Label
+	}
+	return key
+}
+
+// Validate checks that the rules reference known care levels
+func (r EligibilityRules) Validate() error {
+	if r.MinCareLevel != "" && !IsCareLevel(r.MinCareLevel) {
+		return fmt.Errorf("%w: %s", ErrUnknownCareLevel, r.MinCareLevel)
+	}
+	if r.MinAge < 0 {
+		return fmt.Errorf("min_age cannot be negative")
+	}
+	return nil
+}
+
+// Check returns a warning for every rule the patient fails or that cannot be verified
+// because the patient information is missing
+func (r EligibilityRules) Check(typeName string, p PatientProfile) []EligibilityWarning {
+	warnings := []EligibilityWarning{}
+
+	if r.MinCareLevel != "" {
+		switch {
+		case p.CareLevel == nil || *p.CareLevel == "":
+			warnings = append(warnings, EligibilityWarning{
+				Code:    "care_level_unknown",
+				Message: fmt.Sprintf("%s requires care level %s or higher, but the patient's care level is not provided", typeName, careLevelLabel(r.MinCareLevel)),
+			})
+		case careLevelRank(*p.CareLevel) < careLevelRank(r.MinCareLevel):
+			warnings = append(warnings, EligibilityWarning{
+				Code:    "care_level_below_minimum",
+				Message: fmt.Sprintf("%s requires care level %s or higher, but the patient is %s", typeName, careLevelLabel(r.MinCareLevel), careLevelLabel(*p.CareLevel)),
+			})
+		}
+	}
+
+	if r.RequiresDementia {
+		switch {
+		case p.HasDementia == nil:
+			warnings = append(warnings, EligibilityWarning{
+				Code:    "dementia_unknown",
+				Message: fmt.Sprintf("%s requires a dementia diagnosis, but it is not provided", typeName),
+			})
+		case !*p.HasDementia:
+			warnings = append(warnings, EligibilityWarning{
+				Code:    "dementia_required",
+				Message: fmt.Sprintf("%s requires a dementia diagnosis", typeName),
+			})
+		}
+	}
+
+	if r.MinAge > 0 && p.Age < r.MinAge {
+		warnings = append(warnings, EligibilityWarning{
+			Code:    "age_below_minimum",
+			Message: fmt.Sprintf("%s requires age %d or older", typeName, r.MinAge),
+		})
+	}
+
+	return warnings
+}
+
+// ValidateAttributeDefinitions checks attribute keys are unique and types are known
+func ValidateAttributeDefinitions(attrs []FacilityTypeAttribute) error {
+	seen := map[string]bool{}
+	for _, a := range attrs {
+		if a.Key == "" {
+			return fmt.Errorf("attribute key is required")
+		}
+		if seen[a.Key] {
+			return fmt.Errorf("duplicate attribute key: %s", a.Key)
+		}
+		seen[a.Key] = true
+		switch a.Type {
+		case AttributeBoolean, AttributeInteger, AttributeString:
+		default:
+			return fmt.Errorf("unknown attribute type for %s: %s", a.Key, a.Type)
+		}
+	}
+	return nil
+}
+
+// NormalizeTypeAttributes validates facility attribute values against the type's
+// definitions. Unknown keys and values of the wrong type are rejected; null clears a value.
+func (t *FacilityType) NormalizeTypeAttributes(raw json.RawMessage) (json.RawMessage, error) {
+	if len(raw) == 0 {
+		return json.RawMessage(`{}`), nil
+	}
+
+	var values map[string]interface{}
+	if err := json.Unmarshal(raw, &values); err != nil {
+		return nil, fmt.Errorf("type attributes must be a JSON object")
+	}
+
+	defs := make(map[string]FacilityTypeAttribute, len(t.Attributes))
+	for _, a := range t.Attributes {
+		defs[a.Key] = a
+	}
+
+	out := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		def, ok := defs[key]
+		if !ok {
+			return nil, fmt.Errorf("unknown attribute for %s: %s", t.Name, key)
+		}
+		if value == nil {
+			continue
+		}
+		valid := false
+		switch def.Type {
+		case AttributeBoolean:
+			_, valid = value.(bool)
+		case AttributeInteger:
+			n, isNumber := value.(float64)
+			valid = isNumber && n == float64(int64(n))
+		case AttributeString:
+			_, valid = value.(string)
+		}
+		if !valid {
+			return nil, fmt.Errorf("attribute %s must be %s", key, def.Type)
+		}
+		out[key] = value
+	}
+
+	normalized, err := json.Marshal(out)
+	if err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
+
+type FacilityTypeRepository struct {
+	db *sql.DB
+}
+
+func NewFacilityTypeRepository(db *sql.DB) *FacilityTypeRepository {
+	return &FacilityTypeRepository{db: db}
+}
+
+const facilityTypeColumns = `
+		SELECT id, name, short_name, description, attributes, eligibility, sort_order, is_active,
+		       created_at, updated_at
+		FROM facility_types`
+
+func scanFacilityType(row rowScanner) (*FacilityType, error) {
+	t := &FacilityType{}
+	var attrs, eligibility []byte
+	err := row.Scan(&t.ID, &t.Name, &t.ShortName, &t.Description, &attrs, &eligibility,
+		&t.SortOrder, &t.IsActive, &t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(attrs, &t.Attributes); err != nil {
+		return nil, fmt.Errorf("invalid attributes: %w", err)
+	}
+	if err := json.Unmarshal(eligibility, &t.Eligibility); err != nil {
+		return nil, fmt.Errorf("invalid eligibility rules: %w", err)
+	}
+	return t, nil
+}
+
+// GetAll returns the facility types in display order
+func (r *FacilityTypeRepository) GetAll(includeInactive bool) ([]*FacilityType, error) {
+	rows, err := r.db.Query(facilityTypeColumns+`
+		WHERE is_active OR $1
+		ORDER BY sort_order ASC, name ASC
+	`, includeInactive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get facility types: %w", err)
+	}
+	defer rows.Close()
+
+	types := []*FacilityType{}
+	for rows.Next() {
+		t, err := scanFacilityType(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan facility type: %w", err)
+		}
+		types = append(types, t)
+	}
+
+	return types, rows.Err()
+}
+
+func (r *FacilityTypeRepository) GetByID(id int) (*FacilityType, error) {
+	return getFacilityType(r.db, `WHERE id = $1`, id)
+}
+
+func (r *FacilityTypeRepository) GetByName(name string) (*FacilityType, error) {
+	return getFacilityType(r.db, `WHERE name = $1`, name)
+}
+
+// GetByNames returns the facility types with the given names keyed by name
+func (r *FacilityTypeRepository) GetByNames(names []string) (map[string]*FacilityType, error) {
+	rows, err := r.db.Query(facilityTypeColumns+` WHERE name = ANY($1)`, pq.Array(names))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get facility types: %w", err)
+	}
+	defer rows.Close()
+
+	types := map[string]*FacilityType{}
+	for rows.Next() {
+		t, err := scanFacilityType(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan facility type: %w", err)
+		}
+		types[t.Name] = t
+	}
+
+	return types, rows.Err()
+}
+
+func getFacilityType(db *sql.DB, where string, arg interface{}) (*FacilityType, error) {
+	t, err := scanFacilityType(db.QueryRow(facilityTypeColumns+" "+where, arg))
+	if err == sql.ErrNoRows {
+		return nil, ErrFacilityTypeNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get facility type: %w", err)
+	}
+	return t, nil
+}
+
+func (r *FacilityTypeRepository) Create(t *FacilityType) error {
+	attrs, eligibility, err := encodeFacilityTypeRules(t)
+	if err != nil {
+		return err
+	}
+
+	err = r.db.QueryRow(`
+		INSERT INTO facility_types (name, short_name, description, attributes, eligibility, sort_order, is_active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at, updated_at
+	`, t.Name, t.ShortName, t.Description, attrs, eligibility, t.SortOrder, t.IsActive).
+		Scan(&t.ID, &t.CreatedAt, &t.UpdatedAt)
+	if isUniqueViolation(err) {
+		return ErrFacilityTypeExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create facility type: %w", err)
+	}
+	return nil
+}
+
+// Update saves a facility type. Renaming it renames the type of its facilities too.
+func (r *FacilityTypeRepository) Update(t *FacilityType) error {
+	attrs, eligibility, err := encodeFacilityTypeRules(t)
+	if err != nil {
+		return err
+	}
+
+	err = r.db.QueryRow(`
+		UPDATE facility_types
+		SET name = $1, short_name = $2, description = $3, attributes = $4, eligibility = $5,
+		    sort_order = $6, is_active = $7, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $8
+		RETURNING updated_at
+	`, t.Name, t.ShortName, t.Description, attrs, eligibility, t.SortOrder, t.IsActive, t.ID).
+		Scan(&t.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return ErrFacilityTypeNotFound
+	}
+	if isUniqueViolation(err) {
+		return ErrFacilityTypeExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update facility type: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a facility type that no facility uses. Types in use should be
+// deactivated instead.
+func (r *FacilityTypeRepository) Delete(id int) error {
+	result, err := r.db.Exec(`DELETE FROM facility_types WHERE id = $1`, id)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+		return ErrFacilityTypeInUse
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete facility type: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return ErrFacilityTypeNotFound
+	}
+	return nil
+}
+
+func encodeFacilityTypeRules(t *FacilityType) (string, string, error) {
+	if err := ValidateAttributeDefinitions(t.Attributes); err != nil {
+		return "", "", err
+	}
+	if err := t.Eligibility.Validate(); err != nil {
+		return "", "", err
+	}
+	if t.Attributes == nil {
+		t.Attributes = []FacilityTypeAttribute{}
+	}
+	attrs, err := json.Marshal(t.Attributes)
+	if err != nil {
+		return "", "", err
+	}
+	eligibility, err := json.Marshal(t.Eligibility)
+	if err != nil {
+		return "", "", err
+	}
+	return string(attrs), string(eligibility), nil
+}
+
+func isUniqueViolation(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code == "23505"
+}
+
+// ValidateFacilityType checks the facility's type exists and normalizes its type
+// attributes. A deactivated type may only be kept, not newly assigned.
+func (r *FacilityRepository) ValidateFacilityType(facility *Facility, previousType string) error {
+	t, err := getFacilityType(r.db, `WHERE name = $1`, facility.FacilityType)
+	if err != nil {
+		return err
+	}
+	if !t.IsActive && t.Name != previousType {
+		return ErrFacilityTypeInactive
+	}
+
+	attrs, err := t.NormalizeTypeAttributes(facility.TypeAttributes)
+	if err != nil {
+		return err
+	}
+	facility.TypeAttributes = attrs
+	return nil
+}
+
+// CheckPlacementEligibility checks the patient of a request against the eligibility
+// rules of the target facility's type. Warnings are advisory and don't block the request.
+func CheckPlacementEligibility(db *sql.DB, req *PlacementRequest) ([]EligibilityWarning, error) {
+	var typeName string
+	var eligibility []byte
+	err := db.QueryRow(`
+		SELECT ft.name, ft.eligibility
+		FROM facilities f
+		JOIN facility_types ft ON ft.name = f.facility_type
+		WHERE f.id = $1
+	`, req.FacilityID).Scan(&typeName, &eligibility)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var rules EligibilityRules
+	if err := json.Unmarshal(eligibility, &rules); err != nil {
+		return nil, err
+	}
+
+	return rules.Check(typeName, PatientProfile{
+		Age:         req.PatientAge,
+		CareLevel:   req.PatientCareLevel,
+		HasDementia: req.PatientHasDementia,
+	}), nil
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func warningCodes(warnings []EligibilityWarning) []string {
+	codes := []string{}
+	for _, w := range warnings {
+		codes = append(codes, w.Code)
+	}
+	return codes
+}
+
+func TestEligibilityRules_Check(t *testing.T) {
+	care2, care4 := "care_2", "care_4"
+	yes, no := true, false
+
+	tokuyo := EligibilityRules{MinCareLevel: "care_3"}
+	assert.Empty(t, tokuyo.Check("特養", PatientProfile{Age: 80, CareLevel: &care4}))
+	assert.Equal(t, []string{"care_level_below_minimum"}, warningCodes(tokuyo.Check("特養", PatientProfile{Age: 80, CareLevel: &care2})))
+	assert.Equal(t, []string{"care_level_unknown"}, warningCodes(tokuyo.Check("特養", PatientProfile{Age: 80})))
+
+	groupHome := EligibilityRules{MinCareLevel: "support_2", RequiresDementia: true}
+	assert.Empty(t, groupHome.Check("グループホーム", PatientProfile{CareLevel: &care2, HasDementia: &yes}))
+	assert.Equal(t, []string{"dementia_required"}, warningCodes(groupHome.Check("グループホーム", PatientProfile{CareLevel: &care2, HasDementia: &no})))
+	assert.Equal(t, []string{"dementia_unknown"}, warningCodes(groupHome.Check("グループホーム", PatientProfile{CareLevel: &care2})))
+
+	sakoju := EligibilityRules{MinAge: 60}
+	assert.Equal(t, []string{"age_below_minimum"}, warningCodes(sakoju.Check("サ高住", PatientProfile{Age: 55})))
+	assert.Empty(t, EligibilityRules{}.Check("介護施設", PatientProfile{}))
+}
+
+func TestFacilityType_NormalizeTypeAttributes(t *testing.T) {
+	ft := &FacilityType{
+		Name: "介護老人保健施設",
+		Attributes: []FacilityTypeAttribute{
+			{Key: "rehab_staff_count", Label: "リハビリ専門職数", Type: AttributeInteger},
+			{Key: "unit_care", Label: "ユニット型", Type: AttributeBoolean},
+		},
+	}
+
+	out, err := ft.NormalizeTypeAttributes(json.RawMessage(`{"rehab_staff_count": 5, "unit_care": null}`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"rehab_staff_count": 5}`, string(out))
+
+	out, err = ft.NormalizeTypeAttributes(nil)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{}`, string(out))
+
+	invalid := []string{
+		`{"rehab_staff_count": 2.5}`,
+		`{"unit_care": "yes"}`,
+		`{"pool": true}`,
+		`[1, 2]`,
+	}
+	for _, raw := range invalid {
+		_, err := ft.NormalizeTypeAttributes(json.RawMessage(raw))
+		assert.Error(t, err, raw)
+	}
+}
+
+func TestValidateAttributeDefinitions(t *testing.T) {
+	assert.NoError(t, ValidateAttributeDefinitions([]FacilityTypeAttribute{{Key: "a", Type: AttributeString}}))
+	assert.Error(t, ValidateAttributeDefinitions([]FacilityTypeAttribute{{Key: "a", Type: AttributeString}, {Key: "a", Type: AttributeBoolean}}))
+	assert.Error(t, ValidateAttributeDefinitions([]FacilityTypeAttribute{{Key: "a", Type: "date"}}))
+	assert.Error(t, EligibilityRules{MinCareLevel: "care_9"}.Validate())
+}
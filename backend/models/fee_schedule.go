@@ -0,0 +1,327 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// FeeOption is a selectable care level or income tier used for fee estimates
+type FeeOption struct {
+	Key   string `json:"key"`
+	Label string `json:"label"`
+}
+
+// CareLevels are the long-term care certification levels that determine the care co-pay
+var CareLevels = []FeeOption{
+	{Key: "support_1", Label: "要支援1"},
+	{Key: "support_2", Label: "要支援2"},
+	{Key: "care_1", Label: "要介護1"},
+	{Key: "care_2", Label: "要介護2"},
+	{Key: "care_3", Label: "要介護3"},
+	{Key: "care_4", Label: "要介護4"},
+	{Key: "care_5", Label: "要介護5"},
+}
+
+// IncomeTiers are the income brackets that cap rent and food costs (負担限度額).
+// tier_4 pays the regular price.
+var IncomeTiers = []FeeOption{
+	{Key: "tier_1", Label: "第1段階"},
+	{Key: "tier_2", Label: "第2段階"},
+	{Key: "tier_3a", Label: "第3段階①"},
+	{Key: "tier_3b", Label: "第3段階②"},
+	{Key: "tier_4", Label: "第4段階（軽減なし）"},
+}
+
+var (
+	ErrUnknownCareLevel    = errors.New("unknown care level")
+	ErrUnknownIncomeTier   = errors.New("unknown income tier")
+	ErrFeeScheduleNotFound = errors.New("fee schedule not found")
+)
+
+func isFeeOption(options []FeeOption, key string) bool {
+	for _, o := range options {
+		if o.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// IsCareLevel reports whether key is one of CareLevels
+func IsCareLevel(key string) bool {
+	return isFeeOption(CareLevels, key)
+}
+
+// IsIncomeTier reports whether key is one of IncomeTiers
+func IsIncomeTier(key string) bool {
+	return isFeeOption(IncomeTiers, key)
+}
+
+// FeeReduction caps the monthly rent and food cost charged to an income tier
+type FeeReduction struct {
+	RentCap *int `json:"rent_cap,omitempty"`
+	FoodCap *int `json:"food_cap,omitempty"`
+}
+
+// FeeSchedule is the price list of a facility, either facility-wide (RoomTypeID nil)
+// or for one room type. All amounts are in yen; everything but Deposit is monthly.
+type FeeSchedule struct {
+	ID                   int                     `json:"id"`
+	FacilityID           int                     `json:"facility_id"`
+	RoomTypeID           *int                    `json:"room_type_id,omitempty"`
+	RoomType             *string                 `json:"room_type,omitempty"`
+	Deposit              int                     `json:"deposit"`
+	Rent                 int                     `json:"rent"`
+	ManagementFee        int                     `json:"management_fee"`
+	FoodCost             int                     `json:"food_cost"`
+	OtherFee             int                     `json:"other_fee"`
+	CareCopays           map[string]int          `json:"care_copays"`            // keyed by care level
+	IncomeTierReductions map[string]FeeReduction `json:"income_tier_reductions"` // keyed by income tier
+	CreatedAt            time.Time               `json:"created_at"`
+	UpdatedAt            time.Time               `json:"updated_at"`
+}
+
+type FeeScheduleInput struct {
+	RoomTypeID           *int                    `json:"room_type_id"`
+	Deposit              int                     `json:"deposit" binding:"min=0"`
+	Rent                 int                     `json:"rent" binding:"min=0"`
+	ManagementFee        int                     `json:"management_fee" binding:"min=0"`
+	FoodCost             int                     `json:"food_cost" binding:"min=0"`
+	OtherFee             int                     `json:"other_fee" binding:"min=0"`
+	CareCopays           map[string]int          `json:"care_copays"`
+	IncomeTierReductions map[string]FeeReduction `json:"income_tier_reductions"`
+}
+
+// Validate checks the care level and income tier keys and that amounts are not negative
+func (in FeeScheduleInput) Validate() error {
+	for level, amount := range in.CareCopays {
+		if !IsCareLevel(level) {
+			return fmt.Errorf("%w: %s", ErrUnknownCareLevel, level)
+		}
+		if amount < 0 {
+			return fmt.Errorf("care co-pay for %s cannot be negative", level)
+		}
+	}
+	for tier, reduction := range in.IncomeTierReductions {
+		if !IsIncomeTier(tier) {
+			return fmt.Errorf("%w: %s", ErrUnknownIncomeTier, tier)
+		}
+		if (reduction.RentCap != nil && *reduction.RentCap < 0) || (reduction.FoodCap != nil && *reduction.FoodCap < 0) {
+			return fmt.Errorf("reduction caps for %s cannot be negative", tier)
+		}
+	}
+	return nil
+}
+
+// FeeEstimate is the projected monthly cost for one resident
+type FeeEstimate struct {
+	FacilityID    int     `json:"facility_id"`
+	RoomTypeID    *int    `json:"room_type_id,omitempty"`
+	RoomType      *string `json:"room_type,omitempty"`
+	CareLevel     string  `json:"care_level,omitempty"`
+	IncomeTier    string  `json:"income_tier,omitempty"`
+	Deposit       int     `json:"deposit"`
+	Rent          int     `json:"rent"`
+	ManagementFee int     `json:"management_fee"`
+	FoodCost      int     `json:"food_cost"`
+	OtherFee      int     `json:"other_fee"`
+	CareCopay     int     `json:"care_copay"`
+	MonthlyTotal  int     `json:"monthly_total"`
+}
+
+// Estimate projects the monthly total for a care level and income tier. Either may be
+// empty; a care level without a configured co-pay counts as zero. estimatedTotalSQL
+// must stay in sync with this calculation.
+func (s *FeeSchedule) Estimate(careLevel, incomeTier string) (*FeeEstimate, error) {
+	if careLevel != "" && !IsCareLevel(careLevel) {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCareLevel, careLevel)
+	}
+	if incomeTier != "" && !IsIncomeTier(incomeTier) {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownIncomeTier, incomeTier)
+	}
+
+	e := &FeeEstimate{
+		FacilityID:    s.FacilityID,
+		RoomTypeID:    s.RoomTypeID,
+		RoomType:      s.RoomType,
+		CareLevel:     careLevel,
+		IncomeTier:    incomeTier,
+		Deposit:       s.Deposit,
+		Rent:          s.Rent,
+		ManagementFee: s.ManagementFee,
+		FoodCost:      s.FoodCost,
+		OtherFee:      s.OtherFee,
+		CareCopay:     s.CareCopays[careLevel],
+	}
+
+	if reduction, ok := s.IncomeTierReductions[incomeTier]; ok {
+		if reduction.RentCap != nil && *reduction.RentCap < e.Rent {
+			e.Rent = *reduction.RentCap
+		}
+		if reduction.FoodCap != nil && *reduction.FoodCap < e.FoodCost {
+			e.FoodCost = *reduction.FoodCap
+		}
+	}
+
+	e.MonthlyTotal = e.Rent + e.ManagementFee + e.FoodCost + e.OtherFee + e.CareCopay
+	return e, nil
+}
+
+// estimatedTotalSQL returns the SQL expression of FeeSchedule.Estimate for the row
+// alias fs, with the care level and income tier bound to the given placeholders
+func estimatedTotalSQL(careParam, tierParam int) string {
+	return fmt.Sprintf(`(
+		LEAST(fs.rent, COALESCE((fs.income_tier_reductions->$%[2]d::text->>'rent_cap')::integer, fs.rent))
+		+ fs.management_fee
+		+ LEAST(fs.food_cost, COALESCE((fs.income_tier_reductions->$%[2]d::text->>'food_cap')::integer, fs.food_cost))
+		+ fs.other_fee
+		+ COALESCE((fs.care_copays->>$%[1]d::text)::integer, 0)
+	)`, careParam, tierParam)
+}
+
+func scanFeeSchedule(row rowScanner) (*FeeSchedule, error) {
+	s := &FeeSchedule{}
+	var copays, reductions []byte
+	err := row.Scan(&s.ID, &s.FacilityID, &s.RoomTypeID, &s.RoomType, &s.Deposit, &s.Rent,
+		&s.ManagementFee, &s.FoodCost, &s.OtherFee, &copays, &reductions, &s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(copays, &s.CareCopays); err != nil {
+		return nil, fmt.Errorf("invalid care co-pays: %w", err)
+	}
+	if err := json.Unmarshal(reductions, &s.IncomeTierReductions); err != nil {
+		return nil, fmt.Errorf("invalid income tier reductions: %w", err)
+	}
+	return s, nil
+}
+
+const feeScheduleColumns = `
+		SELECT fs.id, fs.facility_id, fs.room_type_id, rt.room_type, fs.deposit, fs.rent,
+		       fs.management_fee, fs.food_cost, fs.other_fee, fs.care_copays, fs.income_tier_reductions,
+		       fs.created_at, fs.updated_at
+		FROM facility_fee_schedules fs
+		LEFT JOIN facility_room_types rt ON rt.id = fs.room_type_id`
+
+// GetFeeSchedules returns the facility-wide schedule first, then room type schedules
+func (r *FacilityRepository) GetFeeSchedules(facilityID int) ([]*FeeSchedule, error) {
+	rows, err := r.db.Query(feeScheduleColumns+`
+		WHERE fs.facility_id = $1
+		ORDER BY fs.room_type_id NULLS FIRST, rt.room_type ASC
+	`, facilityID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fee schedules: %w", err)
+	}
+	defer rows.Close()
+
+	schedules := []*FeeSchedule{}
+	for rows.Next() {
+		s, err := scanFeeSchedule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan fee schedule: %w", err)
+		}
+		schedules = append(schedules, s)
+	}
+
+	return schedules, rows.Err()
+}
+
+// GetFeeScheduleForRoomType returns the schedule of a room type, falling back to the
+// facility-wide schedule. A nil roomTypeID asks for the facility-wide schedule.
+func (r *FacilityRepository) GetFeeScheduleForRoomType(facilityID int, roomTypeID *int) (*FeeSchedule, error) {
+	s, err := scanFeeSchedule(r.db.QueryRow(feeScheduleColumns+`
+		WHERE fs.facility_id = $1 AND (fs.room_type_id IS NULL OR fs.room_type_id = $2)
+		ORDER BY fs.room_type_id NULLS LAST
+		LIMIT 1
+	`, facilityID, nilIntToInterface(roomTypeID)))
+	if err == sql.ErrNoRows {
+		return nil, ErrFeeScheduleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fee schedule: %w", err)
+	}
+	return s, nil
+}
+
+// ReplaceFeeSchedules saves the complete fee schedule list of a facility.
+// Schedules that are not in the list are removed.
+func (r *FacilityRepository) ReplaceFeeSchedules(facilityID int, schedules []FeeScheduleInput) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	seen := make(map[int]bool)
+	var roomTypeIDs []int64
+	for _, in := range schedules {
+		if err := in.Validate(); err != nil {
+			return err
+		}
+		key := 0
+		if in.RoomTypeID != nil {
+			key = *in.RoomTypeID
+			roomTypeIDs = append(roomTypeIDs, int64(key))
+		}
+		if seen[key] {
+			return fmt.Errorf("duplicate fee schedule for the same room type")
+		}
+		seen[key] = true
+	}
+
+	if len(roomTypeIDs) > 0 {
+		var owned int
+		err = tx.QueryRow(`
+			SELECT COUNT(*) FROM facility_room_types WHERE facility_id = $1 AND id = ANY($2)
+		`, facilityID, pq.Array(roomTypeIDs)).Scan(&owned)
+		if err != nil {
+			return fmt.Errorf("failed to check room types: %w", err)
+		}
+		if owned != len(roomTypeIDs) {
+			return ErrRoomTypeNotFound
+		}
+	}
+
+	if _, err := tx.Exec(`DELETE FROM facility_fee_schedules WHERE facility_id = $1`, facilityID); err != nil {
+		return fmt.Errorf("failed to delete fee schedules: %w", err)
+	}
+
+	for _, in := range schedules {
+		copays, _ := json.Marshal(nonNilMap(in.CareCopays))
+		reductions, _ := json.Marshal(nonNilReductions(in.IncomeTierReductions))
+		_, err = tx.Exec(`
+			INSERT INTO facility_fee_schedules (facility_id, room_type_id, deposit, rent, management_fee,
+			                                    food_cost, other_fee, care_copays, income_tier_reductions)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		`, facilityID, nilIntToInterface(in.RoomTypeID), in.Deposit, in.Rent, in.ManagementFee,
+			in.FoodCost, in.OtherFee, string(copays), string(reductions))
+		if err != nil {
+			return fmt.Errorf("failed to save fee schedule: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func nonNilMap(m map[string]int) map[string]int {
+	if m == nil {
+		return map[string]int{}
+	}
+	return m
+}
+
+func nonNilReductions(m map[string]FeeReduction) map[string]FeeReduction {
+	if m == nil {
+		return map[string]FeeReduction{}
+	}
+	return m
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeeSchedule_Estimate(t *testing.T) {
+	rentCap, foodCap := 25000, 12000
+	schedule := &FeeSchedule{
+		FacilityID:    1,
+		Deposit:       300000,
+		Rent:          60000,
+		ManagementFee: 20000,
+		FoodCost:      45000,
+		OtherFee:      5000,
+		CareCopays:    map[string]int{"care_1": 18000, "care_3": 24000},
+		IncomeTierReductions: map[string]FeeReduction{
+			"tier_2": {RentCap: &rentCap, FoodCap: &foodCap},
+		},
+	}
+
+	t.Run("regular price", func(t *testing.T) {
+		e, err := schedule.Estimate("care_3", "tier_4")
+		require.NoError(t, err)
+		assert.Equal(t, 60000+20000+45000+5000+24000, e.MonthlyTotal)
+		assert.Equal(t, 300000, e.Deposit)
+	})
+
+	t.Run("income tier caps rent and food", func(t *testing.T) {
+		e, err := schedule.Estimate("care_1", "tier_2")
+		require.NoError(t, err)
+		assert.Equal(t, 25000, e.Rent)
+		assert.Equal(t, 12000, e.FoodCost)
+		assert.Equal(t, 25000+20000+12000+5000+18000, e.MonthlyTotal)
+	})
+
+	t.Run("care level without co-pay counts as zero", func(t *testing.T) {
+		e, err := schedule.Estimate("care_5", "")
+		require.NoError(t, err)
+		assert.Equal(t, 0, e.CareCopay)
+		assert.Equal(t, 130000, e.MonthlyTotal)
+	})
+
+	t.Run("unknown keys", func(t *testing.T) {
+		_, err := schedule.Estimate("care_9", "")
+		assert.ErrorIs(t, err, ErrUnknownCareLevel)
+		_, err = schedule.Estimate("", "tier_9")
+		assert.ErrorIs(t, err, ErrUnknownIncomeTier)
+	})
+}
+
+func TestFeeScheduleInput_Validate(t *testing.T) {
+	negative := -1
+	assert.NoError(t, FeeScheduleInput{CareCopays: map[string]int{"support_1": 5000}}.Validate())
+	assert.ErrorIs(t, FeeScheduleInput{CareCopays: map[string]int{"care_6": 1}}.Validate(), ErrUnknownCareLevel)
+	assert.Error(t, FeeScheduleInput{CareCopays: map[string]int{"care_1": -1}}.Validate())
+	assert.ErrorIs(t, FeeScheduleInput{IncomeTierReductions: map[string]FeeReduction{"rich": {}}}.Validate(), ErrUnknownIncomeTier)
+	assert.Error(t, FeeScheduleInput{IncomeTierReductions: map[string]FeeReduction{"tier_1": {RentCap: &negative}}}.Validate())
+}
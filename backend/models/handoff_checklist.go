@@ -0,0 +1,288 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// SettingChecklistBlocksCompletion keeps rooms from being completed while required
+// handoff checklist items are unchecked when "true"
+const SettingChecklistBlocksCompletion = "handoff_checklist_blocks_completion"
+
+// HandoffTemplateItem is an item copied into the checklist of every room accepted by a
+// facility of the type. Items without a type form the default template, used for types
+// that have no items of their own.
+type HandoffTemplateItem struct {
+	ID             int       `json:"id"`
+	FacilityTypeID *int      `json:"facility_type_id,omitempty"`
+	Label          string    `json:"label"`
+	Description    *string   `json:"description,omitempty"`
+	Assignee       string    `json:"assignee"`
+	Required       bool      `json:"required"`
+	SortOrder      int       `json:"sort_order"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// RoomChecklistItem is a document or task the hospital or facility prepares before
+// the room is completed
+type RoomChecklistItem struct {
+	ID             int        `json:"id"`
+	RoomID         string     `json:"room_id"`
+	TemplateItemID *int       `json:"template_item_id,omitempty"`
+	Label          string     `json:"label"`
+	Description    *string    `json:"description,omitempty"`
+	Assignee       string     `json:"assignee"`
+	Required       bool       `json:"required"`
+	SortOrder      int        `json:"sort_order"`
+	FileID         *int       `json:"file_id,omitempty"`
+	FileName       *string    `json:"file_name,omitempty"`
+	CheckedAt      *time.Time `json:"checked_at,omitempty"`
+	CheckedBy      *int       `json:"checked_by,omitempty"`
+	AddedBy        *int       `json:"added_by,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// OpenRequiredItems returns the required items that are not checked yet
+func OpenRequiredItems(items []*RoomChecklistItem) []*RoomChecklistItem {
+	open := []*RoomChecklistItem{}
+	for _, item := range items {
+		if item.Required && item.CheckedAt == nil {
+			open = append(open, item)
+		}
+	}
+	return open
+}
+
+// LockOpenRequiredItems counts the room's required items that are not checked yet,
+// locking the required items so they cannot be unchecked until the transaction ends
+func LockOpenRequiredItems(db Querier, roomID string) (int, error) {
+	rows, err := db.Query(`
+		SELECT checked_at IS NULL
+		FROM room_checklist_items
+		WHERE room_id = $1 AND required
+		FOR SHARE
+	`, roomID)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	open := 0
+	for rows.Next() {
+		var unchecked bool
+		if err := rows.Scan(&unchecked); err != nil {
+			return 0, err
+		}
+		if unchecked {
+			open++
+		}
+	}
+	return open, rows.Err()
+}
+
+const handoffTemplateItemColumns = `
+	id, facility_type_id, label, description, assignee, required, sort_order, created_at, updated_at`
+
+func scanHandoffTemplateItem(row rowScanner) (*HandoffTemplateItem, error) {
+	item := &HandoffTemplateItem{}
+	err := row.Scan(&item.ID, &item.FacilityTypeID, &item.Label, &item.Description, &item.Assignee,
+		&item.Required, &item.SortOrder, &item.CreatedAt, &item.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// GetHandoffTemplateItems returns the template of a facility type in order, or the
+// default template when facilityTypeID is nil
+func GetHandoffTemplateItems(db *sql.DB, facilityTypeID *int) ([]*HandoffTemplateItem, error) {
+	rows, err := db.Query(`SELECT `+handoffTemplateItemColumns+`
+		FROM handoff_template_items
+		WHERE facility_type_id IS NOT DISTINCT FROM $1
+		ORDER BY sort_order, id
+	`, facilityTypeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []*HandoffTemplateItem{}
+	for rows.Next() {
+		item, err := scanHandoffTemplateItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// GetHandoffTemplateItemByID returns a template item, or nil
+func GetHandoffTemplateItemByID(db *sql.DB, id int) (*HandoffTemplateItem, error) {
+	item, err := scanHandoffTemplateItem(db.QueryRow(`SELECT `+handoffTemplateItemColumns+`
+		FROM handoff_template_items WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return item, err
+}
+
+// CreateHandoffTemplateItem adds an item to a template. Rooms accepted earlier keep
+// their checklists.
+func CreateHandoffTemplateItem(db *sql.DB, item *HandoffTemplateItem) error {
+	return db.QueryRow(`
+		INSERT INTO handoff_template_items (facility_type_id, label, description, assignee, required, sort_order)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at
+	`, item.FacilityTypeID, item.Label, item.Description, item.Assignee, item.Required, item.SortOrder).Scan(
+		&item.ID, &item.CreatedAt, &item.UpdatedAt,
+	)
+}
+
+// UpdateHandoffTemplateItem saves a template item. It returns sql.ErrNoRows if the item
+// does not exist.
+func UpdateHandoffTemplateItem(db *sql.DB, item *HandoffTemplateItem) error {
+	return db.QueryRow(`
+		UPDATE handoff_template_items
+		SET label = $1, description = $2, assignee = $3, required = $4, sort_order = $5,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $6
+		RETURNING updated_at
+	`, item.Label, item.Description, item.Assignee, item.Required, item.SortOrder, item.ID).Scan(&item.UpdatedAt)
+}
+
+// DeleteHandoffTemplateItem removes a template item. Checklists copied from it keep the item.
+func DeleteHandoffTemplateItem(db *sql.DB, id int) error {
+	result, err := db.Exec(`DELETE FROM handoff_template_items WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// CreateRoomChecklist copies the template of the room's facility type, or the default
+// template if the type has none, into the room's checklist. A room that already has a
+// checklist, e.g. one accepted again after reopening, keeps it.
+func CreateRoomChecklist(db execer, roomID string) error {
+	_, err := db.Exec(`
+		INSERT INTO room_checklist_items (room_id, template_item_id, label, description, assignee, required, sort_order)
+		SELECT $1, t.id, t.label, t.description, t.assignee, t.required, t.sort_order
+		FROM handoff_template_items t
+		WHERE NOT EXISTS (SELECT 1 FROM room_checklist_items WHERE room_id = $1)
+		  AND t.facility_type_id IS NOT DISTINCT FROM (
+			SELECT ft.id
+			FROM message_rooms mr
+			JOIN facilities f ON mr.facility_id = f.id
+			JOIN facility_types ft ON ft.name = f.facility_type
+			WHERE mr.id = $1
+			  AND EXISTS (SELECT 1 FROM handoff_template_items WHERE facility_type_id = ft.id)
+		  )
+	`, roomID)
+	return err
+}
+
+const roomChecklistItemColumns = `
+	i.id, i.room_id, i.template_item_id, i.label, i.description, i.assignee, i.required,
+	i.sort_order, i.file_id, rf.file_name, i.checked_at, i.checked_by, i.added_by, i.created_at, i.updated_at`
+
+func scanRoomChecklistItem(row rowScanner) (*RoomChecklistItem, error) {
+	item := &RoomChecklistItem{}
+	err := row.Scan(&item.ID, &item.RoomID, &item.TemplateItemID, &item.Label, &item.Description,
+		&item.Assignee, &item.Required, &item.SortOrder, &item.FileID, &item.FileName,
+		&item.CheckedAt, &item.CheckedBy, &item.AddedBy, &item.CreatedAt, &item.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// GetRoomChecklist returns the room's checklist in order with the names of linked files
+func GetRoomChecklist(db *sql.DB, roomID string) ([]*RoomChecklistItem, error) {
+	rows, err := db.Query(`SELECT `+roomChecklistItemColumns+`
+		FROM room_checklist_items i
+		LEFT JOIN room_files rf ON i.file_id = rf.id
+		WHERE i.room_id = $1
+		ORDER BY i.sort_order, i.id
+	`, roomID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []*RoomChecklistItem{}
+	for rows.Next() {
+		item, err := scanRoomChecklistItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// GetRoomChecklistItemByID returns a checklist item, or nil
+func GetRoomChecklistItemByID(db *sql.DB, id int) (*RoomChecklistItem, error) {
+	item, err := scanRoomChecklistItem(db.QueryRow(`SELECT `+roomChecklistItemColumns+`
+		FROM room_checklist_items i
+		LEFT JOIN room_files rf ON i.file_id = rf.id
+		WHERE i.id = $1
+	`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return item, err
+}
+
+// CreateRoomChecklistItem adds an item the parties need for this room only
+func CreateRoomChecklistItem(db *sql.DB, item *RoomChecklistItem) error {
+	return db.QueryRow(`
+		INSERT INTO room_checklist_items (room_id, label, description, assignee, required, added_by, sort_order)
+		VALUES ($1, $2, $3, $4, $5, $6,
+			COALESCE((SELECT MAX(sort_order) FROM room_checklist_items WHERE room_id = $1), 0) + 10)
+		RETURNING id, sort_order, created_at, updated_at
+	`, item.RoomID, item.Label, item.Description, item.Assignee, item.Required, item.AddedBy).Scan(
+		&item.ID, &item.SortOrder, &item.CreatedAt, &item.UpdatedAt,
+	)
+}
+
+// UpdateRoomChecklistItem saves the assignee, linked file and check of an item
+func UpdateRoomChecklistItem(db *sql.DB, item *RoomChecklistItem) error {
+	return db.QueryRow(`
+		UPDATE room_checklist_items
+		SET assignee = $1, file_id = $2, checked_at = $3, checked_by = $4, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $5
+		RETURNING updated_at
+	`, item.Assignee, item.FileID, item.CheckedAt, item.CheckedBy, item.ID).Scan(&item.UpdatedAt)
+}
+
+// DeleteRoomChecklistItem removes an item added in the room. Items copied from a
+// template cannot be removed; it returns sql.ErrNoRows for them.
+func DeleteRoomChecklistItem(db *sql.DB, id int) error {
+	result, err := db.Exec(`DELETE FROM room_checklist_items WHERE id = $1 AND added_by IS NOT NULL`, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
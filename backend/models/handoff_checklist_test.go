@@ -0,0 +1,25 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenRequiredItems(t *testing.T) {
+	checked := time.Now()
+	items := []*RoomChecklistItem{
+		{ID: 1, Label: "診療情報提供書", Required: true},
+		{ID: 2, Label: "看護サマリー", Required: true, CheckedAt: &checked},
+		{ID: 3, Label: "ケアプラン", Required: false},
+	}
+
+	open := OpenRequiredItems(items)
+	assert.Len(t, open, 1)
+	assert.Equal(t, 1, open[0].ID)
+
+	items[0].CheckedAt = &checked
+	assert.Empty(t, OpenRequiredItems(items))
+	assert.NotNil(t, OpenRequiredItems(nil))
+}
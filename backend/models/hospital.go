@@ -99,6 +99,7 @@ func (r *HospitalRepository) GetAll() ([]*Hospital, error) {
 	query := `
 		SELECT id, user_id, name, address, phone, created_at, updated_at
 		FROM hospitals
+		WHERE merged_into_id IS NULL
 		ORDER BY created_at DESC
 	`
 	rows, err := r.db.Query(query)
@@ -10,14 +10,15 @@ type Message struct {
 	RoomID      string    `json:"room_id"`
 	SenderID    int       `json:"sender_id"`
 	MessageText string    `json:"message_text"`
+	IsSystem    bool      `json:"is_system"` // posted on a status change rather than typed by the sender
 	CreatedAt   time.Time `json:"created_at"`
 }
 
 // CreateMessage creates a new message
-func CreateMessage(db *sql.DB, msg *Message) error {
+func CreateMessage(db Querier, msg *Message) error {
 	query := `
-		INSERT INTO messages (room_id, sender_id, message_text)
-		VALUES ($1, $2, $3)
+		INSERT INTO messages (room_id, sender_id, message_text, is_system)
+		VALUES ($1, $2, $3, $4)
 		RETURNING id, created_at
 	`
 	err := db.QueryRow(
@@ -25,15 +26,16 @@ func CreateMessage(db *sql.DB, msg *Message) error {
 		msg.RoomID,
 		msg.SenderID,
 		msg.MessageText,
+		msg.IsSystem,
 	).Scan(&msg.ID, &msg.CreatedAt)
-	
+
 	return err
 }
 
 // GetMessagesByRoomID retrieves all messages for a room
 func GetMessagesByRoomID(db *sql.DB, roomID string) ([]*Message, error) {
 	query := `
-		SELECT id, room_id, sender_id, message_text, created_at
+		SELECT id, room_id, sender_id, message_text, is_system, created_at
 		FROM messages
 		WHERE room_id = $1
 		ORDER BY created_at ASC
@@ -43,7 +45,7 @@ func GetMessagesByRoomID(db *sql.DB, roomID string) ([]*Message, error) {
 		return nil, err
 	}
 	defer rows.Close()
-	
+
 	var messages []*Message
 	for rows.Next() {
 		msg := &Message{}
@@ -52,6 +54,7 @@ func GetMessagesByRoomID(db *sql.DB, roomID string) ([]*Message, error) {
 			&msg.RoomID,
 			&msg.SenderID,
 			&msg.MessageText,
+			&msg.IsSystem,
 			&msg.CreatedAt,
 		)
 		if err != nil {
@@ -59,6 +62,6 @@ func GetMessagesByRoomID(db *sql.DB, roomID string) ([]*Message, error) {
 		}
 		messages = append(messages, msg)
 	}
-	
+
 	return messages, rows.Err()
 }
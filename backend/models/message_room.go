@@ -6,24 +6,24 @@ import (
 )
 
 type MessageRoom struct {
-	ID                  string    `json:"id"`
-	RequestID           int       `json:"request_id"`
-	HospitalID          int       `json:"hospital_id"`
-	FacilityID          int       `json:"facility_id"`
-	Status              string    `json:"status"`
-	HospitalCompleted   bool      `json:"hospital_completed"`
-	FacilityCompleted   bool      `json:"facility_completed"`
-	HospitalName        string    `json:"hospital_name,omitempty"`
-	FacilityName        string    `json:"facility_name,omitempty"`
-	PatientAge          int       `json:"patient_age,omitempty"`
-	PatientGender       string    `json:"patient_gender,omitempty"`
-	MedicalCondition    string    `json:"medical_condition,omitempty"`
-	CreatedAt           time.Time `json:"created_at"`
-	UpdatedAt           time.Time `json:"updated_at"`
+	ID                string    `json:"id"`
+	RequestID         int       `json:"request_id"`
+	HospitalID        int       `json:"hospital_id"`
+	FacilityID        int       `json:"facility_id"`
+	Status            string    `json:"status"`
+	HospitalCompleted bool      `json:"hospital_completed"`
+	FacilityCompleted bool      `json:"facility_completed"`
+	HospitalName      string    `json:"hospital_name,omitempty"`
+	FacilityName      string    `json:"facility_name,omitempty"`
+	PatientAge        int       `json:"patient_age,omitempty"`
+	PatientGender     string    `json:"patient_gender,omitempty"`
+	MedicalCondition  string    `json:"medical_condition,omitempty"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
 	// For room list view
-	LatestMessage       string    `json:"latest_message,omitempty"`
-	LatestMessageAt     *time.Time `json:"latest_message_at,omitempty"`
-	HasUnread           bool      `json:"has_unread"`
+	LatestMessage   string     `json:"latest_message,omitempty"`
+	LatestMessageAt *time.Time `json:"latest_message_at,omitempty"`
+	HasUnread       bool       `json:"has_unread"`
 }
 
 // CreateMessageRoom creates a new message room
@@ -40,10 +40,24 @@ func CreateMessageRoom(db *sql.DB, room *MessageRoom) error {
 		room.FacilityID,
 		room.Status,
 	).Scan(&room.ID, &room.CreatedAt, &room.UpdatedAt)
-	
+
 	return err
 }
 
+// CreateMessageRoomForRequest opens a room in status for the parties of a placement request
+func CreateMessageRoomForRequest(db Querier, requestID int, status string) (*MessageRoom, error) {
+	room := &MessageRoom{RequestID: requestID, Status: status}
+	err := db.QueryRow(`
+		INSERT INTO message_rooms (request_id, hospital_id, facility_id, status)
+		SELECT id, hospital_id, facility_id, $2 FROM placement_requests WHERE id = $1
+		RETURNING id, hospital_id, facility_id, created_at, updated_at
+	`, requestID, status).Scan(&room.ID, &room.HospitalID, &room.FacilityID, &room.CreatedAt, &room.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return room, nil
+}
+
 // GetMessageRoomByID retrieves a message room by ID with patient info
 func GetMessageRoomByID(db *sql.DB, id string) (*MessageRoom, error) {
 	room := &MessageRoom{}
@@ -103,11 +117,11 @@ func GetMessageRoomByRequestID(db *sql.DB, requestID int) (*MessageRoom, error)
 		&room.CreatedAt,
 		&room.UpdatedAt,
 	)
-	
+
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
-	
+
 	return room, err
 }
 
@@ -267,32 +281,50 @@ func GetMessageRoomsByFacilityID(db *sql.DB, facilityID int, userID int) ([]*Mes
 	return rooms, rows.Err()
 }
 
-// UpdateMessageRoomStatus updates the status of a message room
-func UpdateMessageRoomStatus(db *sql.DB, id string, status string) error {
+// UpdateMessageRoomStatus moves a message room from status from to status.
+// It returns ErrStatusChanged if the room is no longer in from.
+func UpdateMessageRoomStatus(db execer, id string, from, status string) error {
 	query := `
 		UPDATE message_rooms
 		SET status = $1, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $2
+		WHERE id = $2 AND status = $3
 	`
-	result, err := db.Exec(query, status, id)
+	result, err := db.Exec(query, status, id, from)
 	if err != nil {
 		return err
 	}
-	
+
 	rows, err := result.RowsAffected()
 	if err != nil {
 		return err
 	}
-	
+
 	if rows == 0 {
-		return sql.ErrNoRows
+		return ErrStatusChanged
 	}
-	
+
 	return nil
 }
 
+// LockMessageRoomCompletion reads the status and completion flags of a room and locks it
+// until the end of the transaction, so when both parties mark the room complete at the
+// same time the second one sees the flag of the first
+func LockMessageRoomCompletion(db Querier, id string) (*MessageRoom, error) {
+	room := &MessageRoom{ID: id}
+	err := db.QueryRow(`
+		SELECT request_id, status, hospital_completed, facility_completed
+		FROM message_rooms
+		WHERE id = $1
+		FOR UPDATE
+	`, id).Scan(&room.RequestID, &room.Status, &room.HospitalCompleted, &room.FacilityCompleted)
+	if err != nil {
+		return nil, err
+	}
+	return room, nil
+}
+
 // UpdateHospitalCompletion updates the hospital completion flag
-func UpdateHospitalCompletion(db *sql.DB, id string, completed bool) error {
+func UpdateHospitalCompletion(db execer, id string, completed bool) error {
 	query := `
 		UPDATE message_rooms
 		SET hospital_completed = $1, updated_at = CURRENT_TIMESTAMP
@@ -302,21 +334,21 @@ func UpdateHospitalCompletion(db *sql.DB, id string, completed bool) error {
 	if err != nil {
 		return err
 	}
-	
+
 	rows, err := result.RowsAffected()
 	if err != nil {
 		return err
 	}
-	
+
 	if rows == 0 {
 		return sql.ErrNoRows
 	}
-	
+
 	return nil
 }
 
 // UpdateFacilityCompletion updates the facility completion flag
-func UpdateFacilityCompletion(db *sql.DB, id string, completed bool) error {
+func UpdateFacilityCompletion(db execer, id string, completed bool) error {
 	query := `
 		UPDATE message_rooms
 		SET facility_completed = $1, updated_at = CURRENT_TIMESTAMP
@@ -326,15 +358,15 @@ func UpdateFacilityCompletion(db *sql.DB, id string, completed bool) error {
 	if err != nil {
 		return err
 	}
-	
+
 	rows, err := result.RowsAffected()
 	if err != nil {
 		return err
 	}
-	
+
 	if rows == 0 {
 		return sql.ErrNoRows
 	}
-	
+
 	return nil
 }
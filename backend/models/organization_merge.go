@@ -0,0 +1,457 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Organization kinds that can be checked for duplicates and merged
+const (
+	OrganizationFacility = "facility"
+	OrganizationHospital = "hospital"
+)
+
+var (
+	ErrOrganizationNotFound      = errors.New("organization not found")
+	ErrOrganizationAlreadyMerged = errors.New("organization has already been merged")
+	ErrMergeSameOrganization     = errors.New("cannot merge an organization into itself")
+)
+
+// IsOrganizationKind reports whether kind is a known organization kind
+func IsOrganizationKind(kind string) bool {
+	return kind == OrganizationFacility || kind == OrganizationHospital
+}
+
+// OrganizationRecord is the identifying data of a facility or hospital used to find duplicates
+type OrganizationRecord struct {
+	Kind      string    `json:"kind"`
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	Email     string    `json:"email"`
+	Name      string    `json:"name"`
+	Address   string    `json:"address"`
+	Phone     string    `json:"phone"`
+	Latitude  *float64  `json:"latitude,omitempty"`
+	Longitude *float64  `json:"longitude,omitempty"`
+	IsActive  bool      `json:"is_active"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// OrganizationMerge records one duplicate merged into a surviving record
+type OrganizationMerge struct {
+	ID          int            `json:"id"`
+	Kind        string         `json:"kind"`
+	SurvivorID  int            `json:"survivor_id"`
+	DuplicateID int            `json:"duplicate_id"`
+	MergedBy    *int           `json:"merged_by,omitempty"`
+	Moved       map[string]int `json:"moved"`
+	CreatedAt   time.Time      `json:"created_at"`
+}
+
+// DuplicatePairKey identifies a pair of records regardless of order
+type DuplicatePairKey struct {
+	FirstID  int
+	SecondID int
+}
+
+// NewDuplicatePairKey orders the ids of a pair
+func NewDuplicatePairKey(a, b int) DuplicatePairKey {
+	if a > b {
+		a, b = b, a
+	}
+	return DuplicatePairKey{FirstID: a, SecondID: b}
+}
+
+type MergeRepository struct {
+	db *sql.DB
+}
+
+func NewMergeRepository(db *sql.DB) *MergeRepository {
+	return &MergeRepository{db: db}
+}
+
+// GetOrganizationRecords returns all facilities or hospitals that have not been merged
+func (r *MergeRepository) GetOrganizationRecords(kind string) ([]*OrganizationRecord, error) {
+	var query string
+	switch kind {
+	case OrganizationFacility:
+		query = `
+			SELECT o.id, o.user_id, u.email, o.name, COALESCE(o.address, ''), COALESCE(o.phone, ''),
+			       o.latitude, o.longitude, COALESCE(u.is_active, true), o.created_at
+			FROM facilities o
+			JOIN users u ON u.id = o.user_id
+			WHERE o.merged_into_id IS NULL
+			ORDER BY o.id
+		`
+	case OrganizationHospital:
+		query = `
+			SELECT o.id, o.user_id, u.email, o.name, COALESCE(o.address, ''), COALESCE(o.phone, ''),
+			       NULL::double precision, NULL::double precision, COALESCE(u.is_active, true), o.created_at
+			FROM hospitals o
+			JOIN users u ON u.id = o.user_id
+			WHERE o.merged_into_id IS NULL
+			ORDER BY o.id
+		`
+	default:
+		return nil, fmt.Errorf("unknown organization kind: %q", kind)
+	}
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s records: %w", kind, err)
+	}
+	defer rows.Close()
+
+	records := []*OrganizationRecord{}
+	for rows.Next() {
+		rec := &OrganizationRecord{Kind: kind}
+		if err := rows.Scan(&rec.ID, &rec.UserID, &rec.Email, &rec.Name, &rec.Address, &rec.Phone,
+			&rec.Latitude, &rec.Longitude, &rec.IsActive, &rec.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan %s record: %w", kind, err)
+		}
+		records = append(records, rec)
+	}
+
+	return records, rows.Err()
+}
+
+// GetDismissedPairs returns the pairs an admin has marked as not being duplicates
+func (r *MergeRepository) GetDismissedPairs(kind string) (map[DuplicatePairKey]bool, error) {
+	rows, err := r.db.Query(`SELECT first_id, second_id FROM duplicate_dismissals WHERE kind = $1`, kind)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dismissed duplicates: %w", err)
+	}
+	defer rows.Close()
+
+	dismissed := map[DuplicatePairKey]bool{}
+	for rows.Next() {
+		var key DuplicatePairKey
+		if err := rows.Scan(&key.FirstID, &key.SecondID); err != nil {
+			return nil, fmt.Errorf("failed to scan dismissed duplicate: %w", err)
+		}
+		dismissed[key] = true
+	}
+
+	return dismissed, rows.Err()
+}
+
+// DismissDuplicate marks a pair as not being duplicates so it is no longer reported
+func (r *MergeRepository) DismissDuplicate(kind string, a, b, dismissedBy int) error {
+	if a == b {
+		return ErrMergeSameOrganization
+	}
+	key := NewDuplicatePairKey(a, b)
+	_, err := r.db.Exec(`
+		INSERT INTO duplicate_dismissals (kind, first_id, second_id, dismissed_by)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (kind, first_id, second_id) DO NOTHING
+	`, kind, key.FirstID, key.SecondID, dismissedBy)
+	if err != nil {
+		return fmt.Errorf("failed to dismiss duplicate: %w", err)
+	}
+	return nil
+}
+
+// GetMerges returns the merge history, newest first
+func (r *MergeRepository) GetMerges(kind string) ([]*OrganizationMerge, error) {
+	rows, err := r.db.Query(`
+		SELECT id, kind, survivor_id, duplicate_id, merged_by, moved, created_at
+		FROM organization_merges
+		WHERE ($1 = '' OR kind = $1)
+		ORDER BY created_at DESC
+	`, kind)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get merges: %w", err)
+	}
+	defer rows.Close()
+
+	merges := []*OrganizationMerge{}
+	for rows.Next() {
+		m := &OrganizationMerge{}
+		var moved []byte
+		if err := rows.Scan(&m.ID, &m.Kind, &m.SurvivorID, &m.DuplicateID, &m.MergedBy, &moved, &m.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan merge: %w", err)
+		}
+		if err := json.Unmarshal(moved, &m.Moved); err != nil {
+			return nil, fmt.Errorf("failed to decode merge counts: %w", err)
+		}
+		merges = append(merges, m)
+	}
+
+	return merges, rows.Err()
+}
+
+// lockMergePair locks both rows of a merge and returns their user ids
+func lockMergePair(tx *sql.Tx, table string, survivorID, duplicateID int) (survivorUserID, duplicateUserID int, err error) {
+	if survivorID == duplicateID {
+		return 0, 0, ErrMergeSameOrganization
+	}
+
+	rows, err := tx.Query(`SELECT id, user_id, merged_into_id FROM `+table+` WHERE id IN ($1, $2) ORDER BY id FOR UPDATE`, survivorID, duplicateID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to lock %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	found := 0
+	for rows.Next() {
+		var id, userID int
+		var mergedInto *int
+		if err := rows.Scan(&id, &userID, &mergedInto); err != nil {
+			return 0, 0, fmt.Errorf("failed to scan %s: %w", table, err)
+		}
+		if mergedInto != nil {
+			return 0, 0, ErrOrganizationAlreadyMerged
+		}
+		if id == survivorID {
+			survivorUserID = userID
+		} else {
+			duplicateUserID = userID
+		}
+		found++
+	}
+	if err := rows.Err(); err != nil {
+		return 0, 0, err
+	}
+	if found != 2 {
+		return 0, 0, ErrOrganizationNotFound
+	}
+
+	return survivorUserID, duplicateUserID, nil
+}
+
+// mergeStep is one statement of a merge; its affected rows are counted under name
+type mergeStep struct {
+	name  string
+	query string
+}
+
+func runMergeSteps(tx *sql.Tx, steps []mergeStep, moved map[string]int, args ...interface{}) error {
+	for _, step := range steps {
+		result, err := tx.Exec(step.query, args...)
+		if err != nil {
+			return fmt.Errorf("failed to move records during merge: %w", err)
+		}
+		if step.name == "" {
+			continue
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		moved[step.name] += int(n)
+	}
+	return nil
+}
+
+// finishMerge moves the duplicate's documents to the survivor's account, deactivates
+// the duplicate's account, marks it merged and records the merge
+func finishMerge(tx *sql.Tx, kind, table string, survivorID, duplicateID, survivorUserID, duplicateUserID, mergedBy int, moved map[string]int) (*OrganizationMerge, error) {
+	err := runMergeSteps(tx, []mergeStep{
+		{"documents", `UPDATE documents SET sender_id = $1 WHERE sender_id = $2`},
+		{"documents", `UPDATE documents SET recipient_id = $1 WHERE recipient_id = $2`},
+	}, moved, survivorUserID, duplicateUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(`UPDATE users SET is_active = false, updated_at = CURRENT_TIMESTAMP WHERE id = $1`, duplicateUserID); err != nil {
+		return nil, fmt.Errorf("failed to deactivate merged account: %w", err)
+	}
+	if _, err := tx.Exec(`UPDATE `+table+` SET merged_into_id = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`, survivorID, duplicateID); err != nil {
+		return nil, fmt.Errorf("failed to mark %s merged: %w", kind, err)
+	}
+
+	movedJSON, err := json.Marshal(moved)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode merge counts: %w", err)
+	}
+	merge := &OrganizationMerge{Kind: kind, SurvivorID: survivorID, DuplicateID: duplicateID, MergedBy: &mergedBy, Moved: moved}
+	err = tx.QueryRow(`
+		INSERT INTO organization_merges (kind, survivor_id, duplicate_id, merged_by, moved)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`, kind, survivorID, duplicateID, mergedBy, movedJSON).Scan(&merge.ID, &merge.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record merge: %w", err)
+	}
+
+	return merge, nil
+}
+
+// facilityMergeSteps move everything attached to the duplicate facility ($2) onto the
+// survivor ($1). Room types the survivor already has by name are folded into the
+// survivor's: their holds, waitlist entries and request conditions are repointed and the duplicate room
+// type (with its fee schedule) is dropped. Shortlist and saved search entries the
+// survivor already has are dropped rather than duplicated. The duplicate's pending
+// profile changes are rejected; its applied ones stay as the history of its profile.
+var facilityMergeSteps = []mergeStep{
+	{"", `
+		UPDATE bed_holds b SET room_type_id = s.id
+		FROM facility_room_types d
+		JOIN facility_room_types s ON s.facility_id = $1 AND s.room_type = d.room_type
+		WHERE d.facility_id = $2 AND b.room_type_id = d.id`},
+	{"", `
+		UPDATE waitlist_entries w SET room_type_id = s.id
+		FROM facility_room_types d
+		JOIN facility_room_types s ON s.facility_id = $1 AND s.room_type = d.room_type
+		WHERE d.facility_id = $2 AND w.room_type_id = d.id`},
+	{"", `
+		UPDATE request_conditions rc SET room_type_id = s.id
+		FROM facility_room_types d
+		JOIN facility_room_types s ON s.facility_id = $1 AND s.room_type = d.room_type
+		WHERE d.facility_id = $2 AND rc.room_type_id = d.id`},
+	{"", `
+		DELETE FROM facility_room_types d
+		USING facility_room_types s
+		WHERE d.facility_id = $2 AND s.facility_id = $1 AND s.room_type = d.room_type`},
+	{"room_types", `UPDATE facility_room_types SET facility_id = $1, updated_at = CURRENT_TIMESTAMP WHERE facility_id = $2`},
+	{"", `
+		UPDATE facility_fee_schedules SET facility_id = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE facility_id = $2 AND room_type_id IS NOT NULL`},
+	{"images", `
+		UPDATE facility_images SET facility_id = $1,
+		       sort_order = sort_order + (SELECT COALESCE(MAX(sort_order), -1) + 1 FROM facility_images WHERE facility_id = $1)
+		WHERE facility_id = $2`},
+	{"placement_requests", `UPDATE placement_requests SET facility_id = $1, updated_at = CURRENT_TIMESTAMP WHERE facility_id = $2`},
+	{"rooms", `UPDATE message_rooms SET facility_id = $1, updated_at = CURRENT_TIMESTAMP WHERE facility_id = $2`},
+	{"bed_holds", `UPDATE bed_holds SET facility_id = $1, updated_at = CURRENT_TIMESTAMP WHERE facility_id = $2`},
+	{"waitlist_entries", `
+		UPDATE waitlist_entries SET facility_id = $1,
+		       position = position + (SELECT COALESCE(MAX(position), 0) FROM waitlist_entries WHERE facility_id = $1),
+		       updated_at = CURRENT_TIMESTAMP
+		WHERE facility_id = $2`},
+	{"ratings", `UPDATE facility_ratings SET facility_id = $1, updated_at = CURRENT_TIMESTAMP WHERE facility_id = $2`},
+	{"shortlist_entries", `
+		UPDATE shortlist_facilities SET facility_id = $1
+		WHERE facility_id = $2
+		  AND NOT EXISTS (SELECT 1 FROM shortlist_facilities s WHERE s.shortlist_id = shortlist_facilities.shortlist_id AND s.facility_id = $1)`},
+	{"", `DELETE FROM shortlist_facilities WHERE facility_id = $2`},
+	{"", `
+		UPDATE saved_search_matches SET facility_id = $1
+		WHERE facility_id = $2
+		  AND NOT EXISTS (SELECT 1 FROM saved_search_matches m WHERE m.saved_search_id = saved_search_matches.saved_search_id AND m.facility_id = $1)`},
+	{"", `DELETE FROM saved_search_matches WHERE facility_id = $2`},
+	// A survivor without service areas serves every area, so the duplicate's areas only
+	// extend a survivor that has its own
+	{"service_areas", `
+		UPDATE facility_service_areas SET facility_id = $1
+		WHERE facility_id = $2
+		  AND EXISTS (SELECT 1 FROM facility_service_areas s WHERE s.facility_id = $1)
+		  AND NOT EXISTS (SELECT 1 FROM facility_service_areas s WHERE s.facility_id = $1 AND s.municipality_code = facility_service_areas.municipality_code)`},
+	{"", `DELETE FROM facility_service_areas WHERE facility_id = $2`},
+	// Edits of the duplicate's profile waiting for approval no longer apply to anything
+	{"closed_changes", `
+		UPDATE facility_changes
+		SET status = 'rejected', reviewed_at = CURRENT_TIMESTAMP, review_comment = '施設の統合により却下'
+		WHERE facility_id = $2 AND status = 'pending'`},
+	// Subscribers of the duplicate's feed keep their URL unless the survivor has a feed
+	{"calendar_feeds", `
+		UPDATE calendar_feeds SET facility_id = $1
+		WHERE facility_id = $2
+		  AND NOT EXISTS (SELECT 1 FROM calendar_feeds WHERE facility_id = $1)`},
+	{"", `DELETE FROM calendar_feeds WHERE facility_id = $2`},
+}
+
+// MergeFacilities moves the room types, images, placement requests, rooms, documents
+// and related records of the duplicate facility onto the survivor, deactivates the
+// duplicate's account and records the merge
+func (r *MergeRepository) MergeFacilities(survivorID, duplicateID, mergedBy int) (*OrganizationMerge, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	survivorUserID, duplicateUserID, err := lockMergePair(tx, "facilities", survivorID, duplicateID)
+	if err != nil {
+		return nil, err
+	}
+
+	moved := map[string]int{}
+	if err := runMergeSteps(tx, facilityMergeSteps, moved, survivorID, duplicateID); err != nil {
+		return nil, err
+	}
+	if moved["room_types"] > 0 {
+		if err := syncBedCapacity(tx, survivorID); err != nil {
+			return nil, err
+		}
+	}
+
+	merge, err := finishMerge(tx, OrganizationFacility, "facilities", survivorID, duplicateID, survivorUserID, duplicateUserID, mergedBy, moved)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return merge, nil
+}
+
+// hospitalMergeSteps move everything attached to the duplicate hospital ($2) onto the
+// survivor ($1). The duplicate's favorites are folded into the survivor's favorites
+// list; its other shortlists move as they are.
+var hospitalMergeSteps = []mergeStep{
+	{"placement_requests", `UPDATE placement_requests SET hospital_id = $1, updated_at = CURRENT_TIMESTAMP WHERE hospital_id = $2`},
+	{"rooms", `UPDATE message_rooms SET hospital_id = $1, updated_at = CURRENT_TIMESTAMP WHERE hospital_id = $2`},
+	{"ratings", `UPDATE facility_ratings SET hospital_id = $1, updated_at = CURRENT_TIMESTAMP WHERE hospital_id = $2`},
+	{"saved_searches", `UPDATE saved_searches SET hospital_id = $1, user_id = (SELECT user_id FROM hospitals WHERE id = $1), updated_at = CURRENT_TIMESTAMP WHERE hospital_id = $2`},
+	{"", `
+		INSERT INTO shortlist_facilities (shortlist_id, facility_id, note, added_at)
+		SELECT s.id, df.facility_id, df.note, df.added_at
+		FROM shortlists s
+		JOIN shortlists d ON d.hospital_id = $2 AND d.is_favorites
+		JOIN shortlist_facilities df ON df.shortlist_id = d.id
+		WHERE s.hospital_id = $1 AND s.is_favorites
+		ON CONFLICT (shortlist_id, facility_id) DO NOTHING`},
+	{"", `
+		DELETE FROM shortlists d
+		WHERE d.hospital_id = $2 AND d.is_favorites
+		  AND EXISTS (SELECT 1 FROM shortlists s WHERE s.hospital_id = $1 AND s.is_favorites)`},
+	{"shortlists", `UPDATE shortlists SET hospital_id = $1, updated_at = CURRENT_TIMESTAMP WHERE hospital_id = $2`},
+	// Template names are unique per hospital; clashing names get the template ID appended
+	{"request_templates", `
+		UPDATE request_templates d
+		SET hospital_id = $1,
+		    name = CASE WHEN EXISTS (SELECT 1 FROM request_templates s WHERE s.hospital_id = $1 AND s.name = d.name)
+		                THEN LEFT(d.name, 88) || ' (' || d.id || ')' ELSE d.name END,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE d.hospital_id = $2`},
+}
+
+// MergeHospitals moves the placement requests, rooms, documents, saved searches,
+// shortlists and request templates of the duplicate hospital onto the survivor, deactivates the duplicate's
+// account and records the merge
+func (r *MergeRepository) MergeHospitals(survivorID, duplicateID, mergedBy int) (*OrganizationMerge, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	survivorUserID, duplicateUserID, err := lockMergePair(tx, "hospitals", survivorID, duplicateID)
+	if err != nil {
+		return nil, err
+	}
+
+	moved := map[string]int{}
+	if err := runMergeSteps(tx, hospitalMergeSteps, moved, survivorID, duplicateID); err != nil {
+		return nil, err
+	}
+
+	merge, err := finishMerge(tx, OrganizationHospital, "hospitals", survivorID, duplicateID, survivorUserID, duplicateUserID, mergedBy, moved)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return merge, nil
+}
@@ -2,31 +2,87 @@ package models
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
 	"log"
+	"strings"
 	"time"
 )
 
+// DefaultRequestUrgency is the urgency of requests the hospital did not rate
+const DefaultRequestUrgency = "routine"
+
+// RequestUrgencies are the urgency levels of a placement request, most urgent first
+var RequestUrgencies = []string{"urgent", "soon", DefaultRequestUrgency}
+
+// IsRequestUrgency reports whether u is one of RequestUrgencies
+func IsRequestUrgency(u string) bool {
+	for _, v := range RequestUrgencies {
+		if v == u {
+			return true
+		}
+	}
+	return false
+}
+
+// DischargeDeadlineDays is how many days before its target discharge date a request
+// whose room is not accepted yet is flagged as near its deadline
+const DischargeDeadlineDays = 7
+
+// nearDeadlineSQL flags open requests whose target discharge date is at most
+// DischargeDeadlineDays away, or already past, while no room for them is accepted. It
+// expects the request as pr and its room as mr.
+var nearDeadlineSQL = fmt.Sprintf(`(pr.target_discharge_date IS NOT NULL
+	AND pr.target_discharge_date <= CURRENT_DATE + %d
+	AND pr.status IN ('draft', 'pending', 'conditionally_accepted', 'waitlisted', 'accepted')
+	AND (mr.status IS NULL OR mr.status NOT IN ('accepted', 'completed')))`, DischargeDeadlineDays)
+
 type PlacementRequest struct {
-	ID               int        `json:"id"`
-	HospitalID       int        `json:"hospital_id"`
-	FacilityID       int        `json:"facility_id"`
-	PatientAge       int        `json:"patient_age"`
-	PatientGender    string     `json:"patient_gender"`
-	MedicalCondition string     `json:"medical_condition"`
-	Status           string     `json:"status"`
-	RoomID           *string    `json:"room_id,omitempty"`
-	HospitalName     string     `json:"hospital_name,omitempty"`
-	FacilityName     string     `json:"facility_name,omitempty"`
-	CreatedAt        time.Time  `json:"created_at"`
-	UpdatedAt        time.Time  `json:"updated_at"`
+	ID                 int     `json:"id"`
+	HospitalID         int     `json:"hospital_id"`
+	FacilityID         int     `json:"facility_id"`
+	PatientAge         int     `json:"patient_age"`
+	PatientGender      string  `json:"patient_gender"`
+	MedicalCondition   string  `json:"medical_condition"`
+	PatientCareLevel   *string `json:"patient_care_level,omitempty"`
+	PatientHasDementia *bool   `json:"patient_has_dementia,omitempty"`
+	Status             string  `json:"status"`
+	// One of RequestUrgencies
+	Urgency             string     `json:"urgency"`
+	TargetDischargeDate *time.Time `json:"target_discharge_date,omitempty"`
+	// Set when the target discharge date is near or past and no room is accepted yet
+	NearDeadline bool      `json:"near_deadline"`
+	RoomID       *string   `json:"room_id,omitempty"`
+	HospitalName string    `json:"hospital_name,omitempty"`
+	FacilityName string    `json:"facility_name,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	// When the request was submitted to the facility; nil while it is a draft
+	SubmittedAt *time.Time `json:"submitted_at,omitempty"`
+	// Set when the hospital withdrew the request
+	WithdrawnAt      *time.Time `json:"withdrawn_at,omitempty"`
+	WithdrawalReason *string    `json:"withdrawal_reason,omitempty"`
+	// Set when the request was forwarded from an earlier request for the same patient
+	ForwardedFromID *int `json:"forwarded_from_id,omitempty"`
+	// Set when the request is created and the patient may not meet the facility type's requirements
+	EligibilityWarnings []EligibilityWarning `json:"eligibility_warnings,omitempty"`
+	// Set on the request detail: status changes of the request and its room, oldest first
+	History []*StatusTransition `json:"history,omitempty"`
+	// Set on the request detail: files carried over from the room of the request it was forwarded from
+	CarriedFiles []*RequestFile `json:"carried_files,omitempty"`
+	// Set on the hospital's request detail: every request of the referral chain, oldest first
+	Attempts []*ReferralAttempt `json:"attempts,omitempty"`
+	// Set on the request detail: conditions the facility proposed and the hospital's answers, oldest first
+	Conditions []*RequestCondition `json:"conditions,omitempty"`
 }
 
 // CreatePlacementRequest creates a new placement request
-func CreatePlacementRequest(db *sql.DB, req *PlacementRequest) error {
+func CreatePlacementRequest(db Querier, req *PlacementRequest) error {
 	query := `
-		INSERT INTO placement_requests (hospital_id, facility_id, patient_age, patient_gender, medical_condition, status)
-		VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING id, created_at, updated_at
+		INSERT INTO placement_requests (hospital_id, facility_id, patient_age, patient_gender, medical_condition,
+		                                patient_care_level, patient_has_dementia, status, urgency, target_discharge_date, submitted_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, CASE WHEN $8::varchar = 'draft' THEN NULL ELSE CURRENT_TIMESTAMP END)
+		RETURNING id, created_at, updated_at, submitted_at
 	`
 	err := db.QueryRow(
 		query,
@@ -35,17 +91,36 @@ func CreatePlacementRequest(db *sql.DB, req *PlacementRequest) error {
 		req.PatientAge,
 		req.PatientGender,
 		req.MedicalCondition,
+		req.PatientCareLevel,
+		req.PatientHasDementia,
 		req.Status,
-	).Scan(&req.ID, &req.CreatedAt, &req.UpdatedAt)
-	
+		requestUrgency(req),
+		req.TargetDischargeDate,
+	).Scan(&req.ID, &req.CreatedAt, &req.UpdatedAt, &req.SubmittedAt)
+
 	return err
 }
 
+// CreatePlacementRequests creates several placement requests in the transaction tx, so
+// either all of them are created or none
+func CreatePlacementRequests(tx *sql.Tx, reqs []*PlacementRequest) error {
+	for _, req := range reqs {
+		if err := CreatePlacementRequest(tx, req); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // GetPlacementRequestByID retrieves a placement request by ID
 func GetPlacementRequestByID(db *sql.DB, id int) (*PlacementRequest, error) {
 	req := &PlacementRequest{}
 	query := `
-		SELECT pr.id, pr.hospital_id, pr.facility_id, pr.patient_age, pr.patient_gender, pr.medical_condition, pr.status, pr.created_at, pr.updated_at, mr.id as room_id, h.name as hospital_name, f.name as facility_name
+		SELECT pr.id, pr.hospital_id, pr.facility_id, pr.patient_age, pr.patient_gender, pr.medical_condition,
+		       pr.patient_care_level, pr.patient_has_dementia, pr.status, pr.created_at, pr.updated_at, pr.submitted_at,
+		       pr.withdrawn_at, pr.withdrawal_reason, pr.forwarded_from_id, pr.urgency, pr.target_discharge_date, ` + nearDeadlineSQL + `,
+		       mr.id as room_id, h.name as hospital_name, f.name as facility_name
 		FROM placement_requests pr LEFT JOIN message_rooms mr ON pr.id = mr.request_id LEFT JOIN hospitals h ON pr.hospital_id = h.id LEFT JOIN facilities f ON pr.facility_id = f.id
 		WHERE pr.id = $1
 	`
@@ -56,18 +131,27 @@ func GetPlacementRequestByID(db *sql.DB, id int) (*PlacementRequest, error) {
 		&req.PatientAge,
 		&req.PatientGender,
 		&req.MedicalCondition,
+		&req.PatientCareLevel,
+		&req.PatientHasDementia,
 		&req.Status,
 		&req.CreatedAt,
 		&req.UpdatedAt,
+		&req.SubmittedAt,
+		&req.WithdrawnAt,
+		&req.WithdrawalReason,
+		&req.ForwardedFromID,
+		&req.Urgency,
+		&req.TargetDischargeDate,
+		&req.NearDeadline,
 		&req.RoomID,
 		&req.HospitalName,
 		&req.FacilityName,
 	)
-	
+
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
-	
+
 	return req, err
 }
 
@@ -76,7 +160,10 @@ func GetPlacementRequestsByHospitalID(db *sql.DB, hospitalID int) ([]*PlacementR
 	query := `
 		SELECT 
 			pr.id, pr.hospital_id, pr.facility_id, pr.patient_age, pr.patient_gender, 
-			pr.medical_condition, pr.status, pr.created_at, pr.updated_at,
+			pr.medical_condition, pr.patient_care_level, pr.patient_has_dementia,
+			pr.status, pr.created_at, pr.updated_at, pr.submitted_at,
+			pr.withdrawn_at, pr.withdrawal_reason, pr.forwarded_from_id,
+			pr.urgency, pr.target_discharge_date, ` + nearDeadlineSQL + `,
 			h.name as hospital_name,
 			f.name as facility_name,
 			mr.id as room_id
@@ -93,7 +180,7 @@ func GetPlacementRequestsByHospitalID(db *sql.DB, hospitalID int) ([]*PlacementR
 		return nil, err
 	}
 	defer rows.Close()
-	
+
 	var requests []*PlacementRequest
 	for rows.Next() {
 		req := &PlacementRequest{}
@@ -104,9 +191,18 @@ func GetPlacementRequestsByHospitalID(db *sql.DB, hospitalID int) ([]*PlacementR
 			&req.PatientAge,
 			&req.PatientGender,
 			&req.MedicalCondition,
+			&req.PatientCareLevel,
+			&req.PatientHasDementia,
 			&req.Status,
 			&req.CreatedAt,
 			&req.UpdatedAt,
+			&req.SubmittedAt,
+			&req.WithdrawnAt,
+			&req.WithdrawalReason,
+			&req.ForwardedFromID,
+			&req.Urgency,
+			&req.TargetDischargeDate,
+			&req.NearDeadline,
 			&req.HospitalName,
 			&req.FacilityName,
 			&req.RoomID,
@@ -117,22 +213,78 @@ func GetPlacementRequestsByHospitalID(db *sql.DB, hospitalID int) ([]*PlacementR
 		}
 		requests = append(requests, req)
 	}
-	
+
 	if err := rows.Err(); err != nil {
 		log.Printf("Error iterating placement request rows: %v", err)
 		return nil, err
 	}
-	
+
 	log.Printf("Successfully fetched %d requests for hospital %d", len(requests), hospitalID)
 	return requests, nil
 }
 
-// GetPlacementRequestsByFacilityID retrieves all placement requests for a facility
-func GetPlacementRequestsByFacilityID(db *sql.DB, facilityID int) ([]*PlacementRequest, error) {
+// RequestListParams filters and sorts a facility's placement requests
+type RequestListParams struct {
+	Urgency string // one of RequestUrgencies
+	Status  string
+	// Requests whose target discharge date is on or before this date
+	DischargeBy  *time.Time
+	NearDeadline bool
+	SortBy       string // created_at, urgency, target_discharge_date
+	SortOrder    string // asc, desc
+}
+
+// orderClause sorts most urgent and earliest discharge first unless SortOrder says otherwise
+func (p RequestListParams) orderClause() string {
+	order := func(defaultOrder string) string {
+		switch p.SortOrder {
+		case "asc":
+			return "ASC"
+		case "desc":
+			return "DESC"
+		}
+		return defaultOrder
+	}
+
+	switch p.SortBy {
+	case "urgency":
+		return fmt.Sprintf(` ORDER BY CASE pr.urgency WHEN 'urgent' THEN 2 WHEN 'soon' THEN 1 ELSE 0 END %s,
+			pr.target_discharge_date ASC NULLS LAST, pr.created_at DESC`, order("DESC"))
+	case "target_discharge_date":
+		return fmt.Sprintf(" ORDER BY pr.target_discharge_date %s NULLS LAST, pr.created_at DESC", order("ASC"))
+	default:
+		return fmt.Sprintf(" ORDER BY pr.created_at %s", order("DESC"))
+	}
+}
+
+// GetPlacementRequestsByFacilityID retrieves the placement requests for a facility that
+// match params. Drafts are left out; the facility only sees requests that were submitted.
+func GetPlacementRequestsByFacilityID(db *sql.DB, facilityID int, params RequestListParams) ([]*PlacementRequest, error) {
+	args := []interface{}{facilityID}
+	whereClause := "pr.facility_id = $1 AND pr.submitted_at IS NOT NULL"
+	if params.Urgency != "" {
+		args = append(args, params.Urgency)
+		whereClause += fmt.Sprintf(" AND pr.urgency = $%d", len(args))
+	}
+	if params.Status != "" {
+		args = append(args, params.Status)
+		whereClause += fmt.Sprintf(" AND pr.status = $%d", len(args))
+	}
+	if params.DischargeBy != nil {
+		args = append(args, *params.DischargeBy)
+		whereClause += fmt.Sprintf(" AND pr.target_discharge_date <= $%d", len(args))
+	}
+	if params.NearDeadline {
+		whereClause += " AND " + nearDeadlineSQL
+	}
+
 	query := `
 		SELECT 
 			pr.id, pr.hospital_id, pr.facility_id, pr.patient_age, pr.patient_gender, 
-			pr.medical_condition, pr.status, pr.created_at, pr.updated_at,
+			pr.medical_condition, pr.patient_care_level, pr.patient_has_dementia,
+			pr.status, pr.created_at, pr.updated_at, pr.submitted_at,
+			pr.withdrawn_at, pr.withdrawal_reason, pr.forwarded_from_id,
+			pr.urgency, pr.target_discharge_date, ` + nearDeadlineSQL + `,
 			h.name as hospital_name,
 			f.name as facility_name,
 			mr.id as room_id
@@ -140,15 +292,13 @@ func GetPlacementRequestsByFacilityID(db *sql.DB, facilityID int) ([]*PlacementR
 		JOIN hospitals h ON pr.hospital_id = h.id
 		JOIN facilities f ON pr.facility_id = f.id
 		LEFT JOIN message_rooms mr ON pr.id = mr.request_id
-		WHERE pr.facility_id = $1
-		ORDER BY pr.created_at DESC
-	`
-	rows, err := db.Query(query, facilityID)
+		WHERE ` + whereClause + params.orderClause()
+	rows, err := db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	
+
 	var requests []*PlacementRequest
 	for rows.Next() {
 		req := &PlacementRequest{}
@@ -159,9 +309,18 @@ func GetPlacementRequestsByFacilityID(db *sql.DB, facilityID int) ([]*PlacementR
 			&req.PatientAge,
 			&req.PatientGender,
 			&req.MedicalCondition,
+			&req.PatientCareLevel,
+			&req.PatientHasDementia,
 			&req.Status,
 			&req.CreatedAt,
 			&req.UpdatedAt,
+			&req.SubmittedAt,
+			&req.WithdrawnAt,
+			&req.WithdrawalReason,
+			&req.ForwardedFromID,
+			&req.Urgency,
+			&req.TargetDischargeDate,
+			&req.NearDeadline,
 			&req.HospitalName,
 			&req.FacilityName,
 			&req.RoomID,
@@ -171,74 +330,159 @@ func GetPlacementRequestsByFacilityID(db *sql.DB, facilityID int) ([]*PlacementR
 		}
 		requests = append(requests, req)
 	}
-	
+
 	return requests, rows.Err()
 }
 
-// UpdatePlacementRequestStatus updates the status of a placement request
-func UpdatePlacementRequestStatus(db *sql.DB, id int, status string) error {
+// ErrStatusChanged is returned when a record no longer has the status a change was
+// made from, e.g. because the other party acted on it at the same time
+var ErrStatusChanged = errors.New("status was changed by someone else")
+
+// UpdatePlacementRequestStatus moves a placement request from status from to status.
+// It returns ErrStatusChanged if the request is no longer in from.
+func UpdatePlacementRequestStatus(db execer, id int, from, status string) error {
 	query := `
 		UPDATE placement_requests
-		SET status = $1, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $2
+		SET status = $1, updated_at = CURRENT_TIMESTAMP,
+		    submitted_at = CASE WHEN $1::varchar = 'pending' THEN COALESCE(submitted_at, CURRENT_TIMESTAMP) ELSE submitted_at END,
+		    -- The facility responded when it proposed conditions, not when the hospital answers them
+		    responded_at = CASE WHEN $1::varchar = 'conditionally_accepted'
+		                          OR ($1::varchar IN ('accepted', 'rejected') AND status <> 'conditionally_accepted')
+		                        THEN CURRENT_TIMESTAMP ELSE responded_at END
+		WHERE id = $2 AND status = $3
 	`
-	result, err := db.Exec(query, status, id)
+	result, err := db.Exec(query, status, id, from)
 	if err != nil {
 		return err
 	}
-	
+
 	rows, err := result.RowsAffected()
 	if err != nil {
 		return err
 	}
-	
+
 	if rows == 0 {
-		return sql.ErrNoRows
+		return ErrStatusChanged
 	}
-	
+
 	return nil
 }
 
-// UpdatePlacementRequest updates a placement request
-func UpdatePlacementRequest(db *sql.DB, id int, patientAge int, patientGender string, medicalCondition string) error {
+// UpdatePlacementRequest saves the patient details, urgency and target discharge date of
+// a submitted request
+func UpdatePlacementRequest(db *sql.DB, req *PlacementRequest) error {
 	query := `
 		UPDATE placement_requests
-		SET patient_age = $1, patient_gender = $2, medical_condition = $3, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $4
+		SET patient_age = $1, patient_gender = $2, medical_condition = $3,
+		    patient_care_level = $4, patient_has_dementia = $5, urgency = $6, target_discharge_date = $7,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $8
 	`
-	result, err := db.Exec(query, patientAge, patientGender, medicalCondition, id)
+	result, err := db.Exec(query, req.PatientAge, req.PatientGender, req.MedicalCondition, req.PatientCareLevel,
+		req.PatientHasDementia, requestUrgency(req), req.TargetDischargeDate, req.ID)
 	if err != nil {
 		return err
 	}
-	
+
 	rows, err := result.RowsAffected()
 	if err != nil {
 		return err
 	}
-	
+
 	if rows == 0 {
 		return sql.ErrNoRows
 	}
-	
+
 	return nil
 }
 
-// DeletePlacementRequest deletes a placement request
-func DeletePlacementRequest(db *sql.DB, id int) error {
-	query := `DELETE FROM placement_requests WHERE id = $1`
-	result, err := db.Exec(query, id)
+// SaveDraftPlacementRequest saves the facility and patient details of a draft. It returns
+// sql.ErrNoRows if the request is not a draft (anymore).
+func SaveDraftPlacementRequest(db *sql.DB, req *PlacementRequest) error {
+	query := `
+		UPDATE placement_requests
+		SET facility_id = $1, patient_age = $2, patient_gender = $3, medical_condition = $4,
+		    patient_care_level = $5, patient_has_dementia = $6, urgency = $7, target_discharge_date = $8,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $9 AND status = 'draft'
+		RETURNING updated_at
+	`
+	return db.QueryRow(query, req.FacilityID, req.PatientAge, req.PatientGender, req.MedicalCondition,
+		req.PatientCareLevel, req.PatientHasDementia, requestUrgency(req), req.TargetDischargeDate, req.ID).Scan(&req.UpdatedAt)
+}
+
+// requestUrgency is the urgency to store for req, defaulting to DefaultRequestUrgency
+func requestUrgency(req *PlacementRequest) string {
+	if req.Urgency == "" {
+		return DefaultRequestUrgency
+	}
+	return req.Urgency
+}
+
+// MissingRequestFields lists the patient details a request needs before it can be
+// submitted to a facility
+func MissingRequestFields(req *PlacementRequest) []string {
+	missing := []string{}
+	if req.PatientAge <= 0 {
+		missing = append(missing, "patient_age")
+	}
+	if strings.TrimSpace(req.PatientGender) == "" {
+		missing = append(missing, "patient_gender")
+	}
+	if strings.TrimSpace(req.MedicalCondition) == "" {
+		missing = append(missing, "medical_condition")
+	}
+	return missing
+}
+
+// WithdrawPlacementRequest closes a request the hospital no longer needs, together with
+// its waitlist entry, in the transaction tx. The request is kept, with the reason, so its
+// history is preserved. It returns ErrStatusChanged if the request is no longer in from.
+func WithdrawPlacementRequest(tx *sql.Tx, id int, from string, reason *string) error {
+	result, err := tx.Exec(`
+		UPDATE placement_requests
+		SET status = 'withdrawn', withdrawn_at = CURRENT_TIMESTAMP, withdrawal_reason = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2 AND status = $3
+	`, reason, id, from)
 	if err != nil {
 		return err
 	}
-	
-	rows, err := result.RowsAffected()
-	if err != nil {
+	if rows, err := result.RowsAffected(); err != nil {
 		return err
+	} else if rows == 0 {
+		return ErrStatusChanged
 	}
-	
-	if rows == 0 {
-		return sql.ErrNoRows
+
+	var entryID int
+	err = tx.QueryRow(`
+		SELECT id FROM waitlist_entries WHERE request_id = $1 AND status IN ('waiting', 'notified')
+	`, id).Scan(&entryID)
+	if err == nil {
+		if _, err := closeWaitlistEntry(tx, entryID, WaitlistRemoved); err != nil {
+			return err
+		}
+	} else if err != sql.ErrNoRows {
+		return err
 	}
-	
+
 	return nil
 }
+
+// NotifyRequestWithdrawn tells the facility that a request it received was withdrawn.
+// Drafts never reached the facility, so nothing is sent for them.
+func NotifyRequestWithdrawn(db execer, id int) error {
+	_, err := db.Exec(`
+		INSERT INTO notifications (user_id, type, title, body, link, data)
+		SELECT f.user_id, $1,
+		       '「' || h.name || '」が入居依頼を取り下げました',
+		       pr.patient_age || '歳・' || pr.patient_gender || 'の入居依頼が取り下げられました。' ||
+		       COALESCE('理由: ' || pr.withdrawal_reason, ''),
+		       '/requests/' || pr.id,
+		       json_build_object('request_id', pr.id, 'hospital_id', h.id)
+		FROM placement_requests pr
+		JOIN hospitals h ON pr.hospital_id = h.id
+		JOIN facilities f ON pr.facility_id = f.id
+		WHERE pr.id = $2 AND pr.submitted_at IS NOT NULL
+	`, NotificationRequestWithdrawn, id)
+	return err
+}
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/social-worker-platform/backend/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMissingRequestFields(t *testing.T) {
+	assert.Equal(t, []string{"patient_age", "patient_gender", "medical_condition"}, MissingRequestFields(&PlacementRequest{}))
+	assert.Equal(t, []string{"medical_condition"}, MissingRequestFields(&PlacementRequest{PatientAge: 82, PatientGender: "female", MedicalCondition: "  "}))
+	assert.Empty(t, MissingRequestFields(&PlacementRequest{PatientAge: 82, PatientGender: "female", MedicalCondition: "脳梗塞後のリハビリ"}))
+}
+
+// createTestPlacementRequest creates a hospital, a facility and a pending request between them
+func createTestPlacementRequest(t *testing.T, db *sql.DB) *PlacementRequest {
+	t.Helper()
+
+	userRepo := NewUserRepository(db)
+	suffix := time.Now().UnixNano()
+
+	hospitalUser, err := userRepo.Create(fmt.Sprintf("hospital%d@example.com", suffix), "password", "hospital")
+	require.NoError(t, err)
+	hospital, err := NewHospitalRepository(db).Create(hospitalUser.ID, "Test Hospital", "Address", "123-456")
+	require.NoError(t, err)
+
+	facilityUser, err := userRepo.Create(fmt.Sprintf("facility%d@example.com", suffix), "password", "facility")
+	require.NoError(t, err)
+	facility, err := NewFacilityRepository(db).Create(facilityUser.ID, "Test Facility", "Address", "123-456", 10, "None")
+	require.NoError(t, err)
+
+	req := &PlacementRequest{
+		HospitalID:       hospital.ID,
+		FacilityID:       facility.ID,
+		PatientAge:       82,
+		PatientGender:    "female",
+		MedicalCondition: "脳梗塞後のリハビリ",
+		Status:           "pending",
+	}
+	require.NoError(t, CreatePlacementRequest(db, req))
+	return req
+}
+
+func TestStatusUpdatesRefuseStaleStatus(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping database tests in short mode")
+	}
+
+	db := config.SetupTestDatabase(t)
+	defer config.TeardownTestDatabase(t, db)
+
+	req := createTestPlacementRequest(t, db)
+
+	t.Run("second of two concurrent accepts", func(t *testing.T) {
+		require.NoError(t, UpdatePlacementRequestStatus(db, req.ID, "pending", "accepted"))
+		assert.ErrorIs(t, UpdatePlacementRequestStatus(db, req.ID, "pending", "accepted"), ErrStatusChanged)
+
+		stored, err := GetPlacementRequestByID(db, req.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "accepted", stored.Status)
+	})
+
+	t.Run("accept after the request was withdrawn", func(t *testing.T) {
+		other := createTestPlacementRequest(t, db)
+		tx, err := db.Begin()
+		require.NoError(t, err)
+		require.NoError(t, WithdrawPlacementRequest(tx, other.ID, "pending", nil))
+		require.NoError(t, tx.Commit())
+
+		assert.ErrorIs(t, UpdatePlacementRequestStatus(db, other.ID, "pending", "accepted"), ErrStatusChanged)
+
+		stored, err := GetPlacementRequestByID(db, other.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "withdrawn", stored.Status)
+	})
+
+	t.Run("room moved on by the other party", func(t *testing.T) {
+		room, err := CreateMessageRoomForRequest(db, req.ID, "negotiating")
+		require.NoError(t, err)
+		assert.Equal(t, req.HospitalID, room.HospitalID)
+		assert.Equal(t, req.FacilityID, room.FacilityID)
+
+		require.NoError(t, UpdateMessageRoomStatus(db, room.ID, "negotiating", "rejected"))
+		assert.ErrorIs(t, UpdateMessageRoomStatus(db, room.ID, "negotiating", "accepted"), ErrStatusChanged)
+	})
+}
@@ -0,0 +1,148 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// RequestFile is a room file the hospital carried over when forwarding a request. It
+// becomes a file of the new request's room once the facility accepts the request.
+type RequestFile struct {
+	ID           int       `json:"id"`
+	RequestID    int       `json:"request_id"`
+	SourceFileID *int      `json:"source_file_id,omitempty"`
+	SenderID     int       `json:"sender_id"`
+	FileName     string    `json:"file_name"`
+	FilePath     string    `json:"-"`
+	FileType     string    `json:"file_type"`
+	FileSize     int64     `json:"file_size"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// ReferralAttempt is one request of a referral chain: the original request and every
+// request forwarded from it
+type ReferralAttempt struct {
+	RequestID       int       `json:"request_id"`
+	ForwardedFromID *int      `json:"forwarded_from_id,omitempty"`
+	FacilityID      int       `json:"facility_id"`
+	FacilityName    string    `json:"facility_name"`
+	Status          string    `json:"status"`
+	RoomID          *string   `json:"room_id,omitempty"`
+	RoomStatus      *string   `json:"room_status,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// ForwardPlacementRequest creates req, whose ForwardedFromID names the request it was
+// forwarded from, together with the files carried over, in the transaction tx
+func ForwardPlacementRequest(tx *sql.Tx, req *PlacementRequest, files []*RequestFile) error {
+	err := tx.QueryRow(`
+		INSERT INTO placement_requests (hospital_id, facility_id, patient_age, patient_gender, medical_condition,
+		                                patient_care_level, patient_has_dementia, status, urgency, target_discharge_date,
+		                                forwarded_from_id, submitted_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, CASE WHEN $8::varchar = 'draft' THEN NULL ELSE CURRENT_TIMESTAMP END)
+		RETURNING id, created_at, updated_at, submitted_at
+	`, req.HospitalID, req.FacilityID, req.PatientAge, req.PatientGender, req.MedicalCondition,
+		req.PatientCareLevel, req.PatientHasDementia, req.Status, requestUrgency(req), req.TargetDischargeDate,
+		req.ForwardedFromID,
+	).Scan(&req.ID, &req.CreatedAt, &req.UpdatedAt, &req.SubmittedAt)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		f.RequestID = req.ID
+		err = tx.QueryRow(`
+			INSERT INTO placement_request_files (request_id, source_file_id, sender_id, file_name, file_path, file_type, file_size)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			RETURNING id, created_at
+		`, f.RequestID, f.SourceFileID, f.SenderID, f.FileName, f.FilePath, f.FileType, f.FileSize).Scan(&f.ID, &f.CreatedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	req.CarriedFiles = files
+	return nil
+}
+
+// GetRequestFiles returns the files carried over to a request
+func GetRequestFiles(db *sql.DB, requestID int) ([]*RequestFile, error) {
+	rows, err := db.Query(`
+		SELECT id, request_id, source_file_id, sender_id, file_name, file_path,
+		       COALESCE(file_type, ''), COALESCE(file_size, 0), created_at
+		FROM placement_request_files
+		WHERE request_id = $1
+		ORDER BY id
+	`, requestID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	files := []*RequestFile{}
+	for rows.Next() {
+		f := &RequestFile{}
+		err := rows.Scan(&f.ID, &f.RequestID, &f.SourceFileID, &f.SenderID, &f.FileName, &f.FilePath,
+			&f.FileType, &f.FileSize, &f.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	return files, rows.Err()
+}
+
+// AttachForwardedFiles adds the files carried over to a request to the room opened for it
+func AttachForwardedFiles(db execer, requestID int, roomID string) error {
+	_, err := db.Exec(`
+		INSERT INTO room_files (room_id, sender_id, file_name, file_path, file_type, file_size)
+		SELECT $2, sender_id, file_name, file_path, file_type, file_size
+		FROM placement_request_files
+		WHERE request_id = $1
+		ORDER BY id
+	`, requestID, roomID)
+	return err
+}
+
+// GetReferralAttempts returns every request of the referral chain requestID belongs to,
+// oldest first
+func GetReferralAttempts(db *sql.DB, requestID int) ([]*ReferralAttempt, error) {
+	rows, err := db.Query(`
+		WITH RECURSIVE ancestors AS (
+			SELECT id, forwarded_from_id FROM placement_requests WHERE id = $1
+			UNION ALL
+			SELECT pr.id, pr.forwarded_from_id
+			FROM placement_requests pr
+			JOIN ancestors a ON pr.id = a.forwarded_from_id
+		),
+		chain AS (
+			SELECT id FROM ancestors WHERE forwarded_from_id IS NULL
+			UNION ALL
+			SELECT pr.id
+			FROM placement_requests pr
+			JOIN chain c ON pr.forwarded_from_id = c.id
+		)
+		SELECT pr.id, pr.forwarded_from_id, pr.facility_id, f.name, pr.status, mr.id, mr.status, pr.created_at
+		FROM chain
+		JOIN placement_requests pr ON pr.id = chain.id
+		JOIN facilities f ON pr.facility_id = f.id
+		LEFT JOIN message_rooms mr ON mr.request_id = pr.id
+		ORDER BY pr.created_at, pr.id
+	`, requestID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attempts := []*ReferralAttempt{}
+	for rows.Next() {
+		a := &ReferralAttempt{}
+		err := rows.Scan(&a.RequestID, &a.ForwardedFromID, &a.FacilityID, &a.FacilityName, &a.Status,
+			&a.RoomID, &a.RoomStatus, &a.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		attempts = append(attempts, a)
+	}
+	return attempts, rows.Err()
+}
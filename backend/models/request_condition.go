@@ -0,0 +1,186 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Request condition statuses
+const (
+	ConditionsProposed  = "proposed"
+	ConditionsAccepted  = "accepted"
+	ConditionsDeclined  = "declined"
+	ConditionsWithdrawn = "withdrawn"
+)
+
+var (
+	ErrConditionsAlreadyProposed = errors.New("conditions are already awaiting the hospital's answer")
+	ErrConditionsEmpty           = errors.New("at least one condition is required")
+	ErrConditionsAnswered        = errors.New("conditions were already answered")
+)
+
+// RequestCondition is what a facility asks for to accept a placement request, e.g. a
+// later admission date or a shared room instead of the one requested. The hospital
+// accepts the conditions, which opens the message room, or declines them.
+type RequestCondition struct {
+	ID           int        `json:"id"`
+	RequestID    int        `json:"request_id"`
+	ProposedBy   *int       `json:"proposed_by,omitempty"`
+	EarliestDate *time.Time `json:"earliest_date,omitempty"`
+	RoomTypeID   *int       `json:"room_type_id,omitempty"`
+	RoomTypeName *string    `json:"room_type_name,omitempty"`
+	MonthlyFee   *int       `json:"monthly_fee,omitempty"`
+	Terms        *string    `json:"terms,omitempty"`
+	Status       string     `json:"status"`
+	RespondedBy  *int       `json:"responded_by,omitempty"`
+	ResponseNote *string    `json:"response_note,omitempty"`
+	RespondedAt  *time.Time `json:"responded_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// Empty reports whether no condition is set
+func (rc *RequestCondition) Empty() bool {
+	return rc.EarliestDate == nil && rc.RoomTypeID == nil && rc.MonthlyFee == nil &&
+		(rc.Terms == nil || strings.TrimSpace(*rc.Terms) == "")
+}
+
+// Summary lists the conditions one per line, as posted to the room once they are accepted
+func (rc *RequestCondition) Summary() string {
+	var lines []string
+	if rc.EarliestDate != nil {
+		lines = append(lines, "入居可能日: "+rc.EarliestDate.Format("2006-01-02")+" 以降")
+	}
+	if rc.RoomTypeName != nil {
+		lines = append(lines, "部屋種別: "+*rc.RoomTypeName)
+	}
+	if rc.MonthlyFee != nil {
+		lines = append(lines, fmt.Sprintf("月額料金: %d円", *rc.MonthlyFee))
+	}
+	if rc.Terms != nil && strings.TrimSpace(*rc.Terms) != "" {
+		lines = append(lines, "その他: "+strings.TrimSpace(*rc.Terms))
+	}
+	return strings.Join(lines, "\n")
+}
+
+const requestConditionColumns = `
+	rc.id, rc.request_id, rc.proposed_by, rc.earliest_date, rc.room_type_id, COALESCE(rc.room_type_name, frt.room_type), rc.monthly_fee,
+	rc.terms, rc.status, rc.responded_by, rc.response_note, rc.responded_at, rc.created_at`
+
+func scanRequestCondition(row rowScanner) (*RequestCondition, error) {
+	rc := &RequestCondition{}
+	err := row.Scan(&rc.ID, &rc.RequestID, &rc.ProposedBy, &rc.EarliestDate, &rc.RoomTypeID, &rc.RoomTypeName,
+		&rc.MonthlyFee, &rc.Terms, &rc.Status, &rc.RespondedBy, &rc.ResponseNote, &rc.RespondedAt, &rc.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return rc, nil
+}
+
+// CreateRequestCondition records conditions proposed by the facility. A request has at
+// most one set of conditions awaiting an answer. The room type name is kept with the
+// conditions, so they still read the same once the room type is deleted.
+func CreateRequestCondition(db Querier, rc *RequestCondition) error {
+	if rc.Empty() {
+		return ErrConditionsEmpty
+	}
+	err := db.QueryRow(`
+		INSERT INTO request_conditions (request_id, proposed_by, earliest_date, room_type_id, room_type_name, monthly_fee, terms)
+		VALUES ($1, $2, $3, $4, (SELECT room_type FROM facility_room_types WHERE id = $4), $5, $6)
+		RETURNING id, status, created_at, room_type_name
+	`, rc.RequestID, rc.ProposedBy, rc.EarliestDate, rc.RoomTypeID, rc.MonthlyFee, rc.Terms).Scan(
+		&rc.ID, &rc.Status, &rc.CreatedAt, &rc.RoomTypeName,
+	)
+	if isUniqueViolation(err) {
+		return ErrConditionsAlreadyProposed
+	}
+	return err
+}
+
+// GetRequestConditions returns every set of conditions proposed for a request, oldest first
+func GetRequestConditions(db *sql.DB, requestID int) ([]*RequestCondition, error) {
+	rows, err := db.Query(`SELECT `+requestConditionColumns+`
+		FROM request_conditions rc
+		LEFT JOIN facility_room_types frt ON rc.room_type_id = frt.id
+		WHERE rc.request_id = $1
+		ORDER BY rc.created_at, rc.id
+	`, requestID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	conditions := []*RequestCondition{}
+	for rows.Next() {
+		rc, err := scanRequestCondition(rows)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, rc)
+	}
+	return conditions, rows.Err()
+}
+
+// GetProposedRequestCondition returns the conditions awaiting the hospital's answer, or nil
+func GetProposedRequestCondition(db *sql.DB, requestID int) (*RequestCondition, error) {
+	rc, err := scanRequestCondition(db.QueryRow(`SELECT `+requestConditionColumns+`
+		FROM request_conditions rc
+		LEFT JOIN facility_room_types frt ON rc.room_type_id = frt.id
+		WHERE rc.request_id = $1 AND rc.status = 'proposed'
+	`, requestID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return rc, err
+}
+
+// GetAcceptedRequestCondition returns the latest conditions the hospital accepted, or nil
+func GetAcceptedRequestCondition(db Querier, requestID int) (*RequestCondition, error) {
+	rc, err := scanRequestCondition(db.QueryRow(`SELECT `+requestConditionColumns+`
+		FROM request_conditions rc
+		LEFT JOIN facility_room_types frt ON rc.room_type_id = frt.id
+		WHERE rc.request_id = $1 AND rc.status = 'accepted'
+		ORDER BY rc.responded_at DESC, rc.id DESC
+		LIMIT 1
+	`, requestID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return rc, err
+}
+
+// RespondToRequestCondition records the hospital's answer to proposed conditions. It
+// returns ErrConditionsAnswered if the conditions are no longer awaiting an answer.
+func RespondToRequestCondition(db execer, id int, status string, respondedBy int, note *string) error {
+	result, err := db.Exec(`
+		UPDATE request_conditions
+		SET status = $1, responded_by = $2, response_note = $3, responded_at = CURRENT_TIMESTAMP
+		WHERE id = $4 AND status = 'proposed'
+	`, status, respondedBy, note, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrConditionsAnswered
+	}
+
+	return nil
+}
+
+// WithdrawRequestConditions closes the conditions of a request that was withdrawn
+// before the hospital answered them
+func WithdrawRequestConditions(db execer, requestID int) error {
+	_, err := db.Exec(`
+		UPDATE request_conditions SET status = 'withdrawn', responded_at = CURRENT_TIMESTAMP
+		WHERE request_id = $1 AND status = 'proposed'
+	`, requestID)
+	return err
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/social-worker-platform/backend/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestConditionSummary(t *testing.T) {
+	blank := "  "
+	assert.True(t, (&RequestCondition{}).Empty())
+	assert.True(t, (&RequestCondition{Terms: &blank}).Empty())
+
+	date := time.Date(2026, 11, 15, 0, 0, 0, 0, time.UTC)
+	roomType := "2人部屋"
+	fee := 150000
+	terms := " 保証金にご家族が同意すること "
+	rc := &RequestCondition{EarliestDate: &date, RoomTypeName: &roomType, MonthlyFee: &fee, Terms: &terms}
+	assert.False(t, rc.Empty())
+	assert.Equal(t, "入居可能日: 2026-11-15 以降\n部屋種別: 2人部屋\n月額料金: 150000円\nその他: 保証金にご家族が同意すること", rc.Summary())
+
+	assert.Equal(t, "月額料金: 150000円", (&RequestCondition{MonthlyFee: &fee}).Summary())
+}
+
+func TestRequestConditionsOutliveTheirRoomType(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping database tests in short mode")
+	}
+
+	db := config.SetupTestDatabase(t)
+	defer config.TeardownTestDatabase(t, db)
+
+	repo := NewFacilityRepository(db)
+	req := createTestPlacementRequest(t, db)
+
+	roomType, err := repo.CreateRoomType(req.FacilityID, FacilityRoomTypeInput{RoomType: "2人部屋", Capacity: 2, Available: 1})
+	require.NoError(t, err)
+
+	conditions := &RequestCondition{RequestID: req.ID, RoomTypeID: &roomType.ID}
+	require.NoError(t, CreateRequestCondition(db, conditions))
+	require.NotNil(t, conditions.RoomTypeName)
+	assert.Equal(t, "2人部屋", *conditions.RoomTypeName)
+
+	assert.ErrorIs(t, CreateRequestCondition(db, &RequestCondition{RequestID: req.ID, RoomTypeID: &roomType.ID}), ErrConditionsAlreadyProposed)
+
+	// The room type was the only condition, which must not keep it from being deleted
+	require.NoError(t, repo.DeleteRoomType(req.FacilityID, roomType.ID, roomType.Version))
+
+	saved, err := GetProposedRequestCondition(db, req.ID)
+	require.NoError(t, err)
+	require.NotNil(t, saved)
+	assert.Nil(t, saved.RoomTypeID)
+	require.NotNil(t, saved.RoomTypeName)
+	assert.Equal(t, "2人部屋", *saved.RoomTypeName)
+
+	responder, err := NewUserRepository(db).Create("responder@example.com", "password", "hospital")
+	require.NoError(t, err)
+	require.NoError(t, RespondToRequestCondition(db, saved.ID, ConditionsDeclined, responder.ID, nil))
+	assert.ErrorIs(t, RespondToRequestCondition(db, saved.ID, ConditionsAccepted, responder.ID, nil), ErrConditionsAnswered)
+}
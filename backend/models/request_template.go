@@ -0,0 +1,117 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+var ErrRequestTemplateNameTaken = errors.New("a template with this name already exists")
+
+// RequestTemplate is named, reusable content for placement requests, such as the
+// standard medical summary of a ward
+type RequestTemplate struct {
+	ID                 int       `json:"id"`
+	HospitalID         int       `json:"hospital_id"`
+	Name               string    `json:"name"`
+	MedicalCondition   string    `json:"medical_condition"`
+	PatientCareLevel   *string   `json:"patient_care_level,omitempty"`
+	PatientHasDementia *bool     `json:"patient_has_dementia,omitempty"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+// CreateRequestTemplate creates a new template
+func CreateRequestTemplate(db *sql.DB, t *RequestTemplate) error {
+	query := `
+		INSERT INTO request_templates (hospital_id, name, medical_condition, patient_care_level, patient_has_dementia)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at, updated_at
+	`
+	err := db.QueryRow(query, t.HospitalID, t.Name, t.MedicalCondition, t.PatientCareLevel, t.PatientHasDementia).
+		Scan(&t.ID, &t.CreatedAt, &t.UpdatedAt)
+	if isUniqueViolation(err) {
+		return ErrRequestTemplateNameTaken
+	}
+	return err
+}
+
+const requestTemplateSelect = `
+	SELECT id, hospital_id, name, medical_condition, patient_care_level, patient_has_dementia, created_at, updated_at
+	FROM request_templates
+`
+
+func scanRequestTemplate(row rowScanner) (*RequestTemplate, error) {
+	t := &RequestTemplate{}
+	err := row.Scan(&t.ID, &t.HospitalID, &t.Name, &t.MedicalCondition, &t.PatientCareLevel, &t.PatientHasDementia,
+		&t.CreatedAt, &t.UpdatedAt)
+	return t, err
+}
+
+// GetRequestTemplateByID retrieves a template by ID
+func GetRequestTemplateByID(db *sql.DB, id int) (*RequestTemplate, error) {
+	t, err := scanRequestTemplate(db.QueryRow(requestTemplateSelect+` WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// GetRequestTemplatesByHospitalID retrieves the templates of a hospital by name
+func GetRequestTemplatesByHospitalID(db *sql.DB, hospitalID int) ([]*RequestTemplate, error) {
+	rows, err := db.Query(requestTemplateSelect+` WHERE hospital_id = $1 ORDER BY name`, hospitalID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	templates := []*RequestTemplate{}
+	for rows.Next() {
+		t, err := scanRequestTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, t)
+	}
+
+	return templates, rows.Err()
+}
+
+// UpdateRequestTemplate saves the name and content of a template
+func UpdateRequestTemplate(db *sql.DB, t *RequestTemplate) error {
+	query := `
+		UPDATE request_templates
+		SET name = $1, medical_condition = $2, patient_care_level = $3, patient_has_dementia = $4,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $5
+		RETURNING updated_at
+	`
+	err := db.QueryRow(query, t.Name, t.MedicalCondition, t.PatientCareLevel, t.PatientHasDementia, t.ID).
+		Scan(&t.UpdatedAt)
+	if isUniqueViolation(err) {
+		return ErrRequestTemplateNameTaken
+	}
+	return err
+}
+
+// DeleteRequestTemplate deletes a template. Requests created from it are not affected.
+func DeleteRequestTemplate(db *sql.DB, id int) error {
+	result, err := db.Exec(`DELETE FROM request_templates WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
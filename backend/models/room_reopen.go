@@ -0,0 +1,125 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// Reopen request statuses
+const (
+	ReopenPending   = "pending"
+	ReopenConfirmed = "confirmed"
+	ReopenDeclined  = "declined"
+	ReopenCancelled = "cancelled"
+)
+
+var (
+	ErrReopenAlreadyRequested = errors.New("reopening this room has already been requested")
+	ErrReopenNotPending       = errors.New("reopen request is no longer pending")
+)
+
+// RoomReopenRequest asks the other party to reopen a completed or rejected room
+type RoomReopenRequest struct {
+	ID            int        `json:"id"`
+	RoomID        string     `json:"room_id"`
+	RequestedBy   *int       `json:"requested_by,omitempty"`
+	RequestedRole string     `json:"requested_role"`
+	Reason        *string    `json:"reason,omitempty"`
+	Status        string     `json:"status"`
+	RespondedBy   *int       `json:"responded_by,omitempty"`
+	RespondedAt   *time.Time `json:"responded_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// CreateRoomReopenRequest records a reopen request. A room has at most one pending
+// request at a time.
+func CreateRoomReopenRequest(db *sql.DB, r *RoomReopenRequest) error {
+	err := db.QueryRow(`
+		INSERT INTO room_reopen_requests (room_id, requested_by, requested_role, reason)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, status, created_at
+	`, r.RoomID, r.RequestedBy, r.RequestedRole, r.Reason).Scan(&r.ID, &r.Status, &r.CreatedAt)
+	if isUniqueViolation(err) {
+		return ErrReopenAlreadyRequested
+	}
+	return err
+}
+
+// GetPendingRoomReopenRequest returns the room's reopen request awaiting an answer, or nil
+func GetPendingRoomReopenRequest(db *sql.DB, roomID string) (*RoomReopenRequest, error) {
+	r := &RoomReopenRequest{}
+	err := db.QueryRow(`
+		SELECT id, room_id, requested_by, requested_role, reason, status, responded_by, responded_at, created_at
+		FROM room_reopen_requests
+		WHERE room_id = $1 AND status = 'pending'
+	`, roomID).Scan(&r.ID, &r.RoomID, &r.RequestedBy, &r.RequestedRole, &r.Reason, &r.Status,
+		&r.RespondedBy, &r.RespondedAt, &r.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// CloseRoomReopenRequest answers a pending reopen request without reopening the room.
+// It returns sql.ErrNoRows if the request is no longer pending.
+func CloseRoomReopenRequest(db *sql.DB, id int, status string, respondedBy int) error {
+	return closeRoomReopenRequest(db, id, status, respondedBy)
+}
+
+func closeRoomReopenRequest(db execer, id int, status string, respondedBy int) error {
+	result, err := db.Exec(`
+		UPDATE room_reopen_requests
+		SET status = $1, responded_by = $2, responded_at = CURRENT_TIMESTAMP
+		WHERE id = $3 AND status = 'pending'
+	`, status, respondedBy, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// ReopenMessageRoom moves a room from status from back to status and clears both
+// completion flags so the room has to be completed again, in the transaction tx. A
+// pending reopen request is marked confirmed by confirmedBy. It returns ErrStatusChanged
+// if the room is no longer in from and ErrReopenNotPending if the request was answered.
+func ReopenMessageRoom(tx *sql.Tx, roomID, from, status string, reopenRequestID *int, confirmedBy int) error {
+	result, err := tx.Exec(`
+		UPDATE message_rooms
+		SET status = $1, hospital_completed = false, facility_completed = false, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2 AND status = $3
+	`, status, roomID, from)
+	if err != nil {
+		return err
+	}
+	if rows, err := result.RowsAffected(); err != nil {
+		return err
+	} else if rows == 0 {
+		return ErrStatusChanged
+	}
+
+	if reopenRequestID != nil {
+		err := closeRoomReopenRequest(tx, *reopenRequestID, ReopenConfirmed, confirmedBy)
+		if err == sql.ErrNoRows {
+			return ErrReopenNotPending
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,366 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Kinds of event arranged in a message room
+const (
+	ScheduleInterview = "interview"
+	ScheduleAdmission = "admission"
+	ScheduleTransport = "transport"
+)
+
+// Schedule proposal statuses
+const (
+	ProposalPending   = "pending"
+	ProposalAccepted  = "accepted"
+	ProposalDeclined  = "declined"
+	ProposalCancelled = "cancelled"
+)
+
+// MaxProposalSlots limits how many alternative times one proposal offers
+const MaxProposalSlots = 10
+
+var (
+	ErrProposalPending = errors.New("a proposal for this event is already awaiting an answer")
+	ErrSlotNotFound    = errors.New("slot does not belong to this proposal")
+)
+
+// ValidScheduleEventType reports whether t is a known event kind
+func ValidScheduleEventType(t string) bool {
+	return t == ScheduleInterview || t == ScheduleAdmission || t == ScheduleTransport
+}
+
+// ScheduleSlot is one time offered in a proposal
+type ScheduleSlot struct {
+	ID         int        `json:"id"`
+	ProposalID int        `json:"proposal_id"`
+	StartsAt   time.Time  `json:"starts_at"`
+	EndsAt     *time.Time `json:"ends_at,omitempty"`
+}
+
+// ScheduleProposal offers the other party of a room one or more times for an event
+type ScheduleProposal struct {
+	ID             int             `json:"id"`
+	RoomID         string          `json:"room_id"`
+	EventType      string          `json:"event_type"`
+	ProposedBy     *int            `json:"proposed_by,omitempty"`
+	ProposedRole   string          `json:"proposed_role"`
+	Location       *string         `json:"location,omitempty"`
+	Note           *string         `json:"note,omitempty"`
+	Status         string          `json:"status"`
+	AcceptedSlotID *int            `json:"accepted_slot_id,omitempty"`
+	RespondedBy    *int            `json:"responded_by,omitempty"`
+	RespondedAt    *time.Time      `json:"responded_at,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	Slots          []*ScheduleSlot `json:"slots"`
+}
+
+// RoomEvent is a confirmed event of a room. A room has at most one event of each kind;
+// confirming a new time replaces it.
+type RoomEvent struct {
+	ID          int        `json:"id"`
+	RoomID      string     `json:"room_id"`
+	EventType   string     `json:"event_type"`
+	StartsAt    time.Time  `json:"starts_at"`
+	EndsAt      *time.Time `json:"ends_at,omitempty"`
+	Location    *string    `json:"location,omitempty"`
+	Note        *string    `json:"note,omitempty"`
+	ProposalID  *int       `json:"proposal_id,omitempty"`
+	ConfirmedBy *int       `json:"confirmed_by,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	// For schedule views
+	RequestID     int    `json:"request_id,omitempty"`
+	HospitalName  string `json:"hospital_name,omitempty"`
+	FacilityName  string `json:"facility_name,omitempty"`
+	PatientAge    int    `json:"patient_age,omitempty"`
+	PatientGender string `json:"patient_gender,omitempty"`
+}
+
+// CreateScheduleProposal saves a proposal with its slots. A room has at most one
+// pending proposal per event kind.
+func CreateScheduleProposal(db *sql.DB, p *ScheduleProposal) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		INSERT INTO schedule_proposals (room_id, event_type, proposed_by, proposed_role, location, note)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, status, created_at
+	`, p.RoomID, p.EventType, p.ProposedBy, p.ProposedRole, p.Location, p.Note).Scan(&p.ID, &p.Status, &p.CreatedAt)
+	if isUniqueViolation(err) {
+		return ErrProposalPending
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, slot := range p.Slots {
+		slot.ProposalID = p.ID
+		err = tx.QueryRow(`
+			INSERT INTO schedule_proposal_slots (proposal_id, starts_at, ends_at)
+			VALUES ($1, $2, $3)
+			RETURNING id
+		`, p.ID, slot.StartsAt, slot.EndsAt).Scan(&slot.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+const scheduleProposalColumns = `
+	id, room_id, event_type, proposed_by, proposed_role, location, note, status,
+	accepted_slot_id, responded_by, responded_at, created_at`
+
+func scanScheduleProposal(row rowScanner) (*ScheduleProposal, error) {
+	p := &ScheduleProposal{}
+	err := row.Scan(&p.ID, &p.RoomID, &p.EventType, &p.ProposedBy, &p.ProposedRole, &p.Location, &p.Note,
+		&p.Status, &p.AcceptedSlotID, &p.RespondedBy, &p.RespondedAt, &p.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	p.Slots = []*ScheduleSlot{}
+	return p, nil
+}
+
+// GetScheduleProposalByID returns a proposal with its slots, or nil
+func GetScheduleProposalByID(db *sql.DB, id int) (*ScheduleProposal, error) {
+	p, err := scanScheduleProposal(db.QueryRow(`SELECT `+scheduleProposalColumns+`
+		FROM schedule_proposals WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := attachScheduleSlots(db, []*ScheduleProposal{p}); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// GetScheduleProposalsByRoomID returns the room's proposals, newest first, with their slots
+func GetScheduleProposalsByRoomID(db *sql.DB, roomID string) ([]*ScheduleProposal, error) {
+	rows, err := db.Query(`SELECT `+scheduleProposalColumns+`
+		FROM schedule_proposals
+		WHERE room_id = $1
+		ORDER BY created_at DESC, id DESC
+	`, roomID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	proposals := []*ScheduleProposal{}
+	for rows.Next() {
+		p, err := scanScheduleProposal(rows)
+		if err != nil {
+			return nil, err
+		}
+		proposals = append(proposals, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := attachScheduleSlots(db, proposals); err != nil {
+		return nil, err
+	}
+	return proposals, nil
+}
+
+// attachScheduleSlots loads the slots of proposals in time order
+func attachScheduleSlots(db *sql.DB, proposals []*ScheduleProposal) error {
+	if len(proposals) == 0 {
+		return nil
+	}
+
+	byID := make(map[int]*ScheduleProposal, len(proposals))
+	ids := make([]int64, len(proposals))
+	for i, p := range proposals {
+		byID[p.ID] = p
+		ids[i] = int64(p.ID)
+	}
+
+	rows, err := db.Query(`
+		SELECT id, proposal_id, starts_at, ends_at
+		FROM schedule_proposal_slots
+		WHERE proposal_id = ANY($1)
+		ORDER BY starts_at, id
+	`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		slot := &ScheduleSlot{}
+		if err := rows.Scan(&slot.ID, &slot.ProposalID, &slot.StartsAt, &slot.EndsAt); err != nil {
+			return err
+		}
+		if p := byID[slot.ProposalID]; p != nil {
+			p.Slots = append(p.Slots, slot)
+		}
+	}
+	return rows.Err()
+}
+
+// AcceptScheduleProposal confirms slotID of a pending proposal and stores the room's
+// event of that kind, replacing an earlier one. It returns sql.ErrNoRows if the proposal
+// is no longer pending and ErrSlotNotFound if the slot is not one of its slots.
+func AcceptScheduleProposal(db *sql.DB, proposalID, slotID, respondedBy int) (*RoomEvent, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	event := &RoomEvent{}
+	err = tx.QueryRow(`
+		SELECT p.room_id, p.event_type, p.location, p.note, s.starts_at, s.ends_at
+		FROM schedule_proposals p
+		JOIN schedule_proposal_slots s ON s.proposal_id = p.id AND s.id = $2
+		WHERE p.id = $1
+	`, proposalID, slotID).Scan(&event.RoomID, &event.EventType, &event.Location, &event.Note,
+		&event.StartsAt, &event.EndsAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrSlotNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := tx.Exec(`
+		UPDATE schedule_proposals
+		SET status = 'accepted', accepted_slot_id = $1, responded_by = $2, responded_at = CURRENT_TIMESTAMP
+		WHERE id = $3 AND status = 'pending'
+	`, slotID, respondedBy, proposalID)
+	if err != nil {
+		return nil, err
+	}
+	if rows, err := result.RowsAffected(); err != nil {
+		return nil, err
+	} else if rows == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	event.ProposalID = &proposalID
+	event.ConfirmedBy = &respondedBy
+	err = tx.QueryRow(`
+		INSERT INTO room_events (room_id, event_type, starts_at, ends_at, location, note, proposal_id, confirmed_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (room_id, event_type) DO UPDATE SET
+			starts_at = EXCLUDED.starts_at, ends_at = EXCLUDED.ends_at,
+			location = EXCLUDED.location, note = EXCLUDED.note,
+			proposal_id = EXCLUDED.proposal_id, confirmed_by = EXCLUDED.confirmed_by,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING id, created_at, updated_at
+	`, event.RoomID, event.EventType, event.StartsAt, event.EndsAt, event.Location, event.Note,
+		event.ProposalID, event.ConfirmedBy).Scan(&event.ID, &event.CreatedAt, &event.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// CloseScheduleProposal answers a pending proposal without confirming a time.
+// It returns sql.ErrNoRows if the proposal is no longer pending.
+func CloseScheduleProposal(db *sql.DB, id int, status string, respondedBy int) error {
+	result, err := db.Exec(`
+		UPDATE schedule_proposals
+		SET status = $1, responded_by = $2, responded_at = CURRENT_TIMESTAMP
+		WHERE id = $3 AND status = 'pending'
+	`, status, respondedBy, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+const roomEventColumns = `
+	e.id, e.room_id, e.event_type, e.starts_at, e.ends_at, e.location, e.note,
+	e.proposal_id, e.confirmed_by, e.created_at, e.updated_at,
+	mr.request_id, h.name, f.name,
+	COALESCE(pr.patient_age, 0), COALESCE(pr.patient_gender, '')`
+
+const roomEventJoins = `
+	FROM room_events e
+	JOIN message_rooms mr ON e.room_id = mr.id
+	JOIN hospitals h ON mr.hospital_id = h.id
+	JOIN facilities f ON mr.facility_id = f.id
+	LEFT JOIN placement_requests pr ON mr.request_id = pr.id`
+
+func queryRoomEvents(db *sql.DB, query string, args ...interface{}) ([]*RoomEvent, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []*RoomEvent{}
+	for rows.Next() {
+		e := &RoomEvent{}
+		err := rows.Scan(&e.ID, &e.RoomID, &e.EventType, &e.StartsAt, &e.EndsAt, &e.Location, &e.Note,
+			&e.ProposalID, &e.ConfirmedBy, &e.CreatedAt, &e.UpdatedAt,
+			&e.RequestID, &e.HospitalName, &e.FacilityName, &e.PatientAge, &e.PatientGender)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// GetRoomEventsByRoomID returns the room's confirmed events in time order
+func GetRoomEventsByRoomID(db *sql.DB, roomID string) ([]*RoomEvent, error) {
+	return queryRoomEvents(db, `SELECT `+roomEventColumns+roomEventJoins+`
+		WHERE e.room_id = $1
+		ORDER BY e.starts_at, e.id
+	`, roomID)
+}
+
+// GetRoomEventsByHospitalID returns the hospital's events starting from since in time
+// order. Events of rejected and withdrawn rooms are left out.
+func GetRoomEventsByHospitalID(db *sql.DB, hospitalID int, since time.Time) ([]*RoomEvent, error) {
+	return queryRoomEvents(db, `SELECT `+roomEventColumns+roomEventJoins+`
+		WHERE mr.hospital_id = $1 AND e.starts_at >= $2
+		  AND mr.status NOT IN ('rejected', 'withdrawn')
+		ORDER BY e.starts_at, e.id
+	`, hospitalID, since)
+}
+
+// GetRoomEventsByFacilityID returns the facility's events starting from since in time
+// order. Events of rejected and withdrawn rooms are left out.
+func GetRoomEventsByFacilityID(db *sql.DB, facilityID int, since time.Time) ([]*RoomEvent, error) {
+	return queryRoomEvents(db, `SELECT `+roomEventColumns+roomEventJoins+`
+		WHERE mr.facility_id = $1 AND e.starts_at >= $2
+		  AND mr.status NOT IN ('rejected', 'withdrawn')
+		ORDER BY e.starts_at, e.id
+	`, facilityID, since)
+}
@@ -0,0 +1,289 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// SavedSearch is a named facility search a hospital is watching for new matches
+type SavedSearch struct {
+	ID            int                  `json:"id"`
+	HospitalID    int                  `json:"hospital_id"`
+	UserID        int                  `json:"user_id"`
+	UserEmail     string               `json:"-"`
+	Name          string               `json:"name"`
+	Criteria      FacilitySearchParams `json:"criteria"`
+	NotifyEmail   bool                 `json:"notify_email"`
+	NewMatches    int                  `json:"new_matches"`
+	LastViewedAt  time.Time            `json:"last_viewed_at"`
+	LastCheckedAt *time.Time           `json:"last_checked_at,omitempty"`
+	CreatedAt     time.Time            `json:"created_at"`
+	UpdatedAt     time.Time            `json:"updated_at"`
+}
+
+// SavedSearchMatch is a facility that currently matches a saved search
+type SavedSearchMatch struct {
+	FacilityID int       `json:"facility_id"`
+	MatchedAt  time.Time `json:"matched_at"`
+}
+
+type SavedSearchRepository struct {
+	db *sql.DB
+}
+
+func NewSavedSearchRepository(db *sql.DB) *SavedSearchRepository {
+	return &SavedSearchRepository{db: db}
+}
+
+const savedSearchSelect = `
+	SELECT s.id, s.hospital_id, s.user_id, u.email, s.name, s.criteria, s.notify_email,
+	       (SELECT COUNT(*) FROM saved_search_matches m
+	        WHERE m.saved_search_id = s.id AND m.matched_at > s.last_viewed_at) as new_matches,
+	       s.last_viewed_at, s.last_checked_at, s.created_at, s.updated_at
+	FROM saved_searches s
+	JOIN users u ON u.id = s.user_id
+`
+
+func scanSavedSearch(row rowScanner) (*SavedSearch, error) {
+	search := &SavedSearch{}
+	var criteria []byte
+	err := row.Scan(
+		&search.ID, &search.HospitalID, &search.UserID, &search.UserEmail, &search.Name, &criteria,
+		&search.NotifyEmail, &search.NewMatches,
+		&search.LastViewedAt, &search.LastCheckedAt, &search.CreatedAt, &search.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(criteria, &search.Criteria); err != nil {
+		return nil, fmt.Errorf("failed to decode search criteria: %w", err)
+	}
+	return search, nil
+}
+
+func (r *SavedSearchRepository) query(query string, args ...interface{}) ([]*SavedSearch, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get saved searches: %w", err)
+	}
+	defer rows.Close()
+
+	searches := []*SavedSearch{}
+	for rows.Next() {
+		search, err := scanSavedSearch(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan saved search: %w", err)
+		}
+		searches = append(searches, search)
+	}
+
+	return searches, rows.Err()
+}
+
+func (r *SavedSearchRepository) Create(search *SavedSearch) error {
+	criteria, err := json.Marshal(search.Criteria)
+	if err != nil {
+		return fmt.Errorf("failed to encode search criteria: %w", err)
+	}
+
+	query := `
+		INSERT INTO saved_searches (hospital_id, user_id, name, criteria, notify_email)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, last_viewed_at, created_at, updated_at
+	`
+	err = r.db.QueryRow(query, search.HospitalID, search.UserID, search.Name, criteria, search.NotifyEmail).Scan(
+		&search.ID, &search.LastViewedAt, &search.CreatedAt, &search.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create saved search: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SavedSearchRepository) GetByID(id int) (*SavedSearch, error) {
+	search, err := scanSavedSearch(r.db.QueryRow(savedSearchSelect+` WHERE s.id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("saved search not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get saved search: %w", err)
+	}
+	return search, nil
+}
+
+func (r *SavedSearchRepository) GetByHospitalID(hospitalID int) ([]*SavedSearch, error) {
+	return r.query(savedSearchSelect+` WHERE s.hospital_id = $1 ORDER BY s.created_at DESC`, hospitalID)
+}
+
+// GetAll returns every saved search of active users, for match refreshes
+func (r *SavedSearchRepository) GetAll() ([]*SavedSearch, error) {
+	return r.query(savedSearchSelect + ` WHERE u.is_active = true ORDER BY s.id`)
+}
+
+// Update saves the name, criteria and notification preference. When the criteria
+// change, the current matches are cleared and the next refresh rebuilds them silently.
+func (r *SavedSearchRepository) Update(search *SavedSearch) error {
+	criteria, err := json.Marshal(search.Criteria)
+	if err != nil {
+		return fmt.Errorf("failed to encode search criteria: %w", err)
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var criteriaChanged bool
+	query := `
+		UPDATE saved_searches
+		SET name = $1, notify_email = $2,
+		    last_checked_at = CASE WHEN criteria = $3::jsonb THEN last_checked_at ELSE NULL END,
+		    criteria = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $4
+		RETURNING last_checked_at IS NULL, updated_at
+	`
+	err = tx.QueryRow(query, search.Name, search.NotifyEmail, string(criteria), search.ID).Scan(&criteriaChanged, &search.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("saved search not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update saved search: %w", err)
+	}
+
+	if criteriaChanged {
+		if _, err := tx.Exec(`DELETE FROM saved_search_matches WHERE saved_search_id = $1`, search.ID); err != nil {
+			return fmt.Errorf("failed to reset saved search matches: %w", err)
+		}
+		search.LastCheckedAt = nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SavedSearchRepository) Delete(id int) error {
+	result, err := r.db.Exec(`DELETE FROM saved_searches WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete saved search: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("saved search not found")
+	}
+
+	return nil
+}
+
+// SyncMatches replaces the stored matches of a search with facilityIDs and returns
+// the facilities that were not matching before. Facilities that stop matching are
+// forgotten, so they are reported again when they match later. A baseline sync also
+// marks the search as viewed so the initial matches don't count as new.
+func (r *SavedSearchRepository) SyncMatches(searchID int, facilityIDs []int, baseline bool) ([]int, error) {
+	if facilityIDs == nil {
+		facilityIDs = []int{}
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	ids := pq.Array(facilityIDs)
+
+	_, err = tx.Exec(`
+		DELETE FROM saved_search_matches
+		WHERE saved_search_id = $1 AND NOT (facility_id = ANY($2::int[]))
+	`, searchID, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove stale matches: %w", err)
+	}
+
+	rows, err := tx.Query(`
+		INSERT INTO saved_search_matches (saved_search_id, facility_id)
+		SELECT $1, unnest($2::int[])
+		ON CONFLICT (saved_search_id, facility_id) DO NOTHING
+		RETURNING facility_id
+	`, searchID, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record matches: %w", err)
+	}
+
+	newIDs := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan match: %w", err)
+		}
+		newIDs = append(newIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to record matches: %w", err)
+	}
+
+	query := `
+		UPDATE saved_searches
+		SET last_checked_at = CURRENT_TIMESTAMP,
+		    last_viewed_at = CASE WHEN $2 THEN CURRENT_TIMESTAMP ELSE last_viewed_at END
+		WHERE id = $1
+	`
+	if _, err := tx.Exec(query, searchID, baseline); err != nil {
+		return nil, fmt.Errorf("failed to update saved search: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return newIDs, nil
+}
+
+// GetMatchesSince returns the current matches that appeared after since, newest first
+func (r *SavedSearchRepository) GetMatchesSince(searchID int, since time.Time) ([]*SavedSearchMatch, error) {
+	query := `
+		SELECT facility_id, matched_at
+		FROM saved_search_matches
+		WHERE saved_search_id = $1 AND matched_at > $2
+		ORDER BY matched_at DESC
+	`
+	rows, err := r.db.Query(query, searchID, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get matches: %w", err)
+	}
+	defer rows.Close()
+
+	matches := []*SavedSearchMatch{}
+	for rows.Next() {
+		m := &SavedSearchMatch{}
+		if err := rows.Scan(&m.FacilityID, &m.MatchedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan match: %w", err)
+		}
+		matches = append(matches, m)
+	}
+
+	return matches, rows.Err()
+}
+
+// MarkViewed records that the hospital has looked at the search's matches
+func (r *SavedSearchRepository) MarkViewed(searchID int) error {
+	_, err := r.db.Exec(`UPDATE saved_searches SET last_viewed_at = CURRENT_TIMESTAMP WHERE id = $1`, searchID)
+	if err != nil {
+		return fmt.Errorf("failed to update saved search: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/social-worker-platform/backend/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncMatches(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping database tests in short mode")
+	}
+
+	db := config.SetupTestDatabase(t)
+	defer config.TeardownTestDatabase(t, db)
+
+	req := createTestPlacementRequest(t, db)
+	facilityUser, err := NewUserRepository(db).Create(fmt.Sprintf("facility%d@example.com", time.Now().UnixNano()), "password", "facility")
+	require.NoError(t, err)
+	other, err := NewFacilityRepository(db).Create(facilityUser.ID, "Other Facility", "Address", "123-456", 10, "None")
+	require.NoError(t, err)
+
+	search := &SavedSearch{HospitalID: req.HospitalID, Name: "個室あり", Criteria: FacilitySearchParams{HasAvailableBeds: true}}
+	require.NoError(t, db.QueryRow(`SELECT user_id FROM hospitals WHERE id = $1`, req.HospitalID).Scan(&search.UserID))
+	repo := NewSavedSearchRepository(db)
+	require.NoError(t, repo.Create(search))
+
+	newMatches := func() int {
+		stored, err := repo.GetByID(search.ID)
+		require.NoError(t, err)
+		return stored.NewMatches
+	}
+
+	t.Run("baseline sync does not count its matches as new", func(t *testing.T) {
+		_, err := repo.SyncMatches(search.ID, []int{req.FacilityID}, true)
+		require.NoError(t, err)
+		assert.Equal(t, 0, newMatches())
+	})
+
+	t.Run("only facilities that were not matching are new", func(t *testing.T) {
+		newIDs, err := repo.SyncMatches(search.ID, []int{req.FacilityID, other.ID}, false)
+		require.NoError(t, err)
+		assert.Equal(t, []int{other.ID}, newIDs)
+		assert.Equal(t, 1, newMatches())
+	})
+
+	t.Run("a facility that stopped matching is new when it matches again", func(t *testing.T) {
+		newIDs, err := repo.SyncMatches(search.ID, []int{other.ID}, false)
+		require.NoError(t, err)
+		assert.Empty(t, newIDs)
+
+		newIDs, err = repo.SyncMatches(search.ID, []int{req.FacilityID, other.ID}, false)
+		require.NoError(t, err)
+		assert.Equal(t, []int{req.FacilityID}, newIDs)
+	})
+
+	t.Run("changing the criteria resets the matches", func(t *testing.T) {
+		// Saving the same criteria keeps them
+		require.NoError(t, repo.Update(search))
+		stored, err := repo.GetByID(search.ID)
+		require.NoError(t, err)
+		require.NotNil(t, stored.LastCheckedAt)
+
+		search.Criteria.Name = "Facility"
+		require.NoError(t, repo.Update(search))
+		assert.Nil(t, search.LastCheckedAt)
+
+		stored, err = repo.GetByID(search.ID)
+		require.NoError(t, err)
+		assert.Nil(t, stored.LastCheckedAt)
+
+		newIDs, err := repo.SyncMatches(search.ID, []int{req.FacilityID, other.ID}, true)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []int{req.FacilityID, other.ID}, newIDs)
+	})
+}
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidMunicipalityCode = errors.New("invalid municipality code")
+	ErrDuplicateServiceArea    = errors.New("duplicate municipality code")
+)
+
+// FacilityServiceArea is a municipality whose residents the facility accepts
+type FacilityServiceArea struct {
+	MunicipalityCode string    `json:"municipality_code"`
+	MunicipalityName *string   `json:"municipality_name,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+type FacilityServiceAreaInput struct {
+	MunicipalityCode string  `json:"municipality_code" binding:"required"`
+	MunicipalityName *string `json:"municipality_name" binding:"omitempty,max=100"`
+}
+
+// NormalizeMunicipalityCode accepts a 5-digit municipality code or the 6-digit local
+// government code with its check digit, and returns the 5-digit form
+func NormalizeMunicipalityCode(code string) (string, error) {
+	code = strings.TrimSpace(code)
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return "", fmt.Errorf("%w: %s", ErrInvalidMunicipalityCode, code)
+		}
+	}
+
+	switch len(code) {
+	case 5:
+		return code, nil
+	case 6:
+		// JIS X 0402 check digit: weights 6..2, modulus 11
+		sum := 0
+		for i := 0; i < 5; i++ {
+			sum += int(code[i]-'0') * (6 - i)
+		}
+		check := (11 - sum%11) % 10
+		if int(code[5]-'0') != check {
+			return "", fmt.Errorf("%w: %s (check digit mismatch)", ErrInvalidMunicipalityCode, code)
+		}
+		return code[:5], nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrInvalidMunicipalityCode, code)
+	}
+}
+
+// GetServiceAreas returns the declared service areas of a facility. An empty list
+// means the facility accepts residents from anywhere.
+func (r *FacilityRepository) GetServiceAreas(facilityID int) ([]*FacilityServiceArea, error) {
+	rows, err := r.db.Query(`
+		SELECT municipality_code, municipality_name, created_at
+		FROM facility_service_areas
+		WHERE facility_id = $1
+		ORDER BY municipality_code ASC
+	`, facilityID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service areas: %w", err)
+	}
+	defer rows.Close()
+
+	areas := []*FacilityServiceArea{}
+	for rows.Next() {
+		area := &FacilityServiceArea{}
+		if err := rows.Scan(&area.MunicipalityCode, &area.MunicipalityName, &area.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan service area: %w", err)
+		}
+		areas = append(areas, area)
+	}
+
+	return areas, rows.Err()
+}
+
+// ReplaceServiceAreas saves the complete list of service areas of a facility
+func (r *FacilityRepository) ReplaceServiceAreas(facilityID int, areas []FacilityServiceAreaInput) error {
+	codes := make([]string, 0, len(areas))
+	seen := map[string]bool{}
+	for i := range areas {
+		code, err := NormalizeMunicipalityCode(areas[i].MunicipalityCode)
+		if err != nil {
+			return err
+		}
+		if seen[code] {
+			return fmt.Errorf("%w: %s", ErrDuplicateServiceArea, code)
+		}
+		seen[code] = true
+		codes = append(codes, code)
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM facility_service_areas WHERE facility_id = $1`, facilityID); err != nil {
+		return fmt.Errorf("failed to delete service areas: %w", err)
+	}
+
+	for i, code := range codes {
+		_, err := tx.Exec(`
+			INSERT INTO facility_service_areas (facility_id, municipality_code, municipality_name)
+			VALUES ($1, $2, $3)
+		`, facilityID, code, areas[i].MunicipalityName)
+		if err != nil {
+			return fmt.Errorf("failed to save service area: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
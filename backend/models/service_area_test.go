@@ -0,0 +1,27 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeMunicipalityCode(t *testing.T) {
+	valid := map[string]string{
+		"13101":   "13101", // 千代田区
+		"131016":  "13101",
+		"011002":  "01100", // 札幌市
+		" 27100 ": "27100",
+	}
+	for in, want := range valid {
+		got, err := NormalizeMunicipalityCode(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got)
+	}
+
+	for _, in := range []string{"", "1310", "131017", "1310a", "1310161"} {
+		_, err := NormalizeMunicipalityCode(in)
+		assert.ErrorIs(t, err, ErrInvalidMunicipalityCode, in)
+	}
+}
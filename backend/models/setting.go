@@ -0,0 +1,63 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+)
+
+// SettingFacilityModeration holds changes to sensitive facility fields for admin approval when "true"
+const SettingFacilityModeration = "facility_moderation_enabled"
+
+type SettingRepository struct {
+	db *sql.DB
+}
+
+func NewSettingRepository(db *sql.DB) *SettingRepository {
+	return &SettingRepository{db: db}
+}
+
+// Get returns the raw value of a setting and whether it has been set
+func (r *SettingRepository) Get(key string) (string, bool, error) {
+	var value string
+	err := r.db.QueryRow(`SELECT value FROM app_settings WHERE key = $1`, key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get setting: %w", err)
+	}
+	return value, true, nil
+}
+
+// GetBool returns a boolean setting, falling back to defaultValue when unset or malformed
+func (r *SettingRepository) GetBool(key string, defaultValue bool) (bool, error) {
+	value, ok, err := r.Get(key)
+	if err != nil || !ok {
+		return defaultValue, err
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return defaultValue, nil
+	}
+	return b, nil
+}
+
+// Set stores a setting, recording which user changed it
+func (r *SettingRepository) Set(key, value string, updatedBy int) error {
+	query := `
+		INSERT INTO app_settings (key, value, updated_by, updated_at)
+		VALUES ($1, $2, $3, CURRENT_TIMESTAMP)
+		ON CONFLICT (key) DO UPDATE
+		SET value = EXCLUDED.value, updated_by = EXCLUDED.updated_by, updated_at = CURRENT_TIMESTAMP
+	`
+	if _, err := r.db.Exec(query, key, value, updatedBy); err != nil {
+		return fmt.Errorf("failed to save setting: %w", err)
+	}
+	return nil
+}
+
+// SetBool stores a boolean setting
+func (r *SettingRepository) SetBool(key string, value bool, updatedBy int) error {
+	return r.Set(key, strconv.FormatBool(value), updatedBy)
+}
@@ -0,0 +1,208 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// FavoritesShortlistName is the name of the favorites list created for each hospital
+const FavoritesShortlistName = "お気に入り"
+
+// Shortlist is a named list of facilities kept by a hospital, such as its favorites
+// or the candidates for one patient
+type Shortlist struct {
+	ID            int                  `json:"id"`
+	HospitalID    int                  `json:"hospital_id"`
+	Name          string               `json:"name"`
+	Description   *string              `json:"description,omitempty"`
+	IsFavorites   bool                 `json:"is_favorites"`
+	FacilityCount int                  `json:"facility_count"`
+	Facilities    []*ShortlistFacility `json:"facilities,omitempty"`
+	CreatedAt     time.Time            `json:"created_at"`
+	UpdatedAt     time.Time            `json:"updated_at"`
+}
+
+// ShortlistFacility is a facility on a shortlist with the hospital's note
+type ShortlistFacility struct {
+	FacilityID    int       `json:"facility_id"`
+	FacilityName  string    `json:"facility_name"`
+	Address       string    `json:"address"`
+	AvailableBeds int       `json:"available_beds"`
+	Note          *string   `json:"note,omitempty"`
+	AddedAt       time.Time `json:"added_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// CreateShortlist creates a new shortlist
+func CreateShortlist(db *sql.DB, s *Shortlist) error {
+	query := `
+		INSERT INTO shortlists (hospital_id, name, description)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at, updated_at
+	`
+	return db.QueryRow(query, s.HospitalID, s.Name, s.Description).Scan(&s.ID, &s.CreatedAt, &s.UpdatedAt)
+}
+
+const shortlistSelect = `
+	SELECT s.id, s.hospital_id, s.name, s.description, s.is_favorites,
+	       (SELECT COUNT(*) FROM shortlist_facilities sf WHERE sf.shortlist_id = s.id) as facility_count,
+	       s.created_at, s.updated_at
+	FROM shortlists s
+`
+
+func scanShortlist(row rowScanner) (*Shortlist, error) {
+	s := &Shortlist{}
+	err := row.Scan(&s.ID, &s.HospitalID, &s.Name, &s.Description, &s.IsFavorites, &s.FacilityCount, &s.CreatedAt, &s.UpdatedAt)
+	return s, err
+}
+
+// GetShortlistByID retrieves a shortlist by ID
+func GetShortlistByID(db *sql.DB, id int) (*Shortlist, error) {
+	s, err := scanShortlist(db.QueryRow(shortlistSelect+` WHERE s.id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// GetShortlistsByHospitalID retrieves all shortlists of a hospital, favorites first
+func GetShortlistsByHospitalID(db *sql.DB, hospitalID int) ([]*Shortlist, error) {
+	rows, err := db.Query(shortlistSelect+` WHERE s.hospital_id = $1 ORDER BY s.is_favorites DESC, s.created_at DESC`, hospitalID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	shortlists := []*Shortlist{}
+	for rows.Next() {
+		s, err := scanShortlist(rows)
+		if err != nil {
+			return nil, err
+		}
+		shortlists = append(shortlists, s)
+	}
+
+	return shortlists, rows.Err()
+}
+
+// GetFavoritesShortlist returns the hospital's favorites list, creating it on first use
+func GetFavoritesShortlist(db *sql.DB, hospitalID int) (*Shortlist, error) {
+	_, err := db.Exec(`
+		INSERT INTO shortlists (hospital_id, name, is_favorites)
+		VALUES ($1, $2, true)
+		ON CONFLICT (hospital_id) WHERE is_favorites DO NOTHING
+	`, hospitalID, FavoritesShortlistName)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanShortlist(db.QueryRow(shortlistSelect+` WHERE s.hospital_id = $1 AND s.is_favorites`, hospitalID))
+}
+
+// UpdateShortlist updates the name and description of a shortlist
+func UpdateShortlist(db *sql.DB, id int, name string, description *string) error {
+	query := `
+		UPDATE shortlists
+		SET name = $1, description = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $3
+	`
+	result, err := db.Exec(query, name, description, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// DeleteShortlist deletes a shortlist and its entries
+func DeleteShortlist(db *sql.DB, id int) error {
+	result, err := db.Exec(`DELETE FROM shortlists WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// GetShortlistFacilities retrieves the facilities on a shortlist in the order they were added
+func GetShortlistFacilities(db *sql.DB, shortlistID int) ([]*ShortlistFacility, error) {
+	query := `
+		SELECT sf.facility_id, f.name, COALESCE(f.address, ''), f.available_beds, sf.note, sf.added_at, sf.updated_at
+		FROM shortlist_facilities sf
+		JOIN facilities f ON f.id = sf.facility_id
+		WHERE sf.shortlist_id = $1
+		ORDER BY sf.added_at, sf.facility_id
+	`
+	rows, err := db.Query(query, shortlistID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	facilities := []*ShortlistFacility{}
+	for rows.Next() {
+		f := &ShortlistFacility{}
+		if err := rows.Scan(&f.FacilityID, &f.FacilityName, &f.Address, &f.AvailableBeds, &f.Note, &f.AddedAt, &f.UpdatedAt); err != nil {
+			return nil, err
+		}
+		facilities = append(facilities, f)
+	}
+
+	return facilities, rows.Err()
+}
+
+// SaveShortlistFacility adds a facility to a shortlist, or updates its note when already listed
+func SaveShortlistFacility(db *sql.DB, shortlistID, facilityID int, note *string) error {
+	query := `
+		INSERT INTO shortlist_facilities (shortlist_id, facility_id, note)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (shortlist_id, facility_id) DO UPDATE
+		SET note = EXCLUDED.note, updated_at = CURRENT_TIMESTAMP
+	`
+	if _, err := db.Exec(query, shortlistID, facilityID, note); err != nil {
+		return err
+	}
+
+	_, err := db.Exec(`UPDATE shortlists SET updated_at = CURRENT_TIMESTAMP WHERE id = $1`, shortlistID)
+	return err
+}
+
+// RemoveShortlistFacility removes a facility from a shortlist
+func RemoveShortlistFacility(db *sql.DB, shortlistID, facilityID int) error {
+	result, err := db.Exec(`DELETE FROM shortlist_facilities WHERE shortlist_id = $1 AND facility_id = $2`, shortlistID, facilityID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
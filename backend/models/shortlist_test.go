@@ -0,0 +1,96 @@
+package models
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/social-worker-platform/backend/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShortlistFacilities(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping database tests in short mode")
+	}
+
+	db := config.SetupTestDatabase(t)
+	defer config.TeardownTestDatabase(t, db)
+
+	req := createTestPlacementRequest(t, db)
+	shortlist := &Shortlist{HospitalID: req.HospitalID, Name: "田中様の候補"}
+	require.NoError(t, CreateShortlist(db, shortlist))
+
+	t.Run("adding a listed facility again updates its note", func(t *testing.T) {
+		first, second := "見学済み", "空き待ち"
+		require.NoError(t, SaveShortlistFacility(db, shortlist.ID, req.FacilityID, &first))
+		require.NoError(t, SaveShortlistFacility(db, shortlist.ID, req.FacilityID, &second))
+
+		facilities, err := GetShortlistFacilities(db, shortlist.ID)
+		require.NoError(t, err)
+		require.Len(t, facilities, 1)
+		require.NotNil(t, facilities[0].Note)
+		assert.Equal(t, "空き待ち", *facilities[0].Note)
+	})
+
+	t.Run("removing an unlisted facility", func(t *testing.T) {
+		require.NoError(t, RemoveShortlistFacility(db, shortlist.ID, req.FacilityID))
+		assert.ErrorIs(t, RemoveShortlistFacility(db, shortlist.ID, req.FacilityID), sql.ErrNoRows)
+
+		facilities, err := GetShortlistFacilities(db, shortlist.ID)
+		require.NoError(t, err)
+		assert.Empty(t, facilities)
+	})
+}
+
+func TestCreatePlacementRequestsIsAllOrNothing(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping database tests in short mode")
+	}
+
+	db := config.SetupTestDatabase(t)
+	defer config.TeardownTestDatabase(t, db)
+
+	existing := createTestPlacementRequest(t, db)
+	newRequest := func(facilityID int) *PlacementRequest {
+		return &PlacementRequest{
+			HospitalID:       existing.HospitalID,
+			FacilityID:       facilityID,
+			PatientAge:       78,
+			PatientGender:    "male",
+			MedicalCondition: "大腿骨骨折術後",
+			Status:           "pending",
+		}
+	}
+	countRequests := func() int {
+		var count int
+		require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM placement_requests WHERE hospital_id = $1`, existing.HospitalID).Scan(&count))
+		return count
+	}
+
+	t.Run("one bad facility rolls back the others", func(t *testing.T) {
+		tx, err := db.Begin()
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		err = CreatePlacementRequests(tx, []*PlacementRequest{newRequest(existing.FacilityID), newRequest(999999)})
+		assert.Error(t, err)
+		require.NoError(t, tx.Rollback())
+
+		assert.Equal(t, 1, countRequests())
+	})
+
+	t.Run("creates every request", func(t *testing.T) {
+		tx, err := db.Begin()
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		reqs := []*PlacementRequest{newRequest(existing.FacilityID), newRequest(existing.FacilityID)}
+		require.NoError(t, CreatePlacementRequests(tx, reqs))
+		require.NoError(t, tx.Commit())
+
+		assert.NotZero(t, reqs[0].ID)
+		assert.NotZero(t, reqs[1].ID)
+		assert.Equal(t, 3, countRequests())
+	})
+}
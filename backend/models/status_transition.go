@@ -0,0 +1,91 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Entities whose status changes are recorded in status_transitions
+const (
+	StatusEntityRequest = "placement_request"
+	StatusEntityRoom    = "message_room"
+)
+
+// StatusTransition is one entry of the status history of a placement request or its
+// message room. FromStatus is nil for the entry recording creation.
+type StatusTransition struct {
+	ID         int       `json:"id"`
+	Entity     string    `json:"entity"`
+	RequestID  int       `json:"request_id"`
+	RoomID     *string   `json:"room_id,omitempty"`
+	FromStatus *string   `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	Action     *string   `json:"action,omitempty"`
+	ActorID    *int      `json:"actor_id,omitempty"`
+	ActorRole  *string   `json:"actor_role,omitempty"`
+	ActorName  *string   `json:"actor_name,omitempty"`
+	Reason     *string   `json:"reason,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	// Seconds spent in FromStatus, i.e. since the previous entry of the same entity
+	SecondsInPreviousStatus *int64 `json:"seconds_in_previous_status,omitempty"`
+}
+
+// RecordStatusTransition appends an entry to the status history
+func RecordStatusTransition(db execer, t *StatusTransition) error {
+	_, err := db.Exec(`
+		INSERT INTO status_transitions (entity, request_id, room_id, from_status, to_status, action, actor_id, actor_role, reason)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`, t.Entity, t.RequestID, t.RoomID, t.FromStatus, t.ToStatus, t.Action, t.ActorID, t.ActorRole, t.Reason)
+	return err
+}
+
+// GetStatusHistoryByRequestID returns the status history of a request and its room,
+// oldest first
+func GetStatusHistoryByRequestID(db *sql.DB, requestID int) ([]*StatusTransition, error) {
+	rows, err := db.Query(`
+		SELECT st.id, st.entity, st.request_id, st.room_id, st.from_status, st.to_status, st.action,
+		       st.actor_id, st.actor_role, COALESCE(h.name, f.name), st.reason, st.created_at
+		FROM status_transitions st
+		LEFT JOIN hospitals h ON h.user_id = st.actor_id
+		LEFT JOIN facilities f ON f.user_id = st.actor_id
+		WHERE st.request_id = $1
+		ORDER BY st.created_at, st.id
+	`, requestID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []*StatusTransition{}
+	for rows.Next() {
+		t := &StatusTransition{}
+		if err := rows.Scan(&t.ID, &t.Entity, &t.RequestID, &t.RoomID, &t.FromStatus, &t.ToStatus, &t.Action,
+			&t.ActorID, &t.ActorRole, &t.ActorName, &t.Reason, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		history = append(history, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	setTimeInPreviousStatus(history)
+	return history, nil
+}
+
+// setTimeInPreviousStatus fills SecondsInPreviousStatus from the previous entry of the
+// same entity, which is what lead-time statistics are built from
+func setTimeInPreviousStatus(history []*StatusTransition) {
+	last := map[string]time.Time{}
+	for _, t := range history {
+		key := t.Entity
+		if t.RoomID != nil {
+			key += ":" + *t.RoomID
+		}
+		if prev, ok := last[key]; ok && t.FromStatus != nil {
+			seconds := int64(t.CreatedAt.Sub(prev).Seconds())
+			t.SecondsInPreviousStatus = &seconds
+		}
+		last[key] = t.CreatedAt
+	}
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetTimeInPreviousStatus(t *testing.T) {
+	start := time.Date(2024, 4, 1, 9, 0, 0, 0, time.UTC)
+	pending, accepted, negotiating := "pending", "accepted", "negotiating"
+	room := "room-1"
+
+	history := []*StatusTransition{
+		{Entity: StatusEntityRequest, ToStatus: pending, CreatedAt: start},
+		{Entity: StatusEntityRequest, FromStatus: &pending, ToStatus: accepted, CreatedAt: start.Add(2 * time.Hour)},
+		{Entity: StatusEntityRoom, RoomID: &room, ToStatus: negotiating, CreatedAt: start.Add(2 * time.Hour)},
+		{Entity: StatusEntityRoom, RoomID: &room, FromStatus: &negotiating, ToStatus: accepted, CreatedAt: start.Add(26 * time.Hour)},
+	}
+	setTimeInPreviousStatus(history)
+
+	assert.Nil(t, history[0].SecondsInPreviousStatus, "creation has no previous status")
+	require.NotNil(t, history[1].SecondsInPreviousStatus)
+	assert.Equal(t, int64(2*60*60), *history[1].SecondsInPreviousStatus)
+	assert.Nil(t, history[2].SecondsInPreviousStatus, "room times are not measured from the request")
+	require.NotNil(t, history[3].SecondsInPreviousStatus)
+	assert.Equal(t, int64(24*60*60), *history[3].SecondsInPreviousStatus)
+}
@@ -7,8 +7,11 @@ import (
 
 // UnreadCounts represents the unread counts for a user
 type UnreadCounts struct {
-	Messages int `json:"messages"` // Number of rooms with unread messages
-	Requests int `json:"requests"` // Number of unread requests
+	Messages      int `json:"messages"`      // Number of rooms with unread messages
+	Requests      int `json:"requests"`      // Number of unread requests
+	Notifications int `json:"notifications"` // Number of unread in-app notifications
+	// Unread requests split by urgency, so the most urgent can be badged separately
+	RequestsByUrgency map[string]int `json:"requests_by_urgency"`
 }
 
 // MessageReadStatus represents the read status of a message room for a user
@@ -88,43 +91,61 @@ func GetUnreadMessageCount(db *sql.DB, userID int, role string, entityID int) (i
 	return count, nil
 }
 
-// GetUnreadRequestCount returns the number of unread placement requests for a user
+// GetUnreadRequestCounts returns the number of unread placement requests for a user by
+// urgency, with every urgency present
 // For facility users: counts pending requests that haven't been read
-// For hospital users: counts requests with status changes (accepted/rejected) that haven't been read
-func GetUnreadRequestCount(db *sql.DB, userID int, role string, entityID int) (int, error) {
+// For hospital users: counts requests with status changes (accepted/rejected/conditionally accepted) that haven't been read
+func GetUnreadRequestCounts(db *sql.DB, userID int, role string, entityID int) (map[string]int, error) {
 	var query string
 
 	if role == "facility" {
 		// Facility: count new pending requests
 		query = `
-			SELECT COUNT(DISTINCT pr.id)
+			SELECT pr.urgency, COUNT(DISTINCT pr.id)
 			FROM placement_requests pr
 			LEFT JOIN request_read_status rrs ON pr.id = rrs.request_id AND rrs.user_id = $1
 			WHERE pr.facility_id = $2
 			AND pr.status = 'pending'
 			AND (rrs.last_read_at IS NULL OR pr.updated_at > rrs.last_read_at)
+			GROUP BY pr.urgency
 		`
 	} else if role == "hospital" {
-		// Hospital: count requests with status changes (accepted/rejected)
+		// Hospital: count requests with status changes (accepted/rejected/conditionally accepted)
 		query = `
-			SELECT COUNT(DISTINCT pr.id)
+			SELECT pr.urgency, COUNT(DISTINCT pr.id)
 			FROM placement_requests pr
 			LEFT JOIN request_read_status rrs ON pr.id = rrs.request_id AND rrs.user_id = $1
 			WHERE pr.hospital_id = $2
-			AND pr.status IN ('accepted', 'rejected')
+			AND pr.status IN ('accepted', 'rejected', 'conditionally_accepted')
 			AND (rrs.last_read_at IS NULL OR pr.updated_at > rrs.last_read_at)
+			GROUP BY pr.urgency
 		`
-	} else {
-		return 0, nil
 	}
 
-	var count int
-	err := db.QueryRow(query, userID, entityID).Scan(&count)
+	counts := make(map[string]int, len(RequestUrgencies))
+	for _, u := range RequestUrgencies {
+		counts[u] = 0
+	}
+	if query == "" {
+		return counts, nil
+	}
+
+	rows, err := db.Query(query, userID, entityID)
 	if err != nil {
-		return 0, err
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var urgency string
+		var count int
+		if err := rows.Scan(&urgency, &count); err != nil {
+			return nil, err
+		}
+		counts[urgency] = count
 	}
 
-	return count, nil
+	return counts, rows.Err()
 }
 
 // GetUnreadCounts returns all unread counts for a user
@@ -134,14 +155,26 @@ func GetUnreadCounts(db *sql.DB, userID int, role string, entityID int) (*Unread
 		return nil, err
 	}
 
-	requests, err := GetUnreadRequestCount(db, userID, role, entityID)
+	requestsByUrgency, err := GetUnreadRequestCounts(db, userID, role, entityID)
+	if err != nil {
+		return nil, err
+	}
+	requests := 0
+	for _, count := range requestsByUrgency {
+		requests += count
+	}
+
+	notifications, err := GetUnreadNotificationCount(db, userID)
 	if err != nil {
 		return nil, err
 	}
 
 	return &UnreadCounts{
-		Messages: messages,
-		Requests: requests,
+		Messages:      messages,
+		Requests:      requests,
+		Notifications: notifications,
+
+		RequestsByUrgency: requestsByUrgency,
 	}, nil
 }
 
@@ -158,7 +191,7 @@ func MarkRoomAsRead(db *sql.DB, roomID string, userID int) error {
 }
 
 // MarkRequestAsRead marks a placement request as read for a user
-func MarkRequestAsRead(db *sql.DB, requestID int, userID int) error {
+func MarkRequestAsRead(db execer, requestID int, userID int) error {
 	query := `
 		INSERT INTO request_read_status (request_id, user_id, last_read_at)
 		VALUES ($1, $2, CURRENT_TIMESTAMP)
@@ -178,7 +211,7 @@ func MarkAllRequestsAsRead(db *sql.DB, userID int, role string, entityID int) er
 			INSERT INTO request_read_status (request_id, user_id, last_read_at)
 			SELECT pr.id, $1, CURRENT_TIMESTAMP
 			FROM placement_requests pr
-			WHERE pr.facility_id = $2
+			WHERE pr.facility_id = $2 AND pr.submitted_at IS NOT NULL
 			ON CONFLICT (request_id, user_id)
 			DO UPDATE SET last_read_at = CURRENT_TIMESTAMP
 		`
@@ -0,0 +1,391 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Waitlist entry statuses. waiting and notified entries are active and hold a position.
+const (
+	WaitlistWaiting   = "waiting"
+	WaitlistNotified  = "notified"
+	WaitlistConverted = "converted"
+	WaitlistRemoved   = "removed"
+)
+
+// WaitlistOffersPerOpening is how many waitlisted hospitals are notified for each
+// bed that opens up, so the facility still has a candidate when the first declines
+const WaitlistOffersPerOpening = 3
+
+// WaitlistPriorities are the urgency levels a hospital can give an entry
+var WaitlistPriorities = []string{"urgent", "high", "normal"}
+
+var (
+	ErrWaitlistEntryNotActive = errors.New("waitlist entry is no longer active")
+	ErrInvalidWaitlistOrder   = errors.New("order must list every active waitlist entry exactly once")
+)
+
+// WaitlistEntry is a placement request waiting for a bed at a full facility
+type WaitlistEntry struct {
+	ID                    int        `json:"id"`
+	RequestID             int        `json:"request_id"`
+	FacilityID            int        `json:"facility_id"`
+	RoomTypeID            *int       `json:"room_type_id,omitempty"`
+	RoomType              *string    `json:"room_type,omitempty"`
+	Position              int        `json:"position"`
+	Priority              string     `json:"priority"`
+	PriorityNote          *string    `json:"priority_note,omitempty"`
+	ExpectedAvailableDate *time.Time `json:"expected_available_date,omitempty"`
+	Status                string     `json:"status"`
+	NotifiedAt            *time.Time `json:"notified_at,omitempty"`
+	HospitalID            int        `json:"hospital_id"`
+	HospitalName          string     `json:"hospital_name,omitempty"`
+	FacilityName          string     `json:"facility_name,omitempty"`
+	PatientAge            int        `json:"patient_age"`
+	PatientGender         string     `json:"patient_gender"`
+	MedicalCondition      string     `json:"medical_condition"`
+	PatientCareLevel      *string    `json:"patient_care_level,omitempty"`
+	CreatedAt             time.Time  `json:"created_at"`
+	UpdatedAt             time.Time  `json:"updated_at"`
+}
+
+// IsActive reports whether the entry still holds a position on the waitlist
+func (e *WaitlistEntry) IsActive() bool {
+	return e.Status == WaitlistWaiting || e.Status == WaitlistNotified
+}
+
+// IsWaitlistPriority reports whether p is one of WaitlistPriorities
+func IsWaitlistPriority(p string) bool {
+	for _, v := range WaitlistPriorities {
+		if v == p {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateWaitlistOrder checks that ordered is a permutation of the active entry IDs
+func ValidateWaitlistOrder(active, ordered []int) error {
+	if len(active) != len(ordered) {
+		return ErrInvalidWaitlistOrder
+	}
+	remaining := make(map[int]bool, len(active))
+	for _, id := range active {
+		remaining[id] = true
+	}
+	for _, id := range ordered {
+		if !remaining[id] {
+			return ErrInvalidWaitlistOrder
+		}
+		delete(remaining, id)
+	}
+	return nil
+}
+
+const waitlistSelect = `
+	SELECT w.id, w.request_id, w.facility_id, w.room_type_id, frt.room_type, w.position, w.priority,
+	       w.priority_note, w.expected_available_date, w.status, w.notified_at,
+	       pr.hospital_id, h.name, f.name, pr.patient_age, pr.patient_gender, pr.medical_condition,
+	       pr.patient_care_level, w.created_at, w.updated_at
+	FROM waitlist_entries w
+	JOIN placement_requests pr ON w.request_id = pr.id
+	JOIN hospitals h ON pr.hospital_id = h.id
+	JOIN facilities f ON w.facility_id = f.id
+	LEFT JOIN facility_room_types frt ON w.room_type_id = frt.id
+`
+
+func scanWaitlistEntry(row rowScanner) (*WaitlistEntry, error) {
+	e := &WaitlistEntry{}
+	err := row.Scan(
+		&e.ID, &e.RequestID, &e.FacilityID, &e.RoomTypeID, &e.RoomType, &e.Position, &e.Priority,
+		&e.PriorityNote, &e.ExpectedAvailableDate, &e.Status, &e.NotifiedAt,
+		&e.HospitalID, &e.HospitalName, &e.FacilityName, &e.PatientAge, &e.PatientGender, &e.MedicalCondition,
+		&e.PatientCareLevel, &e.CreatedAt, &e.UpdatedAt,
+	)
+	return e, err
+}
+
+func queryWaitlistEntries(db *sql.DB, query string, args ...interface{}) ([]*WaitlistEntry, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*WaitlistEntry{}
+	for rows.Next() {
+		e, err := scanWaitlistEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+// AddToWaitlist puts a placement request at the end of its facility's waitlist and marks
+// the request as waitlisted, in the transaction tx. A request removed earlier can be put
+// back on the list. It returns ErrStatusChanged if the request is no longer in from.
+func AddToWaitlist(tx *sql.Tx, e *WaitlistEntry, from string) error {
+	// Serialize position assignment per facility
+	if _, err := tx.Exec(`SELECT id FROM facilities WHERE id = $1 FOR UPDATE`, e.FacilityID); err != nil {
+		return err
+	}
+
+	err := tx.QueryRow(`
+		INSERT INTO waitlist_entries (request_id, facility_id, room_type_id, position, priority, priority_note)
+		VALUES ($1, $2, $3,
+		        (SELECT COALESCE(MAX(position), 0) + 1 FROM waitlist_entries
+		         WHERE facility_id = $2 AND status IN ('waiting', 'notified')),
+		        $4, $5)
+		ON CONFLICT (request_id) DO UPDATE SET
+			room_type_id = EXCLUDED.room_type_id,
+			position = EXCLUDED.position,
+			priority = EXCLUDED.priority,
+			priority_note = EXCLUDED.priority_note,
+			expected_available_date = NULL,
+			status = 'waiting',
+			notified_at = NULL,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING id, position, status, created_at, updated_at
+	`, e.RequestID, e.FacilityID, e.RoomTypeID, e.Priority, e.PriorityNote).Scan(
+		&e.ID, &e.Position, &e.Status, &e.CreatedAt, &e.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	return UpdatePlacementRequestStatus(tx, e.RequestID, from, "waitlisted")
+}
+
+// GetWaitlistEntryByID retrieves a waitlist entry by ID
+func GetWaitlistEntryByID(db *sql.DB, id int) (*WaitlistEntry, error) {
+	e, err := scanWaitlistEntry(db.QueryRow(waitlistSelect+` WHERE w.id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// GetWaitlistByFacilityID returns the waitlist of a facility in position order.
+// Converted and removed entries are included only when includeClosed is set.
+func GetWaitlistByFacilityID(db *sql.DB, facilityID int, includeClosed bool) ([]*WaitlistEntry, error) {
+	return queryWaitlistEntries(db, waitlistSelect+`
+		WHERE w.facility_id = $1 AND ($2 OR w.status IN ('waiting', 'notified'))
+		ORDER BY w.status IN ('waiting', 'notified') DESC, w.position ASC, w.updated_at DESC
+	`, facilityID, includeClosed)
+}
+
+// GetWaitlistByHospitalID returns the active waitlist entries of a hospital's requests
+func GetWaitlistByHospitalID(db *sql.DB, hospitalID int) ([]*WaitlistEntry, error) {
+	return queryWaitlistEntries(db, waitlistSelect+`
+		WHERE pr.hospital_id = $1 AND w.status IN ('waiting', 'notified')
+		ORDER BY w.created_at DESC
+	`, hospitalID)
+}
+
+// UpdateWaitlistEntry saves the editable fields of an active entry
+func UpdateWaitlistEntry(db *sql.DB, e *WaitlistEntry) error {
+	result, err := db.Exec(`
+		UPDATE waitlist_entries
+		SET room_type_id = $1, priority = $2, priority_note = $3, expected_available_date = $4,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $5 AND status IN ('waiting', 'notified')
+	`, e.RoomTypeID, e.Priority, e.PriorityNote, e.ExpectedAvailableDate, e.ID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrWaitlistEntryNotActive
+	}
+	return nil
+}
+
+// ReorderWaitlist assigns positions 1..n to a facility's active entries in the given order
+func ReorderWaitlist(db *sql.DB, facilityID int, entryIDs []int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		SELECT id FROM waitlist_entries
+		WHERE facility_id = $1 AND status IN ('waiting', 'notified')
+		FOR UPDATE
+	`, facilityID)
+	if err != nil {
+		return err
+	}
+	active := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		active = append(active, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if err := ValidateWaitlistOrder(active, entryIDs); err != nil {
+		return err
+	}
+
+	for i, id := range entryIDs {
+		_, err := tx.Exec(`
+			UPDATE waitlist_entries SET position = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2
+		`, i+1, id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// closeWaitlistEntry takes an active entry off the list and closes the gap it leaves
+func closeWaitlistEntry(tx *sql.Tx, entryID int, status string) (*WaitlistEntry, error) {
+	e := &WaitlistEntry{ID: entryID}
+	err := tx.QueryRow(`
+		UPDATE waitlist_entries SET status = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2 AND status IN ('waiting', 'notified')
+		RETURNING request_id, facility_id, position
+	`, status, entryID).Scan(&e.RequestID, &e.FacilityID, &e.Position)
+	if err == sql.ErrNoRows {
+		return nil, ErrWaitlistEntryNotActive
+	}
+	if err != nil {
+		return nil, err
+	}
+	e.Status = status
+
+	_, err = tx.Exec(`
+		UPDATE waitlist_entries SET position = position - 1
+		WHERE facility_id = $1 AND status IN ('waiting', 'notified') AND position > $2
+	`, e.FacilityID, e.Position)
+	if err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// RemoveFromWaitlist takes an entry off the waitlist and closes its placement request, in
+// the transaction tx. It returns ErrStatusChanged if the request is no longer in from.
+func RemoveFromWaitlist(tx *sql.Tx, entryID int, from string) error {
+	e, err := closeWaitlistEntry(tx, entryID, WaitlistRemoved)
+	if err != nil {
+		return err
+	}
+
+	return UpdatePlacementRequestStatus(tx, e.RequestID, from, "rejected")
+}
+
+// ConvertWaitlistEntry takes an entry off the waitlist and accepts its placement request,
+// in the transaction tx. The workflow then opens the negotiation room, as if the facility
+// had accepted the request directly. It returns ErrStatusChanged if the request is no
+// longer in from.
+func ConvertWaitlistEntry(tx *sql.Tx, entryID int, from string) error {
+	e, err := closeWaitlistEntry(tx, entryID, WaitlistConverted)
+	if err != nil {
+		return err
+	}
+
+	return UpdatePlacementRequestStatus(tx, e.RequestID, from, "accepted")
+}
+
+// OfferWaitlistOpenings notifies the hospitals at the top of a facility's waitlist that
+// openings beds of a room type became available. Entries asking for any room type are
+// eligible for every opening. Entries already notified are skipped. Returns the number of
+// hospitals notified.
+func (r *FacilityRepository) OfferWaitlistOpenings(facilityID, roomTypeID, openings int) (int, error) {
+	if openings <= 0 {
+		return 0, nil
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		UPDATE waitlist_entries w
+		SET status = 'notified', notified_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		FROM (
+			SELECT id FROM waitlist_entries
+			WHERE facility_id = $1 AND status = 'waiting' AND (room_type_id IS NULL OR room_type_id = $2)
+			ORDER BY position ASC
+			LIMIT $3
+			FOR UPDATE
+		) top
+		WHERE w.id = top.id
+		RETURNING w.request_id, w.position
+	`, facilityID, roomTypeID, openings*WaitlistOffersPerOpening)
+	if err != nil {
+		return 0, fmt.Errorf("failed to select waitlist entries: %w", err)
+	}
+	type offer struct{ requestID, position int }
+	offers := []offer{}
+	for rows.Next() {
+		var o offer
+		if err := rows.Scan(&o.requestID, &o.position); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan waitlist entry: %w", err)
+		}
+		offers = append(offers, o)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to select waitlist entries: %w", err)
+	}
+
+	for _, o := range offers {
+		_, err := tx.Exec(`
+			INSERT INTO notifications (user_id, type, title, body, link, data)
+			SELECT h.user_id, $1,
+			       '「' || f.name || '」に空きが出ました',
+			       '待機リスト' || $2::int || '番目の入居依頼（' || pr.patient_age || '歳・' || pr.patient_gender ||
+			       '）について、施設からの受け入れ調整の連絡をお待ちください。',
+			       '/requests/' || pr.id,
+			       json_build_object('request_id', pr.id, 'facility_id', f.id, 'room_type_id', $3::int, 'position', $2::int)
+			FROM placement_requests pr
+			JOIN hospitals h ON pr.hospital_id = h.id
+			JOIN facilities f ON pr.facility_id = f.id
+			WHERE pr.id = $4
+		`, NotificationWaitlistOpening, o.position, roomTypeID, o.requestID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to create waitlist notification: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return len(offers), nil
+}
+
+// RoomTypeBelongsToFacility reports whether roomTypeID is one of the facility's room types
+func RoomTypeBelongsToFacility(db *sql.DB, facilityID, roomTypeID int) (bool, error) {
+	var exists bool
+	err := db.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM facility_room_types WHERE id = $1 AND facility_id = $2)
+	`, roomTypeID, facilityID).Scan(&exists)
+	return exists, err
+}
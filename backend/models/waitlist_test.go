@@ -0,0 +1,35 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateWaitlistOrder(t *testing.T) {
+	active := []int{4, 7, 9}
+
+	assert.NoError(t, ValidateWaitlistOrder(active, []int{9, 4, 7}))
+	assert.NoError(t, ValidateWaitlistOrder([]int{}, []int{}))
+
+	for name, ordered := range map[string][]int{
+		"missing entry":   {9, 4},
+		"unknown entry":   {9, 4, 8},
+		"duplicate entry": {9, 4, 4},
+		"extra entry":     {9, 4, 7, 8},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.ErrorIs(t, ValidateWaitlistOrder(active, ordered), ErrInvalidWaitlistOrder)
+		})
+	}
+}
+
+func TestWaitlistEntryIsActive(t *testing.T) {
+	assert.True(t, (&WaitlistEntry{Status: WaitlistWaiting}).IsActive())
+	assert.True(t, (&WaitlistEntry{Status: WaitlistNotified}).IsActive())
+	assert.False(t, (&WaitlistEntry{Status: WaitlistConverted}).IsActive())
+	assert.False(t, (&WaitlistEntry{Status: WaitlistRemoved}).IsActive())
+
+	assert.True(t, IsWaitlistPriority("urgent"))
+	assert.False(t, IsWaitlistPriority("low"))
+}
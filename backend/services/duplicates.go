@@ -0,0 +1,271 @@
+package services
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/width"
+
+	"github.com/social-worker-platform/backend/models"
+)
+
+// Duplicate detection thresholds
+const (
+	// DuplicateNameThreshold is the name similarity at which two records are
+	// reported on their name alone
+	DuplicateNameThreshold = 0.85
+	// DuplicateNearbyKm is the distance within which two facilities are treated as
+	// being at the same site
+	DuplicateNearbyKm = 0.2
+	// DuplicateNearbyNameThreshold is the name similarity needed for records at the
+	// same site, where a branch and its sister facility are common
+	DuplicateNearbyNameThreshold = 0.5
+)
+
+// Reasons a pair is reported as a probable duplicate
+const (
+	DuplicateReasonSameName    = "same_name"
+	DuplicateReasonSimilarName = "similar_name"
+	DuplicateReasonSamePhone   = "same_phone"
+	DuplicateReasonSameAddress = "same_address"
+	DuplicateReasonNearby      = "nearby"
+)
+
+// DuplicatePair is two records that probably describe the same organization
+type DuplicatePair struct {
+	Kind                string                       `json:"kind"`
+	Score               float64                      `json:"score"`
+	NameSimilarity      float64                      `json:"name_similarity"`
+	DistanceKm          *float64                     `json:"distance_km,omitempty"`
+	Reasons             []string                     `json:"reasons"`
+	SuggestedSurvivorID int                          `json:"suggested_survivor_id"`
+	Records             []*models.OrganizationRecord `json:"records"`
+}
+
+// legalEntityWords are corporate designations that prefix or suffix Japanese
+// organization names inconsistently ("社会福祉法人○○会 ××園" vs "××園"). Longer
+// forms come first so they are removed before their prefixes.
+var legalEntityWords = []string{
+	"特定非営利活動法人", "社会福祉法人", "医療法人社団", "医療法人財団", "医療法人",
+	"一般社団法人", "一般財団法人", "公益社団法人", "公益財団法人",
+	"株式会社", "有限会社", "合同会社", "npo法人",
+	"(福)", "(医)", "(株)", "(有)", "(社)", "(財)",
+}
+
+// NormalizeOrganizationName reduces a name to the characters that identify it:
+// full-width letters and digits are folded to half-width, legal entity designations,
+// spaces and punctuation are removed and Latin letters are lowercased
+func NormalizeOrganizationName(name string) string {
+	s := strings.ToLower(width.Fold.String(name))
+	for _, w := range legalEntityWords {
+		s = strings.ReplaceAll(s, w, "")
+	}
+	return keepLettersAndDigits(s, nil)
+}
+
+var postalCodePattern = regexp.MustCompile(`^〒?\s*\d{3}-?\d{4}`)
+
+// addressBlockNumbers are block numbers written out ("2丁目8番1号") where other
+// records abbreviate them to "2-8-1"
+var addressBlockNumbers = regexp.MustCompile(`(\d)(?:丁目|番地|番|[ー−‐―])`)
+
+var addressHouseNumber = regexp.MustCompile(`(\d)号`)
+
+// NormalizeAddress reduces an address so that "東京都新宿区西新宿２丁目８番１号" and
+// "〒163-8001 東京都新宿区西新宿2-8-1" compare equal
+func NormalizeAddress(address string) string {
+	s := strings.ToLower(width.Fold.String(strings.TrimSpace(address)))
+	s = postalCodePattern.ReplaceAllString(s, "")
+	s = addressBlockNumbers.ReplaceAllString(s, "$1-")
+	s = addressHouseNumber.ReplaceAllString(s, "$1")
+	s = keepLettersAndDigits(s, map[rune]bool{'-': true})
+	return strings.Trim(s, "-")
+}
+
+// NormalizePhone returns the digits of a Japanese phone number in domestic form, or ""
+// if it is too short to be a full number
+func NormalizePhone(phone string) string {
+	digits := strings.Map(func(r rune) rune {
+		if r < '0' || r > '9' {
+			return -1
+		}
+		return r
+	}, width.Fold.String(phone))
+	if strings.HasPrefix(digits, "81") && len(digits) >= 11 {
+		digits = "0" + digits[2:]
+	}
+	if len(digits) < 10 {
+		return ""
+	}
+	return digits
+}
+
+func keepLettersAndDigits(s string, keep map[rune]bool) string {
+	var b strings.Builder
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || keep[r] {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// bigrams returns the multiset of adjacent rune pairs of s
+func bigrams(s string) map[string]int {
+	runes := []rune(s)
+	grams := make(map[string]int, len(runes))
+	for i := 0; i+1 < len(runes); i++ {
+		grams[string(runes[i:i+2])]++
+	}
+	return grams
+}
+
+// diceCoefficient compares two bigram multisets: 1 for identical, 0 for nothing in common
+func diceCoefficient(a, b map[string]int) float64 {
+	total := 0
+	for _, n := range a {
+		total += n
+	}
+	for _, n := range b {
+		total += n
+	}
+	if total == 0 {
+		return 0
+	}
+	shared := 0
+	for g, n := range a {
+		if m := b[g]; m < n {
+			shared += m
+		} else {
+			shared += n
+		}
+	}
+	return 2 * float64(shared) / float64(total)
+}
+
+// NameSimilarity compares two normalized names by their character bigrams, which
+// works for Japanese names without word segmentation
+func NameSimilarity(a, b string) float64 {
+	if a == "" || b == "" {
+		return 0
+	}
+	if a == b {
+		return 1
+	}
+	return diceCoefficient(bigrams(a), bigrams(b))
+}
+
+type duplicateCandidate struct {
+	record  *models.OrganizationRecord
+	name    string
+	grams   map[string]int
+	phone   string
+	address string
+}
+
+// FindDuplicates compares every pair of records and returns the probable duplicates,
+// most likely first. Pairs in dismissed are skipped.
+func FindDuplicates(records []*models.OrganizationRecord, dismissed map[models.DuplicatePairKey]bool) []*DuplicatePair {
+	candidates := make([]*duplicateCandidate, len(records))
+	for i, rec := range records {
+		name := NormalizeOrganizationName(rec.Name)
+		candidates[i] = &duplicateCandidate{
+			record:  rec,
+			name:    name,
+			grams:   bigrams(name),
+			phone:   NormalizePhone(rec.Phone),
+			address: NormalizeAddress(rec.Address),
+		}
+	}
+
+	pairs := []*DuplicatePair{}
+	for i := 0; i < len(candidates); i++ {
+		for j := i + 1; j < len(candidates); j++ {
+			a, b := candidates[i], candidates[j]
+			if dismissed[models.NewDuplicatePairKey(a.record.ID, b.record.ID)] {
+				continue
+			}
+			if pair := compareCandidates(a, b); pair != nil {
+				pairs = append(pairs, pair)
+			}
+		}
+	}
+
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return pairs[i].Score > pairs[j].Score
+	})
+	return pairs
+}
+
+// compareCandidates returns the pair if a and b are probable duplicates, otherwise nil
+func compareCandidates(a, b *duplicateCandidate) *DuplicatePair {
+	pair := &DuplicatePair{Kind: a.record.Kind, Reasons: []string{}}
+
+	switch {
+	case a.name == "" || b.name == "":
+	case a.name == b.name:
+		pair.NameSimilarity = 1
+	default:
+		pair.NameSimilarity = diceCoefficient(a.grams, b.grams)
+	}
+
+	// Each signal adds to the score; the name carries the most weight because
+	// phone numbers and addresses are shared by facilities on one campus
+	score := 0.5 * pair.NameSimilarity
+	matched := false
+
+	if pair.NameSimilarity == 1 {
+		pair.Reasons = append(pair.Reasons, DuplicateReasonSameName)
+		matched = true
+	} else if pair.NameSimilarity >= DuplicateNameThreshold {
+		pair.Reasons = append(pair.Reasons, DuplicateReasonSimilarName)
+		matched = true
+	}
+	if a.phone != "" && a.phone == b.phone {
+		pair.Reasons = append(pair.Reasons, DuplicateReasonSamePhone)
+		score += 0.25
+		matched = true
+	}
+	if a.address != "" && a.address == b.address {
+		pair.Reasons = append(pair.Reasons, DuplicateReasonSameAddress)
+		score += 0.15
+		matched = true
+	}
+	if a.record.Latitude != nil && a.record.Longitude != nil && b.record.Latitude != nil && b.record.Longitude != nil {
+		d := HaversineKm(*a.record.Latitude, *a.record.Longitude, *b.record.Latitude, *b.record.Longitude)
+		pair.DistanceKm = &d
+		if d <= DuplicateNearbyKm {
+			score += 0.1 * (1 - d/DuplicateNearbyKm)
+			if pair.NameSimilarity >= DuplicateNearbyNameThreshold {
+				pair.Reasons = append(pair.Reasons, DuplicateReasonNearby)
+				matched = true
+			}
+		}
+	}
+
+	if !matched {
+		return nil
+	}
+
+	pair.Score = score
+	pair.Records = []*models.OrganizationRecord{a.record, b.record}
+	pair.SuggestedSurvivorID = suggestSurvivor(a.record, b.record).ID
+	return pair
+}
+
+// suggestSurvivor prefers the record whose account is still active, then the one
+// registered first
+func suggestSurvivor(a, b *models.OrganizationRecord) *models.OrganizationRecord {
+	if a.IsActive != b.IsActive {
+		if a.IsActive {
+			return a
+		}
+		return b
+	}
+	if b.CreatedAt.Before(a.CreatedAt) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/social-worker-platform/backend/models"
+)
+
+func TestNormalizeOrganizationName(t *testing.T) {
+	assert.Equal(t, "さくら苑", NormalizeOrganizationName("社会福祉法人 さくら苑"))
+	assert.Equal(t, "さくら苑", NormalizeOrganizationName("（福）さくら苑"))
+	assert.Equal(t, "abcケアホーム2", NormalizeOrganizationName("株式会社ＡＢＣ ケアホーム・２"))
+	assert.Equal(t, "", NormalizeOrganizationName(" 医療法人社団 "))
+}
+
+func TestNormalizeAddress(t *testing.T) {
+	a := NormalizeAddress("東京都新宿区西新宿２丁目８番１号")
+	assert.Equal(t, "東京都新宿区西新宿2-8-1", a)
+	assert.Equal(t, a, NormalizeAddress("〒163-8001 東京都新宿区西新宿2-8-1"))
+	assert.Equal(t, a, NormalizeAddress("東京都新宿区西新宿2ー8ー1"))
+	// "番" that is part of a place name is kept
+	assert.Equal(t, "東京都千代田区一番町1-2", NormalizeAddress("東京都千代田区一番町1-2"))
+}
+
+func TestNormalizePhone(t *testing.T) {
+	assert.Equal(t, "0312345678", NormalizePhone("03-1234-5678"))
+	assert.Equal(t, "0312345678", NormalizePhone("（０３）１２３４－５６７８"))
+	assert.Equal(t, "0312345678", NormalizePhone("+81 3 1234 5678"))
+	assert.Equal(t, "", NormalizePhone("内線123"))
+}
+
+func TestNameSimilarity(t *testing.T) {
+	assert.Equal(t, 1.0, NameSimilarity("さくら苑", "さくら苑"))
+	assert.Zero(t, NameSimilarity("さくら苑", ""))
+	assert.Zero(t, NameSimilarity("さくら苑", "ひまわり荘"))
+
+	similar := NameSimilarity(NormalizeOrganizationName("特別養護老人ホームさくら苑"), NormalizeOrganizationName("特別養護老人ホーム さくらえん"))
+	far := NameSimilarity(NormalizeOrganizationName("特別養護老人ホームさくら苑"), NormalizeOrganizationName("グループホームひまわり"))
+	assert.Greater(t, similar, far)
+}
+
+func facilityRecord(id int, name, address, phone string, lat, lng float64) *models.OrganizationRecord {
+	return &models.OrganizationRecord{
+		Kind: models.OrganizationFacility, ID: id, Name: name, Address: address, Phone: phone,
+		Latitude: &lat, Longitude: &lng, IsActive: true,
+		CreatedAt: time.Date(2024, 1, id, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestFindDuplicates(t *testing.T) {
+	records := []*models.OrganizationRecord{
+		facilityRecord(1, "社会福祉法人 特別養護老人ホーム さくら苑", "東京都新宿区西新宿２丁目８番１号", "03-1234-5678", 35.6896, 139.6917),
+		facilityRecord(2, "特別養護老人ホームさくら苑", "東京都新宿区西新宿2-8-1", "", 35.6897, 139.6918),
+		// Same campus, different facility: shares the phone number only
+		facilityRecord(3, "グループホームひまわり", "東京都新宿区西新宿2-8-3", "0312345678", 35.6899, 139.6920),
+		// Unrelated
+		facilityRecord(4, "介護老人保健施設あおば", "大阪府大阪市北区梅田1-1", "06-1111-2222", 34.70, 135.49),
+	}
+
+	pairs := FindDuplicates(records, nil)
+	require.Len(t, pairs, 2)
+
+	top := pairs[0]
+	assert.Equal(t, 1, top.Records[0].ID)
+	assert.Equal(t, 2, top.Records[1].ID)
+	assert.Equal(t, 1.0, top.NameSimilarity)
+	assert.Contains(t, top.Reasons, DuplicateReasonSameName)
+	assert.Contains(t, top.Reasons, DuplicateReasonSameAddress)
+	assert.Contains(t, top.Reasons, DuplicateReasonNearby)
+	require.NotNil(t, top.DistanceKm)
+	assert.Less(t, *top.DistanceKm, 0.05)
+	assert.Equal(t, 1, top.SuggestedSurvivorID, "older record survives")
+
+	phoneOnly := pairs[1]
+	assert.Equal(t, []string{DuplicateReasonSamePhone}, phoneOnly.Reasons)
+	assert.Less(t, phoneOnly.Score, top.Score)
+
+	dismissed := map[models.DuplicatePairKey]bool{models.NewDuplicatePairKey(2, 1): true}
+	pairs = FindDuplicates(records, dismissed)
+	require.Len(t, pairs, 1)
+	assert.Equal(t, []string{DuplicateReasonSamePhone}, pairs[0].Reasons)
+}
+
+func TestFindDuplicates_SuggestsActiveSurvivor(t *testing.T) {
+	a := facilityRecord(1, "さくら苑", "", "", 35, 139)
+	b := facilityRecord(2, "さくら苑", "", "", 36, 140)
+	a.IsActive = false
+
+	pairs := FindDuplicates([]*models.OrganizationRecord{a, b}, nil)
+	require.Len(t, pairs, 1)
+	assert.Equal(t, 2, pairs[0].SuggestedSurvivorID)
+}
@@ -0,0 +1,255 @@
+package services
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/social-worker-platform/backend/models"
+)
+
+// AddressGeocoder converts an address to coordinates; GeocodingService implements it
+type AddressGeocoder interface {
+	GeocodeAddress(address string) (*GeocodingResult, error)
+}
+
+// FacilityImportResult reports what an import did, or would do in a dry run
+type FacilityImportResult struct {
+	DryRun         bool                           `json:"dry_run"`
+	Committed      bool                           `json:"committed"`
+	Total          int                            `json:"total"`
+	Created        int                            `json:"created"`
+	Updated        int                            `json:"updated"`
+	Unchanged      int                            `json:"unchanged"`
+	Errors         []models.FacilityImportError   `json:"errors"`
+	IgnoredColumns []string                       `json:"ignored_columns,omitempty"`
+	Rows           []*models.FacilityImportRecord `json:"rows"`
+}
+
+// FacilityImporter loads facility directories (as published by prefectures) into the
+// platform, creating facility accounts for new entries and updating existing ones
+type FacilityImporter struct {
+	facilityRepo *models.FacilityRepository
+	geocoder     AddressGeocoder // optional
+}
+
+// NewFacilityImporter creates a FacilityImporter. geocoder may be nil to import
+// without looking up coordinates.
+func NewFacilityImporter(facilityRepo *models.FacilityRepository, geocoder AddressGeocoder) *FacilityImporter {
+	return &FacilityImporter{facilityRepo: facilityRepo, geocoder: geocoder}
+}
+
+// Import validates the rows of an import sheet and, unless dryRun is set or any row
+// has an error, saves them all in one transaction. changedBy is recorded in the change
+// history of updated facilities.
+func (im *FacilityImporter) Import(rows [][]string, changedBy int, dryRun bool) (*FacilityImportResult, error) {
+	records, rowErrors, ignored, err := models.ParseFacilityImport(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &FacilityImportResult{
+		DryRun:         dryRun,
+		Total:          len(records) + countLines(rowErrors),
+		Errors:         rowErrors,
+		IgnoredColumns: ignored,
+		Rows:           []*models.FacilityImportRecord{},
+	}
+
+	emails := make([]string, len(records))
+	for i, rec := range records {
+		emails[i] = rec.Email
+	}
+	accounts, err := im.facilityRepo.LookupImportAccounts(emails)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rec := range records {
+		if err := im.plan(rec, accounts[rec.Email]); err != nil {
+			var rowErr *importRowError
+			if errors.As(err, &rowErr) {
+				result.Errors = append(result.Errors, models.FacilityImportError{Line: rec.Line, Column: rowErr.column, Message: rowErr.message})
+				continue
+			}
+			return nil, err
+		}
+		result.Rows = append(result.Rows, rec)
+
+		switch rec.Action {
+		case models.ImportActionCreate:
+			result.Created++
+		case models.ImportActionUpdate:
+			result.Updated++
+		default:
+			result.Unchanged++
+		}
+	}
+
+	if dryRun || len(result.Errors) > 0 {
+		return result, nil
+	}
+
+	for _, rec := range result.Rows {
+		if rec.Action == models.ImportActionCreate && rec.UserID == 0 && rec.Password == "" {
+			password, err := generatePassword(12)
+			if err != nil {
+				return nil, err
+			}
+			rec.Password = password
+			rec.InitialPassword = password
+		}
+	}
+
+	if err := im.facilityRepo.ImportFacilities(result.Rows, changedBy); err != nil {
+		return nil, err
+	}
+	result.Committed = true
+
+	return result, nil
+}
+
+type importRowError struct {
+	column  string
+	message string
+}
+
+func (e *importRowError) Error() string { return e.column + ": " + e.message }
+
+// plan works out whether a record creates or updates a facility and prepares the
+// facility as it will be saved. Problems specific to the row are returned as *importRowError.
+func (im *FacilityImporter) plan(rec *models.FacilityImportRecord, account *models.ImportAccount) error {
+	if rec.FacilityType != "" {
+		t, err := im.facilityRepo.ResolveImportFacilityType(rec.FacilityType)
+		if errors.Is(err, models.ErrFacilityTypeNotFound) {
+			return &importRowError{"facility_type", fmt.Sprintf("unknown facility type: %q", rec.FacilityType)}
+		}
+		if err != nil {
+			return err
+		}
+		rec.FacilityType = t.Name
+	}
+
+	facility := &models.Facility{FacilityType: "介護施設"}
+	previousType := ""
+	hasRoomTypes := false
+
+	switch {
+	case account == nil:
+		rec.Action = models.ImportActionCreate
+	case account.Role != "facility":
+		return &importRowError{"email", fmt.Sprintf("belongs to a %s account", account.Role)}
+	case account.FacilityID == nil:
+		rec.Action = models.ImportActionCreate
+		rec.UserID = account.UserID
+	default:
+		existing, err := im.facilityRepo.GetByID(*account.FacilityID)
+		if err != nil {
+			return err
+		}
+		if hasRoomTypes, err = im.facilityRepo.HasRoomTypes(existing.ID); err != nil {
+			return err
+		}
+		facility = existing
+		previousType = existing.FacilityType
+		rec.Action = models.ImportActionUpdate
+		rec.UserID = account.UserID
+		rec.FacilityID = existing.ID
+	}
+
+	before := models.ProfileOf(facility)
+	addressChanged := facility.Address != rec.Address
+	rec.ApplyTo(facility, hasRoomTypes)
+
+	if hasRoomTypes && (rec.BedCapacity != facility.BedCapacity || rec.AvailableBeds != facility.AvailableBeds) {
+		rec.Warnings = append(rec.Warnings, "bed counts are managed by room types and were not changed")
+	}
+
+	if err := im.facilityRepo.ValidateFacilityType(facility, previousType); err != nil {
+		if errors.Is(err, models.ErrFacilityTypeInactive) {
+			return &importRowError{"facility_type", err.Error()}
+		}
+		return err
+	}
+
+	// Look up coordinates for new addresses unless the sheet gives them
+	if rec.Latitude == nil && rec.Address != "" && (addressChanged || facility.Latitude == nil) {
+		im.geocode(rec, facility)
+	}
+
+	rec.Facility = facility
+	if rec.Action == models.ImportActionUpdate {
+		changes, err := models.DiffProfiles(before, models.ProfileOf(facility))
+		if err != nil {
+			return err
+		}
+		rec.Changes = changes
+		if len(changes) == 0 {
+			rec.Action = models.ImportActionUnchanged
+		}
+	}
+
+	return nil
+}
+
+func (im *FacilityImporter) geocode(rec *models.FacilityImportRecord, facility *models.Facility) {
+	if im.geocoder == nil {
+		return
+	}
+
+	result, err := im.geocoder.GeocodeAddress(rec.Address)
+	if err != nil {
+		rec.Warnings = append(rec.Warnings, "geocoding failed: "+err.Error())
+		return
+	}
+	if !result.Found {
+		rec.Warnings = append(rec.Warnings, "address could not be geocoded")
+		return
+	}
+
+	facility.Latitude = &result.Latitude
+	facility.Longitude = &result.Longitude
+	rec.Latitude = facility.Latitude
+	rec.Longitude = facility.Longitude
+}
+
+// countLines counts the distinct lines among row errors
+func countLines(errs []models.FacilityImportError) int {
+	lines := map[int]bool{}
+	for _, e := range errs {
+		lines[e.Line] = true
+	}
+	return len(lines)
+}
+
+const passwordAlphabet = "abcdefghijkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// generatePassword returns a random password without easily confused characters
+func generatePassword(length int) (string, error) {
+	b := make([]byte, length)
+	for i := range b {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(passwordAlphabet))))
+		if err != nil {
+			return "", fmt.Errorf("failed to generate password: %w", err)
+		}
+		b[i] = passwordAlphabet[n.Int64()]
+	}
+	return string(b), nil
+}
+
+// ExportFacilities returns the facility directory as sheet rows, header first, in
+// the format Import reads
+func (im *FacilityImporter) ExportFacilities() ([][]string, error) {
+	records, err := im.facilityRepo.ExportFacilities()
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([][]string, 0, len(records)+1)
+	rows = append(rows, models.ImportHeader())
+	for _, rec := range records {
+		rows = append(rows, rec.Cells())
+	}
+	return rows, nil
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// DefaultEventDuration is assumed for events confirmed without an end time
+const DefaultEventDuration = time.Hour
+
+// CalendarEvent is one event of an iCalendar feed
+type CalendarEvent struct {
+	UID         string
+	Start       time.Time
+	End         *time.Time
+	Summary     string
+	Location    string
+	Description string
+	Updated     time.Time
+}
+
+// icalEscaper escapes TEXT values (RFC 5545 section 3.3.11)
+var icalEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+func icalTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// foldICalLine splits a content line into lines of at most 75 octets, continued
+// with a leading space, without breaking multibyte characters
+func foldICalLine(line string) string {
+	const limit = 75
+	if len(line) <= limit {
+		return line + "\r\n"
+	}
+
+	var b strings.Builder
+	width := limit
+	for len(line) > width {
+		cut := width
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// The leading space counts towards the limit of continuation lines
+		width = limit - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+	return b.String()
+}
+
+// WriteICalendar writes events as an iCalendar document that calendar apps can
+// subscribe to. Times are written in UTC; apps show them in the viewer's time zone.
+func WriteICalendar(w io.Writer, name string, events []CalendarEvent) error {
+	bw := bufio.NewWriter(w)
+	line := func(s string) {
+		bw.WriteString(foldICalLine(s))
+	}
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//social-worker-platform//schedule//JA")
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:PUBLISH")
+	line("X-WR-CALNAME:" + icalEscaper.Replace(name))
+	for _, e := range events {
+		line("BEGIN:VEVENT")
+		line("UID:" + e.UID)
+		line("DTSTAMP:" + icalTime(e.Updated))
+		line("DTSTART:" + icalTime(e.Start))
+		if e.End != nil {
+			line("DTEND:" + icalTime(*e.End))
+		} else {
+			line("DTEND:" + icalTime(e.Start.Add(DefaultEventDuration)))
+		}
+		line("SUMMARY:" + icalEscaper.Replace(e.Summary))
+		if e.Location != "" {
+			line("LOCATION:" + icalEscaper.Replace(e.Location))
+		}
+		if e.Description != "" {
+			line("DESCRIPTION:" + icalEscaper.Replace(e.Description))
+		}
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+
+	return bw.Flush()
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteICalendar(t *testing.T) {
+	jst := time.FixedZone("JST", 9*60*60)
+	start := time.Date(2026, 11, 2, 10, 0, 0, 0, jst)
+	end := start.Add(30 * time.Minute)
+
+	var buf bytes.Buffer
+	err := WriteICalendar(&buf, "さくら病院 受け入れ予定", []CalendarEvent{
+		{UID: "room-event-1@example", Start: start, End: &end, Summary: "入居前面談", Location: "さくら苑, 面談室", Updated: start},
+		{UID: "room-event-2@example", Start: start.Add(48 * time.Hour), Summary: "入居", Description: "車椅子対応;\n家族同伴", Updated: start},
+	})
+	require.NoError(t, err)
+	out := buf.String()
+
+	assert.True(t, strings.HasPrefix(out, "BEGIN:VCALENDAR\r\n"))
+	assert.True(t, strings.HasSuffix(out, "END:VCALENDAR\r\n"))
+	assert.Equal(t, 2, strings.Count(out, "BEGIN:VEVENT\r\n"))
+
+	// Times are converted to UTC
+	assert.Contains(t, out, "DTSTART:20261102T010000Z\r\n")
+	assert.Contains(t, out, "DTEND:20261102T013000Z\r\n")
+	// An event without an end gets the default duration
+	assert.Contains(t, out, "DTSTART:20261104T010000Z\r\nDTEND:20261104T020000Z\r\n")
+
+	assert.Contains(t, out, `LOCATION:さくら苑\, 面談室`)
+	assert.Contains(t, out, `DESCRIPTION:車椅子対応\;\n家族同伴`)
+}
+
+func TestFoldICalLine(t *testing.T) {
+	assert.Equal(t, "SUMMARY:short\r\n", foldICalLine("SUMMARY:short"))
+
+	line := "DESCRIPTION:" + strings.Repeat("退院調整", 20)
+	folded := foldICalLine(line)
+
+	parts := strings.Split(strings.TrimSuffix(folded, "\r\n"), "\r\n")
+	require.Greater(t, len(parts), 1)
+	for i, p := range parts {
+		assert.LessOrEqual(t, len(p), 75)
+		assert.True(t, utf8.ValidString(p), "part %d splits a character", i)
+		if i > 0 {
+			assert.True(t, strings.HasPrefix(p, " "))
+		}
+	}
+
+	unfolded := strings.ReplaceAll(strings.TrimSuffix(folded, "\r\n"), "\r\n ", "")
+	assert.Equal(t, line, unfolded)
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodeJPEG(t *testing.T, w, h int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, img, nil))
+	return buf.Bytes()
+}
+
+// withExif inserts an APP1 Exif segment holding only an Orientation tag right after SOI
+func withExif(jpg []byte, orientation uint16) []byte {
+	tiff := new(bytes.Buffer)
+	tiff.WriteString("II")
+	binary.Write(tiff, binary.LittleEndian, uint16(42))
+	binary.Write(tiff, binary.LittleEndian, uint32(8))
+	binary.Write(tiff, binary.LittleEndian, uint16(1))
+	binary.Write(tiff, binary.LittleEndian, uint16(0x0112))
+	binary.Write(tiff, binary.LittleEndian, uint16(3))
+	binary.Write(tiff, binary.LittleEndian, uint32(1))
+	binary.Write(tiff, binary.LittleEndian, orientation)
+	binary.Write(tiff, binary.LittleEndian, uint16(0))
+	binary.Write(tiff, binary.LittleEndian, uint32(0))
+
+	payload := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	segment = append(segment, payload...)
+
+	out := append([]byte{}, jpg[:2]...)
+	out = append(out, segment...)
+	return append(out, jpg[2:]...)
+}
+
+func TestImageProcessor_GeneratesVariants(t *testing.T) {
+	p := NewImageProcessor(FacilityImageVariants)
+
+	result, err := p.Process(bytes.NewReader(encodeJPEG(t, 3000, 2000)))
+	require.NoError(t, err)
+
+	expected := map[string][2]int{
+		"thumbnail": {240, 160},
+		"card":      {640, 427},
+		"full":      {1920, 1280},
+	}
+	for name, size := range expected {
+		data, ok := result.Variants[name]
+		require.True(t, ok, name)
+		cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+		require.NoError(t, err)
+		assert.Equal(t, "jpeg", format)
+		assert.Equal(t, size[0], cfg.Width, name)
+		assert.Equal(t, size[1], cfg.Height, name)
+	}
+}
+
+func TestImageProcessor_KeepsSmallImagesAtOriginalSize(t *testing.T) {
+	p := NewImageProcessor(FacilityImageVariants)
+
+	result, err := p.Process(bytes.NewReader(encodeJPEG(t, 100, 50)))
+	require.NoError(t, err)
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(result.Variants["full"]))
+	require.NoError(t, err)
+	assert.Equal(t, 100, cfg.Width)
+	assert.Equal(t, 50, cfg.Height)
+}
+
+func TestImageProcessor_StripsExifAndAppliesOrientation(t *testing.T) {
+	p := NewImageProcessor(FacilityImageVariants)
+	src := withExif(encodeJPEG(t, 400, 200), 6)
+	require.Equal(t, 6, jpegOrientation(src))
+
+	result, err := p.Process(bytes.NewReader(src))
+	require.NoError(t, err)
+
+	for name, data := range result.Variants {
+		assert.False(t, bytes.Contains(data, []byte("Exif")), "%s still carries EXIF", name)
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(result.Variants["full"]))
+	require.NoError(t, err)
+	assert.Equal(t, 200, cfg.Width)
+	assert.Equal(t, 400, cfg.Height)
+}
+
+func TestImageProcessor_FlattensTransparentPNG(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 10, 10))
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+
+	result, err := NewImageProcessor(FacilityImageVariants).Process(&buf)
+	require.NoError(t, err)
+
+	out, err := jpeg.Decode(bytes.NewReader(result.Variants["thumbnail"]))
+	require.NoError(t, err)
+	r, g, b, _ := out.At(5, 5).RGBA()
+	assert.Greater(t, r>>8, uint32(240))
+	assert.Greater(t, g>>8, uint32(240))
+	assert.Greater(t, b>>8, uint32(240))
+}
+
+func TestImageProcessor_RejectsNonImages(t *testing.T) {
+	_, err := NewImageProcessor(FacilityImageVariants).Process(strings.NewReader("%PDF-1.4 not an image"))
+	assert.ErrorIs(t, err, ErrUnsupportedImage)
+}
+
+func TestFitWithin(t *testing.T) {
+	w, h := fitWithin(1000, 500, 200, 200)
+	assert.Equal(t, 200, w)
+	assert.Equal(t, 100, h)
+
+	w, h = fitWithin(10, 5000, 200, 200)
+	assert.Equal(t, 1, w)
+	assert.Equal(t, 200, h)
+}
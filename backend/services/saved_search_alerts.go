@@ -0,0 +1,121 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/social-worker-platform/backend/models"
+)
+
+// SavedSearchAlerter re-runs saved facility searches and notifies hospitals about
+// facilities that newly match, e.g. because a bed became available
+type SavedSearchAlerter struct {
+	searchRepo       *models.SavedSearchRepository
+	facilityRepo     *models.FacilityRepository
+	notificationRepo *models.NotificationRepository
+	mailer           Mailer // optional
+}
+
+// NewSavedSearchAlerter creates a SavedSearchAlerter. mailer may be nil to disable email.
+func NewSavedSearchAlerter(searchRepo *models.SavedSearchRepository, facilityRepo *models.FacilityRepository, notificationRepo *models.NotificationRepository, mailer Mailer) *SavedSearchAlerter {
+	return &SavedSearchAlerter{
+		searchRepo:       searchRepo,
+		facilityRepo:     facilityRepo,
+		notificationRepo: notificationRepo,
+		mailer:           mailer,
+	}
+}
+
+// RefreshAll checks every saved search and returns the number of new matches found
+func (a *SavedSearchAlerter) RefreshAll() (int, error) {
+	searches, err := a.searchRepo.GetAll()
+	if err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for _, search := range searches {
+		matches, err := a.Refresh(search)
+		if err != nil {
+			log.Printf("Failed to refresh saved search %d: %v", search.ID, err)
+			continue
+		}
+		total += len(matches)
+	}
+
+	return total, nil
+}
+
+// Refresh runs one saved search, records its matches and notifies about facilities
+// that newly match. The first run after creating or editing a search only records
+// the baseline and does not notify.
+func (a *SavedSearchAlerter) Refresh(search *models.SavedSearch) ([]*models.Facility, error) {
+	facilities, err := a.facilityRepo.SearchAdvanced(search.Criteria)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int]*models.Facility, len(facilities))
+	ids := make([]int, 0, len(facilities))
+	for _, f := range facilities {
+		byID[f.ID] = f
+		ids = append(ids, f.ID)
+	}
+
+	baseline := search.LastCheckedAt == nil
+	newIDs, err := a.searchRepo.SyncMatches(search.ID, ids, baseline)
+	if err != nil {
+		return nil, err
+	}
+	if baseline || len(newIDs) == 0 {
+		return nil, nil
+	}
+
+	matched := make([]*models.Facility, 0, len(newIDs))
+	for _, id := range newIDs {
+		if f, ok := byID[id]; ok {
+			matched = append(matched, f)
+		}
+	}
+
+	a.notify(search, matched)
+	return matched, nil
+}
+
+func (a *SavedSearchAlerter) notify(search *models.SavedSearch, facilities []*models.Facility) {
+	names := make([]string, len(facilities))
+	ids := make([]int, len(facilities))
+	for i, f := range facilities {
+		names[i] = f.Name
+		ids[i] = f.ID
+	}
+
+	title := fmt.Sprintf("保存した検索「%s」に%d件の施設が新たに一致しました", search.Name, len(facilities))
+	body := strings.Join(names, "、")
+	link := fmt.Sprintf("/facilities?saved_search=%d", search.ID)
+	data, _ := json.Marshal(map[string]interface{}{
+		"saved_search_id": search.ID,
+		"facility_ids":    ids,
+	})
+
+	notification := &models.Notification{
+		UserID: search.UserID,
+		Type:   models.NotificationSavedSearchMatch,
+		Title:  title,
+		Body:   &body,
+		Link:   &link,
+		Data:   data,
+	}
+	if err := a.notificationRepo.Create(notification); err != nil {
+		log.Printf("Failed to create notification for saved search %d: %v", search.ID, err)
+	}
+
+	if search.NotifyEmail && a.mailer != nil && search.UserEmail != "" {
+		text := title + "\n\n" + strings.Join(names, "\n") + "\n"
+		if err := a.mailer.Send(search.UserEmail, title, text); err != nil {
+			log.Printf("Failed to email saved search %d alert: %v", search.ID, err)
+		}
+	}
+}
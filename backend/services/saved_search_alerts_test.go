@@ -0,0 +1,126 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/social-worker-platform/backend/config"
+	"github.com/social-worker-platform/backend/models"
+)
+
+// recordingMailer keeps the subjects of the emails it was asked to send
+type recordingMailer struct {
+	subjects []string
+}
+
+func (m *recordingMailer) Send(to, subject, body string) error {
+	m.subjects = append(m.subjects, subject)
+	return nil
+}
+
+func TestSavedSearchAlerterRefresh(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping database tests in short mode")
+	}
+
+	db := config.SetupTestDatabase(t)
+	defer config.TeardownTestDatabase(t, db)
+
+	suffix := time.Now().UnixNano()
+	userRepo := models.NewUserRepository(db)
+	facilityRepo := models.NewFacilityRepository(db)
+	setAvailableBeds := func(facility *models.Facility, beds int) {
+		_, err := db.Exec(`UPDATE facilities SET available_beds = $1 WHERE id = $2`, beds, facility.ID)
+		require.NoError(t, err)
+	}
+	createFacility := func(name string) *models.Facility {
+		user, err := userRepo.Create(fmt.Sprintf("%s%d@example.com", name, suffix), "password", "facility")
+		require.NoError(t, err)
+		facility, err := facilityRepo.Create(user.ID, fmt.Sprintf("%s %d", name, suffix), "Address", "123-456", 10, "None")
+		require.NoError(t, err)
+		setAvailableBeds(facility, 3)
+		return facility
+	}
+
+	hospitalUser, err := userRepo.Create(fmt.Sprintf("hospital%d@example.com", suffix), "password", "hospital")
+	require.NoError(t, err)
+	hospital, err := models.NewHospitalRepository(db).Create(hospitalUser.ID, "Test Hospital", "Address", "123-456")
+	require.NoError(t, err)
+
+	sakura := createFacility("sakura")
+	searchRepo := models.NewSavedSearchRepository(db)
+	search := &models.SavedSearch{
+		HospitalID:  hospital.ID,
+		UserID:      hospitalUser.ID,
+		Name:        "空きのある施設",
+		Criteria:    models.FacilitySearchParams{Name: fmt.Sprint(suffix), HasAvailableBeds: true},
+		NotifyEmail: true,
+	}
+	require.NoError(t, searchRepo.Create(search))
+
+	mailer := &recordingMailer{}
+	notificationRepo := models.NewNotificationRepository(db)
+	alerter := NewSavedSearchAlerter(searchRepo, facilityRepo, notificationRepo, mailer)
+
+	// Refresh works on the stored search, as RefreshAll does
+	refresh := func() []*models.Facility {
+		stored, err := searchRepo.GetByID(search.ID)
+		require.NoError(t, err)
+		matched, err := alerter.Refresh(stored)
+		require.NoError(t, err)
+		return matched
+	}
+	notifications := func() []*models.Notification {
+		list, err := notificationRepo.GetByUserID(hospitalUser.ID, false, 50)
+		require.NoError(t, err)
+		return list
+	}
+
+	t.Run("first run records the baseline silently", func(t *testing.T) {
+		assert.Empty(t, refresh())
+		assert.Empty(t, notifications())
+		assert.Empty(t, mailer.subjects)
+	})
+
+	t.Run("notifies only about new matches", func(t *testing.T) {
+		momiji := createFacility("momiji")
+
+		matched := refresh()
+		require.Len(t, matched, 1)
+		assert.Equal(t, momiji.ID, matched[0].ID)
+		require.Len(t, notifications(), 1)
+		assert.Len(t, mailer.subjects, 1)
+
+		assert.Empty(t, refresh())
+		assert.Len(t, notifications(), 1)
+	})
+
+	t.Run("a facility that stopped matching is reported when it matches again", func(t *testing.T) {
+		setAvailableBeds(sakura, 0)
+		assert.Empty(t, refresh())
+
+		setAvailableBeds(sakura, 3)
+		matched := refresh()
+		require.Len(t, matched, 1)
+		assert.Equal(t, sakura.ID, matched[0].ID)
+		assert.Len(t, notifications(), 2)
+	})
+
+	t.Run("changing the criteria starts a new silent baseline", func(t *testing.T) {
+		search.Criteria.HasAvailableBeds = false
+		require.NoError(t, searchRepo.Update(search))
+		createFacility("kaede")
+
+		assert.Empty(t, refresh())
+		assert.Len(t, notifications(), 2)
+		assert.Len(t, mailer.subjects, 2)
+
+		var matches int
+		require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM saved_search_matches WHERE saved_search_id = $1`, search.ID).Scan(&matches))
+		assert.Equal(t, 3, matches)
+	})
+}
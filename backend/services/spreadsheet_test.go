@@ -0,0 +1,81 @@
+package services
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/encoding/japanese"
+)
+
+func TestReadCSV(t *testing.T) {
+	want := [][]string{{"施設名", "定員"}, {"さくら苑", "30"}}
+
+	t.Run("UTF-8 with BOM", func(t *testing.T) {
+		rows, err := ReadCSV(append([]byte{0xEF, 0xBB, 0xBF}, "施設名,定員\nさくら苑,30\n"...))
+		require.NoError(t, err)
+		assert.Equal(t, want, rows)
+	})
+
+	t.Run("Shift_JIS", func(t *testing.T) {
+		sjis, err := japanese.ShiftJIS.NewEncoder().Bytes([]byte("施設名,定員\nさくら苑,30\n"))
+		require.NoError(t, err)
+		rows, err := ReadCSV(sjis)
+		require.NoError(t, err)
+		assert.Equal(t, want, rows)
+	})
+
+	t.Run("ragged rows", func(t *testing.T) {
+		rows, err := ReadCSV([]byte("a,b,c\nx\n"))
+		require.NoError(t, err)
+		assert.Equal(t, [][]string{{"a", "b", "c"}, {"x"}}, rows)
+	})
+}
+
+func TestXLSXRoundTrip(t *testing.T) {
+	rows := [][]string{
+		{"施設名", "電話番号", "", "備考"},
+		{"さくら苑 & <別館>", "03-0000-0000", "", "  前後の空白  "},
+		{"", "", "", "00123"},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteXLSX(&buf, "施設一覧", rows))
+
+	got, err := ReadSpreadsheet("export.XLSX", &buf)
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"施設名", "電話番号", "", "備考"},
+		{"さくら苑 & <別館>", "03-0000-0000", "", "  前後の空白  "},
+		{"", "", "", "00123"},
+	}, got)
+}
+
+func TestWriteCSVRoundTrip(t *testing.T) {
+	rows := [][]string{{"施設名", "住所"}, {"さくら苑", "東京都世田谷区北沢2-19-12, 3F"}}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteCSV(&buf, rows))
+	assert.True(t, bytes.HasPrefix(buf.Bytes(), utf8BOM))
+
+	got, err := ReadSpreadsheet("facilities.csv", &buf)
+	require.NoError(t, err)
+	assert.Equal(t, rows, got)
+}
+
+func TestReadSpreadsheet_UnsupportedFormat(t *testing.T) {
+	_, err := ReadSpreadsheet("facilities.xls", bytes.NewReader(nil))
+	assert.ErrorIs(t, err, ErrUnsupportedSpreadsheet)
+}
+
+func TestXLSXColumns(t *testing.T) {
+	for col, name := range map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"} {
+		assert.Equal(t, name, xlsxColumnName(col))
+		idx, ok := xlsxColumnIndex(name + "12")
+		assert.True(t, ok)
+		assert.Equal(t, col, idx)
+	}
+	_, ok := xlsxColumnIndex("12")
+	assert.False(t, ok)
+}
@@ -0,0 +1,70 @@
+package services
+
+import "math"
+
+// TravelTimeEstimator estimates door-to-door travel time by car in minutes
+type TravelTimeEstimator interface {
+	EstimateMinutes(fromLat, fromLng, toLat, toLng float64) (float64, error)
+}
+
+// SpeedBand is the average road speed for trips up to MaxKm of road distance
+type SpeedBand struct {
+	MaxKm    float64
+	SpeedKmh float64
+}
+
+// RoadSpeedEstimator is an offline model that needs no routing service: the
+// straight-line distance is stretched by a detour factor to approximate road
+// distance, and longer trips are assumed to use faster roads.
+type RoadSpeedEstimator struct {
+	DetourFactor    float64
+	Bands           []SpeedBand // ascending by MaxKm; the last band covers everything beyond
+	OverheadMinutes float64     // parking, getting in and out
+}
+
+// NewRoadSpeedEstimator returns the estimator with defaults tuned for Japanese
+// local roads: slow in town, faster once a trip reaches bypasses and expressways
+func NewRoadSpeedEstimator() *RoadSpeedEstimator {
+	return &RoadSpeedEstimator{
+		DetourFactor: 1.3,
+		Bands: []SpeedBand{
+			{MaxKm: 5, SpeedKmh: 20},
+			{MaxKm: 20, SpeedKmh: 30},
+			{MaxKm: 60, SpeedKmh: 45},
+			{MaxKm: math.Inf(1), SpeedKmh: 70},
+		},
+		OverheadMinutes: 5,
+	}
+}
+
+// EstimateMinutes charges each stretch of the road distance at its band's speed
+func (e *RoadSpeedEstimator) EstimateMinutes(fromLat, fromLng, toLat, toLng float64) (float64, error) {
+	remaining := HaversineKm(fromLat, fromLng, toLat, toLng) * e.DetourFactor
+	minutes := e.OverheadMinutes
+
+	covered := 0.0
+	for _, band := range e.Bands {
+		if remaining <= 0 {
+			break
+		}
+		stretch := math.Min(remaining, band.MaxKm-covered)
+		minutes += stretch / band.SpeedKmh * 60
+		remaining -= stretch
+		covered = band.MaxKm
+	}
+	if remaining > 0 && len(e.Bands) > 0 {
+		minutes += remaining / e.Bands[len(e.Bands)-1].SpeedKmh * 60
+	}
+
+	return math.Round(minutes), nil
+}
+
+// HaversineKm returns the great-circle distance between two points (Earth radius 6371 km)
+func HaversineKm(lat1, lng1, lat2, lng2 float64) float64 {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := toRad(lat2 - lat1)
+	dLng := toRad(lng2 - lng1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 6371 * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
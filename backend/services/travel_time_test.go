@@ -0,0 +1,41 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHaversineKm(t *testing.T) {
+	// Tokyo Station to Shin-Osaka Station is about 400 km in a straight line
+	d := HaversineKm(35.6812, 139.7671, 34.7335, 135.5003)
+	assert.InDelta(t, 403, d, 5)
+	assert.Zero(t, HaversineKm(35, 139, 35, 139))
+}
+
+func TestRoadSpeedEstimator(t *testing.T) {
+	e := NewRoadSpeedEstimator()
+
+	same, err := e.EstimateMinutes(35.68, 139.76, 35.68, 139.76)
+	require.NoError(t, err)
+	assert.Equal(t, e.OverheadMinutes, same)
+
+	// ~3 km straight line -> ~3.9 km of road, all at town speed
+	near, err := e.EstimateMinutes(35.68, 139.76, 35.707, 139.76)
+	require.NoError(t, err)
+	assert.InDelta(t, 5+3.9/20*60, near, 1)
+
+	// Longer trips get faster per kilometre
+	far, err := e.EstimateMinutes(35.68, 139.76, 36.13, 139.76)
+	require.NoError(t, err)
+	assert.Greater(t, far, near)
+	assert.Less(t, far/HaversineKm(35.68, 139.76, 36.13, 139.76), near/HaversineKm(35.68, 139.76, 35.707, 139.76))
+}
+
+func TestRoadSpeedEstimator_BeyondLastBand(t *testing.T) {
+	e := &RoadSpeedEstimator{DetourFactor: 1, Bands: []SpeedBand{{MaxKm: 10, SpeedKmh: 60}}}
+	minutes, err := e.EstimateMinutes(35, 139, 35.18, 139)
+	require.NoError(t, err)
+	assert.InDelta(t, 20, minutes, 1)
+}
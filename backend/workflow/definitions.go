@@ -0,0 +1,401 @@
+package workflow
+
+import (
+	"github.com/social-worker-platform/backend/models"
+)
+
+// Placement request statuses
+const (
+	RequestDraft       = "draft"
+	RequestPending     = "pending"
+	RequestConditional = "conditionally_accepted"
+	RequestWaitlisted  = "waitlisted"
+	RequestAccepted    = "accepted"
+	RequestRejected    = "rejected"
+	RequestWithdrawn   = "withdrawn"
+)
+
+// Message room statuses
+const (
+	RoomNegotiating = "negotiating"
+	RoomAccepted    = "accepted"
+	RoomCompleted   = "completed"
+	RoomRejected    = "rejected"
+	RoomWithdrawn   = "withdrawn"
+)
+
+// Actions that change a status
+const (
+	ActionSubmit            = "submit"
+	ActionAccept            = "accept"
+	ActionReject            = "reject"
+	ActionAcceptConditional = "accept_with_conditions"
+	ActionAcceptConditions  = "accept_conditions"
+	ActionDeclineConditions = "decline_conditions"
+	ActionWaitlist          = "waitlist"
+	ActionConvert           = "convert"
+	ActionRemove            = "remove"
+	ActionComplete          = "complete"
+	ActionWithdraw          = "withdraw"
+	ActionReopen            = "reopen"
+)
+
+// Operations that keep the status
+const (
+	OpUpdate           = "update"
+	OpSendMessage      = "send_message"
+	OpUploadFile       = "upload_file"
+	OpMarkComplete     = "mark_complete"
+	OpCancelCompletion = "cancel_completion"
+	OpHoldBed          = "hold_bed"
+	OpReleaseBed       = "release_bed"
+	OpRate             = "rate"
+	OpRequestReopen    = "request_reopen"
+	OpSchedule         = "schedule"
+	OpEditChecklist    = "edit_checklist"
+	OpForward          = "forward"
+)
+
+// Guards a caller tells apart from other refusals, e.g. a room waiting for the other party
+const (
+	GuardBothCompleted = "both_parties_completed"
+	GuardChecklistDone = "checklist_done"
+)
+
+const (
+	roleHospital = "hospital"
+	roleFacility = "facility"
+	roleAdmin    = "admin"
+)
+
+var (
+	bothParties  = []string{roleHospital, roleFacility}
+	hospitalOnly = []string{roleHospital}
+	facilityOnly = []string{roleFacility}
+	// Admins may override the parties for transitions that list them
+	partiesOrAdmin = []string{roleHospital, roleFacility, roleAdmin}
+)
+
+var (
+	guardRequestComplete = &Guard{
+		Name:        "request_complete",
+		Description: "患者の年齢・性別・病状が入力されていること",
+		Check: func(s *Subject) bool {
+			return s.RequestComplete
+		},
+	}
+	guardRoomOpen = &Guard{
+		Name:        "room_open",
+		Description: "受け入れ調整のメッセージルームが終了していないこと",
+		Check: func(s *Subject) bool {
+			return !s.RoomClosed
+		},
+	}
+	guardReopenConfirmed = &Guard{
+		Name:        "reopen_confirmed",
+		Description: "相手方が再開を承認していること（管理者は不要）",
+		Check: func(s *Subject) bool {
+			return s.ReopenConfirmed || s.AdminOverride
+		},
+	}
+	guardChecklistDone = &Guard{
+		Name:        GuardChecklistDone,
+		Description: "引き継ぎチェックリストの必須項目がチェック済みであること（設定で有効な場合）",
+		Check: func(s *Subject) bool {
+			return !s.ChecklistIncomplete
+		},
+	}
+	guardBothCompleted = &Guard{
+		Name:        GuardBothCompleted,
+		Description: "病院と施設の両方が完了にしていること",
+		Check: func(s *Subject) bool {
+			return s.HospitalCompleted && s.FacilityCompleted
+		},
+	}
+
+	effectDeliverRequest = &Effect{
+		Name:        "deliver_request",
+		Description: "依頼を施設の一覧と未読件数に表示する",
+	}
+	effectRecordConditions = &Effect{
+		Name:        "record_conditions",
+		Description: "施設が提示した受け入れ条件を依頼に記録する",
+	}
+	effectRespondToConditions = &Effect{
+		Name:        "respond_to_conditions",
+		Description: "受け入れ条件への病院の回答を記録する",
+	}
+	effectPostConditionsMessage = &Effect{
+		Name:        "post_conditions_message",
+		Description: "承諾した受け入れ条件をシステムメッセージとしてルームに投稿する",
+		Run: func(db models.Querier, s *Subject) error {
+			conditions, err := models.GetAcceptedRequestCondition(db, s.RequestID)
+			if err != nil || conditions == nil {
+				return err
+			}
+			text := "病院が施設の受け入れ条件を承諾しました。\n" + conditions.Summary()
+			return models.CreateMessage(db, &models.Message{RoomID: s.RoomID, SenderID: s.UserID, MessageText: text, IsSystem: true})
+		},
+	}
+	effectWithdrawConditions = &Effect{
+		Name:        "withdraw_conditions",
+		Description: "回答前の受け入れ条件を取り下げ済みにする",
+		Required:    true,
+		Run: func(db models.Querier, s *Subject) error {
+			return models.WithdrawRequestConditions(db, s.RequestID)
+		},
+	}
+	effectWithdrawRoom = &Effect{
+		Name:        "withdraw_room",
+		Description: "受け入れ調整中のメッセージルームも取り下げにする",
+	}
+	effectLeaveWaitlist = &Effect{
+		Name:        "leave_waitlist",
+		Description: "待機リストから外す",
+	}
+	effectNotifyWithdrawal = &Effect{
+		Name:        "notify_withdrawal",
+		Description: "提出済みの依頼であれば施設に取り下げを通知する",
+		Run: func(db models.Querier, s *Subject) error {
+			return models.NotifyRequestWithdrawn(db, s.RequestID)
+		},
+	}
+	effectPostWithdrawalMessage = &Effect{
+		Name:        "post_withdrawal_message",
+		Description: "取り下げと理由をシステムメッセージとしてルームに投稿する",
+		Run: func(db models.Querier, s *Subject) error {
+			text := "病院が入居依頼を取り下げました。"
+			if s.Reason != "" {
+				text += "\n理由: " + s.Reason
+			}
+			return models.CreateMessage(db, &models.Message{RoomID: s.RoomID, SenderID: s.UserID, MessageText: text, IsSystem: true})
+		},
+	}
+	effectResetCompletion = &Effect{
+		Name:        "reset_completion",
+		Description: "病院・施設の完了をどちらも取り消す",
+	}
+	effectPostReopenMessage = &Effect{
+		Name:        "post_reopen_message",
+		Description: "再開と理由をシステムメッセージとしてルームに投稿する",
+		Run: func(db models.Querier, s *Subject) error {
+			text := "ルームが再開されました。"
+			if s.AdminOverride {
+				text = "管理者がルームを再開しました。"
+			}
+			if s.Reason != "" {
+				text += "\n理由: " + s.Reason
+			}
+			return models.CreateMessage(db, &models.Message{RoomID: s.RoomID, SenderID: s.UserID, MessageText: text, IsSystem: true})
+		},
+	}
+	effectCreateRoom = &Effect{
+		Name:        "create_room",
+		Description: "受け入れ調整のメッセージルームを検討中の状態で作成する",
+		Required:    true,
+		Run: func(db models.Querier, s *Subject) error {
+			room, err := models.CreateMessageRoomForRequest(db, s.RequestID, MessageRooms.Initial)
+			if err != nil {
+				return err
+			}
+			s.RoomID = room.ID
+			return record(db, MessageRooms.Name, nil, room.Status, "", s.role, s)
+		},
+	}
+	effectCarryForwardedFiles = &Effect{
+		Name:        "carry_forwarded_files",
+		Description: "転送時に引き継いだ添付ファイルをルームに追加する",
+		Required:    true,
+		Run: func(db models.Querier, s *Subject) error {
+			return models.AttachForwardedFiles(db, s.RequestID, s.RoomID)
+		},
+	}
+	effectMarkRequestRead = &Effect{
+		Name:        "mark_request_read",
+		Description: "操作した利用者の未読から依頼を外す",
+		Run: func(db models.Querier, s *Subject) error {
+			return models.MarkRequestAsRead(db, s.RequestID, s.UserID)
+		},
+	}
+	effectHoldBed = &Effect{
+		Name:        "hold_bed",
+		Description: "部屋種別が指定された場合はベッドを仮押さえする",
+	}
+	effectCreateChecklist = &Effect{
+		Name:        "create_checklist",
+		Description: "施設種別の引き継ぎチェックリストをルームに用意する",
+		Required:    true,
+		Run: func(db models.Querier, s *Subject) error {
+			return models.CreateRoomChecklist(db, s.RoomID)
+		},
+	}
+	effectReleaseBedHold = &Effect{
+		Name:        "release_bed_hold",
+		Description: "仮押さえしたベッドを解除する",
+		Required:    true,
+		Run: func(db models.Querier, s *Subject) error {
+			return models.ReleaseBedHold(db, s.RoomID)
+		},
+	}
+	effectConvertBedHold = &Effect{
+		Name:        "convert_bed_hold",
+		Description: "仮押さえしたベッドを入居確定にして空き数から差し引く",
+		Required:    true,
+		Run: func(db models.Querier, s *Subject) error {
+			_, err := models.ConvertBedHold(db, s.RoomID)
+			return err
+		},
+	}
+)
+
+// PlacementRequests is the workflow of a placement request from a hospital to a facility.
+// A request starts as a draft only the hospital sees, unless it is submitted as it is
+// created. Accepting a request opens a message room in RoomNegotiating. The facility
+// may instead accept on conditions, in which case the room opens once the hospital
+// accepts them. The hospital can withdraw a request at any point until that room closes.
+var PlacementRequests = &Machine{
+	Name:    "placement_request",
+	Initial: RequestDraft,
+	States: []*State{
+		{Name: RequestDraft, Label: "下書き"},
+		{Name: RequestPending, Label: "回答待ち"},
+		{Name: RequestConditional, Label: "条件付き受け入れ"},
+		{Name: RequestWaitlisted, Label: "待機中"},
+		{Name: RequestAccepted, Label: "受け入れ"},
+		{Name: RequestRejected, Label: "お断り"},
+		{Name: RequestWithdrawn, Label: "取り下げ", Terminal: true},
+	},
+	Transitions: []*Transition{
+		{
+			Action: ActionSubmit, Label: "施設に提出",
+			From: []string{RequestDraft}, To: RequestPending, Roles: hospitalOnly,
+			Guards:  []*Guard{guardRequestComplete},
+			Effects: []*Effect{effectDeliverRequest},
+		},
+		{
+			Action: ActionAccept, Label: "受け入れる",
+			From: []string{RequestPending}, To: RequestAccepted, Roles: facilityOnly,
+			Effects: []*Effect{effectCreateRoom, effectCarryForwardedFiles, effectMarkRequestRead},
+		},
+		{
+			Action: ActionReject, Label: "お断りする",
+			From: []string{RequestPending}, To: RequestRejected, Roles: facilityOnly,
+			Effects: []*Effect{effectMarkRequestRead},
+		},
+		{
+			Action: ActionAcceptConditional, Label: "条件付きで受け入れる",
+			From: []string{RequestPending}, To: RequestConditional, Roles: facilityOnly,
+			Effects: []*Effect{effectRecordConditions, effectMarkRequestRead},
+		},
+		{
+			Action: ActionAcceptConditions, Label: "条件を承諾する",
+			From: []string{RequestConditional}, To: RequestAccepted, Roles: hospitalOnly,
+			Effects: []*Effect{effectRespondToConditions, effectCreateRoom, effectCarryForwardedFiles, effectPostConditionsMessage, effectMarkRequestRead},
+		},
+		{
+			Action: ActionDeclineConditions, Label: "条件を断る",
+			From: []string{RequestConditional}, To: RequestRejected, Roles: hospitalOnly,
+			Effects: []*Effect{effectRespondToConditions, effectMarkRequestRead},
+		},
+		{
+			Action: ActionWaitlist, Label: "待機リストに登録",
+			From: []string{RequestPending, RequestRejected}, To: RequestWaitlisted, Roles: hospitalOnly,
+		},
+		{
+			Action: ActionConvert, Label: "待機から受け入れる",
+			From: []string{RequestWaitlisted}, To: RequestAccepted, Roles: facilityOnly,
+			Effects: []*Effect{effectCreateRoom, effectCarryForwardedFiles, effectMarkRequestRead},
+		},
+		{
+			Action: ActionRemove, Label: "待機リストから外す",
+			From: []string{RequestWaitlisted}, To: RequestRejected, Roles: bothParties,
+		},
+		{
+			// Accepted requests can be withdrawn while their room is still open
+			Action: ActionWithdraw, Label: "取り下げる",
+			From: []string{RequestDraft, RequestPending, RequestConditional, RequestWaitlisted, RequestRejected, RequestAccepted},
+			To:   RequestWithdrawn, Roles: hospitalOnly,
+			Guards:  []*Guard{guardRoomOpen},
+			Effects: []*Effect{effectWithdrawRoom, effectLeaveWaitlist, effectWithdrawConditions, effectNotifyWithdrawal},
+		},
+	},
+	Operations: []*Operation{
+		{Name: OpUpdate, Label: "編集", States: []string{RequestDraft, RequestPending}, Roles: hospitalOnly},
+		{Name: OpForward, Label: "別の施設に転送", States: []string{RequestPending, RequestConditional, RequestWaitlisted, RequestAccepted, RequestRejected, RequestWithdrawn}, Roles: hospitalOnly},
+	},
+}
+
+// MessageRooms is the workflow of the room in which a hospital and a facility arrange
+// an accepted placement. Completed and rejected rooms are closed but can be reopened
+// when the other party confirms a reopen request, or by an admin.
+var MessageRooms = &Machine{
+	Name:    "message_room",
+	Initial: RoomNegotiating,
+	States: []*State{
+		{Name: RoomNegotiating, Label: "検討中"},
+		{Name: RoomAccepted, Label: "受け入れ承認"},
+		{Name: RoomCompleted, Label: "受け入れ完了", Closed: true},
+		{Name: RoomRejected, Label: "受け入れ不可", Closed: true},
+		{Name: RoomWithdrawn, Label: "取り下げ", Closed: true, Terminal: true},
+	},
+	Transitions: []*Transition{
+		{
+			Action: ActionAccept, Label: "受け入れを承認",
+			From: []string{RoomNegotiating}, To: RoomAccepted, Roles: facilityOnly,
+			Effects: []*Effect{effectHoldBed, effectCreateChecklist},
+		},
+		{
+			Action: ActionReject, Label: "受け入れ不可",
+			From: []string{RoomNegotiating}, To: RoomRejected, Roles: facilityOnly,
+			Effects: []*Effect{effectReleaseBedHold},
+		},
+		{
+			Action: ActionComplete, Label: "完了",
+			From: []string{RoomAccepted}, To: RoomCompleted, Roles: bothParties,
+			Guards:  []*Guard{guardChecklistDone, guardBothCompleted},
+			Effects: []*Effect{effectConvertBedHold},
+		},
+		{
+			Action: ActionWithdraw, Label: "取り下げる",
+			From: []string{RoomNegotiating, RoomAccepted}, To: RoomWithdrawn, Roles: hospitalOnly,
+			Effects: []*Effect{effectReleaseBedHold, effectPostWithdrawalMessage},
+		},
+		{
+			// e.g. to exchange paperwork after admission
+			Action: ActionReopen, Label: "再開する",
+			From: []string{RoomCompleted}, To: RoomAccepted, Roles: partiesOrAdmin,
+			Guards:  []*Guard{guardReopenConfirmed},
+			Effects: []*Effect{effectResetCompletion, effectPostReopenMessage},
+		},
+		{
+			// e.g. when a rejection is reversed after a phone call
+			Action: ActionReopen, Label: "再開する",
+			From: []string{RoomRejected}, To: RoomNegotiating, Roles: partiesOrAdmin,
+			Guards:  []*Guard{guardReopenConfirmed},
+			Effects: []*Effect{effectResetCompletion, effectPostReopenMessage},
+		},
+	},
+	Operations: []*Operation{
+		{Name: OpSendMessage, Label: "メッセージ送信", States: []string{RoomNegotiating, RoomAccepted, RoomCompleted}, Roles: bothParties},
+		{Name: OpUploadFile, Label: "ファイル添付", States: []string{RoomNegotiating, RoomAccepted}, Roles: bothParties},
+		{Name: OpSchedule, Label: "日程調整", States: []string{RoomNegotiating, RoomAccepted}, Roles: bothParties},
+		{Name: OpEditChecklist, Label: "チェックリストを更新", States: []string{RoomAccepted}, Roles: bothParties},
+		{Name: OpMarkComplete, Label: "完了にする", States: []string{RoomAccepted}, Roles: bothParties},
+		{Name: OpCancelCompletion, Label: "完了を取り消す", States: []string{RoomAccepted}, Roles: bothParties},
+		{Name: OpHoldBed, Label: "ベッドを仮押さえ", States: []string{RoomAccepted}, Roles: facilityOnly},
+		{Name: OpReleaseBed, Label: "仮押さえを解除", States: []string{RoomAccepted}, Roles: facilityOnly},
+		{Name: OpRate, Label: "施設を評価", States: []string{RoomCompleted}, Roles: hospitalOnly},
+		{Name: OpRequestReopen, Label: "再開を依頼", States: []string{RoomCompleted, RoomRejected}, Roles: bothParties},
+	},
+}
+
+// Machines are all workflows, as served by GET /api/workflow
+var Machines = []*Machine{PlacementRequests, MessageRooms}
+
+func init() {
+	for _, m := range Machines {
+		for _, t := range m.Transitions {
+			t.machine = m.Name
+		}
+	}
+}
@@ -0,0 +1,322 @@
+// Package workflow declares the statuses placement requests and message rooms move
+// through: which transitions exist, which role may trigger each one, the guards that
+// must hold and the side effects that follow. Handlers check every status change
+// against it instead of comparing status strings themselves.
+package workflow
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/social-worker-platform/backend/models"
+)
+
+var (
+	ErrUnknownAction     = errors.New("unknown workflow action")
+	ErrRoleNotAllowed    = errors.New("role may not perform this action")
+	ErrInvalidState      = errors.New("action is not allowed in the current status")
+	ErrGuardNotSatisfied = errors.New("transition guard is not satisfied")
+)
+
+// State is one status of a machine. Work on a record in a closed state is finished;
+// a closed state that is not terminal can still be left by reopening the record.
+type State struct {
+	Name     string `json:"name"`
+	Label    string `json:"label"`
+	Closed   bool   `json:"closed"`
+	Terminal bool   `json:"terminal"`
+}
+
+// Subject carries what guards and effects need to know about the record being moved.
+// RequestID is set for rooms too, since their history is kept with the request.
+type Subject struct {
+	UserID            int
+	RequestID         int
+	RoomID            string
+	HospitalCompleted bool
+	FacilityCompleted bool
+	// RoomClosed is set for a request whose message room is in a closed status
+	RoomClosed bool
+	// ReopenConfirmed is set when the other party agreed to reopen a closed room
+	ReopenConfirmed bool
+	// AdminOverride is set when an admin acts without the parties' agreement
+	AdminOverride bool
+	// ChecklistIncomplete is set when completion waits for required handoff checklist items
+	ChecklistIncomplete bool
+	// RequestComplete is set when a draft has everything a facility needs to answer it
+	RequestComplete bool
+	// Reason is recorded in the status history, e.g. why a request was declined
+	Reason string
+
+	// Set by Fire for recording the transition
+	from string
+	role string
+}
+
+// From returns the status Fire moved the subject from, which the caller saves the new
+// status against
+func (s *Subject) From() string {
+	return s.from
+}
+
+// Guard is a condition that must hold for a transition to fire
+type Guard struct {
+	Name        string              `json:"name"`
+	Description string              `json:"description"`
+	Check       func(*Subject) bool `json:"-"`
+}
+
+// Effect is work that follows a transition. Effects without Run are carried out
+// together with saving the new status and are declared here so clients know they
+// happen. Required effects run in the transaction that saves the new status, which is
+// rolled back if one fails; the others run once it is committed and may fail on their own.
+type Effect struct {
+	Name        string                               `json:"name"`
+	Description string                               `json:"description"`
+	Required    bool                                 `json:"required"`
+	Run         func(models.Querier, *Subject) error `json:"-"`
+}
+
+// Transition moves a record from one of From to To when a permitted role performs Action
+type Transition struct {
+	Action  string    `json:"action"`
+	Label   string    `json:"label"`
+	From    []string  `json:"from"`
+	To      string    `json:"to"`
+	Roles   []string  `json:"roles"`
+	Guards  []*Guard  `json:"guards"`
+	Effects []*Effect `json:"effects"`
+
+	machine string
+}
+
+// Operation is an action that does not change the status but is only available in
+// some statuses, such as sending a message
+type Operation struct {
+	Name   string   `json:"name"`
+	Label  string   `json:"label"`
+	States []string `json:"states"`
+	Roles  []string `json:"roles"`
+}
+
+// Machine is the workflow of one kind of record
+type Machine struct {
+	Name        string        `json:"name"`
+	Initial     string        `json:"initial"`
+	States      []*State      `json:"states"`
+	Transitions []*Transition `json:"transitions"`
+	Operations  []*Operation  `json:"operations"`
+}
+
+// Error describes why an action was refused
+type Error struct {
+	Machine string
+	Action  string
+	State   string
+	Guard   string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	switch e.Err {
+	case ErrRoleNotAllowed:
+		return fmt.Sprintf("%s: role may not %s", e.Machine, e.Action)
+	case ErrInvalidState:
+		return fmt.Sprintf("%s: cannot %s in status %s", e.Machine, e.Action, e.State)
+	case ErrGuardNotSatisfied:
+		return fmt.Sprintf("%s: cannot %s until %s", e.Machine, e.Action, e.Guard)
+	default:
+		return fmt.Sprintf("%s: %s: %v", e.Machine, e.Action, e.Err)
+	}
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// State returns the named state, or nil
+func (m *Machine) State(name string) *State {
+	for _, s := range m.States {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// transition returns the transition for action that starts from state, or if none
+// does, any transition for action
+func (m *Machine) transition(action, state string) *Transition {
+	var found *Transition
+	for _, t := range m.Transitions {
+		if t.Action != action {
+			continue
+		}
+		if contains(t.From, state) {
+			return t
+		}
+		if found == nil {
+			found = t
+		}
+	}
+	return found
+}
+
+// CanTrigger reports whether role may perform action in some status. Handlers call it
+// before loading the record so role errors come first.
+func (m *Machine) CanTrigger(action, role string) error {
+	for _, t := range m.Transitions {
+		if t.Action == action && contains(t.Roles, role) {
+			return nil
+		}
+	}
+	for _, op := range m.Operations {
+		if op.Name == action && contains(op.Roles, role) {
+			return nil
+		}
+	}
+	if m.transition(action, "") == nil && m.operation(action) == nil {
+		return &Error{Machine: m.Name, Action: action, Err: ErrUnknownAction}
+	}
+	return &Error{Machine: m.Name, Action: action, Err: ErrRoleNotAllowed}
+}
+
+// Fire returns the transition role may take from state by performing action, after
+// checking its guards against subject. The caller saves the new status and applies the
+// transition in one transaction, then runs the transition's other effects.
+func (m *Machine) Fire(state, action, role string, subject *Subject) (*Transition, error) {
+	t := m.transition(action, state)
+	if t == nil {
+		return nil, &Error{Machine: m.Name, Action: action, State: state, Err: ErrUnknownAction}
+	}
+	if !contains(t.Roles, role) {
+		return nil, &Error{Machine: m.Name, Action: action, State: state, Err: ErrRoleNotAllowed}
+	}
+	if !contains(t.From, state) {
+		return nil, &Error{Machine: m.Name, Action: action, State: state, Err: ErrInvalidState}
+	}
+	for _, g := range t.Guards {
+		if g.Check != nil && !g.Check(subject) {
+			return nil, &Error{Machine: m.Name, Action: action, State: state, Guard: g.Name, Err: ErrGuardNotSatisfied}
+		}
+	}
+	if subject != nil {
+		subject.from, subject.role = state, role
+	}
+	return t, nil
+}
+
+func (m *Machine) operation(name string) *Operation {
+	for _, op := range m.Operations {
+		if op.Name == name {
+			return op
+		}
+	}
+	return nil
+}
+
+// Allow checks that role may perform a status-preserving operation in state
+func (m *Machine) Allow(state, operation, role string) error {
+	op := m.operation(operation)
+	if op == nil {
+		return &Error{Machine: m.Name, Action: operation, State: state, Err: ErrUnknownAction}
+	}
+	if !contains(op.Roles, role) {
+		return &Error{Machine: m.Name, Action: operation, State: state, Err: ErrRoleNotAllowed}
+	}
+	if !contains(op.States, state) {
+		return &Error{Machine: m.Name, Action: operation, State: state, Err: ErrInvalidState}
+	}
+	return nil
+}
+
+// Available lists the transitions and operations role may perform in state, ignoring guards
+func (m *Machine) Available(state, role string) []string {
+	actions := []string{}
+	for _, t := range m.Transitions {
+		if contains(t.From, state) && contains(t.Roles, role) && !contains(actions, t.Action) {
+			actions = append(actions, t.Action)
+		}
+	}
+	for _, op := range m.Operations {
+		if contains(op.States, state) && contains(op.Roles, role) {
+			actions = append(actions, op.Name)
+		}
+	}
+	return actions
+}
+
+// Apply records t in the status history and runs its required effects in tx, the
+// transaction that saves the new status. It stops at the first failure, after which the
+// caller rolls tx back so the status is not changed either.
+func (t *Transition) Apply(tx *sql.Tx, subject *Subject) error {
+	from := subject.from
+	if err := record(tx, t.machine, &from, t.To, t.Action, subject.role, subject); err != nil {
+		return fmt.Errorf("history: %w", err)
+	}
+	for _, e := range t.Effects {
+		if e.Run == nil || !e.Required {
+			continue
+		}
+		if err := e.Run(tx, subject); err != nil {
+			return fmt.Errorf("%s: %w", e.Name, err)
+		}
+	}
+	return nil
+}
+
+// RunEffects runs the effects of t that are not required, once the transaction that
+// applied t is committed. Every effect is attempted; the errors of those that failed are
+// returned together.
+func (t *Transition) RunEffects(db *sql.DB, subject *Subject) error {
+	var errs []error
+	for _, e := range t.Effects {
+		if e.Run == nil || e.Required {
+			continue
+		}
+		if err := e.Run(db, subject); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", e.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// RecordCreated records in the status history that a record was created in status by a
+// user with role, in the transaction that creates it. Records usually start in
+// m.Initial, but a request submitted as it is created starts where submitting leads.
+func RecordCreated(tx *sql.Tx, m *Machine, status, role string, subject *Subject) error {
+	return record(tx, m.Name, nil, status, "", role, subject)
+}
+
+func record(db models.Querier, machine string, from *string, to, action, role string, subject *Subject) error {
+	entry := &models.StatusTransition{
+		Entity:     machine,
+		RequestID:  subject.RequestID,
+		FromStatus: from,
+		ToStatus:   to,
+	}
+	if machine == MessageRooms.Name {
+		entry.RoomID = &subject.RoomID
+	}
+	if action != "" {
+		entry.Action = &action
+	}
+	if subject.UserID != 0 {
+		entry.ActorID = &subject.UserID
+	}
+	if role != "" {
+		entry.ActorRole = &role
+	}
+	if subject.Reason != "" {
+		entry.Reason = &subject.Reason
+	}
+	return models.RecordStatusTransition(db, entry)
+}
@@ -0,0 +1,149 @@
+package workflow
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/leanovate/gopter"
+	"github.com/leanovate/gopter/gen"
+	"github.com/leanovate/gopter/prop"
+)
+
+// step is one attempted action in a random walk through a machine
+type step struct {
+	Action            string
+	Role              string
+	HospitalCompleted bool
+	FacilityCompleted bool
+	RequestComplete   bool
+	ReopenConfirmed   bool
+}
+
+func allActions(m *Machine) []interface{} {
+	actions := []interface{}{"bogus"}
+	for _, t := range m.Transitions {
+		actions = append(actions, t.Action)
+	}
+	return actions
+}
+
+func genSteps(m *Machine) gopter.Gen {
+	return gen.SliceOf(gopter.CombineGens(
+		gen.OneConstOf(allActions(m)...),
+		gen.OneConstOf("hospital", "facility", "admin", ""),
+		gen.Bool(),
+		gen.Bool(),
+		gen.Bool(),
+		gen.Bool(),
+	).Map(func(v []interface{}) step {
+		return step{Action: v[0].(string), Role: v[1].(string), HospitalCompleted: v[2].(bool), FacilityCompleted: v[3].(bool), RequestComplete: v[4].(bool), ReopenConfirmed: v[5].(bool)}
+	}))
+}
+
+// declared reports whether a transition for action from state to "to" that role may
+// take is declared in m
+func declared(m *Machine, state, action, role, to string) bool {
+	for _, t := range m.Transitions {
+		if t.Action == action && t.To == to && contains(t.From, state) && contains(t.Roles, role) {
+			return true
+		}
+	}
+	return false
+}
+
+// Feature: social-worker-platform, Property: Workflow transitions
+// For any sequence of actions by any role, a record only ever moves along declared
+// transitions, never leaves a terminal status, and refused actions leave it unchanged
+func TestProperty_NoIllegalTransitionReachable(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping property test in short mode")
+	}
+
+	parameters := gopter.DefaultTestParameters()
+	parameters.MinSuccessfulTests = 500
+	properties := gopter.NewProperties(parameters)
+
+	for _, m := range Machines {
+		m := m
+		properties.Property(m.Name+": every reached status is reached legally", prop.ForAll(
+			func(steps []step) bool {
+				state := m.Initial
+				for _, s := range steps {
+					subject := &Subject{HospitalCompleted: s.HospitalCompleted, FacilityCompleted: s.FacilityCompleted, RequestComplete: s.RequestComplete, ReopenConfirmed: s.ReopenConfirmed}
+					tr, err := m.Fire(state, s.Action, s.Role, subject)
+					if err != nil {
+						var wfErr *Error
+						if !errors.As(err, &wfErr) {
+							return false
+						}
+						continue
+					}
+					if m.State(state).Terminal {
+						return false
+					}
+					if !declared(m, state, s.Action, s.Role, tr.To) || m.State(tr.To) == nil {
+						return false
+					}
+					for _, g := range tr.Guards {
+						if !g.Check(subject) {
+							return false
+						}
+					}
+					state = tr.To
+				}
+				return true
+			},
+			genSteps(m),
+		))
+
+		properties.Property(m.Name+": roles outside the parties never change a status without an override", prop.ForAll(
+			func(steps []step) bool {
+				for _, st := range m.States {
+					for _, s := range steps {
+						if s.Role == "hospital" || s.Role == "facility" {
+							continue
+						}
+						_, err := m.Fire(st.Name, s.Action, s.Role, &Subject{})
+						if err == nil {
+							return false
+						}
+						if errors.Is(err, ErrRoleNotAllowed) || errors.Is(err, ErrUnknownAction) {
+							continue
+						}
+						// Only transitions naming the role explicitly, such as an admin
+						// reopening a room, get as far as their state and guards
+						if tr := m.transition(s.Action, st.Name); tr == nil || !contains(tr.Roles, s.Role) {
+							return false
+						}
+					}
+				}
+				return true
+			},
+			genSteps(m),
+		))
+
+		properties.Property(m.Name+": Available matches what Fire and Allow accept", prop.ForAll(
+			func(stateIndex int, role string) bool {
+				state := m.States[stateIndex%len(m.States)].Name
+				available := m.Available(state, role)
+				for _, t := range m.Transitions {
+					// Guards are ignored by Available, so satisfy them
+					_, err := m.Fire(state, t.Action, role, &Subject{HospitalCompleted: true, FacilityCompleted: true, RequestComplete: true, ReopenConfirmed: true})
+					if (err == nil) != contains(available, t.Action) {
+						return false
+					}
+				}
+				for _, op := range m.Operations {
+					if (m.Allow(state, op.Name, role) == nil) != contains(available, op.Name) {
+						return false
+					}
+				}
+				return true
+			},
+			gen.IntRange(0, 100),
+			gen.OneConstOf("hospital", "facility", "admin"),
+		))
+	}
+
+	properties.TestingRun(t)
+}
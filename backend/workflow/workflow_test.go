@@ -0,0 +1,286 @@
+package workflow
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/social-worker-platform/backend/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMachinesAreWellFormed(t *testing.T) {
+	for _, m := range Machines {
+		require.NotNil(t, m.State(m.Initial), "%s: initial state", m.Name)
+
+		reachable := map[string]bool{m.Initial: true}
+		for changed := true; changed; {
+			changed = false
+			for _, tr := range m.Transitions {
+				for _, from := range tr.From {
+					require.NotNil(t, m.State(from), "%s %s: from %s", m.Name, tr.Action, from)
+					assert.False(t, m.State(from).Terminal, "%s %s: leaves terminal %s", m.Name, tr.Action, from)
+					if reachable[from] && !reachable[tr.To] {
+						reachable[tr.To] = true
+						changed = true
+					}
+				}
+				require.NotNil(t, m.State(tr.To), "%s %s: to %s", m.Name, tr.Action, tr.To)
+				assert.NotEmpty(t, tr.Roles, "%s %s: roles", m.Name, tr.Action)
+			}
+		}
+		for _, s := range m.States {
+			assert.True(t, reachable[s.Name], "%s: %s is unreachable", m.Name, s.Name)
+		}
+
+		for _, op := range m.Operations {
+			for _, s := range op.States {
+				assert.NotNil(t, m.State(s), "%s %s: state %s", m.Name, op.Name, s)
+			}
+		}
+	}
+}
+
+func TestPlacementRequestTransitions(t *testing.T) {
+	tr, err := PlacementRequests.Fire(RequestPending, ActionAccept, "facility", &Subject{})
+	require.NoError(t, err)
+	assert.Equal(t, RequestAccepted, tr.To)
+
+	_, err = PlacementRequests.Fire(RequestPending, ActionAccept, "hospital", &Subject{})
+	assert.True(t, errors.Is(err, ErrRoleNotAllowed))
+
+	_, err = PlacementRequests.Fire(RequestAccepted, ActionReject, "facility", &Subject{})
+	assert.True(t, errors.Is(err, ErrInvalidState))
+
+	tr, err = PlacementRequests.Fire(RequestRejected, ActionWaitlist, "hospital", &Subject{})
+	require.NoError(t, err)
+	assert.Equal(t, RequestWaitlisted, tr.To)
+
+	assert.NoError(t, PlacementRequests.CanTrigger(ActionRemove, "hospital"))
+	assert.True(t, errors.Is(PlacementRequests.CanTrigger(ActionConvert, "hospital"), ErrRoleNotAllowed))
+	assert.True(t, errors.Is(PlacementRequests.CanTrigger("fly", "hospital"), ErrUnknownAction))
+
+	assert.NoError(t, PlacementRequests.Allow(RequestPending, OpUpdate, "hospital"))
+	assert.True(t, errors.Is(PlacementRequests.Allow(RequestAccepted, OpUpdate, "hospital"), ErrInvalidState))
+}
+
+func TestMessageRoomCompletionGuard(t *testing.T) {
+	_, err := MessageRooms.Fire(RoomAccepted, ActionComplete, "hospital", &Subject{HospitalCompleted: true})
+	assert.True(t, errors.Is(err, ErrGuardNotSatisfied))
+	assert.Contains(t, err.Error(), "both_parties_completed")
+	var refused *Error
+	require.True(t, errors.As(err, &refused))
+	assert.Equal(t, GuardBothCompleted, refused.Guard)
+
+	tr, err := MessageRooms.Fire(RoomAccepted, ActionComplete, "facility", &Subject{HospitalCompleted: true, FacilityCompleted: true})
+	require.NoError(t, err)
+	assert.Equal(t, RoomCompleted, tr.To)
+
+	assert.ElementsMatch(t, []string{ActionReopen, OpSendMessage, OpRate, OpRequestReopen}, MessageRooms.Available(RoomCompleted, "hospital"))
+	assert.ElementsMatch(t, []string{ActionReopen, OpSendMessage, OpRequestReopen}, MessageRooms.Available(RoomCompleted, "facility"))
+	assert.ElementsMatch(t, []string{ActionReopen, OpRequestReopen}, MessageRooms.Available(RoomRejected, "facility"))
+}
+
+func TestRoomReopen(t *testing.T) {
+	_, err := MessageRooms.Fire(RoomCompleted, ActionReopen, "hospital", &Subject{})
+	assert.True(t, errors.Is(err, ErrGuardNotSatisfied))
+	assert.Contains(t, err.Error(), "reopen_confirmed")
+
+	tr, err := MessageRooms.Fire(RoomCompleted, ActionReopen, "facility", &Subject{ReopenConfirmed: true})
+	require.NoError(t, err)
+	assert.Equal(t, RoomAccepted, tr.To)
+
+	tr, err = MessageRooms.Fire(RoomRejected, ActionReopen, "hospital", &Subject{ReopenConfirmed: true})
+	require.NoError(t, err)
+	assert.Equal(t, RoomNegotiating, tr.To)
+
+	// Admins reopen without the parties, but only with the override set
+	_, err = MessageRooms.Fire(RoomRejected, ActionReopen, "admin", &Subject{})
+	assert.True(t, errors.Is(err, ErrGuardNotSatisfied))
+	tr, err = MessageRooms.Fire(RoomRejected, ActionReopen, "admin", &Subject{AdminOverride: true})
+	require.NoError(t, err)
+	assert.Equal(t, RoomNegotiating, tr.To)
+	assert.True(t, errors.Is(MessageRooms.Allow(RoomCompleted, OpRequestReopen, "admin"), ErrRoleNotAllowed))
+
+	_, err = MessageRooms.Fire(RoomWithdrawn, ActionReopen, "admin", &Subject{AdminOverride: true})
+	assert.True(t, errors.Is(err, ErrInvalidState))
+	assert.True(t, errors.Is(MessageRooms.Allow(RoomAccepted, OpRequestReopen, "hospital"), ErrInvalidState))
+
+	assert.True(t, MessageRooms.State(RoomCompleted).Closed)
+	assert.False(t, MessageRooms.State(RoomCompleted).Terminal)
+	assert.True(t, MessageRooms.State(RoomWithdrawn).Terminal)
+}
+
+func TestFireKeepsWhatHistoryRecords(t *testing.T) {
+	for _, m := range Machines {
+		for _, tr := range m.Transitions {
+			assert.Equal(t, m.Name, tr.machine, "%s %s: machine", m.Name, tr.Action)
+		}
+	}
+
+	subject := &Subject{}
+	_, err := PlacementRequests.Fire(RequestRejected, ActionWaitlist, "hospital", subject)
+	require.NoError(t, err)
+	assert.Equal(t, RequestRejected, subject.from)
+	assert.Equal(t, "hospital", subject.role)
+
+	refused := &Subject{}
+	_, err = PlacementRequests.Fire(RequestAccepted, ActionWaitlist, "hospital", refused)
+	require.Error(t, err)
+	assert.Empty(t, refused.from)
+}
+
+func TestRunEffectsLeavesRequiredEffectsToApply(t *testing.T) {
+	var ran []string
+	effect := func(name string, required bool) *Effect {
+		return &Effect{Name: name, Required: required, Run: func(models.Querier, *Subject) error {
+			ran = append(ran, name)
+			return errors.New(name + " failed")
+		}}
+	}
+	tr := &Transition{Effects: []*Effect{effect("required", true), effect("first", false), {Name: "declared"}, effect("second", false)}}
+
+	err := tr.RunEffects(nil, &Subject{})
+	assert.Equal(t, []string{"first", "second"}, ran, "every effect that is not required is attempted")
+	assert.ErrorContains(t, err, "first failed")
+	assert.ErrorContains(t, err, "second failed")
+
+	// Rooms are opened in the transaction that accepts the request
+	for _, tr := range []*Transition{
+		PlacementRequests.transition(ActionAccept, RequestPending),
+		PlacementRequests.transition(ActionConvert, RequestWaitlisted),
+		PlacementRequests.transition(ActionAcceptConditions, RequestConditional),
+	} {
+		assert.Contains(t, tr.Effects, effectCreateRoom, tr.Action)
+		// Forwarded files are attached to the room in the same transaction
+		assert.Equal(t, effectCarryForwardedFiles, tr.Effects[indexOf(tr.Effects, effectCreateRoom)+1], tr.Action)
+	}
+	assert.True(t, effectCreateRoom.Required)
+	assert.True(t, effectWithdrawConditions.Required)
+	assert.True(t, effectReleaseBedHold.Required)
+	assert.True(t, effectConvertBedHold.Required)
+	assert.True(t, effectCreateChecklist.Required)
+	assert.True(t, effectCarryForwardedFiles.Required)
+	assert.False(t, effectNotifyWithdrawal.Required)
+}
+
+func TestDraftSubmission(t *testing.T) {
+	assert.Equal(t, RequestDraft, PlacementRequests.Initial)
+
+	_, err := PlacementRequests.Fire(RequestDraft, ActionSubmit, "hospital", &Subject{})
+	assert.True(t, errors.Is(err, ErrGuardNotSatisfied))
+	assert.Contains(t, err.Error(), "request_complete")
+
+	tr, err := PlacementRequests.Fire(RequestDraft, ActionSubmit, "hospital", &Subject{RequestComplete: true})
+	require.NoError(t, err)
+	assert.Equal(t, RequestPending, tr.To)
+
+	_, err = PlacementRequests.Fire(RequestDraft, ActionSubmit, "facility", &Subject{RequestComplete: true})
+	assert.True(t, errors.Is(err, ErrRoleNotAllowed))
+	_, err = PlacementRequests.Fire(RequestDraft, ActionAccept, "facility", &Subject{})
+	assert.True(t, errors.Is(err, ErrInvalidState))
+
+	assert.ElementsMatch(t, []string{ActionSubmit, ActionWithdraw, OpUpdate}, PlacementRequests.Available(RequestDraft, "hospital"))
+	assert.Empty(t, PlacementRequests.Available(RequestDraft, "facility"))
+}
+
+func TestWithdrawal(t *testing.T) {
+	for _, state := range []string{RequestDraft, RequestPending, RequestWaitlisted, RequestAccepted} {
+		tr, err := PlacementRequests.Fire(state, ActionWithdraw, "hospital", &Subject{})
+		require.NoError(t, err, state)
+		assert.Equal(t, RequestWithdrawn, tr.To)
+	}
+
+	_, err := PlacementRequests.Fire(RequestPending, ActionWithdraw, "facility", &Subject{})
+	assert.True(t, errors.Is(err, ErrRoleNotAllowed))
+	_, err = PlacementRequests.Fire(RequestWithdrawn, ActionWithdraw, "hospital", &Subject{})
+	assert.True(t, errors.Is(err, ErrInvalidState))
+
+	// Once the room is completed or rejected the placement can no longer be withdrawn
+	_, err = PlacementRequests.Fire(RequestAccepted, ActionWithdraw, "hospital", &Subject{RoomClosed: true})
+	assert.True(t, errors.Is(err, ErrGuardNotSatisfied))
+
+	for _, state := range []string{RoomNegotiating, RoomAccepted} {
+		tr, err := MessageRooms.Fire(state, ActionWithdraw, "hospital", &Subject{})
+		require.NoError(t, err, state)
+		assert.Equal(t, RoomWithdrawn, tr.To)
+	}
+	_, err = MessageRooms.Fire(RoomCompleted, ActionWithdraw, "hospital", &Subject{})
+	assert.True(t, errors.Is(err, ErrInvalidState))
+	assert.Empty(t, MessageRooms.Available(RoomWithdrawn, "hospital"))
+}
+
+func TestForwarding(t *testing.T) {
+	for _, state := range []string{RequestPending, RequestWaitlisted, RequestAccepted, RequestRejected, RequestWithdrawn} {
+		require.NoError(t, PlacementRequests.Allow(state, OpForward, "hospital"), state)
+	}
+	assert.True(t, errors.Is(PlacementRequests.Allow(RequestRejected, OpForward, "facility"), ErrRoleNotAllowed))
+	assert.True(t, errors.Is(PlacementRequests.Allow(RequestDraft, OpForward, "hospital"), ErrInvalidState))
+
+	// Files carried over to a forwarded request move into its room once one is opened
+	for action, from := range map[string]string{ActionAccept: RequestPending, ActionConvert: RequestWaitlisted} {
+		var names []string
+		for _, e := range PlacementRequests.transition(action, from).Effects {
+			names = append(names, e.Name)
+		}
+		assert.Contains(t, names, "carry_forwarded_files", action)
+	}
+}
+
+func TestConditionalAcceptance(t *testing.T) {
+	tr, err := PlacementRequests.Fire(RequestPending, ActionAcceptConditional, "facility", &Subject{})
+	require.NoError(t, err)
+	assert.Equal(t, RequestConditional, tr.To)
+	_, err = PlacementRequests.Fire(RequestPending, ActionAcceptConditional, "hospital", &Subject{})
+	assert.True(t, errors.Is(err, ErrRoleNotAllowed))
+	_, err = PlacementRequests.Fire(RequestWaitlisted, ActionAcceptConditional, "facility", &Subject{})
+	assert.True(t, errors.Is(err, ErrInvalidState))
+
+	// Only the hospital answers the conditions
+	tr, err = PlacementRequests.Fire(RequestConditional, ActionAcceptConditions, "hospital", &Subject{})
+	require.NoError(t, err)
+	assert.Equal(t, RequestAccepted, tr.To)
+	tr, err = PlacementRequests.Fire(RequestConditional, ActionDeclineConditions, "hospital", &Subject{})
+	require.NoError(t, err)
+	assert.Equal(t, RequestRejected, tr.To)
+	_, err = PlacementRequests.Fire(RequestConditional, ActionAcceptConditions, "facility", &Subject{})
+	assert.True(t, errors.Is(err, ErrRoleNotAllowed))
+	_, err = PlacementRequests.Fire(RequestConditional, ActionAccept, "facility", &Subject{})
+	assert.True(t, errors.Is(err, ErrInvalidState))
+
+	assert.ElementsMatch(t, []string{ActionAcceptConditions, ActionDeclineConditions, ActionWithdraw, OpForward},
+		PlacementRequests.Available(RequestConditional, "hospital"))
+	assert.Empty(t, PlacementRequests.Available(RequestConditional, "facility"))
+}
+
+func TestHandoffChecklistGuard(t *testing.T) {
+	_, err := MessageRooms.Fire(RoomAccepted, ActionComplete, "hospital", &Subject{HospitalCompleted: true, FacilityCompleted: true, ChecklistIncomplete: true})
+	assert.True(t, errors.Is(err, ErrGuardNotSatisfied))
+	assert.Contains(t, err.Error(), "checklist_done")
+
+	// The first party to mark the room is refused too, before it waits for the other
+	_, err = MessageRooms.Fire(RoomAccepted, ActionComplete, "hospital", &Subject{HospitalCompleted: true, ChecklistIncomplete: true})
+	var refused *Error
+	require.True(t, errors.As(err, &refused))
+	assert.Equal(t, GuardChecklistDone, refused.Guard)
+
+	tr, err := MessageRooms.Fire(RoomNegotiating, ActionAccept, "facility", &Subject{})
+	require.NoError(t, err)
+	names := []string{}
+	for _, e := range tr.Effects {
+		names = append(names, e.Name)
+	}
+	assert.Contains(t, names, "create_checklist")
+
+	assert.NoError(t, MessageRooms.Allow(RoomAccepted, OpEditChecklist, "facility"))
+	assert.True(t, errors.Is(MessageRooms.Allow(RoomCompleted, OpEditChecklist, "hospital"), ErrInvalidState))
+}
+
+func indexOf(effects []*Effect, effect *Effect) int {
+	for i, e := range effects {
+		if e == effect {
+			return i
+		}
+	}
+	return -1
+}
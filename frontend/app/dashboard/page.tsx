@@ -117,6 +117,7 @@ interface FacilityDashboardProps {
 function FacilityDashboard({ router }: FacilityDashboardProps) {
   const [facility, setFacility] = useState<Facility | null>(null);
   const [roomTypes, setRoomTypes] = useState<FacilityRoomTypeInput[]>([]);
+  const [roomTypesVersion, setRoomTypesVersion] = useState("");
   const [originalRoomTypes, setOriginalRoomTypes] = useState<FacilityRoomTypeInput[]>([]);
   const [saving, setSaving] = useState(false);
   const [success, setSuccess] = useState("");
@@ -147,10 +148,12 @@ function FacilityDashboard({ router }: FacilityDashboardProps) {
           description: rt.description,
         }));
         setRoomTypes(loadedRoomTypes);
+        setRoomTypesVersion(String(roomTypesResponse.headers.etag ?? ""));
         setOriginalRoomTypes(loadedRoomTypes);
       } catch {
         setRoomTypes([]);
         setOriginalRoomTypes([]);
+        setRoomTypesVersion("");
       } finally {
         setLoadingRoomTypes(false);
       }
@@ -167,7 +170,8 @@ function FacilityDashboard({ router }: FacilityDashboardProps) {
     setSuccess("");
 
     try {
-      await facilityAPI.updateRoomTypes(facility.id, roomTypes);
+      const updated = await facilityAPI.updateRoomTypes(facility.id, roomTypes, roomTypesVersion);
+      setRoomTypesVersion(String(updated.headers.etag ?? ""));
       setOriginalRoomTypes([...roomTypes]);
       // Reload facility to get updated available_beds
       const response = await facilityAPI.getMy();
@@ -123,6 +123,7 @@ export default function FacilityProfilePage() {
   const [geocoding, setGeocoding] = useState(false);
   const [geocodeMessage, setGeocodeMessage] = useState("");
   const [roomTypes, setRoomTypes] = useState<FacilityRoomTypeInput[]>([]);
+  const [roomTypesVersion, setRoomTypesVersion] = useState("");
   const [loadingRoomTypes, setLoadingRoomTypes] = useState(false);
 
   useEffect(() => {
@@ -192,9 +193,11 @@ export default function FacilityProfilePage() {
           description: rt.description,
         }));
         setRoomTypes(loadedRoomTypes);
+        setRoomTypesVersion(String(roomTypesResponse.headers.etag ?? ""));
       } catch {
         // Room types might not exist yet, that's ok
         setRoomTypes([]);
+        setRoomTypesVersion("");
       } finally {
         setLoadingRoomTypes(false);
       }
@@ -252,7 +255,8 @@ export default function FacilityProfilePage() {
 
         // Save room types if any are defined
         if (roomTypes.length > 0) {
-          await facilityAPI.updateRoomTypes(facility.id, roomTypes);
+          const updated = await facilityAPI.updateRoomTypes(facility.id, roomTypes, roomTypesVersion);
+          setRoomTypesVersion(String(updated.headers.etag ?? ""));
         }
 
         setSuccess("施設情報を更新しました");
@@ -101,11 +101,13 @@ export const facilityAPI = {
     id: number | string
   ): Promise<AxiosResponse<FacilityRoomType[]>> =>
     api.get(`/api/facilities/${id}/room-types`),
+  // version is the ETag of the room type list as last read, so concurrent edits are refused
   updateRoomTypes: (
     id: number | string,
-    roomTypes: FacilityRoomTypeInput[]
+    roomTypes: FacilityRoomTypeInput[],
+    version: string
   ): Promise<AxiosResponse<FacilityRoomType[]>> =>
-    api.put(`/api/facilities/${id}/room-types`, { room_types: roomTypes }),
+    api.put(`/api/facilities/${id}/room-types`, { room_types: roomTypes, version }),
 };
 
 // Document API